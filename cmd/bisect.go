@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"got_it/internal/commands/bisect"
+
+	"github.com/spf13/cobra"
+)
+
+// bisectCmd represents the bisect command
+var bisectCmd = &cobra.Command{
+	Use:   "bisect (start [<bad> [<good>...]] | bad [<rev>] | good [<rev>...] | skip [<rev>...] | reset [<commit>] | log | run <cmd>...)",
+	Short: "Use binary search to find the commit that introduced a bug",
+	Long: `Checks out the commit halfway between the known good and bad commits until the first bad one is found.
+With run, each commit is tested by the command: exit code 0 means good, 125 means it cannot be tested, any other code below 128 means bad.`,
+	// the arguments of bisect run are the command to test with
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runBisect(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bisectCmd)
+}
+
+func runBisect(args []string) {
+	bisect.Execute(args)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/blame"
+
+	"github.com/spf13/cobra"
+)
+
+var blameOptions blame.BlameOptions
+
+// blameCmd represents the blame command
+var blameCmd = &cobra.Command{
+	Use:   "blame [-L <start>,<end>] [--porcelain] <file> [<rev>]",
+	Short: "Show what revision and author last modified each line of a file",
+	Long:  `Annotates each line of the file, as of the given revision or HEAD, with the commit that introduced it, its author and its date`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runBlame(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+	blameCmd.Flags().StringVarP(&blameOptions.LineRange, "lines", "L", "", "annotate only the lines start,end or start,+count")
+	blameCmd.Flags().BoolVar(&blameOptions.Porcelain, "porcelain", false, "show the output in a format meant for tools")
+}
+
+func runBlame(args []string) {
+	blame.Execute(args, blameOptions)
+}
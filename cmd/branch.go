@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"got_it/internal/commands/branch"
+
+	"github.com/spf13/cobra"
+)
+
+var branchOptions branch.BranchOptions
+
+// branchCmd represents the branch command
+var branchCmd = &cobra.Command{
+	Use:   "branch [<options>] [<branchname> [<start-point>] | -d <branchname>... | --set-upstream-to=<upstream> [<branchname>]]",
+	Short: "List, create or delete branches",
+	Long: `Lists the branches, marking the current one, creates a branch at HEAD or at the start point given, and deletes branches.
+With -v the commit of each branch is shown along with how far it is ahead of or behind its upstream, and -vv adds the
+upstream name. --set-upstream-to records the branch another one integrates with, which status, pull and push use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runBranch(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(branchCmd)
+	branchCmd.Flags().CountVarP(&branchOptions.Verbose, "verbose", "v", "show the commit and the upstream of each branch, twice for the upstream name")
+	branchCmd.Flags().BoolVarP(&branchOptions.Remotes, "remotes", "r", false, "list the remote-tracking branches")
+	branchCmd.Flags().BoolVarP(&branchOptions.All, "all", "a", false, "list both local and remote-tracking branches")
+	branchCmd.Flags().BoolVarP(&branchOptions.Delete, "delete", "d", false, "delete fully merged branches")
+	branchCmd.Flags().BoolVarP(&branchOptions.ForceDelete, "force-delete", "D", false, "delete branches even when not merged")
+	branchCmd.Flags().StringVarP(&branchOptions.SetUpstreamTo, "set-upstream-to", "u", "", "set the upstream of the branch")
+	branchCmd.Flags().BoolVar(&branchOptions.UnsetUpstream, "unset-upstream", false, "remove the upstream of the branch")
+}
+
+func runBranch(args []string) {
+	branch.Execute(args, branchOptions)
+}
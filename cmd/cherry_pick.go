@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/cherrypick"
+
+	"github.com/spf13/cobra"
+)
+
+var cherryPickOptions cherrypick.CherryPickOptions
+
+// cherryPickCmd represents the cherry-pick command
+var cherryPickCmd = &cobra.Command{
+	Use:   "cherry-pick [-x] <commit>... | --continue | --skip | --abort",
+	Short: "Apply the changes introduced by some existing commits",
+	Long:  `Applies the changes each commit introduced relative to its parent on top of HEAD and records them as new commits, stopping when they conflict`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runCherryPick(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cherryPickCmd)
+	cherryPickCmd.Flags().BoolVarP(&cherryPickOptions.RecordOrigin, "x", "x", false, "append the name of the picked commit to the message")
+	cherryPickCmd.Flags().BoolVar(&cherryPickOptions.Continue, "continue", false, "commit the resolved conflicts and go on")
+	cherryPickCmd.Flags().BoolVar(&cherryPickOptions.Skip, "skip", false, "skip the stopped commit and go on")
+	cherryPickCmd.Flags().BoolVar(&cherryPickOptions.Abort, "abort", false, "cancel the operation and go back to the original HEAD")
+}
+
+func runCherryPick(args []string) {
+	cherrypick.Execute(args, cherryPickOptions)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"got_it/internal/commands/clone"
+
+	"github.com/spf13/cobra"
+)
+
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone <repository> [<directory>]",
+	Short: "Clone a repository into a new directory",
+	Long: `Creates a repository in a new directory, fetches the branches and tags of the cloned one, recorded as the origin remote,
+and checks out the branch its HEAD points to. The repository is a local path, a file:// URL or the http:// URL of got serve.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runClone(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+}
+
+func runClone(args []string) {
+	clone.Execute(args)
+}
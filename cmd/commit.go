@@ -10,21 +10,23 @@ import (
 
 var (
 	allFlagCommit bool = false
-	verboseCommit bool = false
+	commitOptions commit.CommitOptions
 )
 
 // commitCmd represents the commit command
 var commitCmd = &cobra.Command{
-	Use:   "commit [-a] [-F <file> | -m <message>]",
+	Use:   "commit [-a] [-n] [-S] [-F <file> | -m <message>]",
 	Short: "",
 	Long:  ``,
-	Run: func(cmd *cobra.Command, args []string) {
+	// the error is printed by commit.Execute, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		for _, arg := range args {
 			fmt.Println(arg)
 		}
 
-		runCommit(cmd)
-		return
+		return runCommit(cmd)
 	},
 }
 
@@ -33,16 +35,16 @@ func init() {
 	commitCmd.Flags().BoolVarP(&allFlagCommit, "all", "a", false, "add all changes in tracked files to the commit")
 	commitCmd.Flags().StringP("file", "F", "", "read commit message from file")
 	commitCmd.Flags().StringP("message", "m", "", "commit message ")
-	commitCmd.Flags().BoolVarP(&verboseCommit, "verbose", "v", false, "verbose output")
-
+	commitCmd.Flags().BoolVarP(&commitOptions.Verbose, "verbose", "v", false, "verbose output")
+	commitCmd.Flags().BoolVarP(&commitOptions.NoVerify, "no-verify", "n", false, "bypass the pre-commit and commit-msg hooks")
+	commitCmd.Flags().BoolVarP(&commitOptions.Sign, "gpg-sign", "S", false, "sign the commit with user.signingKey")
 }
 
-func runCommit(cmd *cobra.Command) {
+func runCommit(cmd *cobra.Command) error {
 	msg, err := cmd.Flags().GetString("message")
 	if err != nil {
 		msg = ""
 	}
 
-	commit.Execute(msg, verboseCommit)
-	return
+	return commit.Execute(msg, commitOptions)
 }
@@ -8,13 +8,28 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	configGlobal     bool
+	configLocal      bool
+	configSystem     bool
+	configList       bool
+	configShowOrigin bool
+	configUnset      bool
+	configUnsetAll   bool
+	configAdd        bool
+	configGetAll     bool
+	configType       string
+	configDefaults   bool
+)
+
 // configCmd represents the config command
 var configCmd = &cobra.Command{
 	Use:   "config <key> <value>",
 	Short: "",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		if !isInitialized() {
+		// the global and system files can be used outside a repository
+		if !configGlobal && !configSystem && !isInitialized() {
 			return
 		}
 		runConfig(cmd, args)
@@ -24,7 +39,17 @@ var configCmd = &cobra.Command{
 func init() {
 	configCmd.SetHelpFunc(configHelp)
 	rootCmd.AddCommand(configCmd)
-
+	configCmd.Flags().BoolVar(&configGlobal, "global", false, "use the global config file")
+	configCmd.Flags().BoolVar(&configLocal, "local", false, "use the repository config file")
+	configCmd.Flags().BoolVar(&configSystem, "system", false, "use the system config file")
+	configCmd.Flags().BoolVarP(&configList, "list", "l", false, "list all the settings")
+	configCmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "show the file of each setting")
+	configCmd.Flags().BoolVar(&configUnset, "unset", false, "remove a key")
+	configCmd.Flags().BoolVar(&configUnsetAll, "unset-all", false, "remove every value of a key")
+	configCmd.Flags().BoolVar(&configAdd, "add", false, "add a value to a key, keeping the others")
+	configCmd.Flags().BoolVar(&configGetAll, "get-all", false, "show every value of a key")
+	configCmd.Flags().StringVar(&configType, "type", "", "read or write the values as bool or int")
+	configCmd.Flags().BoolVar(&configDefaults, "defaults", false, "with --list, show the known keys and their defaults")
 }
 
 func configHelp(cmd *cobra.Command, args []string) {
@@ -32,9 +57,24 @@ func configHelp(cmd *cobra.Command, args []string) {
 		"Usage:",
 		"  got config [flags]",
 		"  got config [flags] <key> <value>",
+		"  got config [flags] --unset <key>",
+		"  got config [flags] --get-all <key>",
+		"  got config [flags] --list [--show-origin]",
+		"  got config --list --defaults",
 		"",
 		"Flags:",
-		"  -h,    Show this help message",
+		"  --global       Use the global config file, " + config.GLOBAL_CONFIG_FILE + " in the home directory",
+		"  --local        Use the config file of the repository, the default for writing",
+		"  --system       Use the system config file, " + config.SYSTEM_CONFIG_FILE,
+		"  -l, --list     List all the settings",
+		"  --show-origin  Show the file each listed setting comes from",
+		"  --unset        Remove a key",
+		"  --unset-all    Remove every value of a key",
+		"  --add          Add a value to a key, keeping the others",
+		"  --get-all      Show every value of a key",
+		"  --type <type>  Read or write the values as bool or int",
+		"  --defaults     With --list, show the known keys and their default values",
+		"  -h,            Show this help message",
 		"",
 	}
 
@@ -44,26 +84,89 @@ func configHelp(cmd *cobra.Command, args []string) {
 }
 
 func runConfig(cmd *cobra.Command, args []string) {
+	conf := config.NewConfig()
+	scope, err := configScope()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	switch {
+	case configList && configDefaults:
+		listDefaults()
+		return
+	case configList:
+		listConfig(conf, scope)
+		return
+	case configUnset || configUnsetAll:
+		if len(args) != 1 {
+			cmd.Help()
+			return
+		}
+		unsetConfig(conf, scope, args[0])
+		return
+	case configGetAll:
+		if len(args) != 1 {
+			cmd.Help()
+			return
+		}
+		getAllConfig(conf, scope, args[0])
+		return
+	}
+
 	len := len(args)
 	switch len {
 	case 0:
 		cmd.Help()
 	case 1:
-		getConfig(args[0])
+		getConfig(conf, scope, args[0])
 	case 2:
-		setConfig(args[0], args[1])
+		setConfig(conf, scope, args[0], args[1])
 	default:
 		cmd.Help()
 	}
 	return
 }
 
-func setConfig(key, value string) {
+// configScope returns the file the flags select, none meaning every file
+// for reading and the repository one for writing
+func configScope() (config.Scope, error) {
+	scopes := []config.Scope{}
+	for scope, selected := range map[config.Scope]bool{
+		config.SCOPE_GLOBAL: configGlobal,
+		config.SCOPE_LOCAL:  configLocal,
+		config.SCOPE_SYSTEM: configSystem,
+	} {
+		if selected {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) > 1 {
+		return "", fmt.Errorf("only one config file at a time")
+	}
+	if len(scopes) == 0 {
+		return "", nil
+	}
+	return scopes[0], nil
+}
+
+func setConfig(conf *config.Config, scope config.Scope, key, value string) {
 	if !config.IsValidKey(key) {
 		fmt.Print(invalidateKeyMessage(key))
 		return
 	} else {
-		if err := conf.SetConfigKeyValue(key, value); err != nil {
+		if scope == "" {
+			scope = config.SCOPE_LOCAL
+		}
+		value, err := typedValue(value)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		save := conf.SetScopedKeyValue
+		if configAdd {
+			save = conf.AddScopedKeyValue
+		}
+		if err := save(scope, key, value); err != nil {
 			fmt.Println(err)
 			return
 		}
@@ -73,27 +176,91 @@ func setConfig(key, value string) {
 	return
 }
 
+func unsetConfig(conf *config.Config, scope config.Scope, key string) {
+	if scope == "" {
+		scope = config.SCOPE_LOCAL
+	}
+	if err := conf.UnsetConfigKey(scope, key, configUnsetAll); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+func listConfig(conf *config.Config, scope config.Scope) {
+	settings, err := conf.List(scope)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	for _, setting := range settings {
+		if configShowOrigin {
+			fmt.Printf("file:%s\t", setting.Origin)
+		}
+		fmt.Printf("%s=%s\n", setting.Key, setting.Value)
+	}
+}
+
+// listDefaults prints the registered keys with their default values; keys
+// for any subsection, such as remote.<name>.url, have none
+func listDefaults() {
+	for _, info := range config.Schema() {
+		if strings.Contains(info.Key, config.SUBSECTION_WILDCARD) {
+			continue
+		}
+		if configShowOrigin {
+			fmt.Print("default\t")
+		}
+		fmt.Printf("%s=%s\n", info.Key, info.Default)
+	}
+}
+
 func invalidateKeyMessage(key string) string {
-	message := []string{}
-	message = append(message, "Error: "+key+" is not a valid config key\n")
-	message = append(message, "Valid keys are:\n")
+	return "Error: " + key + " is not a valid config key, expected <section>[.<subsection>].<name>\n"
+}
 
-	for _, k := range config.GetAcceptedKeys() {
-		message = append(message, "  "+k+"\n")
+// typedValue checks and normalizes the value for --type
+func typedValue(value string) (string, error) {
+	switch configType {
+	case "":
+		return value, nil
+	case "bool":
+		parsed, err := config.ParseBool(value)
+		return fmt.Sprint(parsed), err
+	case "int":
+		parsed, err := config.ParseInt(value)
+		return fmt.Sprint(parsed), err
 	}
-	return strings.Join(message, "")
+	return "", fmt.Errorf("unrecognized --type argument '%s'", configType)
 }
 
-func getConfig(key string) {
+func getAllConfig(conf *config.Config, scope config.Scope, key string) {
+	values, err := conf.GetAllScoped(scope, key)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, value := range values {
+		if value, err = typedValue(value); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println(value)
+	}
+}
+
+func getConfig(conf *config.Config, scope config.Scope, key string) {
 	if !config.IsValidKey(key) {
 		fmt.Print(invalidateKeyMessage(key))
 		return
 	} else {
-		value, err := conf.GetConfigKeyValue(key)
+		value, err := conf.GetScopedKeyValue(scope, key)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
+		if value, err = typedValue(value); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 		fmt.Println(value)
 	}
 	return
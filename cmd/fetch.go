@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"got_it/internal/commands/fetch"
+
+	"github.com/spf13/cobra"
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [<remote> [<refspec>...]]",
+	Short: "Download objects and refs from another repository",
+	Long: `Copies the commits of the branches of a remote, origin by default, and updates its remote-tracking branches.
+The refspecs replace the ones configured for the remote; tags missing locally are fetched too.`,
+	// the error is printed by fetch.Execute, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInitialized() {
+			return errNotInitialized
+		}
+		return runFetch(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+}
+
+func runFetch(args []string) error {
+	return fetch.Execute(args)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/foreachref"
+
+	"github.com/spf13/cobra"
+)
+
+var forEachRefOptions foreachref.ForEachRefOptions
+
+// forEachRefCmd represents the for-each-ref command
+var forEachRefCmd = &cobra.Command{
+	Use:   "for-each-ref [--format=<format>] [--sort=<key>] [--count=<n>] [<pattern>...]",
+	Short: "Output information on each ref",
+	Long:  `Lists the refs matching the patterns, formatted with %(refname), %(refname:short), %(objectname), %(objectname:short), %(objecttype), %(symref), %(subject) and %(creatordate)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runForEachRef(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forEachRefCmd)
+	forEachRefCmd.Flags().StringVar(&forEachRefOptions.Format, "format", "", "format of each line, with %(<field>) placeholders")
+	forEachRefCmd.Flags().StringVar(&forEachRefOptions.Sort, "sort", "", "sort on refname, objectname or creatordate, prefix with - to reverse")
+	forEachRefCmd.Flags().IntVar(&forEachRefOptions.Count, "count", 0, "stop after showing that many refs")
+}
+
+func runForEachRef(patterns []string) {
+	foreachref.Execute(patterns, forEachRefOptions)
+}
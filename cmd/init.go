@@ -1,28 +1,42 @@
 package cmd
 
 import (
+	"errors"
 	init_ "got_it/internal/commands/init"
 
 	"github.com/spf13/cobra"
 )
 
-var i init_.Init
+var initOptions init_.InitOptions
 
 // initCmd represents the init command
 var initCmd = &cobra.Command{
-	Use:   "init",
+	Use:   "init [--bare] [-b <branch>] [--object-format <format>] [--template <dir>] [-q] [<directory>]",
 	Short: "Initialize a new repository",
-	Long:  `Initialize a new Got_it repository`,
+	Long: `Initialize a new Got_it repository in the directory, by default the current one. A bare repository has no
+worktree: the directory itself holds the objects and refs. The files of the template directory are copied into the
+new repository. The object format, sha1 or sha256, is the hash algorithm naming the objects; it is recorded in
+extensions.objectFormat and cannot change afterwards. Running init in an existing repository recreates the
+directories it misses and keeps the rest.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		i := init_.NewInit()
-		i.InitRepo()
+		init_.Execute(args, initOptions)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&initOptions.Bare, "bare", false, "create a repository without a worktree")
+	initCmd.Flags().StringVarP(&initOptions.InitialBranch, "initial-branch", "b", "", "name of the initial branch, by default init.defaultBranch")
+	initCmd.Flags().StringVar(&initOptions.ObjectFormat, "object-format", "", "hash algorithm of the objects, sha1 or sha256")
+	initCmd.Flags().StringVar(&initOptions.Template, "template", "", "directory whose files are copied into the repository")
+	initCmd.Flags().BoolVarP(&initOptions.Quiet, "quiet", "q", false, "only print errors and warnings")
 }
 
+// errNotInitialized fails the commands whose exit status matters when they
+// run outside a repository
+var errNotInitialized = errors.New("not a got repository")
+
 func isInitialized() bool {
 	i := init_.NewInit()
 	return i.IsInitialized()
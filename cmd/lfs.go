@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"got_it/internal/commands/lfs"
+
+	"github.com/spf13/cobra"
+)
+
+// lfsCmd represents the lfs command
+var lfsCmd = &cobra.Command{
+	Use:   "lfs track [<pattern>...] | untrack <pattern>... | ls-files | push | fetch | checkout",
+	Short: "Store large files outside the object database",
+	Long: `Files matching the patterns tracked in .gotattributes are stored by add in .got/lfs/objects, named by their SHA-256, and staged as a small pointer; checking them out writes their content back.
+track adds patterns, or lists them when none is given, and untrack removes them. ls-files lists the staged pointers.
+push copies the large files to the directory of lfs.url, the LFS server, and fetch downloads those of HEAD from it; checkout then replaces the pointers left in the worktree with their content.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runLfs(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lfsCmd)
+}
+
+func runLfs(args []string) {
+	lfs.Execute(args)
+}
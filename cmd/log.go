@@ -6,21 +6,47 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var logOptions history.LogOptions
+
 // logCmd represents the log command
 var logCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [<options>] [<revision-range>] [[--] <path>...]",
 	Short: "Commit history of a Git repository",
 	Long:  `Shows a chronological list of commits, along with detailed information such as commit hashes, authors, timestamps, and commit messages`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runLog()
+	// the error is printed by history.Execute, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revisions, paths := splitArgsAtDash(cmd, args)
+		return runLog(revisions, paths)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().IntVarP(&logOptions.MaxCount, "max-count", "n", 0, "limit the number of commits to output")
+	logCmd.Flags().BoolVar(&logOptions.Oneline, "oneline", false, "show each commit on a single line")
+	logCmd.Flags().StringVar(&logOptions.Format, "format", "", "pretty-print the commits with the given template (%H %h %an %ae %ad %s %b ...)")
+	logCmd.Flags().StringVar(&logOptions.Since, "since", "", "show commits more recent than a specific date")
+	logCmd.Flags().StringVar(&logOptions.Until, "until", "", "show commits older than a specific date")
+	logCmd.Flags().StringArrayVar(&logOptions.Authors, "author", nil, "limit the commits to authors matching the pattern")
+	logCmd.Flags().StringArrayVar(&logOptions.Greps, "grep", nil, "limit the commits to messages matching the pattern")
+	logCmd.Flags().BoolVar(&logOptions.Graph, "graph", false, "draw a text-based graph of the commit history")
+	logCmd.Flags().BoolVar(&logOptions.Reverse, "reverse", false, "output the commits in reverse order")
+	logCmd.Flags().BoolVar(&logOptions.TopoOrder, "topo-order", false, "show no parents before all of their children")
+	logCmd.Flags().StringVar(&logOptions.DateMode, "date", "", "show dates as relative, local, iso, iso-strict, short, rfc, unix or raw")
+	logCmd.Flags().BoolVar(&logOptions.ShowSignature, "show-signature", false, "check the signatures of the commits and show the result")
+}
 
+func runLog(revisions, paths []string) error {
+	return history.Execute(revisions, paths, logOptions)
 }
 
-func runLog() {
-	history.Execute()
+// splitArgsAtDash separates the arguments before and after "--"
+func splitArgsAtDash(cmd *cobra.Command, args []string) ([]string, []string) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		return args, nil
+	}
+	return args[:dash], args[dash:]
 }
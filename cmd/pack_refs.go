@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"got_it/internal/commands/packrefs"
+
+	"github.com/spf13/cobra"
+)
+
+var packAllRefs bool
+
+// packRefsCmd represents the pack-refs command
+var packRefsCmd = &cobra.Command{
+	Use:   "pack-refs [--all]",
+	Short: "Pack refs into a single file",
+	Long:  `Moves the loose tags, and the branches with --all, into .got/packed-refs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runPackRefs()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packRefsCmd)
+	packRefsCmd.Flags().BoolVar(&packAllRefs, "all", false, "pack every ref, not only the tags")
+}
+
+func runPackRefs() {
+	packrefs.Execute(packAllRefs)
+}
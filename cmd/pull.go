@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/pull"
+
+	"github.com/spf13/cobra"
+)
+
+// pullCmd represents the pull command
+var pullCmd = &cobra.Command{
+	Use:   "pull [<remote> [<branch>]]",
+	Short: "Fetch from another repository and merge into the current branch",
+	Long: `Fetches from a remote and merges one of its branches: by default the upstream of the current branch, or the branch of the
+same name on origin when there is none.
+The current branch is fast-forwarded when possible; otherwise a merge commit is made, or the conflicts are left to resolve and commit.`,
+	Args: cobra.MaximumNArgs(2),
+	// the error is printed by pull.Execute, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInitialized() {
+			return errNotInitialized
+		}
+		return runPull(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+}
+
+func runPull(args []string) error {
+	return pull.Execute(args)
+}
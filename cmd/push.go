@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"got_it/internal/commands/push"
+
+	"github.com/spf13/cobra"
+)
+
+var pushOptions push.PushOptions
+
+// pushCmd represents the push command
+var pushCmd = &cobra.Command{
+	Use:   "push [-u] [--no-verify] [--force | --force-with-lease[=<ref>[:<expect>]]] [<remote> [<refspec>...]]",
+	Short: "Update remote refs along with the objects they need",
+	Long: `Sends the current branch, or the refspecs given, to a remote, by default the one of the upstream of the current branch or origin.
+A remote branch is only updated when that keeps its commits, unless forced. With --force-with-lease the push is
+forced only while the remote branch still matches its remote-tracking branch, or the expected hash.`,
+	// the error is printed by push.Execute, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInitialized() {
+			return errNotInitialized
+		}
+		return runPush(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().BoolVarP(&pushOptions.Force, "force", "f", false, "update the remote refs even when commits are lost")
+	pushCmd.Flags().StringVar(&pushOptions.ForceWithLease, "force-with-lease", "", "force only while the remote ref has the expected value")
+	pushCmd.Flags().Lookup("force-with-lease").NoOptDefVal = push.LEASE_TRACKING
+	pushCmd.Flags().BoolVarP(&pushOptions.SetUpstream, "set-upstream", "u", false, "make the pushed branches the upstream of the local ones")
+	pushCmd.Flags().BoolVar(&pushOptions.NoVerify, "no-verify", false, "bypass the pre-push hook")
+}
+
+func runPush(args []string) error {
+	return push.Execute(args, pushOptions)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"got_it/internal/commands/rebase"
+
+	"github.com/spf13/cobra"
+)
+
+var rebaseOptions rebase.RebaseOptions
+
+// rebaseCmd represents the rebase command
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase [-i] [--onto <newbase>] <upstream> | --continue | --skip | --abort | --edit-todo",
+	Short: "Reapply commits on top of another base",
+	Long: `Replays the commits of the current branch that are not in upstream on top of it, or of the --onto commit.
+With -i the list of commits is edited first, to pick, reword, edit, squash, fixup, drop them or run commands in between.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runRebase(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rebaseCmd)
+	rebaseCmd.Flags().BoolVarP(&rebaseOptions.Interactive, "interactive", "i", false, "edit the list of commits to rebase")
+	rebaseCmd.Flags().StringVar(&rebaseOptions.Onto, "onto", "", "replay the commits on this commit instead of upstream")
+	rebaseCmd.Flags().BoolVar(&rebaseOptions.Continue, "continue", false, "commit the resolved conflicts or the amended commit and go on")
+	rebaseCmd.Flags().BoolVar(&rebaseOptions.Skip, "skip", false, "skip the stopped commit and go on")
+	rebaseCmd.Flags().BoolVar(&rebaseOptions.Abort, "abort", false, "cancel the rebase and go back to the original branch")
+	rebaseCmd.Flags().BoolVar(&rebaseOptions.EditTodo, "edit-todo", false, "edit the rest of the todo list")
+}
+
+func runRebase(args []string) {
+	rebase.Execute(args, rebaseOptions)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/reflog"
+
+	"github.com/spf13/cobra"
+)
+
+var reflogOptions reflog.ReflogOptions
+
+// reflogCmd represents the reflog command
+var reflogCmd = &cobra.Command{
+	Use:   "reflog [show [<ref>] | expire [--expire=<date>] [--all] [<ref>...] | delete <ref>@{<n>}...]",
+	Short: "Manage reflog information",
+	Long:  `Shows the updates recorded for HEAD and the branches, expires old entries and deletes single entries`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runReflog(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reflogCmd)
+	reflogCmd.Flags().IntVarP(&reflogOptions.MaxCount, "max-count", "n", 0, "limit the number of entries to show")
+	reflogCmd.Flags().StringVar(&reflogOptions.Expire, "expire", "", "remove the entries older than the date (default \"90 days ago\", \"all\" or \"never\")")
+	reflogCmd.Flags().BoolVar(&reflogOptions.All, "all", false, "expire the reflogs of every ref")
+}
+
+func runReflog(args []string) {
+	reflog.Execute(args, reflogOptions)
+}
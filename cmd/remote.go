@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"got_it/internal/commands/remote"
+
+	"github.com/spf13/cobra"
+)
+
+var remoteOptions remote.RemoteOptions
+
+// remoteCmd represents the remote command
+var remoteCmd = &cobra.Command{
+	Use:   "remote [-v] | add <name> <url> | remove <name>",
+	Short: "Manage the remote repositories",
+	Long: `Lists the remote repositories, with their URLs with -v, adds one or removes one with its remote-tracking branches.
+Remotes are local paths, file:// URLs or http:// URLs served by got serve.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runRemote(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.Flags().BoolVarP(&remoteOptions.Verbose, "verbose", "v", false, "show the URLs of the remotes")
+}
+
+func runRemote(args []string) {
+	remote.Execute(args, remoteOptions)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/revert"
+
+	"github.com/spf13/cobra"
+)
+
+var revertOptions revert.RevertOptions
+
+// revertCmd represents the revert command
+var revertCmd = &cobra.Command{
+	Use:   "revert <commit>... | --continue | --skip | --abort",
+	Short: "Revert some existing commits",
+	Long:  `Applies the inverse of the changes each commit introduced on top of HEAD and records them as new commits, stopping when they conflict`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runRevert(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+	revertCmd.Flags().BoolVar(&revertOptions.Continue, "continue", false, "commit the resolved conflicts and go on")
+	revertCmd.Flags().BoolVar(&revertOptions.Skip, "skip", false, "skip the stopped commit and go on")
+	revertCmd.Flags().BoolVar(&revertOptions.Abort, "abort", false, "cancel the operation and go back to the original HEAD")
+}
+
+func runRevert(args []string) {
+	revert.Execute(args, revertOptions)
+}
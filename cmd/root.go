@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"got_it/internal/alias"
 	"got_it/internal/commands/config"
 	"os"
 
@@ -30,10 +31,31 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	expansion, err := alias.Expand(config.NewConfig(), os.Args[1:], isBuiltin)
 	if err != nil {
+		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
+	if expansion.Shell != "" {
+		code, err := alias.RunShell(expansion.Shell, expansion.Args)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+		os.Exit(code)
+	}
+	rootCmd.SetArgs(expansion.Args)
+	err = rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// isBuiltin tells if name is a command of got, which no alias overrides
+func isBuiltin(name string) bool {
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+	cmd, _, err := rootCmd.Find([]string{name})
+	return err == nil && cmd != rootCmd
 }
 
 func init() {
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/serve"
+
+	"github.com/spf13/cobra"
+)
+
+var serveOptions serve.ServeOptions
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve [--listen <address>] [--read-only] [--auth-file <file>] [<directory>]",
+	Short: "Serve a repository over HTTP",
+	Long: `Serves the repository in the directory, the current one by default, to clone, fetch and push with http:// URLs.
+With --auth-file the clients must authenticate with one of the "<name>:<password>" lines of the file; with --read-only pushes are refused.
+Requests larger than serve.maxRequestSize, 1g by default, are refused.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runServe(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&serveOptions.Listen, "listen", "l", serve.DEFAULT_ADDRESS, "the address to listen on")
+	serveCmd.Flags().BoolVar(&serveOptions.ReadOnly, "read-only", false, "refuse pushes")
+	serveCmd.Flags().StringVar(&serveOptions.AuthFile, "auth-file", "", "require basic auth with the users of this file")
+}
+
+func runServe(args []string) {
+	serve.Execute(args, serveOptions)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/show"
+
+	"github.com/spf13/cobra"
+)
+
+var showOptions show.ShowOptions
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show [<options>] [<object>...]",
+	Short: "Show various types of objects",
+	Long:  `Shows a commit with its message and the changes it introduced, the entries of a tree or the content of a blob`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runShow(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().BoolVar(&showOptions.Stat, "stat", false, "show a diffstat instead of the patch")
+	showCmd.Flags().BoolVar(&showOptions.NameOnly, "name-only", false, "show only the names of the changed files")
+	showCmd.Flags().BoolVar(&showOptions.NameStatus, "name-status", false, "show the names and the status of the changed files")
+	showCmd.Flags().StringVar(&showOptions.DateMode, "date", "", "show dates as relative, local, iso, iso-strict, short, rfc, unix or raw")
+}
+
+func runShow(revisions []string) {
+	show.Execute(revisions, showOptions)
+}
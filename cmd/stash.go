@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/stash"
+
+	"github.com/spf13/cobra"
+)
+
+var stashOptions stash.StashOptions
+
+// stashCmd represents the stash command
+var stashCmd = &cobra.Command{
+	Use:   "stash [push [-m <message>] [-u] | list | show [-p] [<stash>] | apply [<stash>] | pop [<stash>] | drop [<stash>] | clear]",
+	Short: "Stash the changes in a dirty working directory away",
+	Long:  `Saves the staged and unstaged changes as commits referenced from refs/stash and resets the working directory to HEAD, then lists, shows, applies and drops the saved stashes`,
+	Args:  cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runStash(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stashCmd)
+	stashCmd.Flags().StringVarP(&stashOptions.Message, "message", "m", "", "describe the stash")
+	stashCmd.Flags().BoolVarP(&stashOptions.IncludeUntracked, "include-untracked", "u", false, "stash the untracked files too")
+	stashCmd.Flags().BoolVarP(&stashOptions.Patch, "patch", "p", false, "show the changes as a patch")
+}
+
+func runStash(args []string) {
+	stash.Execute(args, stashOptions)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/status"
+
+	"github.com/spf13/cobra"
+)
+
+var statusOptions status.StatusOptions
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status [-s [-b]]",
+	Short: "Show the working tree status",
+	Long: `Shows the current branch and how far it is ahead of or behind its upstream, then the staged changes,
+the changes not staged yet and the untracked files. With -s each file takes one "XY path" line.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runStatus()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVarP(&statusOptions.Short, "short", "s", false, "give the output in the short format")
+	statusCmd.Flags().BoolVarP(&statusOptions.Branch, "branch", "b", false, "show the branch and its tracking in the short format")
+}
+
+func runStatus() {
+	status.Execute(statusOptions)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"got_it/internal/commands/symbolicref"
+
+	"github.com/spf13/cobra"
+)
+
+var symbolicRefOptions symbolicref.SymbolicRefOptions
+
+// symbolicRefCmd represents the symbolic-ref command
+var symbolicRefCmd = &cobra.Command{
+	Use:   "symbolic-ref [-m <reason>] <name> <ref> | [-q] [--short] <name> | -d <name>",
+	Short: "Read, modify and delete symbolic refs",
+	Long:  `Shows the ref a symbolic ref like HEAD points to, points it to another ref or deletes it`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runSymbolicRef(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(symbolicRefCmd)
+	symbolicRefCmd.Flags().StringVarP(&symbolicRefOptions.Message, "message", "m", "", "reason recorded in the reflog")
+	symbolicRefCmd.Flags().BoolVarP(&symbolicRefOptions.Quiet, "quiet", "q", false, "do not report a name that is not a symbolic ref")
+	symbolicRefCmd.Flags().BoolVar(&symbolicRefOptions.Short, "short", false, "shorten the ref name, refs/heads/main becomes main")
+	symbolicRefCmd.Flags().BoolVarP(&symbolicRefOptions.Delete, "delete", "d", false, "delete the symbolic ref")
+}
+
+func runSymbolicRef(args []string) {
+	symbolicref.Execute(args, symbolicRefOptions)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"got_it/internal/commands/tag"
+
+	"github.com/spf13/cobra"
+)
+
+var tagOptions tag.TagOptions
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag [<options>] [<tagname> [<commit>] | -d <tagname>... | -l [<pattern>...]]",
+	Short: "Create, list or delete tags",
+	Long:  `Creates lightweight tags, or annotated tag objects when a message is given or the tag is signed, lists the existing tags and deletes them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runTag(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolVarP(&tagOptions.Annotate, "annotate", "a", false, "make an annotated tag object")
+	tagCmd.Flags().StringVarP(&tagOptions.Message, "message", "m", "", "use the given tag message, implies -a")
+	tagCmd.Flags().BoolVarP(&tagOptions.Force, "force", "f", false, "replace an existing tag")
+	tagCmd.Flags().BoolVarP(&tagOptions.Delete, "delete", "d", false, "delete tags")
+	tagCmd.Flags().BoolVarP(&tagOptions.List, "list", "l", false, "list tags matching the patterns")
+	tagCmd.Flags().BoolVarP(&tagOptions.Sign, "sign", "s", false, "make a signed tag object, with user.signingKey")
+	tagCmd.Flags().StringVarP(&tagOptions.LocalUser, "local-user", "u", "", "make a signed tag object with the given key")
+}
+
+func runTag(args []string) {
+	tag.Execute(args, tagOptions)
+}
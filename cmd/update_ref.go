@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"got_it/internal/commands/updateref"
+
+	"github.com/spf13/cobra"
+)
+
+var updateRefOptions updateref.UpdateRefOptions
+
+// updateRefCmd represents the update-ref command
+var updateRefCmd = &cobra.Command{
+	Use:   "update-ref [-m <reason>] [--no-deref] (-d <ref> [<old-value>] | <ref> <new-value> [<old-value>])",
+	Short: "Update the object name stored in a ref safely",
+	Long:  `Points a ref to a new object, or deletes it, only if it still has the given old value`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isInitialized() {
+			return
+		}
+		runUpdateRef(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateRefCmd)
+	updateRefCmd.Flags().StringVarP(&updateRefOptions.Message, "message", "m", "", "reason recorded in the reflog")
+	updateRefCmd.Flags().BoolVarP(&updateRefOptions.Delete, "delete", "d", false, "delete the ref")
+	updateRefCmd.Flags().BoolVar(&updateRefOptions.NoDeref, "no-deref", false, "update the symbolic ref itself instead of its target")
+}
+
+func runUpdateRef(args []string) {
+	updateref.Execute(args, updateRefOptions)
+}
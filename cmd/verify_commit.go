@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"got_it/internal/commands/verify"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyCommitOptions verify.VerifyOptions
+
+// verifyCommitCmd represents the verify-commit command
+var verifyCommitCmd = &cobra.Command{
+	Use:   "verify-commit [-v] <commit>...",
+	Short: "Check the signature of commits",
+	Long: `Checks the signature in the gpgsig header of each commit. SSH signatures must come from a key of
+gpg.ssh.allowedSignersFile, OpenPGP signatures are checked by gpg.program.`,
+	Args: cobra.MinimumNArgs(1),
+	// the failures are printed as they are found, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInitialized() {
+			return errNotInitialized
+		}
+		return runVerifyCommit(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCommitCmd)
+	verifyCommitCmd.Flags().BoolVarP(&verifyCommitOptions.Verbose, "verbose", "v", false, "show the content of the commits")
+}
+
+func runVerifyCommit(args []string) error {
+	return verify.ExecuteCommit(args, verifyCommitOptions)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"got_it/internal/commands/verify"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyTagOptions verify.VerifyOptions
+
+// verifyTagCmd represents the verify-tag command
+var verifyTagCmd = &cobra.Command{
+	Use:   "verify-tag [-v] <tag>...",
+	Short: "Check the signature of tags",
+	Long: `Checks the signature appended to each annotated tag. SSH signatures must come from a key of
+gpg.ssh.allowedSignersFile, OpenPGP signatures are checked by gpg.program.`,
+	Args: cobra.MinimumNArgs(1),
+	// the failures are printed as they are found, only the exit status is left
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInitialized() {
+			return errNotInitialized
+		}
+		return runVerifyTag(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyTagCmd)
+	verifyTagCmd.Flags().BoolVarP(&verifyTagOptions.Verbose, "verbose", "v", false, "show the content of the tags")
+}
+
+func runVerifyTag(args []string) error {
+	return verify.ExecuteTag(args, verifyTagOptions)
+}
@@ -0,0 +1,145 @@
+package alias
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ALIAS_SECTION is the config section holding the aliases, as in
+// alias.co = checkout
+const ALIAS_SECTION string = "alias"
+
+// SHELL_PREFIX starts an alias run by the shell instead of by got
+const SHELL_PREFIX string = "!"
+
+func init() {
+	config.Register(config.KeyInfo{Key: "alias.<name>", Description: "command run for got <name>, by the shell when it starts with !",
+		Validate: config.NotEmpty})
+}
+
+// Expansion is the result of expanding the aliases of a command line
+type Expansion struct {
+	Args  []string // the command line to run
+	Shell string   // the shell command of a ! alias, run with Args
+}
+
+// Expand replaces the command name at the start of args with its alias,
+// repeatedly, until it names a command of got or a shell alias. The
+// arguments that follow the name are kept after the expansion. builtin
+// tells the commands aliases cannot override.
+func Expand(conf *config.Config, args []string, builtin func(name string) bool) (Expansion, error) {
+	expanded := append([]string{}, args...)
+	seen := []string{}
+	for len(expanded) > 0 && !strings.HasPrefix(expanded[0], "-") && !builtin(expanded[0]) {
+		name := expanded[0]
+		value, err := conf.GetConfigKeyValue(ALIAS_SECTION + "." + name)
+		if err != nil {
+			break
+		}
+		for i, previous := range seen {
+			if previous == name {
+				return Expansion{}, loopError(append(seen, name), i)
+			}
+		}
+		seen = append(seen, name)
+
+		if command, found := strings.CutPrefix(value, SHELL_PREFIX); found {
+			return Expansion{Args: expanded[1:], Shell: command}, nil
+		}
+		words, err := SplitCommandLine(value)
+		if err != nil {
+			return Expansion{}, fmt.Errorf("bad alias.%s string: %w", name, err)
+		}
+		if len(words) == 0 {
+			return Expansion{}, fmt.Errorf("empty alias for %s", name)
+		}
+		expanded = append(words, expanded[1:]...)
+	}
+	return Expansion{Args: expanded}, nil
+}
+
+// RunShell runs a shell alias with the arguments, which the command gets as
+// "$@", and returns its exit code
+func RunShell(command string, args []string) (int, error) {
+	script := command
+	if len(args) > 0 {
+		script += ` "$@"`
+	}
+	cmd := exec.Command("sh", append([]string{"-c", script, command}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, fmt.Errorf("could not run the alias '%s': %w", command, err)
+	}
+	return 0, nil
+}
+
+// SplitCommandLine splits an alias into words at the spaces, keeping the
+// spaces inside single or double quotes and after a backslash
+func SplitCommandLine(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != '\'' && c == '\\':
+			if i+1 == len(line) {
+				return nil, fmt.Errorf("cmdline ends with \\")
+			}
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case quote != 0:
+			word.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// loopError describes the chain of aliases that leads back to the alias at
+// index start
+func loopError(chain []string, start int) error {
+	var message strings.Builder
+	fmt.Fprintf(&message, "alias loop detected: expansion of '%s' does not terminate:", chain[0])
+	for i, name := range chain[:len(chain)-1] {
+		marker := ""
+		switch {
+		case i == start:
+			marker = " <=="
+		case i == len(chain)-2:
+			marker = " ==>"
+		}
+		fmt.Fprintf(&message, "\n  %s%s", name, marker)
+	}
+	return fmt.Errorf("%s", message.String())
+}
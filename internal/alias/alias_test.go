@@ -0,0 +1,92 @@
+package alias
+
+import (
+	"got_it/internal/commands/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"log --oneline", []string{"log", "--oneline"}},
+		{"  commit   -m 'two words' ", []string{"commit", "-m", "two words"}},
+		{`log --format="%h %s"`, []string{"log", "--format=%h %s"}},
+		{`a\ b "c\"d" 'e\f' ""`, []string{"a b", `c"d`, `e\f`, ""}},
+	}
+	for _, test := range tests {
+		words, err := SplitCommandLine(test.line)
+		if err != nil || !reflect.DeepEqual(words, test.expected) {
+			t.Errorf("SplitCommandLine(%q) = %q, %v, expected %q", test.line, words, err, test.expected)
+		}
+	}
+	for _, bad := range []string{"log 'open", `end\`} {
+		if _, err := SplitCommandLine(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	os.Mkdir(".got", 0755)
+	conf := config.NewConfig()
+	for key, value := range map[string]string{
+		"alias.co":     "checkout",
+		"alias.lg":     "log --oneline -n 20",
+		"alias.last":   "lg -n 1",
+		"alias.hello":  "!echo hello",
+		"alias.greet":  "hello",
+		"alias.loop1":  "loop2 -x",
+		"alias.loop2":  "loop1",
+		"alias.status": "log",
+	} {
+		if err := conf.SetScopedKeyValue(config.SCOPE_GLOBAL, key, value); err != nil {
+			t.Fatalf("SetScopedKeyValue returned error: %v", err)
+		}
+	}
+	builtin := func(name string) bool {
+		return name == "checkout" || name == "log" || name == "status"
+	}
+
+	tests := []struct {
+		args     []string
+		expected Expansion
+	}{
+		{[]string{"co", "-b", "topic"}, Expansion{Args: []string{"checkout", "-b", "topic"}}},
+		{[]string{"last", "main"}, Expansion{Args: []string{"log", "--oneline", "-n", "20", "-n", "1", "main"}}},
+		{[]string{"greet", "world"}, Expansion{Args: []string{"world"}, Shell: "echo hello"}},
+		// commands of got are not overridden
+		{[]string{"status"}, Expansion{Args: []string{"status"}}},
+		{[]string{"--version"}, Expansion{Args: []string{"--version"}}},
+		{[]string{"unknown"}, Expansion{Args: []string{"unknown"}}},
+		{[]string{}, Expansion{Args: []string{}}},
+	}
+	for _, test := range tests {
+		expansion, err := Expand(conf, test.args, builtin)
+		if err != nil || !reflect.DeepEqual(expansion, test.expected) {
+			t.Errorf("Expand(%q) = %+v, %v, expected %+v", test.args, expansion, err, test.expected)
+		}
+	}
+
+	if _, err := Expand(conf, []string{"loop1"}, builtin); err == nil || !strings.Contains(err.Error(), "alias loop detected") {
+		t.Errorf("Expected an alias loop error, got %v", err)
+	}
+}
+
+func TestRunShell(t *testing.T) {
+	code, err := RunShell(`test "$1" = "two words" && exit 4`, []string{"two words"})
+	if err != nil || code != 4 {
+		t.Errorf("Expected the arguments to reach the shell and exit code 4, got %d (%v)", code, err)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"got_it/internal/commands/config"
 	_init "got_it/internal/commands/init"
+	"got_it/internal/lfs"
 	"got_it/internal/logger"
 	"got_it/internal/models"
+	"got_it/internal/objects"
 	"got_it/internal/utils"
 	"os"
 	"path/filepath"
@@ -16,12 +18,18 @@ import (
 type Add struct {
 	config *config.Config
 	logger *logger.Logger
+	store  *objects.Store
+	lfs    *lfs.Store
+	// the patterns of .gotattributes, read once per run
+	lfsPatterns []string
 }
 
 func NewAdd(config *config.Config, logger *logger.Logger) *Add {
 	return &Add{
 		config: config,
 		logger: logger,
+		store:  objects.NewStore(config, logger),
+		lfs:    lfs.NewStore(config, logger),
 	}
 }
 
@@ -51,6 +59,12 @@ func (a *Add) runAdd(files []string) {
 	// Get staged files
 	stagedFiles, err := utils.ReadIndex(indexFile)
 
+	// Get the patterns of the files stored by lfs
+	a.lfsPatterns, err = lfs.Patterns(".")
+	if err != nil {
+		a.logger.Debug("Error reading %s: %v\n", lfs.ATTRIBUTES_FILE, err)
+	}
+
 	// Get the absolute path of the repository root
 	repoRoot, err := filepath.Abs(".")
 	if err != nil {
@@ -68,12 +82,10 @@ func (a *Add) runAdd(files []string) {
 		}
 
 		// Check if the file is within the repository
-		if repoRoot != absFile {
-			if mached, err := filepath.Match(repoRoot+"/*", absFile); err != nil || !mached {
-				fmt.Printf("Error: %s is outside the repository\n", absFile)
-				fmt.Println("root:", repoRoot)
-				continue
-			}
+		if rel, err := filepath.Rel(repoRoot, absFile); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			fmt.Printf("Error: %s is outside the repository\n", absFile)
+			fmt.Println("root:", repoRoot)
+			continue
 		}
 
 		// Get file information
@@ -115,36 +127,19 @@ func (a *Add) stageFile(file string, stagedFiles map[string]string) {
 	if a.ignoreFile(file) {
 		return
 	}
-	hash, err := utils.HashFile(file)
-	if err != nil {
-		fmt.Printf("Error hashing file %v\n", err)
-		return
-	}
-
-	err = a.storeFileContet(file, hash)
+	hash, err := a.storeFile(file)
 	if err != nil {
 		fmt.Printf("Error storing file content for %s: %v\n", file, err)
 		return
 	}
 
 	if isChanged {
-
-		// Create a delta between the old and new file contents
-		stagedFilePath := stagedFiles[file]
-		stagedFile, err := os.ReadFile(stagedFilePath)
-		fileBytes, err := os.ReadFile(file)
-
-		delta := utils.CreateDelta(fileBytes, stagedFile)
+		// Update the index file with the hash of the new content
+		err = a.updateHashChangedFileInIndex(file, hash)
 		if err != nil {
-			fmt.Printf("Error creating delta for %s: %v\n", file, err)
+			fmt.Printf("Error updating %s in index: %v\n", file, err)
 			return
 		}
-		deltaHash := utils.HashContent(string(delta))
-		// TODO: Store the delta in the .got/deltas directory
-		// avaliate if we need to store the delta in a separate folder
-
-		// Udate the index file with the new hash
-		err = a.updateHashChangedFileInIndex(file, deltaHash)
 		a.logger.Log("add '%s' (modified)\n", file)
 	} else {
 		err = addToIndex(indexFile, file, hash)
@@ -156,6 +151,37 @@ func (a *Add) stageFile(file string, stagedFiles map[string]string) {
 	}
 }
 
+// storeFile stores the file and returns the hash of its blob. The file is
+// hashed while it is stored, a chunk at a time; a file tracked by lfs goes
+// to .got/lfs and its pointer is stored instead.
+func (a *Add) storeFile(file string) (string, error) {
+	if !a.isLFS(file) {
+		return a.store.WriteFile(file)
+	}
+	pointer, err := a.lfs.Clean(file, true)
+	if err != nil {
+		return "", err
+	}
+	return a.store.Write(pointer.String())
+}
+
+// hashFile returns the hash the file would be staged with
+func (a *Add) hashFile(file string) (string, error) {
+	if !a.isLFS(file) {
+		return a.config.ObjectFormat().HashFile(file)
+	}
+	pointer, err := a.lfs.Clean(file, false)
+	if err != nil {
+		return "", err
+	}
+	return a.config.ObjectFormat().HashContent(pointer.String()), nil
+}
+
+// isLFS tells if the file matches a pattern of .gotattributes
+func (a *Add) isLFS(file string) bool {
+	return lfs.IsTracked(a.lfsPatterns, utils.SlashPath(file))
+}
+
 // checkStagedAndChanged checks if the file is already staged and if it has changed
 // if it is already staged, it returns true, false
 // if it has changed, it returns false, true
@@ -164,7 +190,7 @@ func (a *Add) checkStagedAndChanged(stagedFiles map[string]string, file string)
 	hashStaged, alreadyStaged := stagedFiles[file]
 	if alreadyStaged {
 		// Get file content and calculate hash
-		hashFromFile, err := utils.HashFile(file)
+		hashFromFile, err := a.hashFile(file)
 		if err != nil {
 			a.logger.Debug("Error hashing file %v\n", err)
 			return true, false
@@ -318,26 +344,6 @@ func isEssentialFile(file string, essentials []string) bool {
 	return false
 }
 
-// storeFileContent saves the file content in the .got/objects directory
-func (a *Add) storeFileContet(filePath, hash string) error {
-	// take the firt two characters of the hash as the directory name
-	objDir := filepath.Join(a.config.GetGotDir(), "objects", hash[:2])
-
-	if err := os.MkdirAll(objDir, 0755); err != nil {
-		return err
-	}
-
-	// take the rest of the hash as the file name
-	objFile := filepath.Join(objDir, hash[2:])
-
-	fileContent, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(objFile, fileContent, 0644)
-}
-
 func addToIndex(indexFile, filePath, hash string) error {
 	entry := models.IndexEntry{Path: filePath, Hash: hash}
 
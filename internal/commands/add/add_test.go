@@ -4,8 +4,10 @@ import (
 	"crypto/rand"
 	"fmt"
 	"got_it/internal/commands/config"
+	"got_it/internal/lfs"
 	"got_it/internal/logger"
 	"got_it/internal/models"
+	"got_it/internal/testrepo"
 	"got_it/internal/utils"
 	"os"
 	"path/filepath"
@@ -120,7 +122,7 @@ func TestUpdateIndexFile(t *testing.T) {
 	}
 	//newContent := generateRandomContent(t)
 	writeToFile(t, files[0], "Hi")
-	newHash := utils.HashContent("Hi")
+	newHash := utils.OF_SHA1.HashContent("Hi")
 	t.Logf("\nfile: %s\nnew hash: %s", files[0], newHash)
 	// ACT
 	err = a.updateHashChangedFileInIndex(files[0], newHash)
@@ -200,5 +202,71 @@ func createRandomFileGetHash(t *testing.T, filePath string) (string, string) {
 	if err != nil {
 		t.Errorf("Error writing to file: %v", err)
 	}
-	return filePath, utils.HashContent(content)
+	return filePath, utils.OF_SHA1.HashContent(content)
+}
+
+func TestAddStoresFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.Chdir(tempDir)
+	os.Mkdir(".got", 0755)
+	content := strings.Repeat("large\n", 1<<16)
+	os.WriteFile("file.bin", []byte(content), 0644)
+	a := NewAdd(config.NewConfig(), logger.NewLogger(false, false))
+
+	a.stageFile("file.bin", map[string]string{})
+
+	staged, err := utils.ReadIndex(a.config.GetIndexPath())
+	if err != nil {
+		t.Fatalf("Error reading index: %v", err)
+	}
+	hash := utils.OF_SHA1.HashContent(content)
+	if staged["file.bin"] != hash {
+		t.Errorf("Expected file.bin staged as %s, got %v", hash, staged)
+	}
+	if stored, err := a.store.Size(hash); err != nil || stored != int64(len(content)) {
+		t.Errorf("Expected the blob of file.bin to be stored with %d bytes, got %d (%v)", len(content), stored, err)
+	}
+}
+
+func TestAddLFSFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.Chdir(tempDir)
+	os.Mkdir(".got", 0755)
+	os.WriteFile(lfs.ATTRIBUTES_FILE, []byte("*.psd "+lfs.LFS_ATTRIBUTES+"\n"), 0644)
+	os.WriteFile("logo.psd", []byte("design\n"), 0644)
+	a := NewAdd(config.NewConfig(), logger.NewLogger(false, false))
+
+	a.runAdd([]string{"logo.psd"})
+
+	staged, err := utils.ReadIndex(a.config.GetIndexPath())
+	if err != nil {
+		t.Fatalf("Error reading index: %v", err)
+	}
+	pointer, _ := a.lfs.Clean("logo.psd", false)
+	if staged["logo.psd"] != utils.OF_SHA1.HashContent(pointer.String()) {
+		t.Errorf("Expected logo.psd staged as its pointer, got %v", staged)
+	}
+	if !a.lfs.Exists(pointer.Oid) {
+		t.Errorf("Expected the content of logo.psd in .got/lfs")
+	}
+	if isStaged, _ := a.checkStagedAndChanged(staged, "logo.psd"); !isStaged {
+		t.Errorf("Expected logo.psd to be already staged")
+	}
+}
+
+func TestAddFileInSubdirectory(t *testing.T) {
+	repo := testrepo.New(t)
+	testrepo.WriteFile(t, filepath.Join("art", "icons", "logo.svg"), "<svg/>\n")
+	testrepo.WriteFile(t, filepath.Join(filepath.Dir(repo.Dir), "outside.txt"), "outside\n")
+	a := NewAdd(repo.Conf, repo.Logger)
+
+	a.runAdd([]string{filepath.Join("art", "icons", "logo.svg"), filepath.Join("..", "outside.txt")})
+
+	staged, err := utils.ReadIndex(a.config.GetIndexPath())
+	if err != nil {
+		t.Fatalf("Error reading index: %v", err)
+	}
+	if len(staged) != 1 || staged["art/icons/logo.svg"] != repo.Write("<svg/>\n") {
+		t.Errorf("Expected only art/icons/logo.svg to be staged, got %v", staged)
+	}
 }
@@ -0,0 +1,406 @@
+package bisect
+
+import (
+	"errors"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+type Bisect struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	worktree *worktree.Worktree
+	out      io.Writer
+}
+
+func NewBisect(conf *config.Config, logger *logger.Logger) *Bisect {
+	return &Bisect{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute runs a bisect subcommand
+func Execute(args []string) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	bs := NewBisect(conf, logger)
+
+	if len(args) == 0 {
+		fmt.Println("Error: a subcommand is required: start, bad, good, skip, reset, log or run")
+		return
+	}
+	var err error
+	switch args[0] {
+	case "start":
+		err = bs.Start(args[1:])
+	case TERM_BAD, TERM_GOOD, TERM_SKIP:
+		_, err = bs.Mark(args[0], args[1:])
+	case "reset":
+		commit := ""
+		if len(args) > 1 {
+			commit = args[1]
+		}
+		err = bs.Reset(commit)
+	case "log":
+		err = bs.Log()
+	case "run":
+		err = bs.Run(args[1:])
+	default:
+		err = fmt.Errorf("unknown subcommand: %s", args[0])
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Start begins a bisection, remembering what HEAD was to get back to it on
+// reset. The bad commit and good ones may be given right away.
+func (bs *Bisect) Start(revs []string) error {
+	if bs.InProgress() {
+		if err := bs.Reset(""); err != nil {
+			return err
+		}
+	}
+	status, err := bs.worktree.Status()
+	if err != nil {
+		return err
+	}
+	if !status.IsClean() {
+		return fmt.Errorf("cannot bisect: you have staged or unstaged changes, commit or stash them")
+	}
+	start, err := bs.refs.CurrentBranch()
+	if err != nil {
+		if start, _, err = bs.refs.Resolve(refs.HEAD); err != nil {
+			return fmt.Errorf("cannot bisect a branch without commits")
+		}
+	}
+	hashes := []string{}
+	for _, rev := range revs {
+		hash, err := bs.resolver.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if err := bs.writeFile(BISECT_START, start+"\n"); err != nil {
+		return err
+	}
+	if err := bs.writeFile(BISECT_LOG, strings.TrimSpace("got bisect start "+strings.Join(revs, " "))+"\n"); err != nil {
+		return err
+	}
+	for i, hash := range hashes {
+		term := TERM_GOOD
+		if i == 0 {
+			term = TERM_BAD
+		}
+		if err := bs.record(term, hash); err != nil {
+			return err
+		}
+	}
+	_, err = bs.next()
+	return err
+}
+
+// Mark records the commits, HEAD by default, as good, bad or skipped and
+// checks out the next commit to test. It tells if the search is over.
+func (bs *Bisect) Mark(term string, revs []string) (bool, error) {
+	if !bs.InProgress() {
+		return false, fmt.Errorf("you need to start by \"got bisect start\"")
+	}
+	if len(revs) == 0 {
+		revs = []string{revision.HEAD}
+	}
+	if term == TERM_BAD && len(revs) > 1 {
+		return false, fmt.Errorf("only one bad commit can be given")
+	}
+	for _, rev := range revs {
+		hash, err := bs.resolver.ResolveCommit(rev)
+		if err != nil {
+			return false, err
+		}
+		if err := bs.record(term, hash); err != nil {
+			return false, err
+		}
+	}
+	return bs.next()
+}
+
+// Reset ends the bisection and checks out the commit HEAD was on when it
+// started, or the given one
+func (bs *Bisect) Reset(commit string) error {
+	if !bs.InProgress() {
+		fmt.Fprintln(bs.out, "We are not bisecting.")
+		return nil
+	}
+	start, err := bs.readFile(BISECT_START)
+	if err != nil {
+		return err
+	}
+	if commit == "" {
+		commit = strings.TrimSpace(start)
+	}
+	if err := bs.checkout(commit); err != nil {
+		return err
+	}
+	return bs.removeState()
+}
+
+// Log writes the commands recorded since the bisection started, in a form
+// that can be replayed
+func (bs *Bisect) Log() error {
+	if !bs.InProgress() {
+		return fmt.Errorf("we are not bisecting")
+	}
+	content, err := bs.readFile(BISECT_LOG)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(bs.out, content)
+	return nil
+}
+
+// Run tests each commit with the shell command: exit code 0 marks it good,
+// 125 skips it, any other code below 128 marks it bad
+func (bs *Bisect) Run(command []string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("bisect run needs a command")
+	}
+	st, err := bs.readState()
+	if err != nil {
+		return err
+	}
+	if st.Bad == "" || len(st.Good) == 0 {
+		return fmt.Errorf("bisect run needs both a good and a bad commit")
+	}
+	line := strings.Join(command, " ")
+	for {
+		fmt.Fprintf(bs.out, "running '%s'\n", line)
+		cmd := exec.Command("sh", "-c", line)
+		cmd.Stdout = bs.out
+		cmd.Stderr = bs.out
+		code := 0
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return err
+			}
+			code = exitErr.ExitCode()
+		}
+
+		term := TERM_BAD
+		switch {
+		case code == 0:
+			term = TERM_GOOD
+		case code == SKIP_EXIT_CODE:
+			term = TERM_SKIP
+		case code < 0 || code >= 128:
+			return fmt.Errorf("bisect run failed: exit code %d from '%s' is < 0 or >= 128", code, line)
+		}
+		done, err := bs.Mark(term, nil)
+		if err != nil {
+			return err
+		}
+		if done {
+			fmt.Fprintln(bs.out, "bisect found first bad commit")
+			return nil
+		}
+	}
+}
+
+// next checks out the commit halving the commits left to test, or reports
+// the first bad commit. It tells if the search is over.
+func (bs *Bisect) next() (bool, error) {
+	st, err := bs.readState()
+	if err != nil {
+		return false, err
+	}
+	if st.Bad == "" || len(st.Good) == 0 {
+		if st.Bad == "" && len(st.Good) == 0 {
+			fmt.Fprintln(bs.out, "status: waiting for both good and bad commits")
+		} else if st.Bad == "" {
+			fmt.Fprintf(bs.out, "status: waiting for bad commit, %d good commit(s) known\n", len(st.Good))
+		} else {
+			fmt.Fprintln(bs.out, "status: waiting for good commit(s), bad commit known")
+		}
+		return false, nil
+	}
+
+	candidates, order, err := bs.candidates(st)
+	if err != nil {
+		return false, err
+	}
+	if len(candidates) == 1 {
+		return true, bs.reportFirstBad(st.Bad)
+	}
+	testable := []string{}
+	for hash := range candidates {
+		if hash != st.Bad && !contains(st.Skip, hash) {
+			testable = append(testable, hash)
+		}
+	}
+	if len(testable) == 0 {
+		fmt.Fprintln(bs.out, "There are only 'skip'ped commits left to test.")
+		fmt.Fprintln(bs.out, "The first bad commit could be any of:")
+		for _, hash := range sortedHashes(candidates) {
+			fmt.Fprintln(bs.out, hash)
+		}
+		return true, fmt.Errorf("we cannot bisect more")
+	}
+
+	midpoint, reached := bs.midpoint(candidates, order, testable)
+	left := len(candidates) - reached - 1
+	commit, err := bs.store.ReadCommit(midpoint)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(bs.out, "Bisecting: %d revision(s) left to test after this (roughly %d step(s))\n", left, estimateSteps(len(candidates)))
+	fmt.Fprintf(bs.out, "[%s] %s\n", midpoint, subjectOf(commit.Message))
+	return false, bs.checkout(midpoint)
+}
+
+// candidates returns the commits that may be the first bad one: reachable
+// from the bad commit and from none of the good ones, with their parents,
+// and their hashes with children before parents
+func (bs *Bisect) candidates(st state) (map[string][]string, []string, error) {
+	walker, err := bs.resolver.Walk(revision.Range{Include: []string{st.Bad}, Exclude: st.Good}, revision.WalkOptions{TopoOrder: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates := map[string][]string{}
+	order := []string{}
+	for commit, ok := walker.Next(); ok; commit, ok = walker.Next() {
+		candidates[commit.Hash] = commit.Parents
+		order = append(order, commit.Hash)
+	}
+	return candidates, order, nil
+}
+
+// midpoint picks the testable commit whose ancestors among the candidates
+// come closest to half of them, and returns it with that number of
+// ancestors. The numbers are counted in one pass, parents first: a commit
+// with a single parent among the candidates has one ancestor more than it,
+// only merges have their ancestors walked.
+func (bs *Bisect) midpoint(candidates map[string][]string, order []string, testable []string) (string, int) {
+	counts := make(map[string]int, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		hash := order[i]
+		parents := []string{}
+		for _, parent := range candidates[hash] {
+			if _, candidate := candidates[parent]; candidate {
+				parents = append(parents, parent)
+			}
+		}
+		switch len(parents) {
+		case 0:
+			counts[hash] = 1
+		case 1:
+			counts[hash] = counts[parents[0]] + 1
+		default:
+			counts[hash] = countAncestors(candidates, hash)
+		}
+	}
+
+	sort.Strings(testable)
+	best, bestReached, bestScore := "", 0, -1
+	for _, hash := range testable {
+		reached := counts[hash]
+		score := min(reached, len(candidates)-reached)
+		if score > bestScore {
+			best, bestReached, bestScore = hash, reached, score
+		}
+	}
+	return best, bestReached
+}
+
+// reportFirstBad writes the first bad commit and records it in the log
+func (bs *Bisect) reportFirstBad(hash string) error {
+	commit, err := bs.store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bs.out, "%s is the first bad commit\n", hash)
+	fmt.Fprintf(bs.out, "commit %s\nAuthor: %s <%s>\nDate:   %s\n\n", hash, commit.AuthorName, commit.AuthorEmail, date.Format(commit.AuthorDate))
+	for _, line := range strings.Split(strings.TrimRight(commit.Message, "\n"), "\n") {
+		fmt.Fprintf(bs.out, "    %s\n", line)
+	}
+	return bs.appendLog(fmt.Sprintf("# first bad commit: [%s] %s\n", hash, subjectOf(commit.Message)))
+}
+
+// checkout moves HEAD to the commit or the branch, detaching it for a
+// commit, and updates the worktree keeping the local changes
+func (bs *Bisect) checkout(target string) error {
+	from := refs.ShortName(bs.headName())
+	if strings.HasPrefix(target, "refs/") {
+		hash, _, err := bs.refs.Resolve(target)
+		if err != nil {
+			return err
+		}
+		if err := bs.updateWorktree(hash); err != nil {
+			return err
+		}
+		return bs.refs.SetSymbolic(refs.HEAD, target, fmt.Sprintf("checkout: moving from %s to %s", from, refs.ShortName(target)))
+	}
+	hash, err := bs.resolver.ResolveCommit(target)
+	if err != nil {
+		return err
+	}
+	if err := bs.updateWorktree(hash); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("checkout: moving from %s to %s", from, hash)
+	return bs.refs.Update(refs.HEAD, hash, refs.UpdateOptions{NoDeref: true, Message: message})
+}
+
+func (bs *Bisect) updateWorktree(hash string) error {
+	headFiles, _, err := bs.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	files, err := bs.worktree.CommitFiles(hash)
+	if err != nil {
+		return err
+	}
+	if err := bs.worktree.CheckConflicts(headFiles, files); err != nil {
+		return err
+	}
+	if err := bs.worktree.Checkout(headFiles, files); err != nil {
+		return err
+	}
+	return bs.worktree.WriteIndex(files)
+}
+
+// headName is the branch HEAD points to, or the commit when it is detached
+func (bs *Bisect) headName() string {
+	if branch, err := bs.refs.CurrentBranch(); err == nil {
+		return branch
+	}
+	hash, _, _ := bs.refs.Resolve(refs.HEAD)
+	return hash
+}
+
+// SetOutput changes where the output is written
+func (bs *Bisect) SetOutput(out io.Writer) {
+	bs.out = out
+}
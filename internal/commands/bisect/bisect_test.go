@@ -0,0 +1,146 @@
+package bisect
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// arrangeRepo creates eight commits on main where the n-th one writes n to
+// version.txt, checks out main and returns the commits oldest first
+func arrangeRepo(t *testing.T) (*Bisect, *bytes.Buffer, []string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	bs := NewBisect(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	bs.SetOutput(out)
+
+	commits := []string{}
+	for i := 0; i < 8; i++ {
+		cd := models.CommitData{Message: fmt.Sprintf("version %d", i), AuthorDate: testrepo.DATE.Add(time.Duration(i) * time.Second)}
+		if i > 0 {
+			cd.Parents = []string{commits[i-1]}
+		}
+		commits = append(commits, repo.CommitData(cd, map[string]string{"version.txt": fmt.Sprintf("%d\n", i)}))
+	}
+	repo.SetRef("refs/heads/main", commits[7])
+	repo.Checkout(commits[7])
+	return bs, out, commits
+}
+
+func head(t *testing.T, bs *Bisect) string {
+	t.Helper()
+	hash, _, err := bs.refs.Resolve(refs.HEAD)
+	if err != nil {
+		t.Fatalf("Error resolving HEAD: %v", err)
+	}
+	return hash
+}
+
+func TestManualBisect(t *testing.T) {
+	bs, out, commits := arrangeRepo(t)
+	if err := bs.Start(nil); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if _, err := bs.Mark(TERM_BAD, nil); err != nil {
+		t.Fatalf("Mark returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "status: waiting for good commit(s), bad commit known") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	out.Reset()
+	if _, err := bs.Mark(TERM_GOOD, []string{commits[0]}); err != nil {
+		t.Fatalf("Mark returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Bisecting: 2 revision(s) left to test after this (roughly 2 step(s))\n") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	if head(t, bs) != commits[4] || !bs.refs.IsDetached() {
+		t.Fatalf("Expected HEAD to be detached at the midpoint")
+	}
+
+	// versions from 3 on are bad and 5 cannot be tested
+	for {
+		content, _ := os.ReadFile("version.txt")
+		term := TERM_GOOD
+		if string(content) == "5\n" {
+			term = TERM_SKIP
+		} else if string(content) >= "3\n" {
+			term = TERM_BAD
+		}
+		done, err := bs.Mark(term, nil)
+		if err != nil {
+			t.Fatalf("Mark returned error: %v", err)
+		}
+		if done {
+			break
+		}
+	}
+	if !strings.Contains(out.String(), commits[3]+" is the first bad commit\n") {
+		t.Errorf("Expected version 3 to be the first bad commit, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	bs.Log()
+	if !strings.HasPrefix(out.String(), "got bisect start\n# bad: ["+commits[7]+"] version 7\ngot bisect bad "+commits[7]+"\n") ||
+		!strings.HasSuffix(out.String(), "# first bad commit: ["+commits[3]+"] version 3\n") {
+		t.Errorf("Unexpected log:\n%s", out.String())
+	}
+
+	if err := bs.Reset(""); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if branch, _ := bs.refs.CurrentBranch(); branch != "refs/heads/main" || head(t, bs) != commits[7] {
+		t.Errorf("Expected reset to check out main again")
+	}
+	content, _ := os.ReadFile("version.txt")
+	if string(content) != "7\n" {
+		t.Errorf("Expected the worktree of main back, got %q", content)
+	}
+	if bs.InProgress() {
+		t.Errorf("Expected the bisect state to be removed")
+	}
+}
+
+func TestRun(t *testing.T) {
+	bs, out, commits := arrangeRepo(t)
+	if err := bs.Start([]string{"HEAD", commits[1]}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	// version 4 cannot be tested, versions from 6 on are bad
+	if err := bs.Run([]string{`v=$(cat version.txt); test $v -eq 4 && exit 125; test $v -lt 6`}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), commits[6]+" is the first bad commit\n") ||
+		!strings.HasSuffix(out.String(), "bisect found first bad commit\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	bs.Start([]string{"HEAD", commits[0]})
+	if err := bs.Run([]string{"exit 200"}); err == nil || !strings.Contains(err.Error(), "exit code 200") {
+		t.Errorf("Expected run to fail on exit code 200, got %v", err)
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	// a -- b -- c -- e -- f, d branching off b and merged by e; good is not
+	// a candidate
+	candidates := map[string][]string{
+		"a": {"good"}, "b": {"a"}, "c": {"b"}, "d": {"b"}, "e": {"c", "d"}, "f": {"e"},
+	}
+	order := []string{"f", "e", "d", "c", "b", "a"}
+	bs := &Bisect{}
+
+	if hash, reached := bs.midpoint(candidates, order, []string{"e", "d", "c", "b", "a"}); hash != "c" || reached != 3 {
+		t.Errorf("Expected c with 3 ancestors, got %s with %d", hash, reached)
+	}
+	if hash, reached := bs.midpoint(candidates, order, []string{"e"}); hash != "e" || reached != 5 {
+		t.Errorf("Expected the merge e to have 5 ancestors, got %s with %d", hash, reached)
+	}
+}
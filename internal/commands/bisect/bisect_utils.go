@@ -0,0 +1,174 @@
+package bisect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BISECT_START holds the branch, or the commit, HEAD was on when the
+// bisection started
+const BISECT_START string = "BISECT_START"
+
+// BISECT_LOG holds the commands run since the bisection started
+const BISECT_LOG string = "BISECT_LOG"
+
+// BISECT_BAD holds the bad commit, BISECT_GOOD and BISECT_SKIP the good and
+// skipped ones, one per line
+const BISECT_BAD string = "BISECT_BAD"
+const BISECT_GOOD string = "BISECT_GOOD"
+const BISECT_SKIP string = "BISECT_SKIP"
+
+const (
+	TERM_BAD  string = "bad"
+	TERM_GOOD string = "good"
+	TERM_SKIP string = "skip"
+)
+
+// SKIP_EXIT_CODE is the exit code telling bisect run that the commit
+// cannot be tested
+const SKIP_EXIT_CODE int = 125
+
+// state is the set of commits marked so far
+type state struct {
+	Bad  string
+	Good []string
+	Skip []string
+}
+
+// InProgress tells if a bisection was started and not reset
+func (bs *Bisect) InProgress() bool {
+	_, err := os.Stat(bs.path(BISECT_START))
+	return err == nil
+}
+
+func (bs *Bisect) readState() (state, error) {
+	if !bs.InProgress() {
+		return state{}, fmt.Errorf("you need to start by \"got bisect start\"")
+	}
+	st := state{}
+	bad, _ := bs.readFile(BISECT_BAD)
+	st.Bad = strings.TrimSpace(bad)
+	good, _ := bs.readFile(BISECT_GOOD)
+	st.Good = strings.Fields(good)
+	skip, _ := bs.readFile(BISECT_SKIP)
+	st.Skip = strings.Fields(skip)
+	return st, nil
+}
+
+// record marks the commit with the term and logs it
+func (bs *Bisect) record(term, hash string) error {
+	var err error
+	switch term {
+	case TERM_BAD:
+		err = bs.writeFile(BISECT_BAD, hash+"\n")
+	case TERM_GOOD:
+		err = bs.addHash(BISECT_GOOD, hash)
+	case TERM_SKIP:
+		err = bs.addHash(BISECT_SKIP, hash)
+	}
+	if err != nil {
+		return err
+	}
+	commit, err := bs.store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+	return bs.appendLog(fmt.Sprintf("# %s: [%s] %s\ngot bisect %s %s\n", term, hash, subjectOf(commit.Message), term, hash))
+}
+
+// addHash adds the hash to the list in the file, unless it is there already
+func (bs *Bisect) addHash(name, hash string) error {
+	content, _ := bs.readFile(name)
+	if contains(strings.Fields(content), hash) {
+		return nil
+	}
+	return bs.writeFile(name, content+hash+"\n")
+}
+
+func (bs *Bisect) appendLog(text string) error {
+	content, err := bs.readFile(BISECT_LOG)
+	if err != nil {
+		return err
+	}
+	return bs.writeFile(BISECT_LOG, content+text)
+}
+
+func (bs *Bisect) readFile(name string) (string, error) {
+	content, err := os.ReadFile(bs.path(name))
+	return string(content), err
+}
+
+func (bs *Bisect) writeFile(name, content string) error {
+	return os.WriteFile(bs.path(name), []byte(content), 0644)
+}
+
+func (bs *Bisect) removeState() error {
+	for _, name := range []string{BISECT_START, BISECT_LOG, BISECT_BAD, BISECT_GOOD, BISECT_SKIP} {
+		if err := os.Remove(bs.path(name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *Bisect) path(name string) string {
+	return filepath.Join(bs.conf.GotDir, name)
+}
+
+// countAncestors counts the candidates reachable from hash, itself included
+func countAncestors(candidates map[string][]string, hash string) int {
+	seen := map[string]bool{hash: true}
+	queue := []string{hash}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parent := range candidates[current] {
+			if _, candidate := candidates[parent]; candidate && !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return len(seen)
+}
+
+// estimateSteps guesses how many more steps are needed among all commits
+func estimateSteps(all int) int {
+	if all < 3 {
+		return 0
+	}
+	n := 0
+	for 1<<(n+1) <= all {
+		n++
+	}
+	if e := 1 << n; e < 3*(all-e) {
+		return n
+	}
+	return n - 1
+}
+
+func sortedHashes(candidates map[string][]string) []string {
+	hashes := []string{}
+	for hash := range candidates {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+func contains(hashes []string, hash string) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n")
+	return subject
+}
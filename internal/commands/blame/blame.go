@@ -0,0 +1,212 @@
+package blame
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/index"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/revision"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type Blame struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	resolver *revision.Resolver
+	worktree *worktree.Worktree
+	out      io.Writer
+}
+
+// BlameOptions holds the flags of the blame command
+type BlameOptions struct {
+	LineRange string
+	Porcelain bool
+}
+
+// Line is a line of the blamed file with the commit it comes from
+type Line struct {
+	Hash     string
+	OrigLine int // number of the line in the file of Hash
+	Line     int // number of the line in the blamed file
+	Content  string
+}
+
+// suspect is a version of the file still holding lines to blame, mapped
+// from their number in this version to their index in the blamed file
+type suspect struct {
+	hash    string
+	commit  models.CommitData
+	entry   models.TreeEntry
+	content string
+	lines   map[int]int
+}
+
+func NewBlame(conf *config.Config, logger *logger.Logger) *Blame {
+	return &Blame{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute blames the file in args[0] as of the revision in args[1], HEAD
+// by default
+func Execute(args []string, opts BlameOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	bl := NewBlame(conf, logger)
+
+	rev := revision.HEAD
+	if len(args) > 1 {
+		rev = args[1]
+	}
+	if err := bl.Show(args[0], rev, opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Show writes the lines of the file, or of the range given with -L, with
+// the commit that last changed each of them
+func (bl *Blame) Show(file, rev string, opts BlameOptions) error {
+	hash, err := bl.resolver.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	filePath := bl.repoPath(file)
+	lines, commits, err := bl.Blame(filePath, hash)
+	if err != nil {
+		return err
+	}
+	if opts.LineRange != "" {
+		start, end, err := parseLineRange(opts.LineRange, len(lines))
+		if err != nil {
+			return err
+		}
+		lines = lines[start-1 : end]
+	}
+	if opts.Porcelain {
+		fmt.Fprint(bl.out, bl.formatPorcelain(filePath, lines, commits))
+	} else {
+		fmt.Fprint(bl.out, bl.formatLines(lines, commits))
+	}
+	return nil
+}
+
+// Blame attributes every line of the file at filePath in the commit hash to
+// the commit that introduced it. The commits are returned by hash.
+func (bl *Blame) Blame(filePath, hash string) ([]Line, map[string]models.CommitData, error) {
+	commit, err := bl.store.ReadCommit(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := bl.store.FindInTree(commit.Tree, filePath)
+	if err != nil || entry.Type != string(models.TT_BLOB) {
+		return nil, nil, fmt.Errorf("no such path '%s' in %s", filePath, bl.store.Abbreviate(hash, objects.ABBREV_LENGTH))
+	}
+	content, err := bl.store.Read(entry.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	texts := diff.SplitLines(content)
+	result := make([]Line, len(texts))
+	start := &suspect{hash: hash, commit: commit, entry: entry, content: content, lines: make(map[int]int)}
+	for i, text := range texts {
+		result[i] = Line{Line: i + 1, Content: text}
+		start.lines[i+1] = i
+	}
+	commits := map[string]models.CommitData{}
+
+	queue := []*suspect{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		parents, err := bl.passToParents(current, filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		queue = append(queue, parents...)
+		if len(current.lines) == 0 {
+			continue
+		}
+		commits[current.hash] = current.commit
+		for origLine, i := range current.lines {
+			result[i].Hash = current.hash
+			result[i].OrigLine = origLine
+		}
+	}
+	return result, commits, nil
+}
+
+// passToParents moves the lines of the suspect that are unchanged in one of
+// its parents to that parent; the lines left are the suspect's own
+func (bl *Blame) passToParents(current *suspect, filePath string) ([]*suspect, error) {
+	parents := []*suspect{}
+	for _, parentHash := range current.commit.Parents {
+		if len(current.lines) == 0 {
+			break
+		}
+		parentCommit, err := bl.store.ReadCommit(parentHash)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := bl.store.FindInTree(parentCommit.Tree, filePath)
+		if err != nil || entry.Type != string(models.TT_BLOB) {
+			continue
+		}
+		parent := &suspect{hash: parentHash, commit: parentCommit, entry: entry, lines: make(map[int]int)}
+		if entry.Hash == current.entry.Hash {
+			parent.content, parent.lines, current.lines = current.content, current.lines, map[int]int{}
+			parents = append(parents, parent)
+			break
+		}
+		if parent.content, err = bl.store.Read(entry.Hash); err != nil {
+			return nil, err
+		}
+		oldLine, newLine := 1, 1
+		for _, edit := range diff.Lines(parent.content, current.content) {
+			switch edit.Op {
+			case diff.OP_EQUAL:
+				if i, pending := current.lines[newLine]; pending {
+					parent.lines[oldLine] = i
+					delete(current.lines, newLine)
+				}
+				oldLine++
+				newLine++
+			case diff.OP_DELETE:
+				oldLine++
+			case diff.OP_INSERT:
+				newLine++
+			}
+		}
+		if len(parent.lines) > 0 {
+			parents = append(parents, parent)
+		}
+	}
+	return parents, nil
+}
+
+// repoPath turns a path given on the command line into a slash separated
+// path relative to the root of the repository
+func (bl *Blame) repoPath(file string) string {
+	if absolute, err := filepath.Abs(file); err == nil {
+		file = absolute
+	}
+	return index.RelativePath(bl.worktree.Root(), file)
+}
+
+// SetOutput changes where the output is written
+func (bl *Blame) SetOutput(out io.Writer) {
+	bl.out = out
+}
@@ -0,0 +1,110 @@
+package blame
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/testrepo"
+	"strings"
+	"testing"
+	"time"
+)
+
+// arrangeRepo creates three commits by different authors changing
+// dir/f.txt and returns them oldest first
+func arrangeRepo(t *testing.T) (*Blame, []string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	commits := []string{}
+	for i, version := range []struct{ author, content, message string }{
+		{"Alice", "1\n2\n3\n", "add f"},
+		{"Bob", "1\ntwo\n3\n4\n", "change 2, add 4"},
+		{"Carol", "0\n1\ntwo\n3\n4\n", "add 0"},
+	} {
+		cd := models.CommitData{Message: version.message, AuthorName: version.author,
+			AuthorEmail: strings.ToLower(version.author) + "@example.com", AuthorDate: testrepo.DATE.Add(time.Duration(i) * time.Minute)}
+		if i > 0 {
+			cd.Parents = []string{commits[i-1]}
+		}
+		commits = append(commits, repo.CommitData(cd, map[string]string{"dir/f.txt": version.content}))
+	}
+	repo.SetRef("refs/heads/main", commits[len(commits)-1])
+	return NewBlame(repo.Conf, repo.Logger), commits
+}
+
+func TestBlame(t *testing.T) {
+	bl, commits := arrangeRepo(t)
+	lines, _, err := bl.Blame("dir/f.txt", commits[2])
+	if err != nil {
+		t.Fatalf("Blame returned error: %v", err)
+	}
+	expected := []Line{
+		{Hash: commits[2], OrigLine: 1, Line: 1, Content: "0\n"},
+		{Hash: commits[0], OrigLine: 1, Line: 2, Content: "1\n"},
+		{Hash: commits[1], OrigLine: 2, Line: 3, Content: "two\n"},
+		{Hash: commits[0], OrigLine: 3, Line: 4, Content: "3\n"},
+		{Hash: commits[1], OrigLine: 4, Line: 5, Content: "4\n"},
+	}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+	if _, _, err := bl.Blame("dir/missing.txt", commits[2]); err == nil {
+		t.Errorf("Expected an error blaming a missing file")
+	}
+}
+
+func TestShow(t *testing.T) {
+	bl, commits := arrangeRepo(t)
+	out := &bytes.Buffer{}
+	bl.SetOutput(out)
+	if err := bl.Show("dir/f.txt", "HEAD~1", BlameOptions{LineRange: "2,+2"}); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	expected := fmt.Sprintf("%s (Bob   2021-06-12 14:34:54 +0200 2) two\n^%s (Alice 2021-06-12 14:33:54 +0200 3) 3\n",
+		commits[1][:8], commits[0][:7])
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := bl.Show("dir/f.txt", "HEAD", BlameOptions{LineRange: "3,4", Porcelain: true}); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != commits[1]+" 2 3 1" || lines[1] != "author Bob" || lines[9] != "summary change 2, add 4" ||
+		lines[10] != "filename dir/f.txt" || lines[11] != "\ttwo" || lines[12] != commits[0]+" 3 4 1" ||
+		lines[22] != "boundary" || lines[24] != "\t3" {
+		t.Errorf("Unexpected porcelain output:\n%s", out.String())
+	}
+
+	if err := bl.Show("dir/f.txt", "HEAD", BlameOptions{LineRange: "9,10"}); err == nil {
+		t.Errorf("Expected an error for a range past the end of the file")
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		value      string
+		start, end int
+		wantErr    bool
+	}{
+		{"2,4", 2, 4, false},
+		{"4,2", 2, 4, false},
+		{"3", 3, 10, false},
+		{"3,", 3, 10, false},
+		{"3,+2", 3, 4, false},
+		{"8,20", 8, 10, false},
+		{"5,0", 0, 0, true},
+		{"3,-2", 0, 0, true},
+		{"0,3", 0, 0, true},
+		{"3,+0", 0, 0, true},
+		{"11,12", 0, 0, true},
+		{"a,b", 0, 0, true},
+	}
+	for _, tt := range tests {
+		start, end, err := parseLineRange(tt.value, 10)
+		if (err != nil) != tt.wantErr || start != tt.start || end != tt.end {
+			t.Errorf("parseLineRange(%q) = %d, %d, %v, want %d, %d", tt.value, start, end, err, tt.start, tt.end)
+		}
+	}
+}
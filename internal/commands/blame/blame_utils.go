@@ -0,0 +1,115 @@
+package blame
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BLAME_DATE_FORMAT is how the default output shows author dates
+const BLAME_DATE_FORMAT string = "2006-01-02 15:04:05 -0700"
+
+// parseLineRange reads the -L argument, "start,end" or "start,+count",
+// and checks it against the number of lines of the file
+func parseLineRange(value string, total int) (int, int, error) {
+	startText, endText, found := strings.Cut(value, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid line range '%s'", value)
+	}
+	end := total
+	if found && endText != "" {
+		if strings.HasPrefix(endText, "+") {
+			count, err := strconv.Atoi(endText[1:])
+			if err != nil || count < 1 {
+				return 0, 0, fmt.Errorf("invalid line range '%s'", value)
+			}
+			end = start + count - 1
+		} else if end, err = strconv.Atoi(endText); err != nil || end < 1 {
+			return 0, 0, fmt.Errorf("invalid line range '%s'", value)
+		}
+	}
+	if end < start {
+		start, end = end, start
+	}
+	if start > total {
+		return 0, 0, fmt.Errorf("file has only %d lines", total)
+	}
+	if end > total {
+		end = total
+	}
+	return start, end, nil
+}
+
+// formatLines writes every line with the abbreviated commit, the author,
+// the date and the line number. Root commits are marked with a caret.
+func (bl *Blame) formatLines(lines []Line, commits map[string]models.CommitData) string {
+	authorWidth, numberWidth := 0, 0
+	for _, line := range lines {
+		authorWidth = max(authorWidth, utf8.RuneCountInString(commits[line.Hash].AuthorName))
+		numberWidth = max(numberWidth, len(strconv.Itoa(line.Line)))
+	}
+	var out strings.Builder
+	for _, line := range lines {
+		commit := commits[line.Hash]
+		// one more digit than usual, replaced by the caret of root commits
+		hash := bl.store.Abbreviate(line.Hash, objects.ABBREV_LENGTH+1)
+		if len(commit.Parents) == 0 {
+			hash = "^" + hash[:len(hash)-1]
+		}
+		author := commit.AuthorName + strings.Repeat(" ", authorWidth-utf8.RuneCountInString(commit.AuthorName))
+		out.WriteString(fmt.Sprintf("%s (%s %s %*d) %s", hash, author, commit.AuthorDate.Format(BLAME_DATE_FORMAT),
+			numberWidth, line.Line, withNewLine(line.Content)))
+	}
+	return out.String()
+}
+
+// formatPorcelain writes the lines in the format meant for tools: a header
+// for each group of lines from the same commit, the commit details the first
+// time it shows up, then each line prefixed by a tab
+func (bl *Blame) formatPorcelain(filePath string, lines []Line, commits map[string]models.CommitData) string {
+	var out strings.Builder
+	shown := map[string]bool{}
+	for i, line := range lines {
+		if i > 0 && lines[i-1].Hash == line.Hash && lines[i-1].OrigLine == line.OrigLine-1 {
+			out.WriteString(fmt.Sprintf("%s %d %d\n", line.Hash, line.OrigLine, line.Line))
+		} else {
+			count := 1
+			for j := i + 1; j < len(lines) && lines[j].Hash == line.Hash && lines[j].OrigLine == lines[j-1].OrigLine+1; j++ {
+				count++
+			}
+			out.WriteString(fmt.Sprintf("%s %d %d %d\n", line.Hash, line.OrigLine, line.Line, count))
+		}
+		if !shown[line.Hash] {
+			shown[line.Hash] = true
+			out.WriteString(porcelainDetails(commits[line.Hash], filePath))
+		}
+		out.WriteString("\t" + withNewLine(line.Content))
+	}
+	return out.String()
+}
+
+func porcelainDetails(commit models.CommitData, filePath string) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("author %s\nauthor-mail <%s>\n", commit.AuthorName, commit.AuthorEmail))
+	out.WriteString(fmt.Sprintf("author-time %d\nauthor-tz %s\n", commit.AuthorDate.Unix(), commit.AuthorDate.Format("-0700")))
+	out.WriteString(fmt.Sprintf("committer %s\ncommitter-mail <%s>\n", commit.CommitterName, commit.CommitterEmail))
+	out.WriteString(fmt.Sprintf("committer-time %d\ncommitter-tz %s\n", commit.CommitterDate.Unix(), commit.CommitterDate.Format("-0700")))
+	subject, _, _ := strings.Cut(strings.TrimLeft(commit.Message, "\n"), "\n")
+	out.WriteString(fmt.Sprintf("summary %s\n", subject))
+	if len(commit.Parents) == 0 {
+		out.WriteString("boundary\n")
+	}
+	out.WriteString(fmt.Sprintf("filename %s\n", filePath))
+	return out.String()
+}
+
+func withNewLine(text string) string {
+	if strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
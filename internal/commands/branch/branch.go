@@ -0,0 +1,273 @@
+package branch
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/transport"
+	"io"
+	"os"
+	"strings"
+)
+
+type Branch struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	out      io.Writer
+}
+
+// BranchOptions holds the flags of the branch command
+type BranchOptions struct {
+	Verbose       int // once for the hash and subject, twice for the upstream name
+	Remotes       bool
+	All           bool
+	Delete        bool
+	ForceDelete   bool
+	SetUpstreamTo string
+	UnsetUpstream bool
+}
+
+func NewBranch(conf *config.Config, logger *logger.Logger) *Branch {
+	return &Branch{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute lists, creates or deletes branches, or changes their upstream,
+// depending on the options
+func Execute(args []string, opts BranchOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	br := NewBranch(conf, logger)
+
+	var err error
+	switch {
+	case opts.SetUpstreamTo != "" || opts.UnsetUpstream:
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if opts.UnsetUpstream {
+			err = br.UnsetUpstream(name)
+		} else {
+			err = br.SetUpstream(name, opts.SetUpstreamTo)
+		}
+	case opts.Delete || opts.ForceDelete:
+		err = br.Delete(args, opts.ForceDelete)
+	case len(args) == 0:
+		err = br.List(opts)
+	case len(args) > 2:
+		err = fmt.Errorf("too many arguments")
+	default:
+		start := revision.HEAD
+		if len(args) == 2 {
+			start = args[1]
+		}
+		err = br.Create(args[0], start)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Create adds the branch name pointing to the commit start resolves to
+func (br *Branch) Create(name, start string) error {
+	if err := refs.ValidateBranchName(name); err != nil {
+		return err
+	}
+	fullRef := BRANCH_PREFIX + name
+	if br.refs.Exists(fullRef) {
+		return fmt.Errorf("a branch named '%s' already exists", name)
+	}
+	hash, err := br.resolver.ResolveCommit(start)
+	if err != nil {
+		return fmt.Errorf("not a valid object name: '%s'", start)
+	}
+	return br.refs.Update(fullRef, hash, refs.UpdateOptions{Message: "branch: Created from " + start})
+}
+
+// Delete removes the branches and their config. Unless forced, a branch
+// is only removed when HEAD contains its commits.
+func (br *Branch) Delete(names []string, force bool) error {
+	if len(names) == 0 {
+		return fmt.Errorf("branch name required")
+	}
+	current, _ := br.refs.CurrentBranch()
+	for _, name := range names {
+		fullRef := BRANCH_PREFIX + name
+		if fullRef == current {
+			return fmt.Errorf("cannot delete branch '%s' checked out", name)
+		}
+		ref, err := br.refs.Read(fullRef)
+		if err != nil {
+			return fmt.Errorf("branch '%s' not found", name)
+		}
+		if !force {
+			merged, err := br.isMerged(ref.Hash)
+			if err != nil {
+				return err
+			}
+			if !merged {
+				return fmt.Errorf("the branch '%s' is not fully merged, use -D to delete it anyway", name)
+			}
+		}
+		if err := br.refs.Delete(fullRef, ref.Hash); err != nil {
+			return err
+		}
+		if err := br.conf.RemoveBranchConfig(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(br.out, "Deleted branch %s (was %s).\n", name, br.store.Abbreviate(ref.Hash, objects.ABBREV_LENGTH))
+	}
+	return nil
+}
+
+// SetUpstream makes upstream, a remote-tracking branch like origin/main or
+// a local branch, the upstream of the branch, the current one by default
+func (br *Branch) SetUpstream(name, upstream string) error {
+	name, err := br.branchOrCurrent(name)
+	if err != nil {
+		return err
+	}
+	_, fullRef, err := br.resolver.ResolveRef(upstream)
+	if err != nil {
+		return fmt.Errorf("the requested upstream branch '%s' does not exist", upstream)
+	}
+	setting, err := br.upstreamFor(fullRef)
+	if err != nil {
+		return err
+	}
+	if err := br.conf.SetUpstream(name, setting); err != nil {
+		return err
+	}
+	fmt.Fprintf(br.out, "branch '%s' set up to track '%s'.\n", name, refs.ShortName(fullRef))
+	return nil
+}
+
+// UnsetUpstream forgets the upstream of the branch, the current one by
+// default
+func (br *Branch) UnsetUpstream(name string) error {
+	name, err := br.branchOrCurrent(name)
+	if err != nil {
+		return err
+	}
+	if err := br.conf.UnsetUpstream(name); err != nil {
+		return fmt.Errorf("branch '%s' has no upstream information", name)
+	}
+	return nil
+}
+
+// List writes the local branches, the remote-tracking ones or both, marking
+// the current branch with a star
+func (br *Branch) List(opts BranchOptions) error {
+	current, _ := br.refs.CurrentBranch()
+	prefixes := []string{BRANCH_PREFIX}
+	if opts.Remotes {
+		prefixes = []string{REMOTE_PREFIX}
+	} else if opts.All {
+		prefixes = append(prefixes, REMOTE_PREFIX)
+	}
+
+	lines := []listLine{}
+	for _, prefix := range prefixes {
+		found, err := br.refs.List(prefix)
+		if err != nil {
+			return err
+		}
+		for _, ref := range found {
+			lines = append(lines, br.listLine(ref, current, opts))
+		}
+	}
+	width := 0
+	for _, line := range lines {
+		if line.detail != "" {
+			width = max(width, len(line.name))
+		}
+	}
+	for _, line := range lines {
+		if opts.Verbose == 0 || line.detail == "" {
+			fmt.Fprintf(br.out, "%s %s\n", line.mark, line.name)
+			continue
+		}
+		fmt.Fprintf(br.out, "%s %-*s %s\n", line.mark, width, line.name, line.detail)
+	}
+	return nil
+}
+
+// listLine builds the line of a ref in the branch list
+func (br *Branch) listLine(ref refs.Ref, current string, opts BranchOptions) listLine {
+	line := listLine{mark: " ", name: refs.ShortName(ref.Name)}
+	if ref.Name == current {
+		line.mark = "*"
+	}
+	if opts.All && strings.HasPrefix(ref.Name, REMOTE_PREFIX) {
+		line.name = strings.TrimPrefix(ref.Name, "refs/")
+	}
+	if ref.IsSymbolic() {
+		line.name += " -> " + refs.ShortName(ref.Target)
+		return line
+	}
+	if opts.Verbose == 0 {
+		return line
+	}
+	line.detail = br.store.Abbreviate(ref.Hash, objects.ABBREV_LENGTH)
+	if strings.HasPrefix(ref.Name, BRANCH_PREFIX) {
+		tracking, found, err := br.Tracking(strings.TrimPrefix(ref.Name, BRANCH_PREFIX))
+		if err != nil {
+			br.logger.Debug("no tracking information for %s: %v", ref.Name, err)
+		} else if found {
+			if summary := tracking.Summary(opts.Verbose > 1); summary != "" {
+				line.detail += " [" + summary + "]"
+			}
+		}
+	}
+	if commit, err := br.store.ReadCommit(ref.Hash); err == nil {
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		line.detail += " " + subject
+	}
+	return line
+}
+
+// Tracking compares the branch with its upstream; found is false when the
+// branch has no upstream
+func (br *Branch) Tracking(name string) (Tracking, bool, error) {
+	upstreamRef, err := transport.UpstreamRef(br.conf, name)
+	if err != nil {
+		if _, missing := br.conf.GetUpstream(name); missing != nil {
+			return Tracking{}, false, nil
+		}
+		return Tracking{}, false, err
+	}
+	tracking := Tracking{Upstream: refs.ShortName(upstreamRef)}
+	theirs, _, err := br.refs.Resolve(upstreamRef)
+	if err != nil {
+		tracking.Gone = true
+		return tracking, true, nil
+	}
+	ours, _, err := br.refs.Resolve(BRANCH_PREFIX + name)
+	if err != nil {
+		// a branch without commits yet has nothing of its own
+		reachable, err := br.resolver.Reachable([]string{theirs})
+		tracking.Behind = len(reachable)
+		return tracking, true, err
+	}
+	tracking.Ahead, tracking.Behind, err = br.resolver.AheadBehind(ours, theirs)
+	return tracking, true, err
+}
+
+// SetOutput changes where the output is written
+func (br *Branch) SetOutput(out io.Writer) {
+	br.out = out
+}
@@ -0,0 +1,117 @@
+package branch
+
+import (
+	"bytes"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a repository in the working directory where main
+// has two commits and origin/main one more on top of the first
+func arrangeRepo(t *testing.T) (*Branch, *bytes.Buffer, map[string]string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	if err := repo.Conf.AddRemote("origin", "/srv/project"); err != nil {
+		t.Fatalf("Error adding remote: %v", err)
+	}
+	br := NewBranch(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	br.SetOutput(out)
+
+	commits := map[string]string{}
+	commits["base"] = repo.Commit(map[string]string{"file.txt": "base\n"}, "base")
+	commits["local"] = repo.Commit(map[string]string{"file.txt": "local\n"}, "local", commits["base"])
+	commits["remote"] = repo.Commit(map[string]string{"file.txt": "remote\n"}, "remote", commits["base"])
+	br.refs.Update("refs/heads/main", commits["local"], refs.UpdateOptions{})
+	br.refs.Update("refs/remotes/origin/main", commits["remote"], refs.UpdateOptions{})
+	return br, out, commits
+}
+
+func TestSetUpstream(t *testing.T) {
+	br, out, _ := arrangeRepo(t)
+	if err := br.SetUpstream("", "origin/main"); err != nil {
+		t.Fatalf("SetUpstream returned error: %v", err)
+	}
+	if out.String() != "branch 'main' set up to track 'origin/main'.\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+	upstream, err := br.conf.GetUpstream("main")
+	if err != nil || upstream.Remote != "origin" || upstream.Merge != "refs/heads/main" {
+		t.Errorf("Unexpected upstream: %+v, %v", upstream, err)
+	}
+	tracking, found, err := br.Tracking("main")
+	if err != nil || !found {
+		t.Fatalf("Tracking returned %v, %v", found, err)
+	}
+	if tracking.Upstream != "origin/main" || tracking.Ahead != 1 || tracking.Behind != 1 {
+		t.Errorf("Unexpected tracking: %+v", tracking)
+	}
+
+	if err := br.Create("topic", "main~1"); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if err := br.SetUpstream("topic", "main"); err != nil {
+		t.Fatalf("SetUpstream returned error: %v", err)
+	}
+	if tracking, _, _ := br.Tracking("topic"); tracking.Upstream != "main" || tracking.Ahead != 0 || tracking.Behind != 1 {
+		t.Errorf("Unexpected tracking of a local upstream: %+v", tracking)
+	}
+	if err := br.SetUpstream("topic", "nowhere"); err == nil {
+		t.Errorf("Expected an error for an unknown upstream")
+	}
+
+	if err := br.UnsetUpstream("topic"); err != nil {
+		t.Fatalf("UnsetUpstream returned error: %v", err)
+	}
+	if _, found, _ := br.Tracking("topic"); found {
+		t.Errorf("Expected the upstream of topic to be removed")
+	}
+	br.refs.Delete("refs/remotes/origin/main", "")
+	if tracking, _, _ := br.Tracking("main"); !tracking.Gone || tracking.Summary(true) != "origin/main: gone" {
+		t.Errorf("Expected the upstream to be gone: %+v", tracking)
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	br, out, commits := arrangeRepo(t)
+	br.SetUpstream("main", "origin/main")
+	br.Create("topic", commits["base"])
+	br.Create("unmerged", commits["remote"])
+	out.Reset()
+
+	if err := br.List(BranchOptions{}); err != nil || out.String() != "* main\n  topic\n  unmerged\n" {
+		t.Errorf("Unexpected list %q, %v", out.String(), err)
+	}
+	out.Reset()
+	br.List(BranchOptions{Verbose: 2})
+	main := "* main     " + br.store.Abbreviate(commits["local"], objects.ABBREV_LENGTH) + " [origin/main: ahead 1, behind 1] local\n"
+	if !strings.HasPrefix(out.String(), main) {
+		t.Errorf("Unexpected verbose list:\n%s", out.String())
+	}
+	out.Reset()
+	br.List(BranchOptions{Remotes: true})
+	if out.String() != "  origin/main\n" {
+		t.Errorf("Unexpected remote list %q", out.String())
+	}
+
+	if err := br.Delete([]string{"main"}, false); err == nil {
+		t.Errorf("Expected an error deleting the current branch")
+	}
+	if err := br.Delete([]string{"unmerged"}, false); err == nil {
+		t.Errorf("Expected an error deleting a branch that is not merged")
+	}
+	br.SetUpstream("unmerged", "main")
+	out.Reset()
+	if err := br.Delete([]string{"topic", "unmerged"}, true); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Deleted branch topic (was ") || br.refs.Exists("refs/heads/unmerged") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+	if _, err := br.conf.GetUpstream("unmerged"); err == nil {
+		t.Errorf("Expected the config of the deleted branch to be removed")
+	}
+}
@@ -0,0 +1,109 @@
+package branch
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/refs"
+	"got_it/internal/transport"
+	"strings"
+)
+
+// BRANCH_PREFIX starts the full names of the local branches
+const BRANCH_PREFIX string = "refs/heads/"
+
+// REMOTE_PREFIX starts the full names of the remote-tracking branches
+const REMOTE_PREFIX string = "refs/remotes/"
+
+// Tracking tells how far a branch and its upstream went apart
+type Tracking struct {
+	Upstream string // short name of the ref following the upstream, like origin/main
+	Gone     bool   // the upstream is configured but its ref does not exist
+	Ahead    int    // commits of the branch the upstream lacks
+	Behind   int    // commits of the upstream the branch lacks
+}
+
+// Summary formats the tracking as "ahead 1, behind 2", prefixed with the
+// upstream name when asked for; it is empty when there is nothing to tell
+func (t Tracking) Summary(withUpstream bool) string {
+	parts := []string{}
+	switch {
+	case t.Gone:
+		parts = append(parts, "gone")
+	default:
+		if t.Ahead > 0 {
+			parts = append(parts, fmt.Sprintf("ahead %d", t.Ahead))
+		}
+		if t.Behind > 0 {
+			parts = append(parts, fmt.Sprintf("behind %d", t.Behind))
+		}
+	}
+	summary := strings.Join(parts, ", ")
+	if !withUpstream {
+		return summary
+	}
+	if summary == "" {
+		return t.Upstream
+	}
+	return t.Upstream + ": " + summary
+}
+
+type listLine struct {
+	mark   string
+	name   string
+	detail string
+}
+
+// branchOrCurrent returns name, or the short name of the current branch
+// when name is empty
+func (br *Branch) branchOrCurrent(name string) (string, error) {
+	if name != "" {
+		if !br.refs.Exists(BRANCH_PREFIX + name) {
+			return "", fmt.Errorf("branch '%s' does not exist", name)
+		}
+		return name, nil
+	}
+	current, err := br.refs.CurrentBranch()
+	if err != nil || current == "" {
+		return "", fmt.Errorf("HEAD does not point to a branch")
+	}
+	return strings.TrimPrefix(current, BRANCH_PREFIX), nil
+}
+
+// upstreamFor returns the upstream setting following fullRef: a local
+// branch is merged from the "." remote, a remote-tracking branch from the
+// remote whose fetch refspecs map to it
+func (br *Branch) upstreamFor(fullRef string) (config.Upstream, error) {
+	if strings.HasPrefix(fullRef, BRANCH_PREFIX) {
+		return config.Upstream{Remote: config.LOCAL_REMOTE, Merge: fullRef}, nil
+	}
+	remotes, err := br.conf.Remotes()
+	if err != nil {
+		return config.Upstream{}, err
+	}
+	for _, remote := range remotes {
+		for _, spec := range remote.Fetch {
+			refspec, err := transport.ParseRefspec(spec)
+			if err != nil {
+				return config.Upstream{}, err
+			}
+			reverse := transport.Refspec{Src: refspec.Dst, Dst: refspec.Src}
+			if merge, matches := reverse.Match(fullRef); matches && merge != "" {
+				return config.Upstream{Remote: remote.Name, Merge: merge}, nil
+			}
+		}
+	}
+	return config.Upstream{}, fmt.Errorf("cannot set up tracking information; starting point '%s' is not a branch", refs.ShortName(fullRef))
+}
+
+// isMerged tells if HEAD contains the commit
+func (br *Branch) isMerged(hash string) (bool, error) {
+	head, _, err := br.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return false, nil
+	}
+	reachable, err := br.resolver.Reachable([]string{head})
+	if err != nil {
+		return false, err
+	}
+	return reachable[hash], nil
+}
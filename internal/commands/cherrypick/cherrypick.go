@@ -0,0 +1,62 @@
+package cherrypick
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/sequencer"
+	"io"
+	"os"
+)
+
+type CherryPick struct {
+	conf      *config.Config
+	logger    *logger.Logger
+	sequencer *sequencer.Sequencer
+}
+
+// CherryPickOptions holds the flags of the cherry-pick command
+type CherryPickOptions struct {
+	RecordOrigin bool
+	Continue     bool
+	Skip         bool
+	Abort        bool
+}
+
+func NewCherryPick(conf *config.Config, logger *logger.Logger) *CherryPick {
+	return &CherryPick{
+		conf:      conf,
+		logger:    logger,
+		sequencer: sequencer.NewSequencer(conf, logger),
+	}
+}
+
+// Execute applies the commits, or goes on with a stopped cherry-pick
+func Execute(revs []string, opts CherryPickOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	cp := NewCherryPick(conf, logger)
+	if err := cp.CherryPick(revs, opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// CherryPick applies the changes introduced by each commit on top of HEAD,
+// creating a new commit for each of them
+func (cp *CherryPick) CherryPick(revs []string, opts CherryPickOptions) error {
+	switch {
+	case opts.Continue:
+		return cp.sequencer.Continue()
+	case opts.Skip:
+		return cp.sequencer.Skip()
+	case opts.Abort:
+		return cp.sequencer.Abort()
+	}
+	return cp.sequencer.Start(sequencer.ACT_PICK, revs, sequencer.Options{RecordOrigin: opts.RecordOrigin})
+}
+
+// SetOutput changes where the output is written
+func (cp *CherryPick) SetOutput(out io.Writer) {
+	cp.sequencer.SetOutput(out)
+}
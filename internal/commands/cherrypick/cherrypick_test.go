@@ -0,0 +1,41 @@
+package cherrypick
+
+import (
+	"bytes"
+	"got_it/internal/testrepo"
+	"os"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a root commit on main and a topic commit adding b.txt
+// on top of it, and checks out main
+func arrangeRepo(t *testing.T) *CherryPick {
+	t.Helper()
+	repo := testrepo.New(t)
+	root := repo.Commit(map[string]string{"a.txt": "a\n"}, "root")
+	topic := repo.Commit(map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "add b", root)
+	repo.SetRef("refs/heads/main", root)
+	repo.SetRef("refs/heads/topic", topic)
+	repo.Checkout(root)
+	return NewCherryPick(repo.Conf, repo.Logger)
+}
+
+func TestCherryPick(t *testing.T) {
+	cp := arrangeRepo(t)
+	out := &bytes.Buffer{}
+	cp.SetOutput(out)
+
+	if err := cp.CherryPick(nil, CherryPickOptions{Continue: true}); err == nil {
+		t.Errorf("Expected an error continuing without a cherry-pick in progress")
+	}
+	if err := cp.CherryPick([]string{"topic"}, CherryPickOptions{}); err != nil {
+		t.Fatalf("CherryPick returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "[main ") || !strings.HasSuffix(out.String(), "] add b\n") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if content, err := os.ReadFile("b.txt"); err != nil || string(content) != "b\n" {
+		t.Errorf("Expected b.txt to be checked out, got %q (%v)", content, err)
+	}
+}
@@ -0,0 +1,208 @@
+package clone
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/commands/fetch"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/refs"
+	"got_it/internal/transport"
+	"got_it/internal/utils"
+	"got_it/internal/worktree"
+	"io"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// REMOTE_NAME is the remote a clone records for the repository it came from
+const REMOTE_NAME string = "origin"
+
+type Clone struct {
+	conf   *config.Config
+	logger *logger.Logger
+	out    io.Writer
+}
+
+func NewClone(conf *config.Config, logger *logger.Logger) *Clone {
+	return &Clone{
+		conf:   conf,
+		logger: logger,
+		out:    os.Stdout,
+	}
+}
+
+// Execute clones the repository at args[0] into args[1], or into a directory
+// named after it
+func Execute(args []string) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	cl := NewClone(conf, logger)
+
+	dir := ""
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if err := cl.Clone(args[0], dir); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Clone creates dir with a new repository, fetches the branches and tags of
+// the repository at url into it and checks out the branch its HEAD points to
+func (cl *Clone) Clone(url, dir string) error {
+	url, path, err := normalizeURL(url)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = DirName(path)
+	}
+	if err := checkTarget(dir); err != nil {
+		return err
+	}
+	fmt.Fprintf(cl.out, "Cloning into '%s'...\n", dir)
+
+	gotDir := filepath.Join(dir, config.GOT_DIR)
+	for _, sub := range []string{"objects", filepath.Join("refs", "heads")} {
+		if err := os.MkdirAll(filepath.Join(gotDir, sub), 0755); err != nil {
+			return err
+		}
+	}
+	cl.conf.GotDir = gotDir
+	if err := cl.setObjectFormat(url); err != nil {
+		return err
+	}
+	refStore := refs.NewStore(cl.conf, cl.logger)
+	if err := refStore.SetSymbolic(refs.HEAD, "refs/heads/"+cl.conf.GetDefaultBranch(), ""); err != nil {
+		return err
+	}
+	if err := cl.conf.AddRemote(REMOTE_NAME, url); err != nil {
+		return err
+	}
+	fe := fetch.NewFetch(cl.conf, cl.logger)
+	fe.SetOutput(io.Discard)
+	if err := fe.Fetch(REMOTE_NAME, nil); err != nil {
+		return err
+	}
+	return cl.checkoutHead(url)
+}
+
+// setObjectFormat makes the new repository name its objects with the hash
+// algorithm of the repository it clones
+func (cl *Clone) setObjectFormat(url string) error {
+	conn, err := transport.Open(url, cl.logger)
+	if err != nil {
+		return err
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		return err
+	}
+	if adv.ObjectFormat == utils.OF_SHA1 {
+		return nil
+	}
+	return cl.conf.SetObjectFormat(adv.ObjectFormat)
+}
+
+// checkoutHead creates the local branch matching the remote HEAD, tracking
+// it, and checks it out
+func (cl *Clone) checkoutHead(url string) error {
+	conn, err := transport.Open(url, cl.logger)
+	if err != nil {
+		return err
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		return err
+	}
+	if _, found := adv.Refs[adv.Head]; !found {
+		fmt.Fprintln(cl.out, "warning: You appear to have cloned an empty repository.")
+		return nil
+	}
+
+	branch := refs.ShortName(adv.Head)
+	tracking := "refs/remotes/" + REMOTE_NAME + "/" + branch
+	refStore := refs.NewStore(cl.conf, cl.logger)
+	hash, _, err := refStore.Resolve(tracking)
+	if err != nil {
+		return err
+	}
+	if err := refStore.Update(adv.Head, hash, refs.UpdateOptions{Message: "clone: from " + url}); err != nil {
+		return err
+	}
+	if err := refStore.SetSymbolic(refs.HEAD, adv.Head, ""); err != nil {
+		return err
+	}
+	if err := refStore.SetSymbolic("refs/remotes/"+REMOTE_NAME+"/HEAD", tracking, ""); err != nil {
+		return err
+	}
+	if err := cl.conf.SetUpstream(branch, config.Upstream{Remote: REMOTE_NAME, Merge: adv.Head}); err != nil {
+		return err
+	}
+
+	wt := worktree.NewWorktree(cl.conf, cl.logger)
+	files, err := wt.CommitFiles(hash)
+	if err != nil {
+		return err
+	}
+	if err := wt.Checkout(map[string]models.TreeEntry{}, files); err != nil {
+		return err
+	}
+	return wt.WriteIndex(files)
+}
+
+// normalizeURL makes the URL of a local repository absolute, checking it
+// holds one, and returns it with the path the clone is named after
+func normalizeURL(rawURL string) (string, string, error) {
+	path, isLocal := transport.LocalPath(rawURL)
+	if !isLocal {
+		parsed, err := neturl.Parse(rawURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid URL '%s': %v", rawURL, err)
+		}
+		if strings.Trim(parsed.Path, "/") == "" {
+			return rawURL, parsed.Hostname(), nil
+		}
+		return rawURL, parsed.Path, nil
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	if _, _, err := transport.FindGotDir(path); err != nil {
+		return "", "", err
+	}
+	if strings.HasPrefix(rawURL, transport.FILE_SCHEME) {
+		return transport.FILE_SCHEME + path, path, nil
+	}
+	return path, path, nil
+}
+
+// DirName returns the directory a repository is cloned into by default: the
+// last element of its path, without a .got extension
+func DirName(path string) string {
+	path = filepath.Clean(filepath.FromSlash(path))
+	name := filepath.Base(strings.TrimSuffix(path, string(filepath.Separator)+config.GOT_DIR))
+	return strings.TrimSuffix(name, config.GOT_DIR)
+}
+
+// checkTarget refuses to clone into anything but a missing or empty directory
+func checkTarget(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil || len(entries) > 0 {
+		return fmt.Errorf("destination path '%s' already exists and is not an empty directory", dir)
+	}
+	return nil
+}
+
+// SetOutput changes where the output is written
+func (cl *Clone) SetOutput(out io.Writer) {
+	cl.out = out
+}
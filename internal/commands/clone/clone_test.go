@@ -0,0 +1,121 @@
+package clone
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/transport"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRemote creates a repository with a commit on the branch topic,
+// which HEAD points to, and returns its directory and the commit
+func arrangeRemote(t *testing.T) (string, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "project")
+	conf := config.NewConfig()
+	conf.GotDir = filepath.Join(dir, config.GOT_DIR)
+	os.MkdirAll(filepath.Join(conf.GotDir, "objects"), 0755)
+	os.MkdirAll(filepath.Join(conf.GotDir, "refs", "heads"), 0755)
+	os.WriteFile(filepath.Join(conf.GotDir, "HEAD"), []byte("ref: refs/heads/topic"), 0644)
+
+	logger := logger.NewLogger(false, false)
+	store := objects.NewStore(conf, logger)
+	readme, _ := store.Write("hello\n")
+	main, _ := store.Write("package main\n")
+	subTree, _ := store.Write(fmt.Sprintf("100644 blob %s\tmain.go\n", main))
+	tree, _ := store.Write(fmt.Sprintf("100644 blob %s\tREADME\n040000 tree %s\tsrc\n100644 blob %s\tmain.go\n", readme, subTree, main))
+	commit, err := store.Write("tree " + tree + "\nauthor A U Thor <author@example.com> 1623501234 +0200\n" +
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\nfirst\n")
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	refs.NewStore(conf, logger).Update("refs/heads/topic", commit, refs.UpdateOptions{})
+	return dir, commit
+}
+
+func newClone(t *testing.T) (*Clone, *bytes.Buffer) {
+	t.Helper()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	cl := NewClone(config.NewConfig(), logger.NewLogger(false, false))
+	out := &bytes.Buffer{}
+	cl.SetOutput(out)
+	return cl, out
+}
+
+func TestClone(t *testing.T) {
+	remoteDir, commit := arrangeRemote(t)
+	cl, out := newClone(t)
+	if err := cl.Clone(remoteDir, ""); err != nil {
+		t.Fatalf("Clone returned error: %v", err)
+	}
+	if out.String() != "Cloning into 'project'...\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	for file, expected := range map[string]string{"README": "hello\n", filepath.Join("src", "main.go"): "package main\n"} {
+		content, err := os.ReadFile(filepath.Join("project", file))
+		if err != nil || string(content) != expected {
+			t.Errorf("Unexpected content of %s: %q, %v", file, content, err)
+		}
+	}
+	store := refs.NewStore(cl.conf, cl.logger)
+	if branch, _ := store.CurrentBranch(); branch != "refs/heads/topic" {
+		t.Errorf("Expected HEAD to point to topic, got %s", branch)
+	}
+	if upstream, err := cl.conf.GetUpstream("topic"); err != nil || upstream.Remote != REMOTE_NAME || upstream.Merge != "refs/heads/topic" {
+		t.Errorf("Expected topic to track origin/topic, got %+v, %v", upstream, err)
+	}
+	for _, name := range []string{"refs/heads/topic", "refs/remotes/origin/topic", "refs/remotes/origin/HEAD"} {
+		if hash, _, _ := store.Resolve(name); hash != commit {
+			t.Errorf("Expected %s at %s, got %s", name, commit, hash)
+		}
+	}
+	remote, err := cl.conf.GetRemote("origin")
+	if err != nil || remote.URL != remoteDir {
+		t.Errorf("Unexpected origin remote: %+v, %v", remote, err)
+	}
+
+	// the destination must be empty
+	if err := NewClone(config.NewConfig(), cl.logger).Clone(remoteDir, "project"); err == nil {
+		t.Errorf("Expected an error cloning into a non empty directory")
+	}
+}
+
+func TestCloneEmptyRepository(t *testing.T) {
+	remoteDir := filepath.Join(t.TempDir(), "empty.got")
+	os.MkdirAll(filepath.Join(remoteDir, "objects"), 0755)
+	os.WriteFile(filepath.Join(remoteDir, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+
+	cl, out := newClone(t)
+	if err := cl.Clone(transport.FILE_SCHEME+remoteDir, ""); err != nil {
+		t.Fatalf("Clone returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "warning: You appear to have cloned an empty repository.\n") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if _, err := os.Stat(filepath.Join("empty", config.GOT_DIR, "HEAD")); err != nil {
+		t.Errorf("Expected a repository in empty: %v", err)
+	}
+}
+
+func TestDirName(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/srv/project":      "project",
+		"/srv/project/":     "project",
+		"/srv/project.got":  "project",
+		"/srv/project/.got": "project",
+	} {
+		if name := DirName(path); name != expected {
+			t.Errorf("DirName(%s) = %s, expected %s", path, name, expected)
+		}
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"got_it/internal/commands/config"
 	"got_it/internal/commands/history"
+	"got_it/internal/hooks"
 	"got_it/internal/logger"
+	"got_it/internal/merge"
 	"got_it/internal/models"
+	"got_it/internal/refs"
+	"got_it/internal/signing"
 	"got_it/internal/utils"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 var supportedEnvVars = []string{
@@ -24,10 +27,28 @@ var supportedEnvVars = []string{
 
 var verbose bool = false
 
+// COMMIT_EDITMSG is the file in .got holding the message of the commit
+// being made, the one the commit-msg hook gets
+const COMMIT_EDITMSG string = "COMMIT_EDITMSG"
+
+func init() {
+	config.Register(config.KeyInfo{Key: "commit.gpgSign", Type: config.TYPE_BOOL, Default: "false",
+		Description: "sign every commit"})
+}
+
 type Commit struct {
 	conf       *config.Config
 	commitData *models.CommitData
 	logger     *logger.Logger
+	noVerify   bool
+	sign       bool
+}
+
+// CommitOptions holds the flags of the commit command
+type CommitOptions struct {
+	Verbose  bool
+	NoVerify bool
+	Sign     bool
 }
 
 func NewCommit(message string) *Commit {
@@ -47,13 +68,25 @@ func NewCommit(message string) *Commit {
 
 // Execute is the entry point for the commit command
 // It is a shortcut for Commit.NewCommit(message).RunCommit()
-func Execute(message string, beVerbose bool) {
-	verbose = beVerbose
+func Execute(message string, opts CommitOptions) error {
+	verbose = opts.Verbose
 	co := NewCommit(message)
-	co.runCommit()
+	co.noVerify = opts.NoVerify
+	co.sign = opts.Sign
+	_, err := co.runCommit()
+	return err
 }
 
+// runCommit writes a commit of the index and moves the current branch to
+// it. The pre-commit and commit-msg hooks may abort the commit, unless
+// noVerify is set; the post-commit hook runs once it is made.
 func (co *Commit) runCommit() (string, error) {
+	if !co.noVerify {
+		if err := hooks.Run(co.conf, hooks.PRE_COMMIT, nil, nil); err != nil {
+			fmt.Println("Error:", err)
+			return "", err
+		}
+	}
 	err := co.fetchTree()
 	if err != nil {
 		fmt.Println("Error fetching tree:", err)
@@ -77,11 +110,21 @@ func (co *Commit) runCommit() (string, error) {
 		return "", err
 	}
 
-	commitMetadata := co.formatCommitMetadata(co.commitData)
+	err = co.fetchMessage()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	commitMetadata, err := co.formatCommitMetadata(co.commitData)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
 	co.logger.Log(commitMetadata)
 
 	// Hash the commit metadata
-	commitHash := utils.HashContent(commitMetadata)
+	commitHash := co.conf.ObjectFormat().HashContent(commitMetadata)
 
 	err = co.storeObject(commitHash, commitMetadata)
 	if err != nil {
@@ -89,7 +132,35 @@ func (co *Commit) runCommit() (string, error) {
 		return "", err
 	}
 
-	return commitMetadata, co.updateHEAD(commitHash)
+	if err := co.updateHEAD(commitHash); err != nil {
+		fmt.Println("Error:", err)
+		return commitMetadata, err
+	}
+	if err := hooks.Run(co.conf, hooks.POST_COMMIT, nil, nil); err != nil {
+		co.logger.Debug("post-commit hook: %s", err)
+	}
+	return commitMetadata, nil
+}
+
+// fetchMessage writes the message to COMMIT_EDITMSG and runs the
+// commit-msg hook on it; the hook may change the message
+func (co *Commit) fetchMessage() error {
+	messagePath := filepath.Join(co.conf.GotDir, COMMIT_EDITMSG)
+	if err := os.WriteFile(messagePath, []byte(co.commitData.Message+"\n"), 0644); err != nil {
+		return err
+	}
+	if co.noVerify {
+		return nil
+	}
+	if err := hooks.Run(co.conf, hooks.COMMIT_MSG, []string{messagePath}, nil); err != nil {
+		return err
+	}
+	message, err := os.ReadFile(messagePath)
+	if err != nil {
+		return err
+	}
+	co.commitData.Message = strings.TrimRight(string(message), "\n")
+	return nil
 }
 
 func (co *Commit) fetchTree() error {
@@ -113,6 +184,10 @@ func (co *Commit) fetchParent() error {
 		return err
 	}
 	co.commitData.Parent = parent
+	mergeHead, err := os.ReadFile(filepath.Join(co.conf.GotDir, merge.MERGE_HEAD))
+	if err == nil {
+		co.commitData.Parents = []string{parent, strings.TrimSpace(string(mergeHead))}
+	}
 	return nil
 }
 
@@ -123,9 +198,10 @@ func (co *Commit) fetchAuthorData(commitData *models.CommitData) error {
 	authorEmail := co.conf.GetUserEmail()
 	getEnvVarValue(&authorEmail, "GOT_AUTHOR_EMAIL")
 
-	timestamp := time.Now()
-	authorDate := timestamp.Format(time.RFC3339)
-	getEnvVarValue(&authorDate, "GOT_AUTHOR_DATE")
+	authorDate, err := envDate("GOT_AUTHOR_DATE")
+	if err != nil {
+		return err
+	}
 
 	commitData.AuthorName = authorName
 	commitData.AuthorEmail = authorEmail
@@ -139,9 +215,10 @@ func (co *Commit) fetchCommitterData(commitData *models.CommitData) error {
 
 	committerEmail := co.conf.GetUserEmail()
 	getEnvVarValue(&committerEmail, "GOT_COMMITTER_EMAIL")
-	timestamp := time.Now()
-	committerDate := timestamp.Format(time.RFC3339)
-	getEnvVarValue(&committerDate, "GOT_COMMITTER_DATE")
+	committerDate, err := envDate("GOT_COMMITTER_DATE")
+	if err != nil {
+		return err
+	}
 
 	commitData.CommitterName = committerName
 	commitData.CommitterEmail = committerEmail
@@ -149,25 +226,22 @@ func (co *Commit) fetchCommitterData(commitData *models.CommitData) error {
 	return nil
 }
 
-func (co *Commit) formatCommitMetadata(commitData *models.CommitData) string {
-	var commitStr string
-
-	// Tree
-	commitStr += fmt.Sprintf("tree %s\n", commitData.Tree)
-	// Parent
-	if commitData.Parent != "" {
-		commitStr += fmt.Sprintf("parent %s\n", commitData.Parent)
-	}
-	// Author
-	commitStr += fmt.Sprintf("author %s <%s> %s\n", commitData.AuthorName, commitData.AuthorEmail, commitData.AuthorDate)
-	// Committer
-	commitStr += fmt.Sprintf("committer %s <%s> %s\n", commitData.CommitterName, commitData.CommitterEmail, commitData.CommitterDate)
-	// Empty line
-	commitStr += "\n"
-	// Message
-	commitStr += fmt.Sprintf("%s\n", commitData.Message)
+// formatCommitMetadata returns the content of the commit object. Asked to
+// by -S or commit.gpgSign, it signs the commit and adds the signature as
+// its gpgsig header.
+func (co *Commit) formatCommitMetadata(commitData *models.CommitData) (string, error) {
+	commitStr := models.FormatCommit(*commitData)
+	if co.sign || co.conf.Bool("commit.gpgSign") {
+		signer := fmt.Sprintf("%s <%s>", commitData.CommitterName, commitData.CommitterEmail)
+		signature, err := signing.Sign(co.conf, commitStr, "", signer)
+		if err != nil {
+			return "", err
+		}
+		commitData.Signature = signature
+		commitStr = models.FormatCommit(*commitData)
+	}
 	co.logger.Log("Commit metadata:\n\n" + commitStr)
-	return commitStr
+	return commitStr, nil
 }
 
 // generateTreeObject creates a tree object from the staged files
@@ -177,7 +251,7 @@ func (co *Commit) generateTreeObject(stagedFiles map[string]string) (string, err
 	prefix, _ := filepath.Abs(".")
 	prefix += separator()
 	treeContent := co.generateTreeContent(stagedFiles, prefix)
-	treeHash := utils.HashContent(treeContent)
+	treeHash := co.conf.ObjectFormat().HashContent(treeContent)
 	err := co.storeObject(treeHash, treeContent)
 
 	co.logger.Log("Tree hash: \n\n" + treeHash)
@@ -227,7 +301,7 @@ func (co *Commit) generateTreeContent(stagedFiles map[string]string, prefix stri
 			prefixedFiles[prefix+file] = hash
 		}
 		subTreeContent := co.generateTreeContent(prefixedFiles, prefix)
-		subTreeHash := utils.HashContent(subTreeContent)
+		subTreeHash := co.conf.ObjectFormat().HashContent(subTreeContent)
 		co.logger.Log("SubTree Hash: %s for Directory: %s", subTreeHash, dir)
 		co.storeObject(subTreeHash, subTreeContent)
 		treeContent.WriteString(fmt.Sprintf("040000 tree %s\t%s\n", subTreeHash, dir))
@@ -316,16 +390,26 @@ func (co *Commit) generateCommitFeedback(commitMetadata string) string {
 	return feedback
 }
 
+// updateHEAD moves the branch HEAD points to, or HEAD itself when it is
+// detached, to the new commit, as long as it still points to the parent
 func (co *Commit) updateHEAD(commitHash string) error {
-	// GEt the ref to the HEAD file
-	// headRef, err := co.readRefFromHEAD()
-	headRef, err := history.ReadRefFromHEAD(co.conf, co.logger)
-	if err != nil {
-		co.logger.Debug("Error reading HEAD file: %s", err)
+	subject, _, _ := strings.Cut(co.commitData.Message, "\n")
+	opts := refs.UpdateOptions{
+		OldHash: co.commitData.Parent,
+		Message: "commit: " + subject,
+	}
+	if co.commitData.Parent == "" {
+		opts.OldHash = co.conf.ObjectFormat().ZeroHash()
+		opts.Message = "commit (initial): " + subject
+	} else if len(co.commitData.Parents) > 1 {
+		opts.Message = "commit (merge): " + subject
+	}
+	if err := refs.NewStore(co.conf, co.logger).Update(refs.HEAD, commitHash, opts); err != nil {
 		return err
 	}
-	err = os.MkdirAll(filepath.Dir(headRef), 0755)
-	f, err := os.Create(headRef)
-	f.Close()
-	return os.WriteFile(headRef, []byte(commitHash), 0644)
+	// the merge is concluded
+	for _, name := range []string{merge.MERGE_HEAD, merge.MERGE_MSG} {
+		os.Remove(filepath.Join(co.conf.GotDir, name))
+	}
+	return nil
 }
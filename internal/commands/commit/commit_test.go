@@ -67,6 +67,51 @@ func TestCommit(t *testing.T) {
 	}
 	testReadStagedFiles(t, addedFiles, stagedFiles)
 	testRefContent(t, defaultBranch, commitHash)
+	testReflog(t, defaultBranch, commitHash)
+}
+
+// TestCommitHooks checks that the hooks run around the commit, that a
+// failing pre-commit hook aborts it and that noVerify bypasses it
+func TestCommitHooks(t *testing.T) {
+	// ARRANGE:
+	arrangeEnvironment(t, "testuser", "test@example.com")
+	os.WriteFile("file.txt", []byte("content\n"), 0644)
+	add.Execute([]string{"file.txt"}, true)
+	hookDir := filepath.Join(".got", "hooks")
+	os.MkdirAll(hookDir, 0755)
+	writeHook := func(name, script string) {
+		os.WriteFile(filepath.Join(hookDir, name), []byte("#!/bin/sh\n"+script), 0755)
+	}
+	writeHook("pre-commit", "exit 1\n")
+	writeHook("commit-msg", "echo 'Ticket: GOT-1' >> \"$1\"\n")
+	writeHook("post-commit", "touch post-commit.ran\n")
+
+	// ACT & ASSERT:
+	if err := Execute("hooked", CommitOptions{}); err == nil {
+		t.Fatalf("Expected the pre-commit hook to abort the commit")
+	}
+	if _, err := os.Stat(filepath.Join(".got", "refs", "heads", "main")); !os.IsNotExist(err) {
+		t.Errorf("Expected no commit to be made")
+	}
+
+	writeHook("pre-commit", "exit 0\n")
+	commitMetadata, err := NewCommit("hooked").runCommit()
+	if err != nil {
+		t.Fatalf("Failed on running commit: %v", err)
+	}
+	if !strings.HasSuffix(commitMetadata, "\n\nhooked\nTicket: GOT-1\n") {
+		t.Errorf("Expected the commit-msg hook to edit the message, got:\n%s", commitMetadata)
+	}
+	if _, err := os.Stat("post-commit.ran"); err != nil {
+		t.Errorf("Expected the post-commit hook to run")
+	}
+
+	writeHook("pre-commit", "exit 1\n")
+	co := NewCommit("unverified")
+	co.noVerify = true
+	if commitMetadata, err = co.runCommit(); err != nil || strings.Contains(commitMetadata, "Ticket") {
+		t.Errorf("Expected noVerify to bypass the hooks, got %v:\n%s", err, commitMetadata)
+	}
 }
 
 // Test GenerateTreeObject, GenereateTreeContent and getFileMode
@@ -166,7 +211,7 @@ func testCommitter(t *testing.T, shouldBeCommiter, shouldBeCommiterEmail string,
 
 func testCommitHash(t *testing.T, commitMetadata string) string {
 	// Create commit hash
-	commitHash := utils.HashContent(commitMetadata)
+	commitHash := utils.OF_SHA1.HashContent(commitMetadata)
 	// check if folder with commit hash exists
 	commitFolder := filepath.Join(originalDir, ".got", "objects", commitHash[:2])
 	if _, err := os.Stat(commitFolder); os.IsNotExist(err) {
@@ -202,6 +247,20 @@ func testRefContent(t *testing.T, defaultBranch, commitHash string) {
 	}
 }
 
+func testReflog(t *testing.T, defaultBranch, commitHash string) {
+	// Check the commit is recorded in the reflogs of the branch and of HEAD
+	for _, logFile := range []string{filepath.Join("refs", "heads", defaultBranch), "HEAD"} {
+		content, err := os.ReadFile(filepath.Join(originalDir, ".got", "logs", logFile))
+		if err != nil {
+			t.Fatalf("Error reading .got/logs/%s file: %v", logFile, err)
+		}
+		prefix := strings.Repeat("0", 40) + " " + commitHash + " testcommiter <testcommiter@example.com> "
+		if !strings.HasPrefix(string(content), prefix) || !strings.HasSuffix(string(content), "\tcommit (initial): test commit\n") {
+			t.Errorf("Unexpected reflog in .got/logs/%s: %s", logFile, content)
+		}
+	}
+}
+
 // HELPER FUNCTIONS
 
 func arrangeEnvironment(t *testing.T, shouldBeUser string, shouldBeEmail string) {
@@ -392,7 +451,7 @@ func generateFilesAndTreContent(t *testing.T) ([]string, string) {
 		}
 		defer file.Close()
 		// defer os.Remove(file.Name())
-		hash, err := utils.HashFile(file.Name())
+		hash, err := utils.OF_SHA1.HashFile(file.Name())
 		if err != nil {
 			t.Fatalf("Error hashing file: %v", err)
 		}
@@ -414,7 +473,7 @@ func generateFilesAndTreContent(t *testing.T) ([]string, string) {
 			dirContent.WriteString(item)
 		}
 	}
-	hash := utils.HashContent(dirContent.String())
+	hash := utils.OF_SHA1.HashContent(dirContent.String())
 
 	for i, item := range treeContentList {
 		if strings.HasPrefix(item, "040000") {
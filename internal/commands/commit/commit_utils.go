@@ -2,8 +2,10 @@ package commit
 
 import (
 	"fmt"
+	"got_it/internal/date"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 func separator() string {
@@ -23,3 +25,18 @@ func getEnvVarValue(localVar *string, envVarName string) error {
 	}
 	return fmt.Errorf("Unsupported environment variable: %s", envVarName)
 }
+
+// envDate returns the date given in the environment variable, or the current
+// time when it is not set
+func envDate(envVarName string) (time.Time, error) {
+	value := ""
+	getEnvVarValue(&value, envVarName)
+	if value == "" {
+		return time.Now(), nil
+	}
+	t, err := date.Parse(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", envVarName, err)
+	}
+	return t, nil
+}
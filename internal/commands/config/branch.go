@@ -0,0 +1,87 @@
+package config
+
+import "fmt"
+
+// BRANCH_SECTION is the section holding the [branch "<name>"] subsections
+const BRANCH_SECTION string = "branch"
+
+// LOCAL_REMOTE is the remote of an upstream that is a local branch
+const LOCAL_REMOTE string = "."
+
+func init() {
+	Register(
+		KeyInfo{Key: "branch.<name>.remote", Description: "remote the branch is fetched from, . for a local upstream"},
+		KeyInfo{Key: "branch.<name>.merge", Description: "full name of the upstream branch on its remote"},
+	)
+}
+
+// Upstream is the branch a local branch integrates with: the remote it is
+// fetched from and the full name of the branch on that remote
+type Upstream struct {
+	Remote string
+	Merge  string
+}
+
+// GetUpstream returns the upstream of the branch, given by its short name
+func (c *Config) GetUpstream(branch string) (Upstream, error) {
+	entries, err := c.ReadSubsection(BRANCH_SECTION, branch)
+	if err != nil {
+		return Upstream{}, fmt.Errorf("no upstream configured for branch '%s'", branch)
+	}
+	upstream := Upstream{}
+	for _, entry := range entries {
+		switch entry.Key {
+		case "remote":
+			upstream.Remote = entry.Value
+		case "merge":
+			upstream.Merge = entry.Value
+		}
+	}
+	if upstream.Remote == "" || upstream.Merge == "" {
+		return Upstream{}, fmt.Errorf("no upstream configured for branch '%s'", branch)
+	}
+	return upstream, nil
+}
+
+// SetUpstream records the upstream of the branch, keeping the other entries
+// of its section
+func (c *Config) SetUpstream(branch string, upstream Upstream) error {
+	entries := []Entry{
+		{Key: "remote", Value: upstream.Remote},
+		{Key: "merge", Value: upstream.Merge},
+	}
+	return c.WriteSubsection(BRANCH_SECTION, branch, append(entries, c.otherEntries(branch)...))
+}
+
+// UnsetUpstream forgets the upstream of the branch
+func (c *Config) UnsetUpstream(branch string) error {
+	if _, err := c.GetUpstream(branch); err != nil {
+		return err
+	}
+	others := c.otherEntries(branch)
+	if len(others) == 0 {
+		return c.RemoveSubsection(BRANCH_SECTION, branch)
+	}
+	return c.WriteSubsection(BRANCH_SECTION, branch, others)
+}
+
+// RemoveBranchConfig deletes the section of a branch, if there is one
+func (c *Config) RemoveBranchConfig(branch string) error {
+	if _, err := c.ReadSubsection(BRANCH_SECTION, branch); err != nil {
+		return nil
+	}
+	return c.RemoveSubsection(BRANCH_SECTION, branch)
+}
+
+// otherEntries returns the entries of the branch section that do not
+// describe the upstream
+func (c *Config) otherEntries(branch string) []Entry {
+	entries, _ := c.ReadSubsection(BRANCH_SECTION, branch)
+	others := []Entry{}
+	for _, entry := range entries {
+		if entry.Key != "remote" && entry.Key != "merge" {
+			others = append(others, entry)
+		}
+	}
+	return others
+}
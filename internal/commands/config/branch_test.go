@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpstream(t *testing.T) {
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+	os.WriteFile(filepath.Join(c.GotDir, CONFIG_FILE), []byte("[branch \"main\"]\n    description = the main line\n"), 0644)
+
+	if _, err := c.GetUpstream("main"); err == nil {
+		t.Errorf("Expected an error for a branch without upstream")
+	}
+	if err := c.SetUpstream("main", Upstream{Remote: "origin", Merge: "refs/heads/main"}); err != nil {
+		t.Fatalf("SetUpstream returned error: %v", err)
+	}
+	upstream, err := c.GetUpstream("main")
+	if err != nil || upstream.Remote != "origin" || upstream.Merge != "refs/heads/main" {
+		t.Errorf("Unexpected upstream: %+v, %v", upstream, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(c.GotDir, CONFIG_FILE))
+	if !strings.Contains(string(content), "description = the main line") {
+		t.Errorf("Expected the other entries to be kept:\n%s", content)
+	}
+
+	if err := c.UnsetUpstream("main"); err != nil {
+		t.Fatalf("UnsetUpstream returned error: %v", err)
+	}
+	if _, err := c.GetUpstream("main"); err == nil {
+		t.Errorf("Expected the upstream to be removed")
+	}
+	c.SetUpstream("topic", Upstream{Remote: LOCAL_REMOTE, Merge: "refs/heads/main"})
+	if err := c.RemoveBranchConfig("topic"); err != nil {
+		t.Fatalf("RemoveBranchConfig returned error: %v", err)
+	}
+	if names, _ := c.Subsections(BRANCH_SECTION); len(names) != 1 || names[0] != "main" {
+		t.Errorf("Unexpected branch sections: %v", names)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -15,8 +16,80 @@ func TestWriteConfig(t *testing.T) {
 
 	defer os.Remove(tmpfile.Name())
 
-	// Create a new Config instance
-	config := NewConfig()
 	// Write a key-value pair to the config file
-	err = config.writeConfig("key", "value")
+	file, err := ParseFile(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("ParseFile returned error: %v", err)
+	}
+	file.Set("core.key", "value")
+	if err = file.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if file, _ = ParseFile(tmpfile.Name()); len(file.GetAll("core.key")) != 1 {
+		t.Errorf("Expected the key to be written:\n%s", file)
+	}
+}
+
+func TestSubsectionKeys(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.Mkdir(GOT_DIR, 0755)
+	os.WriteFile(GOT_DIR+"/"+CONFIG_FILE, []byte("[user]\n    name = A U Thor\n    nameless = x\n[branch \"main\"]\n    remote = origin\n"), 0644)
+
+	c := NewConfig()
+	if value, err := c.GetConfigKeyValue("user.name"); err != nil || value != "A U Thor" {
+		t.Errorf("Expected user.name to be read exactly, got %q, %v", value, err)
+	}
+	if err := c.SetConfigKeyValue("branch.main.merge", "refs/heads/main"); err != nil {
+		t.Fatalf("SetConfigKeyValue returned error: %v", err)
+	}
+	if err := c.SetConfigKeyValue("branch.feature/x.y.remote", "."); err != nil {
+		t.Fatalf("SetConfigKeyValue returned error: %v", err)
+	}
+	if value, _ := c.GetConfigKeyValue("branch.main.merge"); value != "refs/heads/main" {
+		t.Errorf("Unexpected branch.main.merge: %q", value)
+	}
+	if value, _ := c.GetConfigKeyValue("branch.feature/x.y.remote"); value != "." {
+		t.Errorf("Unexpected branch.feature/x.y.remote: %q", value)
+	}
+	content, _ := os.ReadFile(GOT_DIR + "/" + CONFIG_FILE)
+	if !strings.Contains(string(content), "[branch \"feature/x.y\"]\n    remote = .\n") {
+		t.Errorf("Unexpected config file:\n%s", content)
+	}
+	for key, valid := range map[string]bool{
+		"branch.remote":       true,
+		"remote.origin.url":   true,
+		"core.2fa":            false,
+		"nosection":           false,
+		"bad_section.key":     false,
+		"remote.a b\"c.fetch": true,
+	} {
+		if IsValidKey(key) != valid {
+			t.Errorf("IsValidKey(%q) = %v", key, !valid)
+		}
+	}
+}
+
+func TestParseSectionHeader(t *testing.T) {
+	for _, tc := range []struct {
+		line       string
+		section    string
+		subsection string
+		ok         bool
+	}{
+		{"[core]", "core", "", true},
+		{"  [branch \"main\"]  ", "branch", "main", true},
+		{`[branch "we\"ird\\name"]`, "branch", `we"ird\name`, true},
+		{"[branch main]", "", "", false},
+		{"key = value", "", "", false},
+	} {
+		section, subsection, ok := parseSectionHeader(tc.line)
+		if section != tc.section || subsection != tc.subsection || ok != tc.ok {
+			t.Errorf("parseSectionHeader(%q) = %q, %q, %v", tc.line, section, subsection, ok)
+		}
+	}
+	if header := formatSectionHeader("branch", `we"ird\name`); header != `[branch "we\"ird\\name"]` {
+		t.Errorf("Unexpected header %s", header)
+	}
 }
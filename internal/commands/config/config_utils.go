@@ -1,16 +1,60 @@
 package config
 
-func GetAcceptedKeys() []string {
-	keys := make([]string, 0, len(acceptedKeys))
-	for key := range acceptedKeys {
-		keys = append(keys, key)
+import (
+	"fmt"
+	"strings"
+)
+
+// IsValidKey tells if key is section.name or section.subsection.name, with
+// a section of letters, digits and dashes and a name starting with a letter
+func IsValidKey(key string) bool {
+	section, _, name := splitKey(key)
+	if section == "" || name == "" || !isLetter(name[0]) || !strings.Contains(key, ".") {
+		return false
+	}
+	for i := range len(section) {
+		if !isNameChar(section[i], false) {
+			return false
+		}
 	}
-	return keys
+	for i := range len(name) {
+		if !isNameChar(name[i], false) {
+			return false
+		}
+	}
+	return !strings.Contains(key, "\n")
 }
 
-func IsValidKey(key string) bool {
-	_, ok := acceptedKeys[key]
-	return ok
+// splitKey splits section.key, or section.subsection.key where the
+// subsection may hold dots, as in branch.feature.x.remote
+func splitKey(key string) (string, string, string) {
+	key = strings.TrimSpace(key)
+	section, rest, _ := strings.Cut(key, ".")
+	last := strings.LastIndex(rest, ".")
+	if last < 0 {
+		return section, "", rest
+	}
+	return section, rest[:last], rest[last+1:]
+}
+
+// formatSectionHeader returns [section], or [section "subsection"] with the
+// quotes and backslashes of the subsection escaped
+func formatSectionHeader(section, subsection string) string {
+	if subsection == "" {
+		return "[" + section + "]"
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(subsection)
+	return fmt.Sprintf("[%s \"%s\"]", section, escaped)
+}
+
+// parseSectionHeader reads the section and the subsection, if any, of a
+// [section] or [section "subsection"] header
+func parseSectionHeader(line string) (string, string, bool) {
+	section, subsection, err := parseHeader(strings.TrimSpace(line))
+	if err != nil {
+		return "", "", false
+	}
+	return section, subsection, true
 }
 
 func GetEssentilFiles() []string {
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lineKind tells what a line of a config file holds
+type lineKind int
+
+const (
+	kindOther   lineKind = iota // blank lines and comments
+	kindSection                 // [section] or [section "subsection"]
+	kindEntry                   // key = value
+)
+
+// line is a line of a config file, or several physical lines for a value
+// continued with a backslash. raw is written back as is, so that a file is
+// only changed where a value is set or removed.
+type line struct {
+	kind       lineKind
+	raw        string
+	section    string // the section the line is in, lower case
+	subsection string
+	name       string // the key of an entry, lower case
+	value      string
+}
+
+// File is a parsed config file, in the grammar of Git config files
+type File struct {
+	Path  string
+	lines []*line
+}
+
+// ParseFile reads the config file at path; a missing file is empty
+func ParseFile(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{Path: path}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, string(content))
+}
+
+// Parse reads the content of the config file at path
+func Parse(path, content string) (*File, error) {
+	file := &File{Path: path}
+	physical := strings.SplitAfter(content, "\n")
+	if physical[len(physical)-1] == "" {
+		physical = physical[:len(physical)-1]
+	}
+	section, subsection := "", ""
+	for number := 0; number < len(physical); number++ {
+		text := strings.TrimRight(physical[number], "\r\n")
+		trimmed := strings.TrimSpace(text)
+		current := &line{kind: kindOther, raw: physical[number], section: section, subsection: subsection}
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+		case trimmed[0] == '[':
+			var err error
+			section, subsection, err = parseHeader(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("bad config line %d in file %s: %w", number+1, path, err)
+			}
+			current.kind, current.section, current.subsection = kindSection, section, subsection
+		default:
+			if section == "" {
+				return nil, fmt.Errorf("bad config line %d in file %s: key outside of a section", number+1, path)
+			}
+			name, rest, err := parseName(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("bad config line %d in file %s: %w", number+1, path, err)
+			}
+			current.kind, current.name, current.value = kindEntry, strings.ToLower(name), ""
+			if rest == "" {
+				// a key without value is a true boolean
+				current.value = "true"
+				break
+			}
+			parser := valueParser{}
+			first := number
+			for parser.feed(rest) {
+				if number+1 >= len(physical) {
+					break
+				}
+				number++
+				current.raw += physical[number]
+				rest = strings.TrimRight(physical[number], "\r\n")
+			}
+			value, err := parser.result()
+			if err != nil {
+				return nil, fmt.Errorf("bad config line %d in file %s: %w", first+1, path, err)
+			}
+			current.value = value
+		}
+		file.lines = append(file.lines, current)
+	}
+	return file, nil
+}
+
+// Entries returns the keys and values of the file in order, the keys as
+// section[.subsection].name with the section and name in lower case
+func (f *File) Entries() []Entry {
+	entries := []Entry{}
+	for _, l := range f.lines {
+		if l.kind == kindEntry {
+			entries = append(entries, Entry{Key: joinKey(l.section, l.subsection, l.name), Value: l.value})
+		}
+	}
+	return entries
+}
+
+// Get returns the last value of the key
+func (f *File) Get(key string) (string, bool) {
+	values := f.GetAll(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value of a multi-valued key
+func (f *File) GetAll(key string) []string {
+	section, subsection, name := canonicalKey(key)
+	values := []string{}
+	for _, l := range f.lines {
+		if l.kind == kindEntry && l.matches(section, subsection) && l.name == name {
+			values = append(values, l.value)
+		}
+	}
+	return values
+}
+
+// Set gives the key a value, replacing its last value or adding it at the
+// end of its section; the section is created when missing
+func (f *File) Set(key, value string) {
+	section, subsection, name := canonicalKey(key)
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		l := f.lines[i]
+		if l.kind == kindEntry && l.matches(section, subsection) && l.name == name {
+			l.raw, l.value = formatEntry(l.raw, keyName(key), value), value
+			return
+		}
+	}
+	f.Add(key, value)
+}
+
+// Add appends a value to the key, keeping the values it already has
+func (f *File) Add(key, value string) {
+	section, subsection, name := canonicalKey(key)
+	entry := &line{kind: kindEntry, section: section, subsection: subsection, name: name, value: value,
+		raw: formatEntry("", keyName(key), value)}
+	// after the last line of the last block of the section, blank lines aside
+	at := -1
+	for i, l := range f.lines {
+		if l.matches(section, subsection) && (l.kind != kindOther || strings.TrimSpace(l.raw) != "") {
+			at = i
+		}
+	}
+	if at >= 0 {
+		f.insert(at+1, entry)
+		return
+	}
+	if len(f.lines) > 0 {
+		f.ensureNewline()
+		f.lines = append(f.lines, &line{kind: kindOther, raw: "\n"})
+	}
+	header := &line{kind: kindSection, section: section, subsection: subsection,
+		raw: formatSectionHeader(sectionName(key), subsection) + "\n"}
+	f.lines = append(f.lines, header, entry)
+}
+
+// Unset removes every value of the key and returns how many there were
+func (f *File) Unset(key string) int {
+	section, subsection, name := canonicalKey(key)
+	kept := []*line{}
+	removed := 0
+	for _, l := range f.lines {
+		if l.kind == kindEntry && l.matches(section, subsection) && l.name == name {
+			removed++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	f.lines = kept
+	return removed
+}
+
+// Subsections returns the names of the subsections of section, in the
+// order of the file
+func (f *File) Subsections(section string) []string {
+	section = strings.ToLower(section)
+	names := []string{}
+	seen := map[string]bool{}
+	for _, l := range f.lines {
+		if l.kind == kindSection && l.section == section && l.subsection != "" && !seen[l.subsection] {
+			seen[l.subsection] = true
+			names = append(names, l.subsection)
+		}
+	}
+	return names
+}
+
+// HasSection tells if the file has a [section "subsection"] header
+func (f *File) HasSection(section, subsection string) bool {
+	for _, l := range f.lines {
+		if l.kind == kindSection && l.matches(strings.ToLower(section), subsection) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveSection deletes the blocks of [section "subsection"] along with
+// their entries and comments; the blank lines before the next section stay
+func (f *File) RemoveSection(section, subsection string) {
+	section = strings.ToLower(section)
+	kept := []*line{}
+	removing := false
+	pending := []*line{}
+	for _, l := range f.lines {
+		if l.kind == kindSection {
+			removing = l.matches(section, subsection)
+			if !removing {
+				kept = append(kept, pending...)
+			}
+			pending = nil
+		}
+		switch {
+		case !removing:
+			kept = append(kept, l)
+		case l.kind == kindOther && strings.TrimSpace(l.raw) == "":
+			pending = append(pending, l)
+		default:
+			pending = nil
+		}
+	}
+	f.lines = append(kept, pending...)
+}
+
+// String returns the content of the file
+func (f *File) String() string {
+	var content strings.Builder
+	for _, l := range f.lines {
+		content.WriteString(l.raw)
+	}
+	return content.String()
+}
+
+// Save writes the file through a lock file renamed over it
+func (f *File) Save() error {
+	lockPath := f.Path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("could not lock config file %s: %w", f.Path, err)
+	}
+	if _, err := lock.WriteString(f.String()); err != nil {
+		lock.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := lock.Close(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return os.Rename(lockPath, f.Path)
+}
+
+// matches tells if the line belongs to [section "subsection"]
+func (l *line) matches(section, subsection string) bool {
+	return l.section == section && l.subsection == subsection
+}
+
+// insert puts a line at position at
+func (f *File) insert(at int, l *line) {
+	if at > 0 {
+		previous := f.lines[at-1]
+		if !strings.HasSuffix(previous.raw, "\n") {
+			previous.raw += "\n"
+		}
+	}
+	f.lines = append(f.lines[:at], append([]*line{l}, f.lines[at:]...)...)
+}
+
+// ensureNewline ends the last line of the file
+func (f *File) ensureNewline() {
+	last := f.lines[len(f.lines)-1]
+	if !strings.HasSuffix(last.raw, "\n") {
+		last.raw += "\n"
+	}
+}
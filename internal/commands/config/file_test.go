@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `# global settings
+[core]
+	bare = false   ; inline comment
+	editor = "vim -c 'set tw=72'"
+[User]
+    Name = A U Thor
+[remote "origin"]
+	url = /srv/repo
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[alias]
+	lg = log \
+--oneline
+	spaced = "  padded  "
+	escaped = tab\there \"quoted\" back\\slash
+	empty =
+	flag
+[branch.Legacy]
+	remote = origin
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse("config", sampleConfig)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if file.String() != sampleConfig {
+		t.Errorf("Expected the file to round-trip:\n%s", file)
+	}
+	for key, expected := range map[string]string{
+		"core.bare":            "false",
+		"core.editor":          "vim -c 'set tw=72'",
+		"user.name":            "A U Thor",
+		"USER.NAME":            "A U Thor",
+		"remote.origin.fetch":  "+refs/tags/*:refs/tags/*",
+		"alias.lg":             "log --oneline",
+		"alias.spaced":         "  padded  ",
+		"alias.escaped":        "tab\there \"quoted\" back\\slash",
+		"alias.empty":          "",
+		"alias.flag":           "true",
+		"branch.legacy.remote": "origin",
+	} {
+		if value, found := file.Get(key); !found || value != expected {
+			t.Errorf("Get(%s) = %q, %v, want %q", key, value, found, expected)
+		}
+	}
+	if _, found := file.Get("remote.ORIGIN.url"); found {
+		t.Errorf("Expected subsections to be case sensitive")
+	}
+	if fetch := file.GetAll("remote.origin.fetch"); len(fetch) != 2 {
+		t.Errorf("Expected two fetch refspecs, got %v", fetch)
+	}
+
+	for _, bad := range []string{"key = value\n", "[core\n", "[core]\n\tbad_key = x\n", "[core]\n\tx = \"open\n", "[core]\n\tx = a\\qb\n"} {
+		if _, err := Parse("config", bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestEditPreservesFormatting(t *testing.T) {
+	file, _ := Parse("config", sampleConfig)
+	file.Set("user.name", "New Name")
+	file.Set("core.pager", "less # paged")
+	file.Set("alias.spaced", "trimmed")
+	file.Add("remote.origin.push", "refs/heads/main")
+	file.Set("init.defaultBranch", "trunk")
+	file.Unset("alias.lg")
+
+	expected := strings.NewReplacer(
+		"    Name = A U Thor\n", "    Name = New Name\n",
+		"editor = \"vim -c 'set tw=72'\"\n", "editor = \"vim -c 'set tw=72'\"\n    pager = \"less # paged\"\n",
+		"\tspaced = \"  padded  \"\n", "\tspaced = trimmed\n",
+		"fetch = +refs/tags/*:refs/tags/*\n", "fetch = +refs/tags/*:refs/tags/*\n    push = refs/heads/main\n",
+		"\tlg = log \\\n--oneline\n", "",
+	).Replace(sampleConfig) + "\n[init]\n    defaultBranch = trunk\n"
+	if file.String() != expected {
+		t.Errorf("Unexpected file:\n%s\nwant:\n%s", file, expected)
+	}
+	reparsed, err := Parse("config", file.String())
+	if err != nil {
+		t.Fatalf("Error parsing the edited file: %v", err)
+	}
+	if value, _ := reparsed.Get("core.pager"); value != "less # paged" {
+		t.Errorf("Expected the quoted value to read back, got %q", value)
+	}
+
+	file.RemoveSection("remote", "origin")
+	if file.HasSection("remote", "origin") || strings.Contains(file.String(), "fetch") {
+		t.Errorf("Expected the section to be removed:\n%s", file)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	for value, expected := range map[string]bool{"yes": true, "On": true, "1": true, "false": false, "off": false, "": false} {
+		if parsed, err := ParseBool(value); err != nil || parsed != expected {
+			t.Errorf("ParseBool(%q) = %v, %v", value, parsed, err)
+		}
+	}
+	if _, err := ParseBool("maybe"); err == nil {
+		t.Errorf("Expected an error for a bad boolean")
+	}
+	for value, expected := range map[string]int64{"42": 42, "-3": -3, "8k": 8192, "1M": 1 << 20, "2g": 2 << 30} {
+		if parsed, err := ParseInt(value); err != nil || parsed != expected {
+			t.Errorf("ParseInt(%q) = %v, %v", value, parsed, err)
+		}
+	}
+	for _, value := range []string{"12kb", "", "9999999999g"} {
+		if _, err := ParseInt(value); err == nil {
+			t.Errorf("Expected an error for ParseInt(%q)", value)
+		}
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(GLOBAL_CONFIG_ENV, filepath.Join(dir, "global"))
+	t.Setenv(NOSYSTEM_ENV, "1")
+	c := NewConfig()
+	c.GotDir = filepath.Join(dir, "work", "project", GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+
+	os.WriteFile(filepath.Join(dir, "global"), []byte(
+		"[user]\n\tname = Home\n[include]\n\tpath = extra\n"+
+			"[includeIf \"gitdir:work/\"]\n\tpath = "+filepath.Join(dir, "work.inc")+"\n"+
+			"[includeIf \"gitdir:elsewhere/\"]\n\tpath = other.inc\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "extra"), []byte("[user]\n\temail = home@example.com\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "work.inc"), []byte("[user]\n\temail = work@example.com\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "other.inc"), []byte("[user]\n\tname = Other\n"), 0644)
+
+	if email, _ := c.GetConfigKeyValue("user.email"); email != "work@example.com" {
+		t.Errorf("Expected the conditional include to win, got %q", email)
+	}
+	if name, _ := c.GetConfigKeyValue("user.name"); name != "Home" {
+		t.Errorf("Expected the include of another directory to be skipped, got %q", name)
+	}
+	settings, _ := c.List(SCOPE_GLOBAL)
+	// the included settings come right after the include
+	if len(settings) != 6 || settings[2].Origin != filepath.Join(dir, "extra") || settings[4].Origin != filepath.Join(dir, "work.inc") {
+		t.Errorf("Expected the origin of an included setting to be its file: %+v", settings)
+	}
+
+	// a file including itself
+	os.WriteFile(filepath.Join(dir, "extra"), []byte("[include]\n\tpath = extra\n"), 0644)
+	if _, err := c.List(""); err == nil || !strings.Contains(err.Error(), "include depth") {
+		t.Errorf("Expected an include depth error, got %v", err)
+	}
+}
+
+func TestMatchGitdir(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		matches bool
+	}{
+		{"/home/u/work/", true},
+		{"/home/u/work", false},
+		{"work/project/", true},
+		{"project/.got", true},
+		{"/home/*/work/project/.got", true},
+		{"/home/*/.got", false},
+		{"/HOME/U/WORK/", false},
+	} {
+		if matched := matchGitdir(tc.pattern, "/home/u/work/project/.got", "/etc/gotconfig", false); matched != tc.matches {
+			t.Errorf("matchGitdir(%s) = %v", tc.pattern, matched)
+		}
+	}
+	if !matchGitdir("/HOME/U/WORK/", "/home/u/work/project/.got", "", true) {
+		t.Errorf("Expected gitdir/i to ignore case")
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MAX_INCLUDE_DEPTH bounds the chain of included files, which stops
+// include cycles
+const MAX_INCLUDE_DEPTH int = 10
+
+// GITDIR_CONDITION starts the [includeIf "gitdir:<pattern>"] conditions
+// matching the repository directory; gitdir/i: ignores case
+const GITDIR_CONDITION string = "gitdir:"
+
+// readLayer returns the settings of the config file at path, with the
+// settings of the files it includes where the include is
+func (c *Config) readLayer(path string, depth int) ([]Setting, error) {
+	if depth > MAX_INCLUDE_DEPTH {
+		return nil, fmt.Errorf("exceeded maximum include depth (%d) while including %s", MAX_INCLUDE_DEPTH, path)
+	}
+	file, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	settings := []Setting{}
+	for _, entry := range file.Entries() {
+		settings = append(settings, Setting{Key: entry.Key, Value: entry.Value, Origin: path})
+		included, ok := c.includedPath(entry, path)
+		if !ok {
+			continue
+		}
+		found, err := c.readLayer(included, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, found...)
+	}
+	return settings, nil
+}
+
+// includedPath returns the file an include.path entry, or the path of an
+// includeIf section whose condition holds, points to
+func (c *Config) includedPath(entry Entry, from string) (string, bool) {
+	section, subsection, name := splitKey(entry.Key)
+	if name != "path" || entry.Value == "" {
+		return "", false
+	}
+	switch {
+	case section == "include" && subsection == "":
+	case section == "includeif" && c.conditionHolds(subsection, from):
+	default:
+		return "", false
+	}
+	return resolvePath(entry.Value, from), true
+}
+
+// conditionHolds evaluates the condition of an includeIf section
+func (c *Config) conditionHolds(condition, from string) bool {
+	ignoreCase := false
+	switch {
+	case strings.HasPrefix(condition, GITDIR_CONDITION):
+		condition = strings.TrimPrefix(condition, GITDIR_CONDITION)
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		condition, ignoreCase = strings.TrimPrefix(condition, "gitdir/i:"), true
+	default:
+		return false
+	}
+	gotDir, err := filepath.Abs(c.GotDir)
+	if err != nil {
+		return false
+	}
+	return matchGitdir(condition, filepath.ToSlash(gotDir), from, ignoreCase)
+}
+
+// matchGitdir matches the repository directory against a gitdir pattern:
+// ~/ is the home directory, ./ the directory of the including file, other
+// relative patterns match at any depth and a trailing / matches everything
+// below
+func matchGitdir(pattern, gotDir, from string, ignoreCase bool) bool {
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = filepath.ToSlash(home) + pattern[1:]
+	case strings.HasPrefix(pattern, "./"):
+		pattern = filepath.ToSlash(filepath.Dir(from)) + pattern[1:]
+	case !strings.HasPrefix(pattern, "/"):
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	expression := globToRegexp(pattern)
+	if ignoreCase {
+		expression = "(?i)" + expression
+	}
+	matched, err := regexp.MatchString(expression, gotDir)
+	return err == nil && matched
+}
+
+// globToRegexp translates a glob where * stops at slashes and ** does not
+func globToRegexp(pattern string) string {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			expression.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expression.WriteString("$")
+	return expression.String()
+}
+
+// resolvePath expands ~/ and makes a relative path relative to the
+// directory of the file it is written in
+func resolvePath(path, from string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
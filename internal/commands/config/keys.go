@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"got_it/internal/utils"
+	"strings"
+)
+
+// OBJECT_FORMAT_KEY names the hash algorithm of the repository
+const OBJECT_FORMAT_KEY string = "extensions.objectFormat"
+
+func init() {
+	Register(
+		KeyInfo{Key: "user.name", Description: "name recorded in commits and tags"},
+		KeyInfo{Key: "user.email", Description: "email recorded in commits and tags"},
+		KeyInfo{Key: "init.defaultBranch", Default: DEFAULT_BRANCH, Description: "branch HEAD points to in new repositories",
+			Validate: validBranchName},
+		KeyInfo{Key: "core.autocrlf", Default: "false", Description: "convert line endings when files are checked out and added",
+			Validate: OneOf("true", "false", "input")},
+		KeyInfo{Key: "color.ui", Default: "auto", Description: "color the output of the commands",
+			Validate: OneOf("auto", "always", "never", "true", "false")},
+		KeyInfo{Key: "gc.auto", Type: TYPE_INT, Default: "6700", Description: "loose objects that trigger a cleanup, 0 to never clean up"},
+		KeyInfo{Key: OBJECT_FORMAT_KEY, Default: string(utils.OF_SHA1), Description: "hash algorithm of the objects, set by init",
+			Validate: validObjectFormat},
+	)
+}
+
+// validBranchName rejects names no branch can have; refs does the full
+// check when the branch is created
+func validBranchName(value string) error {
+	if err := NotEmpty(value); err != nil {
+		return err
+	}
+	if strings.ContainsAny(value, " ~^:?*[\\") || strings.Contains(value, "..") || strings.HasPrefix(value, "-") {
+		return fmt.Errorf("'%s' is not a valid branch name", value)
+	}
+	return nil
+}
+
+func validObjectFormat(value string) error {
+	_, err := utils.ParseObjectFormat(value)
+	return err
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"got_it/internal/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Scope is a layer of the configuration; the local one overrides the
+// global one, which overrides the system one
+type Scope string
+
+const (
+	SCOPE_SYSTEM Scope = "system"
+	SCOPE_GLOBAL Scope = "global"
+	SCOPE_LOCAL  Scope = "local"
+)
+
+// SYSTEM_CONFIG_FILE holds the settings of every user of the machine
+const SYSTEM_CONFIG_FILE string = "/etc/gotconfig"
+
+// GLOBAL_CONFIG_FILE is the config file of the user, in the home directory
+const GLOBAL_CONFIG_FILE string = ".gotconfig"
+
+// GLOBAL_CONFIG_ENV names a file replacing the global config files
+const GLOBAL_CONFIG_ENV string = "GOT_CONFIG_GLOBAL"
+
+// NOSYSTEM_ENV skips the system config file when set to a true value
+const NOSYSTEM_ENV string = "GOT_CONFIG_NOSYSTEM"
+
+// Layer is a config file and the scope it belongs to
+type Layer struct {
+	Scope Scope
+	Path  string
+}
+
+// Setting is a value of the configuration and the file it comes from
+type Setting struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
+// Layers returns the config files from the lowest precedence to the
+// highest; some of them may not exist
+func (c *Config) Layers() []Layer {
+	layers := []Layer{}
+	if !isTrue(os.Getenv(NOSYSTEM_ENV)) {
+		layers = append(layers, Layer{Scope: SCOPE_SYSTEM, Path: SYSTEM_CONFIG_FILE})
+	}
+	for _, path := range globalPaths() {
+		layers = append(layers, Layer{Scope: SCOPE_GLOBAL, Path: path})
+	}
+	return append(layers, Layer{Scope: SCOPE_LOCAL, Path: c.configPath()})
+}
+
+// ScopePath returns the file the settings of the scope are written to
+func (c *Config) ScopePath(scope Scope) (string, error) {
+	switch scope {
+	case SCOPE_SYSTEM:
+		return SYSTEM_CONFIG_FILE, nil
+	case SCOPE_GLOBAL:
+		paths := globalPaths()
+		if len(paths) == 0 {
+			return "", fmt.Errorf("$HOME not set")
+		}
+		// the XDG file is only written when it is the one in use
+		path := paths[len(paths)-1]
+		if _, err := os.Stat(path); os.IsNotExist(err) && len(paths) > 1 {
+			if _, err := os.Stat(paths[0]); err == nil {
+				return paths[0], nil
+			}
+		}
+		return path, nil
+	case SCOPE_LOCAL, "":
+		return c.configPath(), nil
+	}
+	return "", fmt.Errorf("unknown config scope '%s'", scope)
+}
+
+// GetScopedKeyValue reads the key from the files of the scope, or from
+// every layer when the scope is empty; the last value found wins
+func (c *Config) GetScopedKeyValue(scope Scope, key string) (string, error) {
+	values, err := c.GetAllScoped(scope, key)
+	if err != nil {
+		return "", err
+	}
+	return values[len(values)-1], nil
+}
+
+// GetAllScoped returns every value of a multi-valued key in the files of
+// the scope, or of every layer when the scope is empty
+func (c *Config) GetAllScoped(scope Scope, key string) ([]string, error) {
+	if !IsValidKey(key) {
+		return nil, fmt.Errorf("Invalid config key: %s", key)
+	}
+	settings, err := c.List(scope)
+	if err != nil {
+		return nil, err
+	}
+	section, subsection, name := canonicalKey(key)
+	wanted := joinKey(section, subsection, name)
+	values := []string{}
+	for _, setting := range settings {
+		if setting.Key == wanted {
+			values = append(values, setting.Value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Key %s not found", key)
+	}
+	return values, nil
+}
+
+// SetScopedKeyValue writes the key to the file of the scope, replacing the
+// value it has there
+func (c *Config) SetScopedKeyValue(scope Scope, key, value string) error {
+	if err := checkWritable(scope, key); err != nil {
+		return err
+	}
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+	return c.editScope(scope, key, func(file *File) error {
+		if len(file.GetAll(key)) > 1 {
+			return fmt.Errorf("%s has multiple values", key)
+		}
+		file.Set(key, value)
+		return nil
+	})
+}
+
+// AddScopedKeyValue adds a value to the key in the file of the scope,
+// keeping the values it already has
+func (c *Config) AddScopedKeyValue(scope Scope, key, value string) error {
+	if err := checkWritable(scope, key); err != nil {
+		return err
+	}
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+	return c.editScope(scope, key, func(file *File) error {
+		file.Add(key, value)
+		return nil
+	})
+}
+
+// UnsetConfigKey removes the key from the file of the scope; with all
+// false, a key with several values is left alone
+func (c *Config) UnsetConfigKey(scope Scope, key string, all bool) error {
+	if err := checkWritable(scope, key); err != nil {
+		return err
+	}
+	return c.editScope(scope, key, func(file *File) error {
+		switch count := len(file.GetAll(key)); {
+		case count == 0:
+			return fmt.Errorf("key %s not found in %s", key, file.Path)
+		case count > 1 && !all:
+			return fmt.Errorf("%s has multiple values", key)
+		}
+		file.Unset(key)
+		return nil
+	})
+}
+
+// List returns the settings of the files of the scope, or of every layer
+// when the scope is empty, from the lowest precedence to the highest
+func (c *Config) List(scope Scope) ([]Setting, error) {
+	settings := []Setting{}
+	for _, layer := range c.Layers() {
+		if scope != "" && layer.Scope != scope {
+			continue
+		}
+		found, err := c.readLayer(layer.Path, 0)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, found...)
+	}
+	return settings, nil
+}
+
+// parsedSettings returns the last value of each key over every layer; it
+// is read once per repository and dropped by invalidate
+func (c *Config) parsedSettings() map[string]string {
+	if c.settings == nil || c.settingsDir != c.GotDir {
+		c.settings, c.settingsDir = map[string]string{}, c.GotDir
+		settings, _ := c.List("")
+		for _, setting := range settings {
+			c.settings[setting.Key] = setting.Value
+		}
+	}
+	return c.settings
+}
+
+// invalidate drops the values read from the config files, after a write
+func (c *Config) invalidate() {
+	c.objectFormat = ""
+	c.settings = nil
+}
+
+// editScope parses the file of the scope, changes it and saves it
+func (c *Config) editScope(scope Scope, key string, edit func(*File) error) error {
+	if !IsValidKey(key) {
+		return fmt.Errorf("Invalid config key: %s", key)
+	}
+	path, err := c.ScopePath(scope)
+	if err != nil {
+		return err
+	}
+	file, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(file); err != nil {
+		return err
+	}
+	c.invalidate()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return file.Save()
+}
+
+// SetObjectFormat records the hash algorithm of a new repository in its
+// config; it fails once the repository holds objects, which are named with
+// the algorithm they were written with
+func (c *Config) SetObjectFormat(format utils.ObjectFormat) error {
+	if c.hasObjects() {
+		return fmt.Errorf("cannot change the object format of a repository that has objects")
+	}
+	return c.editScope(SCOPE_LOCAL, OBJECT_FORMAT_KEY, func(file *File) error {
+		file.Set(OBJECT_FORMAT_KEY, string(format))
+		return nil
+	})
+}
+
+// checkWritable rejects the keys the config command must not change:
+// extensions.objectFormat is only written by init --object-format, and only
+// in the config of the repository
+func checkWritable(scope Scope, key string) error {
+	if schemaKey(key) != schemaKey(OBJECT_FORMAT_KEY) {
+		return nil
+	}
+	if scope == SCOPE_SYSTEM || scope == SCOPE_GLOBAL {
+		return fmt.Errorf("%s can only be set in the config of the repository", OBJECT_FORMAT_KEY)
+	}
+	return fmt.Errorf("%s is set by init --object-format", OBJECT_FORMAT_KEY)
+}
+
+// hasObjects tells whether the object database of the repository holds
+// loose or packed objects
+func (c *Config) hasObjects() bool {
+	found := false
+	filepath.WalkDir(filepath.Join(c.GotDir, "objects"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipAll
+		}
+		if entry.IsDir() && entry.Name() == "info" {
+			return filepath.SkipDir
+		}
+		found = entry.Type().IsRegular()
+		return nil
+	})
+	return found
+}
+
+// globalPaths returns the global config files: GOT_CONFIG_GLOBAL alone when
+// set, else $XDG_CONFIG_HOME/got/config then ~/.gotconfig
+func globalPaths() []string {
+	if path := os.Getenv(GLOBAL_CONFIG_ENV); path != "" {
+		return []string{path}
+	}
+	paths := []string{}
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "got", CONFIG_FILE))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, GLOBAL_CONFIG_FILE))
+	}
+	return paths
+}
+
+func isTrue(value string) bool {
+	switch value {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayers(t *testing.T) {
+	global := filepath.Join(t.TempDir(), "gotconfig")
+	t.Setenv(GLOBAL_CONFIG_ENV, global)
+	t.Setenv(NOSYSTEM_ENV, "1")
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+
+	if layers := c.Layers(); len(layers) != 2 || layers[0].Path != global || layers[1].Scope != SCOPE_LOCAL {
+		t.Errorf("Unexpected layers: %+v", layers)
+	}
+	if err := c.SetScopedKeyValue(SCOPE_GLOBAL, "user.name", "Global User"); err != nil {
+		t.Fatalf("SetScopedKeyValue returned error: %v", err)
+	}
+	c.SetScopedKeyValue(SCOPE_GLOBAL, "user.email", "global@example.com")
+	if name := c.GetUserName(); name != "Global User" {
+		t.Errorf("Expected the global user name, got %q", name)
+	}
+
+	c.SetConfigKeyValue("user.email", "local@example.com")
+	if email, _ := c.GetConfigKeyValue("user.email"); email != "local@example.com" {
+		t.Errorf("Expected the local email to win, got %q", email)
+	}
+	if email, _ := c.GetScopedKeyValue(SCOPE_GLOBAL, "user.email"); email != "global@example.com" {
+		t.Errorf("Expected the global email, got %q", email)
+	}
+
+	settings, err := c.List("")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	expected := []Setting{
+		{Key: "user.name", Value: "Global User", Origin: global},
+		{Key: "user.email", Value: "global@example.com", Origin: global},
+		{Key: "user.email", Value: "local@example.com", Origin: c.configPath()},
+	}
+	if len(settings) != len(expected) {
+		t.Fatalf("Unexpected settings: %+v", settings)
+	}
+	for i := range expected {
+		if settings[i] != expected[i] {
+			t.Errorf("Setting %d = %+v, want %+v", i, settings[i], expected[i])
+		}
+	}
+
+	if err := c.UnsetConfigKey(SCOPE_LOCAL, "user.email", false); err != nil {
+		t.Fatalf("UnsetConfigKey returned error: %v", err)
+	}
+	if email, _ := c.GetConfigKeyValue("user.email"); email != "global@example.com" {
+		t.Errorf("Expected the global email once the local one is unset, got %q", email)
+	}
+	if err := c.UnsetConfigKey(SCOPE_LOCAL, "user.email", false); err == nil {
+		t.Errorf("Expected an error unsetting a missing key")
+	}
+}
+
+func TestGlobalPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	t.Setenv(GLOBAL_CONFIG_ENV, "")
+	c := NewConfig()
+
+	path, _ := c.ScopePath(SCOPE_GLOBAL)
+	if path != filepath.Join(home, GLOBAL_CONFIG_FILE) {
+		t.Errorf("Expected ~/.gotconfig to be written by default, got %s", path)
+	}
+	xdg := filepath.Join(home, "xdg", "got", CONFIG_FILE)
+	os.MkdirAll(filepath.Dir(xdg), 0755)
+	os.WriteFile(xdg, []byte("[user]\n    name = X D G\n"), 0644)
+	if path, _ := c.ScopePath(SCOPE_GLOBAL); path != xdg {
+		t.Errorf("Expected the XDG file to be written when it is the only one, got %s", path)
+	}
+	if name, _ := c.GetScopedKeyValue(SCOPE_GLOBAL, "user.name"); name != "X D G" {
+		t.Errorf("Expected the XDG file to be read, got %q", name)
+	}
+}
+
+func TestObjectFormatIsNotWritable(t *testing.T) {
+	t.Setenv(GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "gotconfig"))
+	t.Setenv(NOSYSTEM_ENV, "1")
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(filepath.Join(c.GotDir, "objects", "info"), 0755)
+
+	for _, scope := range []Scope{SCOPE_LOCAL, SCOPE_GLOBAL, SCOPE_SYSTEM} {
+		if err := c.SetScopedKeyValue(scope, "extensions.objectFormat", "sha256"); err == nil {
+			t.Errorf("Expected an error setting extensions.objectFormat in the %s scope", scope)
+		}
+	}
+	if err := c.UnsetConfigKey(SCOPE_LOCAL, "extensions.objectformat", false); err == nil {
+		t.Errorf("Expected an error unsetting extensions.objectFormat")
+	}
+
+	os.WriteFile(filepath.Join(c.GotDir, "objects", "info", "packs"), []byte("\n"), 0644)
+	if err := c.SetObjectFormat(utils.OF_SHA256); err != nil {
+		t.Fatalf("SetObjectFormat returned error: %v", err)
+	}
+	if format := c.ObjectFormat(); format != utils.OF_SHA256 {
+		t.Errorf("Expected sha256, got %s", format)
+	}
+
+	os.MkdirAll(filepath.Join(c.GotDir, "objects", "ab"), 0755)
+	os.WriteFile(filepath.Join(c.GotDir, "objects", "ab", "cdef"), []byte("blob"), 0644)
+	if err := c.SetObjectFormat(utils.OF_SHA1); err == nil {
+		t.Errorf("Expected an error changing the object format of a repository with objects")
+	}
+	if format := c.ObjectFormat(); format != utils.OF_SHA256 {
+		t.Errorf("Expected sha256 to be kept, got %s", format)
+	}
+}
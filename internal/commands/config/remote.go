@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// REMOTE_SECTION is the section holding the [remote "<name>"] subsections
+const REMOTE_SECTION string = "remote"
+
+// Remote is a repository to fetch from and push to
+type Remote struct {
+	Name  string
+	URL   string
+	Fetch []string // refspecs mapping the remote refs to local ones
+}
+
+func init() {
+	Register(
+		KeyInfo{Key: "remote.<name>.url", Type: TYPE_PATH, Description: "where the remote repository is", Validate: NotEmpty},
+		KeyInfo{Key: "remote.<name>.fetch", Description: "refspecs mapping the remote refs to local ones"},
+	)
+}
+
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Remotes returns the configured remotes in the order of the config file
+func (c *Config) Remotes() ([]Remote, error) {
+	names, err := c.Subsections(REMOTE_SECTION)
+	if err != nil {
+		return nil, err
+	}
+	remotes := []Remote{}
+	for _, name := range names {
+		remote, err := c.GetRemote(name)
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, remote)
+	}
+	return remotes, nil
+}
+
+// GetRemote returns the remote called name
+func (c *Config) GetRemote(name string) (Remote, error) {
+	entries, err := c.ReadSubsection(REMOTE_SECTION, name)
+	if err != nil {
+		return Remote{}, fmt.Errorf("no such remote '%s'", name)
+	}
+	remote := Remote{Name: name}
+	for _, entry := range entries {
+		switch entry.Key {
+		case "url":
+			remote.URL = entry.Value
+		case "fetch":
+			remote.Fetch = append(remote.Fetch, entry.Value)
+		}
+	}
+	return remote, nil
+}
+
+// AddRemote records a remote whose branches are fetched to
+// refs/remotes/<name>/*
+func (c *Config) AddRemote(name, url string) error {
+	if !remoteNamePattern.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid remote name", name)
+	}
+	if _, err := c.GetRemote(name); err == nil {
+		return fmt.Errorf("remote %s already exists", name)
+	}
+	return c.WriteSubsection(REMOTE_SECTION, name, []Entry{
+		{Key: "url", Value: url},
+		{Key: "fetch", Value: fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", name)},
+	})
+}
+
+// RemoveRemote forgets the remote called name
+func (c *Config) RemoveRemote(name string) error {
+	if _, err := c.GetRemote(name); err != nil {
+		return err
+	}
+	return c.RemoveSubsection(REMOTE_SECTION, name)
+}
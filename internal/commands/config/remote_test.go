@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemotes(t *testing.T) {
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+	os.WriteFile(filepath.Join(c.GotDir, CONFIG_FILE), []byte("[user]\n    name = A U Thor\n"), 0644)
+
+	if err := c.AddRemote("origin", "/srv/repo"); err != nil {
+		t.Fatalf("AddRemote returned error: %v", err)
+	}
+	if err := c.AddRemote("backup", "file:///mnt/repo"); err != nil {
+		t.Fatalf("AddRemote returned error: %v", err)
+	}
+	if err := c.AddRemote("origin", "/elsewhere"); err == nil {
+		t.Errorf("Expected an error adding an existing remote")
+	}
+	if err := c.AddRemote("bad name", "/elsewhere"); err == nil {
+		t.Errorf("Expected an error for an invalid remote name")
+	}
+
+	remote, err := c.GetRemote("origin")
+	if err != nil {
+		t.Fatalf("GetRemote returned error: %v", err)
+	}
+	if remote.URL != "/srv/repo" || len(remote.Fetch) != 1 || remote.Fetch[0] != "+refs/heads/*:refs/remotes/origin/*" {
+		t.Errorf("Unexpected remote: %+v", remote)
+	}
+	content, _ := os.ReadFile(filepath.Join(c.GotDir, CONFIG_FILE))
+	if !strings.HasPrefix(string(content), "[user]\n    name = A U Thor\n") || !strings.Contains(string(content), "[remote \"backup\"]\n") {
+		t.Errorf("Unexpected config file:\n%s", content)
+	}
+
+	if err := c.RemoveRemote("origin"); err != nil {
+		t.Fatalf("RemoveRemote returned error: %v", err)
+	}
+	remotes, _ := c.Remotes()
+	if len(remotes) != 1 || remotes[0].Name != "backup" {
+		t.Errorf("Unexpected remotes: %+v", remotes)
+	}
+	if _, err := c.GetRemote("origin"); err == nil {
+		t.Errorf("Expected an error for a removed remote")
+	}
+}
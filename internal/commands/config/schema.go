@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyType is the type of the values of a config key
+type KeyType string
+
+const (
+	TYPE_STRING KeyType = "string"
+	TYPE_BOOL   KeyType = "bool"
+	TYPE_INT    KeyType = "int"
+	TYPE_PATH   KeyType = "path"
+)
+
+// SUBSECTION_WILDCARD stands for any subsection in a registered key, as in
+// remote.<name>.url
+const SUBSECTION_WILDCARD string = "<name>"
+
+// Validator checks a value beyond its type
+type Validator func(value string) error
+
+// KeyInfo describes a config key: the type of its values, the value used
+// when it is not set and an optional validator
+type KeyInfo struct {
+	Key         string
+	Type        KeyType
+	Default     string
+	Description string
+	Validate    Validator
+}
+
+// schema holds the registered keys by lower case section and name
+var schema = map[string]KeyInfo{}
+
+// Register declares config keys; each subsystem registers the keys it reads
+// from an init function
+func Register(infos ...KeyInfo) {
+	for _, info := range infos {
+		if info.Type == "" {
+			info.Type = TYPE_STRING
+		}
+		schema[schemaKey(info.Key)] = info
+	}
+}
+
+// Lookup returns the description of a registered key; the <name> wildcard
+// matches any subsection, or any name in sections like alias.<name>
+func Lookup(key string) (KeyInfo, bool) {
+	info, found := schema[schemaKey(key)]
+	if !found {
+		section, subsection, name := canonicalKey(key)
+		if subsection != "" {
+			info, found = schema[joinKey(section, SUBSECTION_WILDCARD, name)]
+		} else {
+			info, found = schema[joinKey(section, "", SUBSECTION_WILDCARD)]
+		}
+	}
+	return info, found
+}
+
+// Schema returns the registered keys sorted by name
+func Schema() []KeyInfo {
+	infos := make([]KeyInfo, 0, len(schema))
+	for _, info := range schema {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return strings.ToLower(infos[i].Key) < strings.ToLower(infos[j].Key)
+	})
+	return infos
+}
+
+// Validate checks the value against the type and validator of the key;
+// keys that are not registered take any value
+func Validate(key, value string) error {
+	info, found := Lookup(key)
+	if !found {
+		return nil
+	}
+	var err error
+	switch info.Type {
+	case TYPE_BOOL:
+		_, err = ParseBool(value)
+	case TYPE_INT:
+		_, err = ParseInt(value)
+	}
+	if err == nil && info.Validate != nil {
+		err = info.Validate(value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value '%s' for %s: %w", value, key, err)
+	}
+	return nil
+}
+
+// String returns the value of the key, or its default when it is not set.
+// The layers are read once and kept until the config is written through c.
+func (c *Config) String(key string) string {
+	if value, found := c.parsedSettings()[schemaKey(key)]; found {
+		return value
+	}
+	info, _ := Lookup(key)
+	return info.Default
+}
+
+// Bool returns the value of a boolean key; a value that does not parse
+// gives the default
+func (c *Config) Bool(key string) bool {
+	value, err := ParseBool(c.String(key))
+	if err != nil {
+		info, _ := Lookup(key)
+		value, _ = ParseBool(info.Default)
+	}
+	return value
+}
+
+// Int returns the value of an integer key, unit suffixes applied; a value
+// that does not parse gives the default
+func (c *Config) Int(key string) int64 {
+	value, err := ParseInt(c.String(key))
+	if err != nil {
+		info, _ := Lookup(key)
+		value, _ = ParseInt(info.Default)
+	}
+	return value
+}
+
+// OneOf accepts the given values, ignoring case
+func OneOf(values ...string) Validator {
+	return func(value string) error {
+		for _, accepted := range values {
+			if strings.EqualFold(value, accepted) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(values, ", "))
+	}
+}
+
+// NotEmpty rejects empty values
+func NotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("empty value")
+	}
+	return nil
+}
+
+func schemaKey(key string) string {
+	section, subsection, name := canonicalKey(key)
+	return joinKey(section, subsection, name)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	if info, found := Lookup("Remote.origin.URL"); !found || info.Key != "remote.<name>.url" {
+		t.Errorf("Expected remote.origin.url to match remote.<name>.url, got %+v", info)
+	}
+	if _, found := Lookup("unknown.key"); found {
+		t.Errorf("Expected unknown.key not to be registered")
+	}
+	keys := Schema()
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1].Key > keys[i].Key {
+			t.Errorf("Expected the keys to be sorted, got %s before %s", keys[i-1].Key, keys[i].Key)
+		}
+	}
+
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"core.autocrlf", "input", true},
+		{"core.autocrlf", "sometimes", false},
+		{"color.ui", "Never", true},
+		{"gc.auto", "10k", true},
+		{"gc.auto", "often", false},
+		{"init.defaultBranch", "trunk", true},
+		{"init.defaultBranch", "bad..name", false},
+		{"remote.origin.url", "", false},
+		{"unknown.key", "anything", true},
+	}
+	for _, test := range tests {
+		if err := Validate(test.key, test.value); (err == nil) != test.valid {
+			t.Errorf("Validate(%q, %q) returned %v, expected valid to be %v", test.key, test.value, err, test.valid)
+		}
+	}
+}
+
+func TestTypedValues(t *testing.T) {
+	t.Setenv(GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "gotconfig"))
+	t.Setenv(NOSYSTEM_ENV, "1")
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+
+	// nothing set, the defaults are read
+	if branch := c.GetDefaultBranch(); branch != DEFAULT_BRANCH {
+		t.Errorf("Expected the default branch %q, got %q", DEFAULT_BRANCH, branch)
+	}
+	if c.Int("gc.auto") != 6700 || c.String("core.autocrlf") != "false" {
+		t.Errorf("Expected the registered defaults, got %d and %q", c.Int("gc.auto"), c.String("core.autocrlf"))
+	}
+	if name := c.GetUserName(); name != "" {
+		t.Errorf("Expected no user name, got %q", name)
+	}
+
+	if err := c.SetConfigKeyValue("gc.auto", "often"); err == nil {
+		t.Errorf("Expected an invalid value to be rejected")
+	}
+	c.SetConfigKeyValue("gc.auto", "1k")
+	c.SetScopedKeyValue(SCOPE_GLOBAL, "init.defaultBranch", "trunk")
+	if c.Int("gc.auto") != 1024 || c.GetDefaultBranch() != "trunk" {
+		t.Errorf("Expected the set values, got %d and %q", c.Int("gc.auto"), c.GetDefaultBranch())
+	}
+
+	// a bad value written by hand falls back to the default
+	Register(KeyInfo{Key: "test.enabled", Type: TYPE_BOOL, Default: "true"})
+	file, _ := ParseFile(filepath.Join(c.GotDir, CONFIG_FILE))
+	file.Set("test.enabled", "perhaps")
+	file.Save()
+	if !c.Bool("test.enabled") {
+		t.Errorf("Expected a bad boolean to read as the default")
+	}
+}
+
+func TestSettingsAreCached(t *testing.T) {
+	t.Setenv(GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "gotconfig"))
+	t.Setenv(NOSYSTEM_ENV, "1")
+	c := NewConfig()
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	os.MkdirAll(c.GotDir, 0755)
+	c.SetConfigKeyValue("gc.auto", "10")
+	if c.Int("gc.auto") != 10 {
+		t.Fatalf("Expected gc.auto to be 10, got %d", c.Int("gc.auto"))
+	}
+
+	// a change behind the back of the config is not read again
+	file, _ := ParseFile(filepath.Join(c.GotDir, CONFIG_FILE))
+	file.Set("gc.auto", "20")
+	file.Save()
+	if c.Int("gc.auto") != 10 {
+		t.Errorf("Expected the cached value 10, got %d", c.Int("gc.auto"))
+	}
+
+	// writing through the config drops the cache
+	c.WriteSubsection("remote", "origin", []Entry{{Key: "url", Value: "/tmp/origin"}})
+	if c.Int("gc.auto") != 20 || c.String("remote.origin.url") != "/tmp/origin" {
+		t.Errorf("Expected the written values, got %d and %q", c.Int("gc.auto"), c.String("remote.origin.url"))
+	}
+
+	// another repository has its own settings
+	c.GotDir = filepath.Join(t.TempDir(), GOT_DIR)
+	if c.Int("gc.auto") != 6700 {
+		t.Errorf("Expected the default of another repository, got %d", c.Int("gc.auto"))
+	}
+}
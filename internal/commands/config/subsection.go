@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a key and its value inside a config section
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// Subsections returns the names of the subsections of section, like the
+// names of the remotes for [remote "origin"], in the order of the file
+func (c *Config) Subsections(section string) ([]string, error) {
+	file, err := ParseFile(c.configPath())
+	if err != nil {
+		return nil, err
+	}
+	return file.Subsections(section), nil
+}
+
+// ReadSubsection returns the entries of [section "name"], keyed by their
+// lower case name; keys may repeat
+func (c *Config) ReadSubsection(section, name string) ([]Entry, error) {
+	file, err := ParseFile(c.configPath())
+	if err != nil {
+		return nil, err
+	}
+	if !file.HasSection(section, name) {
+		return nil, fmt.Errorf("no such section: %s.%s", section, name)
+	}
+	entries := []Entry{}
+	for _, entry := range file.Entries() {
+		entrySection, subsection, key := splitKey(entry.Key)
+		if entrySection == strings.ToLower(section) && subsection == name {
+			entries = append(entries, Entry{Key: key, Value: entry.Value})
+		}
+	}
+	return entries, nil
+}
+
+// WriteSubsection replaces the entries of [section "name"], adding the
+// section at the end of the file when it does not exist yet
+func (c *Config) WriteSubsection(section, name string, entries []Entry) error {
+	file, err := ParseFile(c.configPath())
+	if err != nil {
+		return err
+	}
+	for _, entry := range file.Entries() {
+		if entrySection, subsection, _ := splitKey(entry.Key); entrySection == strings.ToLower(section) && subsection == name {
+			file.Unset(entry.Key)
+		}
+	}
+	for _, entry := range entries {
+		file.Add(joinKey(section, name, entry.Key), entry.Value)
+	}
+	c.invalidate()
+	return file.Save()
+}
+
+// RemoveSubsection deletes [section "name"] and its entries
+func (c *Config) RemoveSubsection(section, name string) error {
+	file, err := ParseFile(c.configPath())
+	if err != nil {
+		return err
+	}
+	if !file.HasSection(section, name) {
+		return fmt.Errorf("no such section: %s.%s", section, name)
+	}
+	file.RemoveSection(section, name)
+	c.invalidate()
+	return file.Save()
+}
+
+func (c *Config) configPath() string {
+	return filepath.Join(c.GotDir, CONFIG_FILE)
+}
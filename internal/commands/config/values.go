@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseHeader reads [section], [section "subsection"] and the legacy
+// [section.subsection]; only a comment may follow the header
+func parseHeader(text string) (string, string, error) {
+	i := 1
+	for i < len(text) && isNameChar(text[i], true) {
+		i++
+	}
+	section := text[1:i]
+	if section == "" {
+		return "", "", fmt.Errorf("missing section name")
+	}
+	subsection := ""
+	switch {
+	case i < len(text) && text[i] == ']':
+		if before, after, legacy := strings.Cut(section, "."); legacy {
+			section, subsection = before, strings.ToLower(after)
+		}
+	case i < len(text) && (text[i] == ' ' || text[i] == '\t'):
+		for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+			i++
+		}
+		if i >= len(text) || text[i] != '"' {
+			return "", "", fmt.Errorf("expected a quoted subsection")
+		}
+		var name strings.Builder
+		for i++; i < len(text) && text[i] != '"'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+			}
+			name.WriteByte(text[i])
+		}
+		if i+1 >= len(text) || text[i+1] != ']' {
+			return "", "", fmt.Errorf("unterminated section header")
+		}
+		subsection = name.String()
+		i++
+	default:
+		return "", "", fmt.Errorf("invalid section header")
+	}
+	if strings.Contains(section, ".") {
+		return "", "", fmt.Errorf("invalid section name '%s'", section)
+	}
+	rest := strings.TrimSpace(text[i+1:])
+	if rest != "" && rest[0] != '#' && rest[0] != ';' {
+		return "", "", fmt.Errorf("unexpected text after the section header")
+	}
+	return strings.ToLower(section), subsection, nil
+}
+
+// parseName reads the key at the start of an entry and returns what
+// follows the "=", or "" when the key stands alone
+func parseName(text string) (string, string, error) {
+	i := 0
+	for i < len(text) && isNameChar(text[i], false) {
+		i++
+	}
+	name := text[:i]
+	if name == "" || !isLetter(name[0]) {
+		return "", "", fmt.Errorf("invalid key name")
+	}
+	rest := strings.TrimLeft(text[i:], " \t")
+	switch {
+	case rest == "" || rest[0] == '#' || rest[0] == ';':
+		return name, "", nil
+	case rest[0] != '=':
+		return "", "", fmt.Errorf("expected '=' after '%s'", name)
+	}
+	// an empty value is kept apart from a missing one
+	if value := rest[1:]; strings.TrimSpace(value) != "" {
+		return name, value, nil
+	}
+	return name, `""`, nil
+}
+
+// valueParser reads a value over one or more lines: quotes keep spaces and
+// comment characters, backslashes escape \n, \t, \b, \" and \\, and end a
+// line that goes on with the next one. Spaces around the value are dropped.
+type valueParser struct {
+	value   strings.Builder
+	spaces  string
+	started bool
+	quoted  bool
+	err     error
+}
+
+// feed parses a line of the value and tells if the value goes on
+func (p *valueParser) feed(text string) bool {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(text) {
+				return true
+			}
+			i++
+			escaped, ok := map[byte]byte{'n': '\n', 't': '\t', 'b': '\b', '"': '"', '\\': '\\'}[text[i]]
+			if !ok {
+				p.err = fmt.Errorf("bad escape sequence '\\%c'", text[i])
+				return false
+			}
+			p.write(escaped)
+		case c == '"':
+			p.quoted = !p.quoted
+			p.started = true
+			p.value.WriteString(p.spaces)
+			p.spaces = ""
+		case p.quoted:
+			p.write(c)
+		case c == '#' || c == ';':
+			return false
+		case c == ' ' || c == '\t':
+			if p.started {
+				p.spaces += string(c)
+			}
+		default:
+			p.write(c)
+		}
+	}
+	return false
+}
+
+func (p *valueParser) write(c byte) {
+	p.started = true
+	p.value.WriteString(p.spaces)
+	p.spaces = ""
+	p.value.WriteByte(c)
+}
+
+// result returns the value parsed
+func (p *valueParser) result() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if p.quoted {
+		return "", fmt.Errorf("unterminated quote")
+	}
+	return p.value.String(), nil
+}
+
+// formatEntry writes "name = value" with the indentation and the spelling
+// of the key of raw, the line it replaces, if any
+func formatEntry(raw, name, value string) string {
+	indent := "    "
+	if raw != "" {
+		trimmed := strings.TrimLeft(raw, " \t")
+		indent = raw[:len(raw)-len(trimmed)]
+		end := 0
+		for end < len(trimmed) && isNameChar(trimmed[end], false) {
+			end++
+		}
+		name = trimmed[:end]
+	}
+	return fmt.Sprintf("%s%s = %s\n", indent, name, formatValue(value))
+}
+
+// formatValue quotes and escapes the value so that it reads back the same
+func formatValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\b", `\b`).Replace(value)
+	if value == "" || value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;") {
+		return `"` + escaped + `"`
+	}
+	return escaped
+}
+
+// ParseBool reads true, yes, on and 1, or false, no, off, 0 and an empty
+// value, ignoring case
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("bad boolean config value '%s'", value)
+}
+
+// ParseInt reads an integer with an optional k, m or g suffix multiplying
+// it by 1024, 1024^2 or 1024^3
+func ParseInt(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	multiplier := int64(1)
+	if value != "" {
+		switch strings.ToLower(value[len(value)-1:]) {
+		case "k":
+			multiplier = 1 << 10
+		case "m":
+			multiplier = 1 << 20
+		case "g":
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad numeric config value '%s'", value)
+	}
+	if number > 0 && number > (1<<63-1)/multiplier || number < 0 && number < -(1<<63-1)/multiplier {
+		return 0, fmt.Errorf("numeric config value '%s' out of range", value)
+	}
+	return number * multiplier, nil
+}
+
+// canonicalKey splits a key into its section and name in lower case and
+// its subsection, whose case matters
+func canonicalKey(key string) (string, string, string) {
+	section, subsection, name := splitKey(key)
+	return strings.ToLower(section), subsection, strings.ToLower(name)
+}
+
+// joinKey builds section[.subsection].name
+func joinKey(section, subsection, name string) string {
+	if subsection == "" {
+		return section + "." + name
+	}
+	return section + "." + subsection + "." + name
+}
+
+// sectionName returns the section of the key as it is written
+func sectionName(key string) string {
+	section, _, _ := splitKey(key)
+	return section
+}
+
+// keyName returns the name of the key as it is written
+func keyName(key string) string {
+	_, _, name := splitKey(key)
+	return name
+}
+
+func isNameChar(c byte, section bool) bool {
+	return isLetter(c) || c >= '0' && c <= '9' || c == '-' || section && c == '.'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,242 @@
+package fetch
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/transport"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DEFAULT_REMOTE is fetched from when no remote is given
+const DEFAULT_REMOTE string = "origin"
+
+type Fetch struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	out      io.Writer
+}
+
+// refUpdate is a local ref to move to a hash fetched from a remote ref
+type refUpdate struct {
+	src   string
+	dst   string
+	hash  string
+	force bool
+}
+
+func NewFetch(conf *config.Config, logger *logger.Logger) *Fetch {
+	return &Fetch{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute fetches from the remote in args[0], origin by default, with the
+// refspecs that follow or the configured ones
+func Execute(args []string) error {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	fe := NewFetch(conf, logger)
+
+	remoteName := DEFAULT_REMOTE
+	if len(args) > 0 {
+		remoteName = args[0]
+		args = args[1:]
+	}
+	if err := fe.Fetch(remoteName, args); err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+	return nil
+}
+
+// Fetch copies the objects of the remote refs matched by the refspecs and
+// updates the local refs they map to. The tags of the remote that are
+// missing locally are fetched as well.
+func (fe *Fetch) Fetch(remoteName string, specs []string) error {
+	remote, err := fe.conf.GetRemote(remoteName)
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 {
+		specs = remote.Fetch
+	}
+	refspecs := []transport.Refspec{}
+	for _, spec := range specs {
+		refspec, err := transport.ParseRefspec(spec)
+		if err != nil {
+			return err
+		}
+		refspecs = append(refspecs, refspec)
+	}
+
+	conn, err := transport.Open(remote.URL, fe.logger)
+	if err != nil {
+		return err
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		return err
+	}
+	if err := transport.CheckObjectFormat(fe.store.Format(), adv); err != nil {
+		return err
+	}
+	updates := fe.updatesFor(adv, refspecs)
+	wants := []string{}
+	for _, update := range updates {
+		if !fe.store.Exists(update.hash) {
+			wants = append(wants, update.hash)
+		}
+	}
+	if len(wants) > 0 {
+		haves, err := fe.haves()
+		if err != nil {
+			return err
+		}
+		if err := conn.Fetch(fe.store, wants, haves); err != nil {
+			return err
+		}
+	}
+
+	header, rejected := false, false
+	for _, update := range updates {
+		line, applied, err := fe.apply(update)
+		if err != nil {
+			return err
+		}
+		rejected = rejected || !applied
+		if line == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(fe.out, "From %s\n", remote.URL)
+			header = true
+		}
+		fmt.Fprintln(fe.out, line)
+	}
+	if rejected {
+		return fmt.Errorf("some local refs could not be updated")
+	}
+	return nil
+}
+
+// updatesFor maps the remote refs through the refspecs, adding the tags
+// that do not exist locally
+func (fe *Fetch) updatesFor(adv transport.Advertisement, refspecs []transport.Refspec) []refUpdate {
+	names := []string{}
+	for name := range adv.Refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	updates := []refUpdate{}
+	mapped := map[string]bool{}
+	for _, name := range names {
+		for _, refspec := range refspecs {
+			dst, matches := refspec.Match(name)
+			if !matches || dst == "" || mapped[dst] {
+				continue
+			}
+			mapped[dst] = true
+			updates = append(updates, refUpdate{src: name, dst: dst, hash: adv.Refs[name], force: refspec.Force})
+		}
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, "refs/tags/") && !mapped[name] && !fe.refs.Exists(name) {
+			updates = append(updates, refUpdate{src: name, dst: name, hash: adv.Refs[name]})
+		}
+	}
+	return updates
+}
+
+// apply moves a local ref to the fetched hash, unless that would lose
+// commits without being forced, and returns the line describing it and
+// whether the ref is now up to date
+func (fe *Fetch) apply(update refUpdate) (string, bool, error) {
+	old, _, err := fe.refs.Resolve(update.dst)
+	if err != nil {
+		old = ""
+	}
+	if old == update.hash {
+		return "", true, nil
+	}
+
+	kind := "branch"
+	if strings.HasPrefix(update.src, "refs/tags/") {
+		kind = "tag"
+	}
+	flag, summary, message, suffix := "*", "[new "+kind+"]", "storing head", ""
+	if old != "" {
+		fastForward, err := fe.isAncestor(old, update.hash)
+		if err != nil {
+			return "", false, err
+		}
+		short := fe.store.Abbreviate(old, objects.ABBREV_LENGTH) + ".." + fe.store.Abbreviate(update.hash, objects.ABBREV_LENGTH)
+		switch {
+		case fastForward:
+			flag, summary, message = " ", short, "fast-forward"
+		case update.force:
+			flag, summary, message, suffix = "+", strings.Replace(short, "..", "...", 1), "forced-update", "  (forced update)"
+		default:
+			return fmt.Sprintf(" ! %-17s %s -> %s  (non-fast-forward)", "[rejected]", refs.ShortName(update.src), refs.ShortName(update.dst)), false, nil
+		}
+	}
+	if err := fe.refs.Update(update.dst, update.hash, refs.UpdateOptions{Message: "fetch: " + message}); err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf(" %s %-17s %s -> %s%s", flag, summary, refs.ShortName(update.src), refs.ShortName(update.dst), suffix), true, nil
+}
+
+// haves lists the commits the local refs point to, whose history does not
+// need to be sent
+func (fe *Fetch) haves() ([]string, error) {
+	list, err := fe.refs.List("refs/")
+	if err != nil {
+		return nil, err
+	}
+	haves := []string{}
+	for _, ref := range list {
+		if ref.Hash != "" {
+			haves = append(haves, ref.Hash)
+		}
+	}
+	return haves, nil
+}
+
+// isAncestor tells if the commit old is reachable from new; tags may point
+// to other objects, which are never ancestors
+func (fe *Fetch) isAncestor(old, new string) (bool, error) {
+	oldCommit, err := fe.resolver.ResolveCommit(old)
+	if err != nil {
+		return false, nil
+	}
+	newCommit, err := fe.resolver.ResolveCommit(new)
+	if err != nil {
+		return false, nil
+	}
+	reachable, err := fe.resolver.Reachable([]string{newCommit})
+	if err != nil {
+		return false, err
+	}
+	return reachable[oldCommit], nil
+}
+
+// SetOutput changes where the output is written
+func (fe *Fetch) SetOutput(out io.Writer) {
+	fe.out = out
+}
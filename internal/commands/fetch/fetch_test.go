@@ -0,0 +1,143 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepos creates an empty repository in the working directory with a
+// remote named origin, a repository holding two commits on main, and
+// returns the store of the remote, its refs and its commits
+func arrangeRepos(t *testing.T) (*Fetch, *bytes.Buffer, *objects.Store, *refs.Store, []string) {
+	t.Helper()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	logger := logger.NewLogger(false, false)
+	remoteDir := t.TempDir()
+	remoteConf := config.NewConfig()
+	remoteConf.GotDir = filepath.Join(remoteDir, config.GOT_DIR)
+	for _, conf := range []*config.Config{config.NewConfig(), remoteConf} {
+		os.MkdirAll(filepath.Join(conf.GotDir, "objects"), 0755)
+		os.MkdirAll(filepath.Join(conf.GotDir, "refs", "heads"), 0755)
+		os.WriteFile(filepath.Join(conf.GotDir, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	}
+	remoteStore := objects.NewStore(remoteConf, logger)
+	remoteRefs := refs.NewStore(remoteConf, logger)
+	commits := []string{}
+	for i := 0; i < 2; i++ {
+		commits = append(commits, writeCommit(t, remoteStore, fmt.Sprintf("version %d\n", i), commits...))
+	}
+	remoteRefs.Update("refs/heads/main", commits[1], refs.UpdateOptions{})
+
+	conf := config.NewConfig()
+	if err := conf.AddRemote("origin", remoteDir); err != nil {
+		t.Fatalf("Error adding remote: %v", err)
+	}
+	fe := NewFetch(conf, logger)
+	out := &bytes.Buffer{}
+	fe.SetOutput(out)
+	return fe, out, remoteStore, remoteRefs, commits
+}
+
+// writeCommit writes a commit with one file on top of the last parent given
+func writeCommit(t *testing.T, store *objects.Store, content string, parents ...string) string {
+	t.Helper()
+	blob, _ := store.Write(content)
+	tree, _ := store.Write(fmt.Sprintf("100644 blob %s\tfile.txt\n", blob))
+	header := "tree " + tree + "\n"
+	if len(parents) > 0 {
+		header += "parent " + parents[len(parents)-1] + "\n"
+	}
+	commit, err := store.Write(header + "author A U Thor <author@example.com> 1623501234 +0200\n" +
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\n" + content)
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	return commit
+}
+
+func TestFetch(t *testing.T) {
+	fe, out, remoteStore, remoteRefs, commits := arrangeRepos(t)
+	tag, _ := remoteStore.Write("object " + commits[0] + "\ntype commit\ntag v1\n" +
+		"tagger A U Thor <author@example.com> 1623501234 +0200\n\nfirst\n")
+	remoteRefs.Update("refs/tags/v1", tag, refs.UpdateOptions{})
+
+	if err := fe.Fetch("origin", nil); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	expected := " * [new branch]      main -> origin/main\n * [new tag]         v1 -> v1\n"
+	if !strings.HasPrefix(out.String(), "From ") || !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if hash, _, _ := fe.refs.Resolve("refs/remotes/origin/main"); hash != commits[1] {
+		t.Errorf("Expected origin/main at %s, got %s", commits[1], hash)
+	}
+	if hash, _, _ := fe.refs.Resolve("refs/tags/v1"); hash != tag || !fe.store.Exists(commits[0]) {
+		t.Errorf("Expected the tag and its commit to be fetched")
+	}
+
+	// nothing new
+	out.Reset()
+	if err := fe.Fetch("origin", nil); err != nil || out.Len() > 0 {
+		t.Errorf("Expected no output, got %q, %v", out.String(), err)
+	}
+
+	third := writeCommit(t, remoteStore, "version 2\n", commits[1])
+	remoteRefs.Update("refs/heads/main", third, refs.UpdateOptions{})
+	if err := fe.Fetch("origin", nil); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	short := fe.store.Abbreviate(commits[1], objects.ABBREV_LENGTH) + ".." + fe.store.Abbreviate(third, objects.ABBREV_LENGTH)
+	if !strings.Contains(out.String(), "   "+short+"  main -> origin/main\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestFetchRewrittenBranch(t *testing.T) {
+	fe, out, remoteStore, remoteRefs, commits := arrangeRepos(t)
+	if err := fe.Fetch("origin", nil); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	rewritten := writeCommit(t, remoteStore, "rewritten\n", commits[0])
+	remoteRefs.Update("refs/heads/main", rewritten, refs.UpdateOptions{})
+
+	// without +, the remote-tracking branch would lose a commit
+	out.Reset()
+	err := fe.Fetch("origin", []string{"refs/heads/main:refs/remotes/origin/main"})
+	if err == nil || !strings.Contains(out.String(), " ! [rejected]        main -> origin/main  (non-fast-forward)\n") {
+		t.Errorf("Expected the update to be rejected, got %v:\n%s", err, out.String())
+	}
+	if hash, _, _ := fe.refs.Resolve("refs/remotes/origin/main"); hash != commits[1] {
+		t.Errorf("Expected origin/main to stay at %s, got %s", commits[1], hash)
+	}
+
+	out.Reset()
+	if err := fe.Fetch("origin", nil); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), " + ") || !strings.HasSuffix(out.String(), "main -> origin/main  (forced update)\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if hash, _, _ := fe.refs.Resolve("refs/remotes/origin/main"); hash != rewritten {
+		t.Errorf("Expected origin/main at %s, got %s", rewritten, hash)
+	}
+}
+
+func TestFetchUnknownRemote(t *testing.T) {
+	fe, _, _, _, _ := arrangeRepos(t)
+	if err := fe.Fetch("upstream", nil); err == nil {
+		t.Errorf("Expected an error for an unknown remote")
+	}
+	if err := Execute([]string{"upstream"}); err == nil {
+		t.Errorf("Expected Execute to return the error")
+	}
+}
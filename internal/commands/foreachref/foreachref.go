@@ -0,0 +1,223 @@
+package foreachref
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DEFAULT_FORMAT is the format used when --format is not given
+const DEFAULT_FORMAT string = "%(objectname) %(objecttype)\t%(refname)"
+
+type ForEachRef struct {
+	conf   *config.Config
+	logger *logger.Logger
+	refs   *refs.Store
+	store  *objects.Store
+	out    io.Writer
+}
+
+// ForEachRefOptions holds the flags of the for-each-ref command
+type ForEachRefOptions struct {
+	Format string
+	Sort   string
+	Count  int
+}
+
+// refInfo holds the fields of a ref that can be shown or sorted on
+type refInfo struct {
+	ref         refs.Ref
+	objectType  models.ObjectType
+	subject     string
+	creatorDate time.Time
+}
+
+func NewForEachRef(conf *config.Config, logger *logger.Logger) *ForEachRef {
+	return &ForEachRef{
+		conf:   conf,
+		logger: logger,
+		refs:   refs.NewStore(conf, logger),
+		store:  objects.NewStore(conf, logger),
+		out:    os.Stdout,
+	}
+}
+
+// Execute lists the refs matching the patterns
+func Execute(patterns []string, opts ForEachRefOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	if err := NewForEachRef(conf, logger).ForEachRef(patterns, opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// ForEachRef writes a line for every ref under refs/ matching one of the
+// patterns, formatted with %(<field>) placeholders
+func (fe *ForEachRef) ForEachRef(patterns []string, opts ForEachRefOptions) error {
+	format := opts.Format
+	if format == "" {
+		format = DEFAULT_FORMAT
+	}
+	allRefs, err := fe.refs.List("refs/")
+	if err != nil {
+		return err
+	}
+	infos := []refInfo{}
+	for _, ref := range allRefs {
+		if matchesAny(ref.Name, patterns) {
+			infos = append(infos, fe.describe(ref))
+		}
+	}
+	if err := sortRefs(infos, opts.Sort); err != nil {
+		return err
+	}
+	for i, info := range infos {
+		if opts.Count > 0 && i >= opts.Count {
+			break
+		}
+		line, err := fe.expand(format, info)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(fe.out, line)
+	}
+	return nil
+}
+
+// SetOutput changes where the refs are written to
+func (fe *ForEachRef) SetOutput(out io.Writer) {
+	fe.out = out
+}
+
+// describe reads the object the ref points to
+func (fe *ForEachRef) describe(ref refs.Ref) refInfo {
+	info := refInfo{ref: ref}
+	content, err := fe.store.Read(ref.Hash)
+	if err != nil {
+		fe.logger.Debug("Error reading object of %s: %s", ref.Name, err)
+		return info
+	}
+	info.objectType = objects.TypeOf(content)
+	switch info.objectType {
+	case models.OT_COMMIT:
+		if commit, err := fe.store.ReadCommit(ref.Hash); err == nil {
+			info.subject, _, _ = strings.Cut(commit.Message, "\n")
+			info.creatorDate = commit.CommitterDate
+		}
+	case models.OT_TAG:
+		if tag, err := fe.store.ReadTag(ref.Hash); err == nil {
+			info.subject, _, _ = strings.Cut(tag.Message, "\n")
+			info.creatorDate = tag.TaggerDate
+		}
+	}
+	return info
+}
+
+// expand replaces the %(<field>) placeholders of the format
+func (fe *ForEachRef) expand(format string, info refInfo) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(format); i++ {
+		switch {
+		case strings.HasPrefix(format[i:], "%%"):
+			result.WriteByte('%')
+			i++
+		case strings.HasPrefix(format[i:], "%("):
+			end := strings.Index(format[i:], ")")
+			if end < 0 {
+				return "", fmt.Errorf("malformed format string %s", format)
+			}
+			value, err := fe.field(format[i+2:i+end], info)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(value)
+			i += end
+		default:
+			result.WriteByte(format[i])
+		}
+	}
+	return result.String(), nil
+}
+
+// field returns the value of a placeholder for the ref
+func (fe *ForEachRef) field(name string, info refInfo) (string, error) {
+	switch name {
+	case "refname":
+		return info.ref.Name, nil
+	case "refname:short":
+		return refs.ShortName(info.ref.Name), nil
+	case "objectname":
+		return info.ref.Hash, nil
+	case "objectname:short":
+		if info.ref.Hash == "" {
+			return "", nil
+		}
+		return fe.store.Abbreviate(info.ref.Hash, objects.ABBREV_LENGTH), nil
+	case "objecttype":
+		return string(info.objectType), nil
+	case "symref":
+		return info.ref.Target, nil
+	case "subject":
+		return info.subject, nil
+	case "creatordate":
+		if info.creatorDate.IsZero() {
+			return "", nil
+		}
+		return date.Format(info.creatorDate), nil
+	}
+	return "", fmt.Errorf("unknown field name: %s", name)
+}
+
+// sortRefs orders the refs by refname, objectname or creatordate; a leading
+// "-" reverses the order
+func sortRefs(infos []refInfo, key string) error {
+	descending := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	var less func(a, b refInfo) bool
+	switch key {
+	case "", "refname":
+		less = func(a, b refInfo) bool { return a.ref.Name < b.ref.Name }
+	case "objectname":
+		less = func(a, b refInfo) bool { return a.ref.Hash < b.ref.Hash }
+	case "creatordate":
+		less = func(a, b refInfo) bool { return a.creatorDate.Before(b.creatorDate) }
+	default:
+		return fmt.Errorf("unknown sort key: %s", key)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if descending {
+			return less(infos[j], infos[i])
+		}
+		return less(infos[i], infos[j])
+	})
+	return nil
+}
+
+// matchesAny tells if the ref is named by a pattern: either a prefix ending
+// at a slash, like refs/heads, or a glob
+func matchesAny(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		prefix := strings.TrimSuffix(pattern, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package foreachref
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/testrepo"
+	"testing"
+)
+
+// arrangeRepo creates two commits, main and topic pointing to them, and an
+// annotated tag on the first one
+func arrangeRepo(t *testing.T) (*ForEachRef, map[string]string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	write := repo.Write
+	hashes := make(map[string]string)
+	tree := write(fmt.Sprintf("100644 blob %s\ta.txt\n", write("a\n")))
+	hashes["first"] = write(fmt.Sprintf("tree %s\nauthor A <a@example.com> 1623500000 +0000\n"+
+		"committer A <a@example.com> 1623500000 +0000\n\nfirst commit\n", tree))
+	hashes["second"] = write(fmt.Sprintf("tree %s\nparent %s\nauthor A <a@example.com> 1623600000 +0000\n"+
+		"committer A <a@example.com> 1623600000 +0000\n\nsecond commit\n", tree, hashes["first"]))
+	hashes["tag"] = write(fmt.Sprintf("object %s\ntype commit\ntag v1\ntagger A <a@example.com> 1623700000 +0000\n\nversion one\n", hashes["first"]))
+
+	repo.SetRef("refs/heads/main", hashes["second"])
+	repo.SetRef("refs/heads/topic", hashes["first"])
+	repo.SetRef("refs/tags/v1", hashes["tag"])
+	repo.SetRef("refs/remotes/origin/HEAD", "ref: refs/heads/main")
+	return NewForEachRef(repo.Conf, repo.Logger), hashes
+}
+
+func TestForEachRef(t *testing.T) {
+	fe, hashes := arrangeRepo(t)
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     ForEachRefOptions
+		want     string
+	}{
+		{"default format", []string{"refs/heads"}, ForEachRefOptions{},
+			hashes["second"] + " commit\trefs/heads/main\n" + hashes["first"] + " commit\trefs/heads/topic\n"},
+		{"fields", []string{"refs/tags/*"}, ForEachRefOptions{Format: "%(refname:short) %(objecttype) %(objectname:short) %(subject) %%"},
+			"v1 tag " + hashes["tag"][:7] + " version one %\n"},
+		{"symref", []string{"refs/remotes/"}, ForEachRefOptions{Format: "%(refname) -> %(symref)"},
+			"refs/remotes/origin/HEAD -> refs/heads/main\n"},
+		{"sort and count", nil, ForEachRefOptions{Format: "%(refname:short)", Sort: "-creatordate", Count: 2}, "v1\nmain\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			fe.SetOutput(out)
+			if err := fe.ForEachRef(tt.patterns, tt.opts); err != nil {
+				t.Fatalf("ForEachRef returned error: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.want, out.String())
+			}
+		})
+	}
+	if err := fe.ForEachRef(nil, ForEachRefOptions{Format: "%(unknown)"}); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+}
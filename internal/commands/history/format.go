@@ -0,0 +1,204 @@
+package history
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/signing"
+	"strings"
+	"time"
+)
+
+// formatter turns commits into the text shown by log
+type formatter struct {
+	conf          *config.Config
+	store         *objects.Store
+	template      string
+	dateMode      date.Mode
+	now           time.Time
+	decorations   map[string][]string
+	showSignature bool
+}
+
+func newFormatter(conf *config.Config, logger *logger.Logger, store *objects.Store, opts LogOptions) (*formatter, error) {
+	dateMode, err := date.ParseMode(opts.DateMode)
+	if err != nil {
+		return nil, err
+	}
+	template := opts.Format
+	template = strings.TrimPrefix(template, "format:")
+	template = strings.TrimPrefix(template, "tformat:")
+	if opts.Oneline && template == "" {
+		template = "%h%d %s"
+	}
+	return &formatter{
+		conf:          conf,
+		store:         store,
+		template:      template,
+		dateMode:      dateMode,
+		now:           time.Now(),
+		decorations:   readDecorations(conf, logger),
+		showSignature: opts.ShowSignature,
+	}, nil
+}
+
+// separateEntries tells if a blank line goes between two entries
+func (f *formatter) separateEntries() bool {
+	return f.template == ""
+}
+
+// format returns the text for a commit, ending with a new line
+func (f *formatter) format(commit *revision.Commit) string {
+	if f.template != "" {
+		return f.signature(commit) + f.expand(commit, f.template) + "\n"
+	}
+
+	var entry strings.Builder
+	entry.WriteString(fmt.Sprintf("Commit: %s%s\n", commit.Hash, f.decoration(commit.Hash, true)))
+	entry.WriteString(f.signature(commit))
+	entry.WriteString(fmt.Sprintf("Author: %s <%s>\n", commit.AuthorName, commit.AuthorEmail))
+	entry.WriteString(fmt.Sprintf("Date: %s\n", f.formatDate(commit.CommitterDate)))
+	entry.WriteString("\n")
+	for _, line := range strings.Split(commit.Message, "\n") {
+		entry.WriteString(strings.TrimRight("    "+line, " ") + "\n")
+	}
+	return entry.String()
+}
+
+// signature returns what the check of the signature of the commit found,
+// when asked to show signatures and the commit is signed
+func (f *formatter) signature(commit *revision.Commit) string {
+	if !f.showSignature || commit.Signature == "" {
+		return ""
+	}
+	content, err := f.store.Read(commit.Hash)
+	if err != nil {
+		return fmt.Sprintf("error: %s\n", err)
+	}
+	payload, signature := signing.SplitCommit(content)
+	result, err := signing.Verify(f.conf, payload, signature, commit.CommitterDate)
+	if err != nil {
+		return fmt.Sprintf("error: %s\n", err)
+	}
+	return result.Message + "\n"
+}
+
+// expand replaces the placeholders of the template:
+//
+//	%H %h   commit hash, abbreviated hash
+//	%T %t   tree hash, abbreviated tree hash
+//	%P %p   parent hashes, abbreviated parent hashes
+//	%an %ae %ad   author name, email and date
+//	%cn %ce %cd   committer name, email and date
+//	%s %b   subject and body of the message
+//	%d %D   ref names, with and without the surrounding " (...)"
+//	%n %%   new line and a literal %
+func (f *formatter) expand(commit *revision.Commit, template string) string {
+	subject, body, _ := strings.Cut(commit.Message, "\n")
+	body = strings.TrimLeft(body, "\n")
+	abbreviated := []string{}
+	for _, parent := range commit.Parents {
+		abbreviated = append(abbreviated, f.store.Abbreviate(parent, objects.ABBREV_LENGTH))
+	}
+	placeholders := map[string]string{
+		"H":  commit.Hash,
+		"h":  f.store.Abbreviate(commit.Hash, objects.ABBREV_LENGTH),
+		"T":  commit.Tree,
+		"t":  f.store.Abbreviate(commit.Tree, objects.ABBREV_LENGTH),
+		"P":  strings.Join(commit.Parents, " "),
+		"p":  strings.Join(abbreviated, " "),
+		"an": commit.AuthorName,
+		"ae": commit.AuthorEmail,
+		"ad": f.formatDate(commit.AuthorDate),
+		"cn": commit.CommitterName,
+		"ce": commit.CommitterEmail,
+		"cd": f.formatDate(commit.CommitterDate),
+		"s":  subject,
+		"b":  body,
+		"d":  f.decoration(commit.Hash, true),
+		"D":  f.decoration(commit.Hash, false),
+		"n":  "\n",
+		"%":  "%",
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i == len(template)-1 {
+			result.WriteByte(template[i])
+			continue
+		}
+		if i+2 < len(template) {
+			if value, found := placeholders[template[i+1:i+3]]; found {
+				result.WriteString(value)
+				i += 2
+				continue
+			}
+		}
+		if value, found := placeholders[template[i+1:i+2]]; found {
+			result.WriteString(value)
+			i++
+			continue
+		}
+		result.WriteByte(template[i])
+	}
+	return result.String()
+}
+
+// decoration returns the names of the refs pointing to the commit
+func (f *formatter) decoration(hash string, wrapped bool) string {
+	names := f.decorations[hash]
+	if len(names) == 0 {
+		return ""
+	}
+	if wrapped {
+		return " (" + strings.Join(names, ", ") + ")"
+	}
+	return strings.Join(names, ", ")
+}
+
+// readDecorations maps every commit pointed to by HEAD, a branch or a tag
+// to the ref names
+func readDecorations(conf *config.Config, logger *logger.Logger) map[string][]string {
+	decorations := make(map[string][]string)
+	resolver := revision.NewResolver(conf, logger)
+	refStore := refs.NewStore(conf, logger)
+
+	branches, _ := refStore.List("refs/heads/")
+	tags, _ := refStore.List("refs/tags/")
+
+	currentBranch, _ := refStore.CurrentBranch()
+	if headHash, _, err := refStore.Resolve(refs.HEAD); err == nil {
+		if refStore.Exists(currentBranch) {
+			decorations[headHash] = append(decorations[headHash], "HEAD -> "+strings.TrimPrefix(currentBranch, "refs/heads/"))
+		} else {
+			decorations[headHash] = append(decorations[headHash], "HEAD")
+		}
+	}
+	for _, branch := range branches {
+		if branch.Name == currentBranch {
+			continue
+		}
+		decorations[branch.Hash] = append(decorations[branch.Hash], strings.TrimPrefix(branch.Name, "refs/heads/"))
+	}
+	for _, tag := range tags {
+		// annotated tags decorate the commit they point to
+		hash, err := resolver.ResolveCommit(tag.Hash)
+		if err != nil {
+			continue
+		}
+		decorations[hash] = append(decorations[hash], "tag: "+strings.TrimPrefix(tag.Name, "refs/tags/"))
+	}
+	return decorations
+}
+
+// formatDate shows a date in the date mode of the formatter
+func (f *formatter) formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return date.FormatMode(t, f.dateMode, f.now)
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"got_it/internal/revision"
+	"strings"
+)
+
+// graphRenderer draws the ASCII history graph of log --graph.
+// Every column is a line of history waiting for the commit it holds.
+type graphRenderer struct {
+	columns []string
+}
+
+func newGraphRenderer() *graphRenderer {
+	return &graphRenderer{}
+}
+
+// render prefixes the lines of a log entry with the graph for the commit
+func (g *graphRenderer) render(commit *revision.Commit, entry string) string {
+	index := g.columnOf(commit.Hash)
+	if index < 0 {
+		g.columns = append(g.columns, commit.Hash)
+		index = len(g.columns) - 1
+	}
+
+	var out strings.Builder
+	lines := strings.Split(strings.TrimSuffix(entry, "\n"), "\n")
+	out.WriteString(g.commitLine(index) + lines[0] + "\n")
+
+	// the first parent takes the place of the commit, the others open new columns
+	transitions := []string{}
+	newColumns := []string{}
+	for _, parent := range commit.Parents[min(1, len(commit.Parents)):] {
+		if g.columnOf(parent) < 0 && !contains(newColumns, parent) {
+			newColumns = append(newColumns, parent)
+		}
+	}
+	if len(commit.Parents) == 0 {
+		g.columns = append(g.columns[:index], g.columns[index+1:]...)
+		if index < len(g.columns) {
+			// the columns on the right move into the place of the ended one
+			line := []byte(g.shiftLine(index+1, len(g.columns)+1, '/'))
+			line[2*index] = ' '
+			transitions = append(transitions, string(line))
+		}
+	} else {
+		g.columns[index] = commit.Parents[0]
+		if len(newColumns) > 0 {
+			columns := append([]string{}, g.columns[:index+1]...)
+			columns = append(columns, newColumns...)
+			g.columns = append(columns, g.columns[index+1:]...)
+			transitions = append(transitions, g.shiftLine(index+1, len(g.columns), '\\'))
+		}
+	}
+	transitions = append(transitions, g.collapse()...)
+
+	for _, line := range transitions {
+		out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	for _, line := range lines[1:] {
+		out.WriteString(strings.TrimRight(g.padding()+line, " ") + "\n")
+	}
+	return out.String()
+}
+
+// collapse merges columns waiting for the same commit
+func (g *graphRenderer) collapse() []string {
+	lines := []string{}
+	for i := 1; i < len(g.columns); i++ {
+		if g.columnOf(g.columns[i]) == i {
+			continue
+		}
+		g.columns = append(g.columns[:i], g.columns[i+1:]...)
+		lines = append(lines, g.shiftLine(i, len(g.columns)+1, '/'))
+		i--
+	}
+	return lines
+}
+
+// columnOf returns the first column waiting for hash, or -1
+func (g *graphRenderer) columnOf(hash string) int {
+	for i, column := range g.columns {
+		if column == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// commitLine draws the columns with a star in the commit column
+func (g *graphRenderer) commitLine(index int) string {
+	var line strings.Builder
+	for i := range g.columns {
+		if i == index {
+			line.WriteString("* ")
+		} else {
+			line.WriteString("| ")
+		}
+	}
+	return line.String()
+}
+
+// shiftLine draws the columns from index on moving one place, leaning
+// towards mark; total is the number of columns on the wider side
+func (g *graphRenderer) shiftLine(from, total int, mark byte) string {
+	line := []byte(strings.Repeat(" ", 2*total))
+	for i := 0; i < total; i++ {
+		if i < from {
+			line[2*i] = '|'
+		} else {
+			line[2*i-1] = mark
+		}
+	}
+	return string(line)
+}
+
+// padding draws the columns for the lines following a commit line
+func (g *graphRenderer) padding() string {
+	if len(g.columns) == 0 {
+		return "  "
+	}
+	return strings.Repeat("| ", len(g.columns))
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -3,56 +3,251 @@ package history
 import (
 	"fmt"
 	"got_it/internal/commands/config"
+	"got_it/internal/date"
 	"got_it/internal/logger"
-	"got_it/internal/models"
-	"log"
+	"got_it/internal/objects"
+	"got_it/internal/revision"
+	"got_it/internal/utils"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type History struct {
 	conf   *config.Config
 	logger *logger.Logger
+	out    io.Writer
+}
+
+// LogOptions holds the flags of the log command
+type LogOptions struct {
+	MaxCount int // 0 or less means no limit
+	Oneline  bool
+	Format   string
+	Since    string
+	Until    string
+	Authors  []string
+	Greps    []string
+	Graph    bool
+	Reverse  bool
+	// TopoOrder shows no parents before all of their children, as --graph
+	// does
+	TopoOrder bool
+	DateMode  string
+	// ShowSignature checks the signatures of the commits and shows the result
+	ShowSignature bool
+}
+
+// logFilter selects the commits shown by log
+type logFilter struct {
+	since   time.Time
+	until   time.Time
+	authors []*regexp.Regexp
+	greps   []*regexp.Regexp
+	paths   []string
 }
 
 func NewHistory(conf *config.Config, logger *logger.Logger) *History {
 	return &History{
 		conf:   conf,
 		logger: logger,
+		out:    os.Stdout,
 	}
 }
 
-func Execute() {
+// Execute shows the commits selected by the revision arguments (HEAD by
+// default), limited to the ones touching paths when any is given
+func Execute(revisions, paths []string, opts LogOptions) error {
+	debug := os.Getenv("GOT_DEBUG") == "true"
 	conf := config.NewConfig()
-	logger := logger.NewLogger(false, false)
+	logger := logger.NewLogger(false, debug)
 	hi := NewHistory(conf, logger)
-	hi.traverseCommitHistory()
+	if err := hi.Log(revisions, paths, opts); err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+	return nil
 }
 
-func (hi *History) traverseCommitHistory() {
-	parser := models.NewCommitDataParser(hi.logger)
-	commitHash, branch, err := GetFirstCommitHash(hi.conf, hi.logger)
-	isHead := true
+// Log writes the selected commits to the output
+func (hi *History) Log(revisions, paths []string, opts LogOptions) error {
+	resolver := revision.NewResolver(hi.conf, hi.logger)
+	if len(revisions) == 0 {
+		revisions = []string{revision.HEAD}
+	}
+	rng, err := resolver.ResolveRange(revisions)
+	if err != nil {
+		return err
+	}
+	filter, err := newLogFilter(opts, paths)
 	if err != nil {
-		log.Fatalf("Error reading HEAD file: %v", err)
+		return err
+	}
+	walker, err := resolver.Walk(rng, revision.WalkOptions{TopoOrder: opts.TopoOrder || opts.Graph})
+	if err != nil {
+		return err
+	}
+
+	selected := []*revision.Commit{}
+	store := objects.NewStore(hi.conf, hi.logger)
+	for commit, ok := walker.Next(); ok; commit, ok = walker.Next() {
+		matches, err := filter.matches(store, commit)
+		if err != nil {
+			return err
+		}
+		if matches {
+			selected = append(selected, commit)
+		}
+		if opts.MaxCount > 0 && len(selected) >= opts.MaxCount {
+			break
+		}
+	}
+	if err := walker.Err(); err != nil {
+		return err
 	}
-	for commitHash != "" {
-		if isHead {
-			isHead = false
-			fmt.Printf("Commit: %s (HEAD -> %s) \n", commitHash, branch)
-		} else {
-			fmt.Printf("Commit: %s\n", commitHash)
+	if opts.Reverse {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
 		}
+	}
+
+	formatter, err := newFormatter(hi.conf, hi.logger, store, opts)
+	if err != nil {
+		return err
+	}
+	var graph *graphRenderer
+	if opts.Graph {
+		graph = newGraphRenderer()
+	}
+	for i, commit := range selected {
+		if i > 0 && formatter.separateEntries() {
+			if graph != nil {
+				fmt.Fprintln(hi.out, strings.TrimRight(graph.padding(), " "))
+			} else {
+				fmt.Fprintln(hi.out)
+			}
+		}
+		entry := formatter.format(commit)
+		if graph != nil {
+			entry = graph.render(commit, entry)
+		}
+		fmt.Fprint(hi.out, entry)
+	}
+	return nil
+}
 
-		commitContent, err := getContentFromHash(hi.conf, hi.logger, commitHash)
+func newLogFilter(opts LogOptions, paths []string) (*logFilter, error) {
+	filter := &logFilter{}
+	var err error
+	if opts.Since != "" {
+		if filter.since, err = date.Parse(opts.Since); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Until != "" {
+		if filter.until, err = date.Parse(opts.Until); err != nil {
+			return nil, err
+		}
+	}
+	for _, author := range opts.Authors {
+		re, err := regexp.Compile(author)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --author pattern: %s", err)
+		}
+		filter.authors = append(filter.authors, re)
+	}
+	for _, grep := range opts.Greps {
+		re, err := regexp.Compile(grep)
 		if err != nil {
-			log.Fatalf("Error reading commit object: %v", err)
+			return nil, fmt.Errorf("invalid --grep pattern: %s", err)
 		}
-		commitMetadata, err := parser.Parse(commitContent)
+		filter.greps = append(filter.greps, re)
+	}
+	for _, p := range paths {
+		filter.paths = append(filter.paths, utils.SlashPath(p))
+	}
+	return filter, nil
+}
+
+// matches tells if the commit passes every filter
+func (f *logFilter) matches(store *objects.Store, commit *revision.Commit) (bool, error) {
+	if !f.since.IsZero() && commit.CommitterDate.Before(f.since) {
+		return false, nil
+	}
+	if !f.until.IsZero() && commit.CommitterDate.After(f.until) {
+		return false, nil
+	}
+	if len(f.authors) > 0 {
+		author := fmt.Sprintf("%s <%s>", commit.AuthorName, commit.AuthorEmail)
+		if !anyMatch(f.authors, author) {
+			return false, nil
+		}
+	}
+	if len(f.greps) > 0 && !anyMatch(f.greps, commit.Message) {
+		return false, nil
+	}
+	if len(f.paths) > 0 {
+		return touchesPaths(store, commit, f.paths)
+	}
+	return true, nil
+}
+
+func anyMatch(patterns []*regexp.Regexp, text string) bool {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// touchesPaths tells if any file under paths differs between the commit and
+// its first parent
+func touchesPaths(store *objects.Store, commit *revision.Commit, paths []string) (bool, error) {
+	files, err := store.FlattenTree(commit.Tree)
+	if err != nil {
+		return false, err
+	}
+	parentFiles := map[string]string{}
+	if commit.Parent != "" {
+		parent, err := store.ReadCommit(commit.Parent)
+		if err != nil {
+			return false, err
+		}
+		entries, err := store.FlattenTree(parent.Tree)
+		if err != nil {
+			return false, err
+		}
+		for name, entry := range entries {
+			parentFiles[name] = entry.Hash
+		}
+	}
+	for name, entry := range files {
+		if underPaths(name, paths) && parentFiles[name] != entry.Hash {
+			return true, nil
+		}
+	}
+	for name := range parentFiles {
+		if _, found := files[name]; !found && underPaths(name, paths) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-		parentHash := commitMetadata.Parent
-		commitHash = parentHash
-		fmt.Printf("Author: %s <%s>\n", commitMetadata.AuthorName, commitMetadata.AuthorEmail)
-		fmt.Printf("Date: %s\n", commitMetadata.CommitterDate)
-		fmt.Printf("\n" + `    `)
-		fmt.Printf("%s\n", commitMetadata.Message)
+// underPaths tells if the slash separated file is one of paths or inside one of them
+func underPaths(file string, paths []string) bool {
+	for _, p := range paths {
+		if p == "." || file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
 	}
+	return false
+}
+
+// SetOutput changes where the log is written
+func (hi *History) SetOutput(out io.Writer) {
+	hi.out = out
 }
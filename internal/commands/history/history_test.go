@@ -1,8 +1,11 @@
 package history
 
 import (
+	"bytes"
+	"fmt"
 	"got_it/internal/commands/config"
 	"got_it/internal/logger"
+	"got_it/internal/testrepo"
 	"got_it/internal/utils"
 	"os"
 	"path/filepath"
@@ -21,7 +24,7 @@ Initial commit with file.txt and README.md
 func TestGetContentFromHash(t *testing.T) {
 	logger := logger.NewLogger(false, false)
 	conf := config.NewConfig()
-	commitHash := utils.HashContent(mockCommitContent)
+	commitHash := utils.OF_SHA1.HashContent(mockCommitContent)
 	// create a temporary directory for the test
 	tmpDir := t.TempDir()
 	err := os.Chdir(tmpDir)
@@ -64,7 +67,7 @@ func TestGetContentFromHash(t *testing.T) {
 func TestReconstructFileContent(t *testing.T) {
 	// logger := logger.NewLogger(false, false)
 	// conf := config.NewConfig()
-	// commitHash := utils.HashContent(mockCommitContent)
+	// commitHash := utils.OF_SHA1.HashContent(mockCommitContent)
 	// // create a temporary directory for the test
 	// tmpDir := t.TempDir()
 }
@@ -97,3 +100,124 @@ func assertFindFileInTree(t *testing.T, treeContent string, fileName string, exp
 		t.Fatalf("Expected %s, got %s", expectedHash, hash)
 	}
 }
+
+// arrangeHistory creates the history
+//
+//	c1 -- c2 -- c3 (main)
+//	        \     \
+//	         f1 -- m (feature)
+//
+// where only c2 and f1 change dir/
+func arrangeHistory(t *testing.T) (*History, map[string]string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	write := repo.Write
+	tree := func(files map[string]string) string {
+		content := ""
+		for name, data := range files {
+			content += fmt.Sprintf("100644 blob %s\t%s\n", write(data), name)
+		}
+		return content
+	}
+	subTree1 := tree(map[string]string{"inner.txt": "one\n"})
+	subTree2 := tree(map[string]string{"inner.txt": "two\n"})
+	top := tree(map[string]string{"file.txt": "top\n"})
+	trees := map[string]string{
+		"c1": write(top),
+		"c2": write(top + fmt.Sprintf("040000 tree %s\tdir\n", write(subTree1)) + subTree1),
+		"f1": write(top + fmt.Sprintf("040000 tree %s\tdir\n", write(subTree2)) + subTree2),
+	}
+	trees["c3"] = trees["c2"]
+	trees["m"] = trees["f1"]
+
+	commits := make(map[string]string)
+	commit := func(name string, seconds int, parents ...string) {
+		content := fmt.Sprintf("tree %s\n", trees[name])
+		for _, parent := range parents {
+			content += fmt.Sprintf("parent %s\n", commits[parent])
+		}
+		author := "John Doe <johndoe@example.com>"
+		if name == "f1" {
+			author = "Jane Roe <janeroe@example.com>"
+		}
+		content += fmt.Sprintf("author %s %d +0200\n", author, seconds)
+		content += fmt.Sprintf("committer %s %d +0200\n\n%s subject\n\n%s body\n", author, seconds, name, name)
+		commits[name] = write(content)
+	}
+	commit("c1", 1623500000)
+	commit("c2", 1623500100, "c1")
+	commit("f1", 1623500200, "c2")
+	commit("c3", 1623500300, "c2")
+	commit("m", 1623500400, "f1", "c3")
+
+	testrepo.WriteFile(t, filepath.Join(".got", "HEAD"), "ref: refs/heads/feature")
+	repo.SetRef("refs/heads/main", commits["c3"])
+	repo.SetRef("refs/heads/feature", commits["m"])
+	repo.SetRef("refs/tags/v1", commits["c1"])
+	return NewHistory(repo.Conf, repo.Logger), commits
+}
+
+func TestLogOptions(t *testing.T) {
+	hi, commits := arrangeHistory(t)
+	tests := []struct {
+		name      string
+		revisions []string
+		paths     []string
+		opts      LogOptions
+		want      string
+	}{
+		{"format", []string{"main"}, nil, LogOptions{Format: "%H %an <%ae> %s|%b"},
+			commits["c3"] + " John Doe <johndoe@example.com> c3 subject|c3 body\n" +
+				commits["c2"] + " John Doe <johndoe@example.com> c2 subject|c2 body\n" +
+				commits["c1"] + " John Doe <johndoe@example.com> c1 subject|c1 body\n"},
+		{"max count", nil, nil, LogOptions{Format: "%s", MaxCount: 2}, "m subject\nc3 subject\n"},
+		{"reverse", nil, nil, LogOptions{Format: "%s", MaxCount: 2, Reverse: true}, "c3 subject\nm subject\n"},
+		{"range", []string{"main..feature"}, nil, LogOptions{Format: "%s"}, "m subject\nf1 subject\n"},
+		{"author", nil, nil, LogOptions{Format: "%s", Authors: []string{"Jane"}}, "f1 subject\n"},
+		{"grep", nil, nil, LogOptions{Format: "%s", Greps: []string{"^c[12]"}}, "c2 subject\nc1 subject\n"},
+		{"since and until", nil, nil, LogOptions{Format: "%s", Since: "1623500100 +0000", Until: "1623500250 +0000"},
+			"f1 subject\nc2 subject\n"},
+		{"paths", nil, []string{"dir"}, LogOptions{Format: "%s"}, "f1 subject\nc2 subject\n"},
+		{"oneline", []string{"main~1"}, nil, LogOptions{Oneline: true}, commits["c2"][:7] + " c2 subject\n" + commits["c1"][:7] + " (tag: v1) c1 subject\n"},
+		{"decorations", nil, nil, LogOptions{Format: "%s%d", MaxCount: 2}, "m subject (HEAD -> feature)\nc3 subject (main)\n"},
+		{"tag decorations", []string{"main"}, nil, LogOptions{Format: "%s%d"},
+			"c3 subject (main)\nc2 subject\nc1 subject (tag: v1)\n"},
+		{"iso date", nil, nil, LogOptions{Format: "%ad|%cd", MaxCount: 1, DateMode: "iso"},
+			"2021-06-12 14:20:00 +0200|2021-06-12 14:20:00 +0200\n"},
+		{"unix date", []string{"main"}, nil, LogOptions{Format: "%ad", MaxCount: 1, DateMode: "unix"}, "1623500300\n"},
+		{"topo order", []string{"feature~1", "main"}, nil, LogOptions{Format: "%s", TopoOrder: true},
+			"c3 subject\nf1 subject\nc2 subject\nc1 subject\n"},
+		{"graph", nil, nil, LogOptions{Format: "%s", Graph: true},
+			"* m subject\n|\\\n| * c3 subject\n* | f1 subject\n|/\n* c2 subject\n* c1 subject\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			hi.out = out
+			if err := hi.Log(tt.revisions, tt.paths, tt.opts); err != nil {
+				t.Fatalf("Log returned error: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestReadRefFromHEAD(t *testing.T) {
+	hi, commits := arrangeHistory(t)
+	headRef, err := ReadRefFromHEAD(hi.conf, hi.logger)
+	if err != nil || headRef != filepath.Join(hi.conf.GotDir, "refs", "heads", "feature") {
+		t.Errorf("Expected HEAD to point to the feature branch file, got %s (%v)", headRef, err)
+	}
+
+	// a detached HEAD is not symbolic and must be reported as an error
+	os.WriteFile(filepath.Join(hi.conf.GotDir, "HEAD"), []byte(commits["c2"]), 0644)
+	if headRef, err := ReadRefFromHEAD(hi.conf, hi.logger); err == nil {
+		t.Errorf("Expected an error for a detached HEAD, got %s", headRef)
+	}
+	hash, branch, err := GetFirstCommitHash(hi.conf, hi.logger)
+	if err != nil || hash != commits["c2"] || branch != "" {
+		t.Errorf("Expected the detached HEAD commit %s, got %s %q (%v)", commits["c2"], hash, branch, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"got_it/internal/commands/config"
 	"got_it/internal/logger"
 	"got_it/internal/models"
+	"got_it/internal/refs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,56 +13,31 @@ import (
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-// ReadRefFromHEAD reads the current commit from the HEAD file
+// ReadRefFromHEAD returns the path of the branch file HEAD points to. It
+// fails when HEAD is detached.
 func ReadRefFromHEAD(conf *config.Config, logger *logger.Logger) (string, error) {
-	// Get the current commit from the HEAD
-	headPath := filepath.Join(conf.GotDir, "HEAD")
-	headRefBytes, err := os.ReadFile(headPath)
+	target, err := refs.NewStore(conf, logger).ReadSymbolic(refs.HEAD)
 	if err != nil {
-		logger.Debug("Error reading HEAD file: %s", err)
+		logger.Debug("Error reading HEAD: %s", err)
 		return "", err
 	}
-	headRef := string(headRefBytes)
-
-	//find the prefix "refs: " in  the headRef
-	if !strings.HasPrefix(string(headRef), "ref: ") {
-		logger.Debug("unespected HEAD format: %s", headRef)
-		return "", err
-	}
-
-	//remove the prefix "ref: "
-	headRef = strings.TrimSpace(headRef)
-	headRef = headRef[5:]
-	headRef = filepath.Join(conf.GotDir, headRef)
-
-	return headRef, nil
+	return filepath.Join(conf.GotDir, filepath.FromSlash(target)), nil
 }
 
 // getFirstCommitHash returns the hash of the parent commit (the HEAD commit)
-// and the name of the file pointed to by the HEAD
+// and the name of the branch HEAD points to, empty when it is detached
 func GetFirstCommitHash(conf *config.Config, logger *logger.Logger) (string, string, error) {
-	/// Read the current commit from the HEAD file
-	headRef, err := ReadRefFromHEAD(conf, logger)
-	if err != nil {
-		return "", "", err
+	refStore := refs.NewStore(conf, logger)
+	hash, fullRef, err := refStore.Resolve(refs.HEAD)
+	headBranch := ""
+	if fullRef != refs.HEAD {
+		headBranch = strings.TrimPrefix(fullRef, "refs/heads/")
 	}
-	// get HEAD name (branch) from headRef
-	headBranch := filepath.Base(headRef)
-
-	// Verrify if the file exists
-	if _, err := os.Stat(headRef); os.IsNotExist(err) {
-		logger.Debug("File does not exist: %s", headRef)
-		return "", headBranch, err
-	}
-
-	// Read the content of the file pointed to by the HEAD reference
-	commitHashBytes, err := os.ReadFile(headRef)
 	if err != nil {
-		logger.Debug("Error reading commit file: %s", err)
+		logger.Debug("Error resolving HEAD: %s", err)
 		return "", headBranch, err
 	}
-
-	return string(commitHashBytes), headBranch, nil
+	return hash, headBranch, nil
 }
 
 // reconstruct file content from deltas
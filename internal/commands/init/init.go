@@ -4,13 +4,45 @@ import (
 	"fmt"
 	"got_it/internal/commands/config"
 	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"got_it/internal/utils"
+	"io"
 	"os"
 	"path/filepath"
 )
 
+// TEMPLATE_DIR_ENV names the template directory, before init.templateDir
+const TEMPLATE_DIR_ENV string = "GOT_TEMPLATE_DIR"
+
+// REPO_DIRS are the directories of a repository, created again when a
+// repository is initialized a second time
+var REPO_DIRS = []string{
+	"objects",
+	filepath.Join("refs", "heads"),
+	filepath.Join("refs", "tags"),
+	"hooks",
+}
+
+func init() {
+	config.Register(
+		config.KeyInfo{Key: "init.templateDir", Type: config.TYPE_PATH, Description: "directory copied into new repositories"},
+		config.KeyInfo{Key: "core.bare", Type: config.TYPE_BOOL, Default: "false", Description: "the repository has no worktree"},
+	)
+}
+
 type Init struct {
 	conf   *config.Config
 	logger *logger.Logger
+	out    io.Writer
+}
+
+// InitOptions holds the flags of the init command
+type InitOptions struct {
+	Bare          bool
+	InitialBranch string
+	Template      string
+	Quiet         bool
+	ObjectFormat  string // the hash algorithm of the objects, sha1 by default
 }
 
 func NewInit() *Init {
@@ -20,6 +52,19 @@ func NewInit() *Init {
 	return &Init{
 		conf:   conf,
 		logger: logger,
+		out:    os.Stdout,
+	}
+}
+
+// Execute creates a repository in args[0], by default the current directory
+func Execute(args []string, opts InitOptions) {
+	i := NewInit()
+	dir := ""
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if err := i.Init(dir, opts); err != nil {
+		fmt.Println("Error:", err)
 	}
 }
 
@@ -31,50 +76,92 @@ func (i *Init) IsInitialized() bool {
 	return true
 }
 
+// InitRepo creates a repository in the current directory
 func (i *Init) InitRepo() {
-	gotDir := i.conf.GetGotDir()
-	// get absolute path of gotDir
-	gotDir, err := filepath.Abs(gotDir)
-
-	// Check if the .got directory already exists
-	if _, err := os.Stat(gotDir); !os.IsNotExist(err) {
-		fmt.Println("Repository already initialized.")
-		return
+	if err := i.Init("", InitOptions{}); err != nil {
+		fmt.Println("Error:", err)
 	}
+}
 
+// Init creates a repository in dir, made if missing: .got in dir, or dir
+// itself for a bare repository. HEAD points to the initial branch, by
+// default init.defaultBranch. An existing repository is kept, only the
+// directories and files it misses are created again; its object format
+// cannot change.
+func (i *Init) Init(dir string, opts InitOptions) error {
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
 	if err != nil {
-		fmt.Println("Error getting absolute path:", err)
-		return
+		return err
+	}
+	gotDir := filepath.Join(dir, config.GOT_DIR)
+	if opts.Bare {
+		gotDir = dir
 	}
+	i.conf.GotDir = gotDir
+	_, err = os.Stat(filepath.Join(gotDir, "HEAD"))
+	reinit := err == nil
 
-	// Create the .got directory
-	if err := os.Mkdir(gotDir, 0755); err != nil {
-		fmt.Println("Error creating .got directory:", err)
-		return
+	branch := opts.InitialBranch
+	if branch == "" {
+		branch = i.conf.GetDefaultBranch()
 	}
-	// Create the .got/refs/heads directory
-	if err := os.MkdirAll(gotDir+"/refs/heads", 0755); err != nil {
-		fmt.Println("Error creating .got/refs/heads directory:", err)
-		return
+	if err := refs.ValidateBranchName(branch); err != nil {
+		return fmt.Errorf("invalid initial branch name: '%s'", branch)
 	}
-	// Generate HEAD file
-	err = i.generateHEADfile(gotDir)
-	if err != nil {
-		fmt.Println("Error: ", err)
-		return
+	format := utils.OF_SHA1
+	if opts.ObjectFormat != "" {
+		if format, err = utils.ParseObjectFormat(opts.ObjectFormat); err != nil {
+			return err
+		}
+		if reinit && format != i.conf.ObjectFormat() {
+			return fmt.Errorf("attempt to reinitialize repository with different hash")
+		}
 	}
 
-	// Create the .got/objects directory
-	if err := os.Mkdir(gotDir+"/objects", 0755); err != nil {
-		fmt.Println("Error creating .got/objects directory:", err)
-		return
+	for _, sub := range REPO_DIRS {
+		if err := os.MkdirAll(filepath.Join(gotDir, sub), 0755); err != nil {
+			return fmt.Errorf("could not create %s: %w", filepath.Join(gotDir, sub), err)
+		}
+	}
+	if err := i.copyTemplate(opts.Template, dir, opts.Bare); err != nil {
+		return err
+	}
+	if reinit {
+		if opts.InitialBranch != "" {
+			fmt.Fprintf(i.out, "warning: re-init: ignored --initial-branch=%s\n", opts.InitialBranch)
+		}
+	} else {
+		if err := i.generateHEADfile(gotDir, branch); err != nil {
+			return err
+		}
+		if format != utils.OF_SHA1 {
+			if err := i.conf.SetObjectFormat(format); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := i.conf.GetScopedKeyValue(config.SCOPE_LOCAL, "core.bare"); err != nil {
+		if err := i.conf.SetConfigKeyValue("core.bare", fmt.Sprint(opts.Bare)); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Initialized empty Git repository in %s\n", gotDir)
+	if opts.Quiet {
+		return nil
+	}
+	if reinit {
+		fmt.Fprintf(i.out, "Reinitialized existing Got repository in %s%c\n", gotDir, filepath.Separator)
+	} else {
+		fmt.Fprintf(i.out, "Initialized empty Got repository in %s%c\n", gotDir, filepath.Separator)
+	}
+	return nil
 }
 
 // Generate HEAD file
-func (i *Init) generateHEADfile(gotDir string) error {
+func (i *Init) generateHEADfile(gotDir, branch string) error {
 	// create a file called HEAD
 	headPath := filepath.Join(gotDir, "HEAD")
 
@@ -86,12 +173,16 @@ func (i *Init) generateHEADfile(gotDir string) error {
 		// LOG ERROR
 	}
 	defer headFile.Close()
-	defaultBranch := i.conf.GetDefaultBranch()
-	refsPath := filepath.Join("refs", "heads", defaultBranch)
-	headFileContent := "ref: " + refsPath
+	refsPath := filepath.Join("refs", "heads", branch)
+	headFileContent := "ref: " + filepath.ToSlash(refsPath)
 	_, err = headFile.WriteString(headFileContent)
 	if err != nil {
 		return fmt.Errorf("Error writing to HEAD file: %v\n", err)
 	}
 	return nil
 }
+
+// SetOutput changes where the output is written
+func (i *Init) SetOutput(out io.Writer) {
+	i.out = out
+}
@@ -1,9 +1,14 @@
 package init
 
 import (
+	"bytes"
 	"got_it/internal/commands/config"
+	"got_it/internal/objects"
+	"got_it/internal/utils"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,3 +38,147 @@ func testGenerateHEADFile(t *testing.T, c *config.Config) {
 		t.Errorf("HEAD file does not exist")
 	}
 }
+
+func TestInitOptions(t *testing.T) {
+	dir := t.TempDir()
+	os.Chdir(dir)
+	global := filepath.Join(dir, "gotconfig")
+	t.Setenv(config.GLOBAL_CONFIG_ENV, global)
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	t.Setenv(TEMPLATE_DIR_ENV, "")
+	os.WriteFile(global, []byte("[init]\n\tdefaultBranch = trunk\n"), 0644)
+	readHead := func(gotDir string) string {
+		content, _ := os.ReadFile(filepath.Join(gotDir, "HEAD"))
+		return string(content)
+	}
+
+	// init.defaultBranch of the global config
+	i := NewInit()
+	i.SetOutput(io.Discard)
+	if err := i.Init("project", InitOptions{}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if head := readHead(filepath.Join("project", ".got")); head != "ref: refs/heads/trunk" {
+		t.Errorf("Expected HEAD on trunk, got %q", head)
+	}
+
+	// a bare repository with the branch given
+	i = NewInit()
+	i.SetOutput(io.Discard)
+	if err := i.Init("bare.got", InitOptions{Bare: true, InitialBranch: "develop"}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if head := readHead("bare.got"); head != "ref: refs/heads/develop" {
+		t.Errorf("Expected HEAD on develop, got %q", head)
+	}
+	if _, err := os.Stat(filepath.Join("bare.got", config.GOT_DIR)); !os.IsNotExist(err) {
+		t.Errorf("Expected no .got in a bare repository")
+	}
+	if bare := i.conf.Bool("core.bare"); !bare {
+		t.Errorf("Expected core.bare to be true")
+	}
+
+	if err := NewInit().Init("other", InitOptions{InitialBranch: "bad..name"}); err == nil {
+		t.Errorf("Expected an invalid branch name to be refused")
+	}
+}
+
+func TestInitTemplateAndReinit(t *testing.T) {
+	dir := t.TempDir()
+	os.Chdir(dir)
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "gotconfig"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	template := filepath.Join(dir, "template")
+	os.MkdirAll(filepath.Join(template, "hooks"), 0755)
+	os.WriteFile(filepath.Join(template, "hooks", "pre-commit"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(template, "config"), []byte("[user]\n\tname = Template User\n"), 0644)
+	os.WriteFile(filepath.Join(template, config.GOTIGNORE_FILE), []byte("*.log\n"), 0644)
+	t.Setenv(TEMPLATE_DIR_ENV, template)
+
+	out := &bytes.Buffer{}
+	i := NewInit()
+	i.SetOutput(out)
+	if err := i.Init("repo", InitOptions{}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	gotDir := filepath.Join(dir, "repo", config.GOT_DIR)
+	if expected := "Initialized empty Got repository in " + gotDir + "/\n"; out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if info, err := os.Stat(filepath.Join(gotDir, "hooks", "pre-commit")); err != nil || info.Mode()&0111 == 0 {
+		t.Errorf("Expected the executable hook of the template, got %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join("repo", config.GOTIGNORE_FILE)); string(content) != "*.log\n" {
+		t.Errorf("Expected the .gotignore of the template in the worktree, got %q", content)
+	}
+	if name, _ := i.conf.GetConfigKeyValue("user.name"); name != "Template User" || i.conf.Bool("core.bare") {
+		t.Errorf("Expected the config of the template with core.bare false, got %q", name)
+	}
+
+	// a second init repairs the repository and keeps what it holds
+	os.RemoveAll(filepath.Join(gotDir, "objects"))
+	os.WriteFile(filepath.Join(gotDir, "HEAD"), []byte("ref: refs/heads/feature"), 0644)
+	out.Reset()
+	i = NewInit()
+	i.SetOutput(out)
+	if err := i.Init("repo", InitOptions{InitialBranch: "other"}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "ignored --initial-branch=other") || !strings.Contains(out.String(), "Reinitialized existing Got repository") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(gotDir, "objects")); err != nil {
+		t.Errorf("Expected the objects directory to be created again")
+	}
+	if content, _ := os.ReadFile(filepath.Join(gotDir, "HEAD")); string(content) != "ref: refs/heads/feature" {
+		t.Errorf("Expected HEAD to be kept, got %q", content)
+	}
+}
+
+func TestInitObjectFormat(t *testing.T) {
+	dir := t.TempDir()
+	os.Chdir(dir)
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "gotconfig"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	t.Setenv(TEMPLATE_DIR_ENV, "")
+
+	i := NewInit()
+	i.SetOutput(io.Discard)
+	if err := i.Init("repo", InitOptions{ObjectFormat: "sha256"}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if value, err := i.conf.GetScopedKeyValue(config.SCOPE_LOCAL, "extensions.objectFormat"); err != nil || value != "sha256" {
+		t.Errorf("Expected extensions.objectFormat to be sha256, got %q (%v)", value, err)
+	}
+	hash, err := objects.NewStore(i.conf, i.logger).Write("hello\n")
+	if err != nil {
+		t.Fatalf("Error writing object: %v", err)
+	}
+	if expected := utils.OF_SHA256.HashContent("hello\n"); hash != expected || len(hash) != 64 {
+		t.Errorf("Expected the SHA-256 hash %s, got %s", expected, hash)
+	}
+
+	// the format of an existing repository cannot change
+	if err := i.Init("repo", InitOptions{ObjectFormat: "sha1"}); err == nil {
+		t.Errorf("Expected a re-init with another hash to be refused")
+	}
+	if err := i.Init("repo", InitOptions{ObjectFormat: "SHA256"}); err != nil {
+		t.Errorf("Expected a re-init with the same hash to work, got %v", err)
+	}
+	if err := i.Init("other", InitOptions{ObjectFormat: "md5"}); err == nil {
+		t.Errorf("Expected an unknown hash algorithm to be refused")
+	}
+
+	// SHA-1 repositories do not record their format
+	i = NewInit()
+	i.SetOutput(io.Discard)
+	if err := i.Init("classic", InitOptions{}); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	if _, err := i.conf.GetScopedKeyValue(config.SCOPE_LOCAL, "extensions.objectFormat"); err == nil {
+		t.Errorf("Expected no extensions.objectFormat in a SHA-1 repository")
+	}
+	if format := i.conf.ObjectFormat(); format != utils.OF_SHA1 {
+		t.Errorf("Expected sha1, got %s", format)
+	}
+}
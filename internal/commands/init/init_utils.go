@@ -0,0 +1,83 @@
+package init
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// templateDir returns the template given, else the one of GOT_TEMPLATE_DIR
+// or init.templateDir; there is none by default
+func (i *Init) templateDir(template string) string {
+	if template != "" {
+		return template
+	}
+	if dir := os.Getenv(TEMPLATE_DIR_ENV); dir != "" {
+		return dir
+	}
+	dir, _ := i.conf.GetScopedKeyValue("", "init.templateDir")
+	return dir
+}
+
+// copyTemplate copies the files of the template directory, such as hooks
+// and config, into the repository, and its .gotignore to the top of the
+// worktree. Files the repository already has are kept.
+func (i *Init) copyTemplate(template, worktree string, bare bool) error {
+	template = i.templateDir(template)
+	if template == "" {
+		return nil
+	}
+	if info, err := os.Stat(template); err != nil || !info.IsDir() {
+		fmt.Fprintf(i.out, "warning: templates not found in %s\n", template)
+		return nil
+	}
+	return filepath.WalkDir(template, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(template, path)
+		if err != nil || relative == "." {
+			return err
+		}
+		target := filepath.Join(i.conf.GotDir, relative)
+		if relative == config.GOTIGNORE_FILE {
+			if bare {
+				return nil
+			}
+			target = filepath.Join(worktree, relative)
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+		i.logger.Debug("copying template %s to %s", path, target)
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies the content and the permissions of a file
+func copyFile(from, to string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,223 @@
+package lfs
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/lfs"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"sort"
+)
+
+type Lfs struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	lfs      *lfs.Store
+	worktree *worktree.Worktree
+	out      io.Writer
+}
+
+func NewLfs(conf *config.Config, logger *logger.Logger) *Lfs {
+	return &Lfs{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		lfs:      lfs.NewStore(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute runs an lfs subcommand
+func Execute(args []string) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	lf := NewLfs(conf, logger)
+
+	if len(args) == 0 {
+		fmt.Println("Error: missing subcommand: track, untrack, ls-files, push, fetch or checkout")
+		return
+	}
+	subcommand, args := args[0], args[1:]
+	var err error
+	switch {
+	case subcommand == "track":
+		err = lf.Track(args)
+	case subcommand == "untrack" && len(args) > 0:
+		err = lf.Untrack(args)
+	case subcommand == "ls-files" && len(args) == 0:
+		err = lf.LsFiles()
+	case subcommand == "push" && len(args) == 0:
+		err = lf.Push()
+	case subcommand == "fetch" && len(args) == 0:
+		err = lf.Fetch()
+	case subcommand == "checkout" && len(args) == 0:
+		err = lf.Checkout()
+	case subcommand == "untrack" || subcommand == "ls-files" || subcommand == "push" || subcommand == "fetch" || subcommand == "checkout":
+		err = fmt.Errorf("wrong number of arguments for lfs %s", subcommand)
+	default:
+		err = fmt.Errorf("unknown subcommand: %s", subcommand)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Track adds the patterns to .gotattributes, or lists the tracked ones
+// when none is given
+func (lf *Lfs) Track(patterns []string) error {
+	root := lf.worktree.Root()
+	if len(patterns) == 0 {
+		tracked, err := lfs.Patterns(root)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(lf.out, "Listing tracked patterns")
+		for _, pattern := range tracked {
+			fmt.Fprintf(lf.out, "    %s (%s)\n", pattern, lfs.ATTRIBUTES_FILE)
+		}
+		return nil
+	}
+	for _, pattern := range patterns {
+		added, err := lfs.Track(root, pattern)
+		if err != nil {
+			return err
+		}
+		if added {
+			fmt.Fprintf(lf.out, "Tracking \"%s\"\n", pattern)
+		} else {
+			fmt.Fprintf(lf.out, "\"%s\" already supported\n", pattern)
+		}
+	}
+	return nil
+}
+
+// Untrack removes the patterns from .gotattributes. The files already
+// staged stay pointers until they are added again.
+func (lf *Lfs) Untrack(patterns []string) error {
+	for _, pattern := range patterns {
+		removed, err := lfs.Untrack(lf.worktree.Root(), pattern)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("\"%s\" is not tracked", pattern)
+		}
+		fmt.Fprintf(lf.out, "Untracking \"%s\"\n", pattern)
+	}
+	return nil
+}
+
+// LsFiles lists the staged pointers: the start of their oid, * when their
+// content is in .got/lfs or - when only the pointer is, and their path
+func (lf *Lfs) LsFiles() error {
+	files, err := lf.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	pointers := lf.pointers(files)
+	for _, filePath := range sortedPaths(pointers) {
+		pointer := pointers[filePath]
+		state := "-"
+		if lf.lfs.Exists(pointer.Oid) {
+			state = "*"
+		}
+		fmt.Fprintf(lf.out, "%s %s %s\n", pointer.Oid[:10], state, filePath)
+	}
+	return nil
+}
+
+// Push copies the large files of .got/lfs the LFS server of lfs.url lacks
+func (lf *Lfs) Push() error {
+	oids, err := lf.lfs.List()
+	if err != nil {
+		return err
+	}
+	sent, err := lf.lfs.Push(oids)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(lf.out, "Uploaded %d LFS objects\n", sent)
+	return nil
+}
+
+// Fetch downloads from the LFS server the missing content of the pointers
+// of HEAD
+func (lf *Lfs) Fetch() error {
+	files, _, err := lf.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	oids := []string{}
+	for _, pointer := range lf.pointers(files) {
+		oids = append(oids, pointer.Oid)
+	}
+	received, err := lf.lfs.Fetch(oids)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(lf.out, "Downloaded %d LFS objects\n", received)
+	return nil
+}
+
+// Checkout replaces the staged files checked out as their pointer with
+// their content, once it has been fetched
+func (lf *Lfs) Checkout() error {
+	files, err := lf.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	count := 0
+	for filePath, pointer := range lf.pointers(files) {
+		content, err := lf.worktree.ReadFile(filePath)
+		if err != nil || content != pointer.String() || !lf.lfs.Exists(pointer.Oid) {
+			continue
+		}
+		if err := lf.worktree.WriteFile(filePath, files[filePath]); err != nil {
+			return err
+		}
+		count++
+	}
+	fmt.Fprintf(lf.out, "Checked out %d LFS files\n", count)
+	return nil
+}
+
+// pointers returns the pointers among the blobs of the files
+func (lf *Lfs) pointers(files map[string]models.TreeEntry) map[string]lfs.Pointer {
+	pointers := make(map[string]lfs.Pointer)
+	for filePath, entry := range files {
+		size, err := lf.store.Size(entry.Hash)
+		if err != nil || size > lfs.MAX_POINTER_SIZE {
+			continue
+		}
+		content, err := lf.store.Read(entry.Hash)
+		if err != nil {
+			continue
+		}
+		if pointer, ok := lfs.ParsePointer(content); ok {
+			pointers[filePath] = pointer
+		}
+	}
+	return pointers
+}
+
+// sortedPaths returns the paths of the pointers, sorted
+func sortedPaths(pointers map[string]lfs.Pointer) []string {
+	paths := make([]string, 0, len(pointers))
+	for filePath := range pointers {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SetOutput changes where the output is written
+func (lf *Lfs) SetOutput(out io.Writer) {
+	lf.out = out
+}
@@ -0,0 +1,133 @@
+package lfs
+
+import (
+	"bytes"
+	"got_it/internal/models"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const design = "layers and layers of design\n"
+
+// arrangeRepo creates a repository tracking *.psd with lfs, with a commit
+// holding logo.psd and notes.txt, and returns an lfs command writing to out
+func arrangeRepo(t *testing.T) (*Lfs, *bytes.Buffer) {
+	t.Helper()
+	repo := testrepo.New(t)
+	lf := NewLfs(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	lf.SetOutput(out)
+	if err := lf.Track([]string{"*.psd"}); err != nil || out.String() != "Tracking \"*.psd\"\n" {
+		t.Fatalf("Unexpected track output %q, %v", out.String(), err)
+	}
+	out.Reset()
+
+	testrepo.WriteFile(t, filepath.Join("art", "logo.psd"), design)
+	testrepo.WriteFile(t, "notes.txt", "notes\n")
+	files, err := lf.worktree.StoreFiles([]string{"art/logo.psd", "notes.txt"})
+	if err != nil {
+		t.Fatalf("StoreFiles returned error: %v", err)
+	}
+	if err := lf.worktree.WriteIndex(files); err != nil {
+		t.Fatalf("WriteIndex returned error: %v", err)
+	}
+	tree, _ := lf.store.WriteTree(files)
+	commit, err := lf.store.WriteCommit(models.CommitData{Tree: tree, Message: "assets",
+		AuthorName: testrepo.AUTHOR_NAME, AuthorEmail: testrepo.AUTHOR_EMAIL, AuthorDate: testrepo.DATE,
+		CommitterName: testrepo.AUTHOR_NAME, CommitterEmail: testrepo.AUTHOR_EMAIL, CommitterDate: testrepo.DATE})
+	if err != nil {
+		t.Fatalf("WriteCommit returned error: %v", err)
+	}
+	refs.NewStore(lf.conf, lf.logger).Update(refs.HEAD, commit, refs.UpdateOptions{Message: "commit: assets"})
+	return lf, out
+}
+
+func TestAddStagesPointer(t *testing.T) {
+	lf, out := arrangeRepo(t)
+
+	files, _ := lf.worktree.IndexFiles()
+	pointers := lf.pointers(files)
+	if len(pointers) != 1 {
+		t.Fatalf("Expected only art/logo.psd to be a pointer, got %v", pointers)
+	}
+	pointer := pointers["art/logo.psd"]
+	if pointer.Size != int64(len(design)) || !lf.lfs.Exists(pointer.Oid) {
+		t.Errorf("Expected the content of art/logo.psd in .got/lfs, got %+v", pointer)
+	}
+	status, err := lf.worktree.Status()
+	if err != nil || len(status.Unstaged) != 0 || len(status.Untracked) != 1 {
+		t.Errorf("Expected only .gotattributes to be untracked, got %+v, %v", status, err)
+	}
+
+	if err := lf.LsFiles(); err != nil || out.String() != pointer.Oid[:10]+" * art/logo.psd\n" {
+		t.Errorf("Unexpected ls-files output %q, %v", out.String(), err)
+	}
+	out.Reset()
+	lf.Track(nil)
+	if out.String() != "Listing tracked patterns\n    *.psd (.gotattributes)\n" {
+		t.Errorf("Unexpected track output %q", out.String())
+	}
+
+	// checking out the pointer writes the content back
+	os.Remove(filepath.Join("art", "logo.psd"))
+	if err := lf.worktree.WriteFile("art/logo.psd", files["art/logo.psd"]); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join("art", "logo.psd")); string(content) != design {
+		t.Errorf("Expected the content of art/logo.psd to be checked out, got %q", content)
+	}
+}
+
+func TestPushFetchAndCheckout(t *testing.T) {
+	lf, out := arrangeRepo(t)
+	server := t.TempDir()
+	if err := lf.Push(); err == nil || !strings.Contains(err.Error(), "lfs.url") {
+		t.Errorf("Expected push to need lfs.url, got %v", err)
+	}
+	lf.conf.SetConfigKeyValue("lfs.url", server)
+	if err := lf.Push(); err != nil || out.String() != "Uploaded 1 LFS objects\n" {
+		t.Fatalf("Unexpected push output %q, %v", out.String(), err)
+	}
+	out.Reset()
+
+	// without its content, the pointer itself is checked out
+	files, _ := lf.worktree.IndexFiles()
+	pointer := lf.pointers(files)["art/logo.psd"]
+	os.RemoveAll(filepath.Join(".got", "lfs"))
+	lf.conf.SetConfigKeyValue("lfs.url", filepath.Join(server, "missing"))
+	warnings := &bytes.Buffer{}
+	lf.worktree.SetOutput(warnings)
+	if err := lf.worktree.WriteFile("art/logo.psd", files["art/logo.psd"]); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if warnings.String() != "warning: the content of art/logo.psd is missing, its LFS pointer is checked out instead\n" {
+		t.Errorf("Unexpected warnings %q", warnings.String())
+	}
+	if content, _ := os.ReadFile(filepath.Join("art", "logo.psd")); string(content) != pointer.String() {
+		t.Errorf("Expected the pointer to be checked out, got %q", content)
+	}
+	if status, _ := lf.worktree.Status(); len(status.Unstaged) != 0 {
+		t.Errorf("Expected a checked out pointer to be unchanged, got %+v", status.Unstaged)
+	}
+	lf.LsFiles()
+	if out.String() != pointer.Oid[:10]+" - art/logo.psd\n" {
+		t.Errorf("Unexpected ls-files output %q", out.String())
+	}
+	out.Reset()
+
+	lf.conf.SetConfigKeyValue("lfs.url", server)
+	if err := lf.Fetch(); err != nil || out.String() != "Downloaded 1 LFS objects\n" {
+		t.Fatalf("Unexpected fetch output %q, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := lf.Checkout(); err != nil || out.String() != "Checked out 1 LFS files\n" {
+		t.Fatalf("Unexpected checkout output %q, %v", out.String(), err)
+	}
+	if content, _ := os.ReadFile(filepath.Join("art", "logo.psd")); string(content) != design {
+		t.Errorf("Expected the content of art/logo.psd to be checked out, got %q", content)
+	}
+}
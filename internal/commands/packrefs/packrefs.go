@@ -0,0 +1,39 @@
+package packrefs
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"os"
+)
+
+type PackRefs struct {
+	conf   *config.Config
+	logger *logger.Logger
+	refs   *refs.Store
+}
+
+func NewPackRefs(conf *config.Config, logger *logger.Logger) *PackRefs {
+	return &PackRefs{
+		conf:   conf,
+		logger: logger,
+		refs:   refs.NewStore(conf, logger),
+	}
+}
+
+// Execute moves the loose refs into .got/packed-refs: the tags, or every
+// ref when all is set
+func Execute(all bool) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	if err := NewPackRefs(conf, logger).Pack(all); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Pack moves the loose refs into the packed-refs file
+func (pr *PackRefs) Pack(all bool) error {
+	return pr.refs.Pack(all)
+}
@@ -0,0 +1,40 @@
+package packrefs
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	hash := strings.Repeat("a", 40)
+	os.MkdirAll(filepath.Join(".got", "refs", "heads"), 0755)
+	os.MkdirAll(filepath.Join(".got", "refs", "tags"), 0755)
+	os.WriteFile(filepath.Join(".got", "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	os.WriteFile(filepath.Join(".got", "refs", "heads", "main"), []byte(hash), 0644)
+	os.WriteFile(filepath.Join(".got", "refs", "tags", "v1"), []byte(hash), 0644)
+	pr := NewPackRefs(config.NewConfig(), logger.NewLogger(false, false))
+
+	if err := pr.Pack(false); err != nil {
+		t.Fatalf("Pack returned error: %v", err)
+	}
+	packed, _ := os.ReadFile(filepath.Join(".got", "packed-refs"))
+	if string(packed) != "# pack-refs with: sorted\n"+hash+" refs/tags/v1\n" {
+		t.Errorf("Expected only the tag to be packed, got:\n%s", packed)
+	}
+	if _, err := os.Stat(filepath.Join(".got", "refs", "heads", "main")); err != nil {
+		t.Errorf("Expected the branch to stay loose without --all")
+	}
+	if err := pr.Pack(true); err != nil {
+		t.Fatalf("Pack returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(".got", "refs", "heads", "main")); !os.IsNotExist(err) {
+		t.Errorf("Expected the branch to be packed with --all")
+	}
+}
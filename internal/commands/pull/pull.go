@@ -0,0 +1,263 @@
+package pull
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/commands/fetch"
+	"got_it/internal/diff"
+	"got_it/internal/ident"
+	"got_it/internal/logger"
+	"got_it/internal/merge"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Pull struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	worktree *worktree.Worktree
+	fetch    *fetch.Fetch
+	out      io.Writer
+}
+
+func NewPull(conf *config.Config, logger *logger.Logger) *Pull {
+	return &Pull{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		fetch:    fetch.NewFetch(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute pulls the branch in args[1] of the remote in args[0], by default
+// the upstream of the current branch
+func Execute(args []string) error {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	pl := NewPull(conf, logger)
+
+	remoteName, branch := "", ""
+	if len(args) > 0 {
+		remoteName = args[0]
+	}
+	if len(args) > 1 {
+		branch = args[1]
+	}
+	if err := pl.Pull(remoteName, branch); err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+	return nil
+}
+
+// Pull fetches from the remote and merges its branch into the current one:
+// a fast-forward when the current branch has no commits of its own, a merge
+// commit otherwise. Conflicts are left in the files for the user to resolve
+// and commit.
+func (pl *Pull) Pull(remoteName, branch string) error {
+	current, err := pl.refs.CurrentBranch()
+	if err != nil || current == "" {
+		return fmt.Errorf("you are not currently on a branch")
+	}
+	if remoteName == "" {
+		remoteName, branch = pl.upstream(current)
+	}
+	if branch == "" {
+		branch = refs.ShortName(current)
+	}
+	if err := pl.fetch.Fetch(remoteName, nil); err != nil {
+		return err
+	}
+	remote, err := pl.conf.GetRemote(remoteName)
+	if err != nil {
+		return err
+	}
+	theirs, _, err := pl.refs.Resolve("refs/remotes/" + remoteName + "/" + branch)
+	if err != nil {
+		return fmt.Errorf("couldn't find remote ref %s", branch)
+	}
+	headFiles, ours, err := pl.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(pl.conf.GotDir, merge.MERGE_HEAD)); err == nil {
+		return fmt.Errorf("you have not concluded your merge (%s exists)", merge.MERGE_HEAD)
+	}
+
+	if ours == "" {
+		return pl.fastForward(headFiles, ours, theirs)
+	}
+	reachable, err := pl.resolver.Reachable([]string{ours})
+	if err != nil {
+		return err
+	}
+	if reachable[theirs] {
+		fmt.Fprintln(pl.out, "Already up to date.")
+		return nil
+	}
+	if reachable, err = pl.resolver.Reachable([]string{theirs}); err != nil {
+		return err
+	}
+	if reachable[ours] {
+		return pl.fastForward(headFiles, ours, theirs)
+	}
+	return pl.merge(headFiles, ours, theirs, fmt.Sprintf("Merge branch '%s' of %s", branch, remote.URL))
+}
+
+// upstream returns the remote and the branch the current branch is set to
+// integrate with, origin and the branch of the same name by default
+func (pl *Pull) upstream(current string) (string, string) {
+	name := refs.ShortName(current)
+	upstream, err := pl.conf.GetUpstream(name)
+	if err != nil || upstream.Remote == config.LOCAL_REMOTE {
+		return fetch.DEFAULT_REMOTE, name
+	}
+	return upstream.Remote, strings.TrimPrefix(upstream.Merge, "refs/heads/")
+}
+
+// fastForward moves the current branch and the files to the fetched commit
+func (pl *Pull) fastForward(headFiles map[string]models.TreeEntry, ours, theirs string) error {
+	files, err := pl.worktree.CommitFiles(theirs)
+	if err != nil {
+		return err
+	}
+	if err := pl.worktree.CheckConflicts(headFiles, files); err != nil {
+		return err
+	}
+	if err := pl.worktree.Checkout(headFiles, files); err != nil {
+		return err
+	}
+	if err := pl.worktree.WriteIndex(files); err != nil {
+		return err
+	}
+	old := ours
+	if old == "" {
+		old = pl.conf.ObjectFormat().ZeroHash()
+	}
+	if err := pl.refs.Update(refs.HEAD, theirs, refs.UpdateOptions{OldHash: old, Message: "pull: Fast-forward"}); err != nil {
+		return err
+	}
+	if ours != "" {
+		fmt.Fprintf(pl.out, "Updating %s..%s\n", pl.store.Abbreviate(ours, objects.ABBREV_LENGTH), pl.store.Abbreviate(theirs, objects.ABBREV_LENGTH))
+	}
+	fmt.Fprintln(pl.out, "Fast-forward")
+	return nil
+}
+
+// merge merges the fetched commit into HEAD and commits the result, or
+// stops with the conflicts in the files and the index holding HEAD for them
+func (pl *Pull) merge(headFiles map[string]models.TreeEntry, ours, theirs, message string) error {
+	indexFiles, err := pl.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	if len(diff.CompareTrees(headFiles, indexFiles)) > 0 {
+		return fmt.Errorf("your local changes would be overwritten by merge; commit them first")
+	}
+	bases, err := pl.resolver.MergeBases(ours, theirs)
+	if err != nil {
+		return err
+	}
+	baseFiles := map[string]models.TreeEntry{}
+	if len(bases) > 0 {
+		if baseFiles, err = pl.worktree.CommitFiles(bases[0]); err != nil {
+			return err
+		}
+	}
+	theirFiles, err := pl.worktree.CommitFiles(theirs)
+	if err != nil {
+		return err
+	}
+	labels := merge.Labels{Ours: "HEAD", Theirs: pl.store.Abbreviate(theirs, objects.ABBREV_LENGTH)}
+	result, err := merge.Trees(pl.store, baseFiles, headFiles, theirFiles, labels)
+	if err != nil {
+		return err
+	}
+	if err := pl.worktree.CheckConflicts(headFiles, result.Files); err != nil {
+		return err
+	}
+	if err := pl.worktree.Checkout(headFiles, result.Files); err != nil {
+		return err
+	}
+
+	staged := result.Files
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintln(pl.out, conflict)
+		if entry, found := headFiles[conflict.Path]; found {
+			staged[conflict.Path] = entry
+		} else {
+			delete(staged, conflict.Path)
+		}
+	}
+	if err := pl.worktree.WriteIndex(staged); err != nil {
+		return err
+	}
+	if len(result.Conflicts) > 0 {
+		if err := os.WriteFile(filepath.Join(pl.conf.GotDir, merge.MERGE_HEAD), []byte(theirs+"\n"), 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(pl.conf.GotDir, merge.MERGE_MSG), []byte(message+"\n"), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(pl.out, "hint: after resolving the conflicts, mark the corrected paths with 'got add <paths>' and run 'got commit'")
+		return fmt.Errorf("automatic merge failed; fix conflicts and then commit the result")
+	}
+	return pl.commitMerge(staged, ours, theirs, message)
+}
+
+// commitMerge writes the merge commit of two parents and moves HEAD to it
+func (pl *Pull) commitMerge(files map[string]models.TreeEntry, ours, theirs, message string) error {
+	tree, err := pl.store.WriteTree(files)
+	if err != nil {
+		return err
+	}
+	author, err := ident.Author(pl.conf)
+	if err != nil {
+		return err
+	}
+	committer, err := ident.Committer(pl.conf)
+	if err != nil {
+		return err
+	}
+	hash, err := pl.store.WriteCommit(models.CommitData{
+		Tree:           tree,
+		Parents:        []string{ours, theirs},
+		AuthorName:     author.Name,
+		AuthorEmail:    author.Email,
+		AuthorDate:     author.Date,
+		CommitterName:  committer.Name,
+		CommitterEmail: committer.Email,
+		CommitterDate:  committer.Date,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	update := refs.UpdateOptions{OldHash: ours, Message: "pull: Merge made by the three-way strategy."}
+	if err := pl.refs.Update(refs.HEAD, hash, update); err != nil {
+		return err
+	}
+	fmt.Fprintln(pl.out, "Merge made by the three-way strategy.")
+	return nil
+}
+
+// SetOutput changes where the output is written
+func (pl *Pull) SetOutput(out io.Writer) {
+	pl.out = out
+	pl.fetch.SetOutput(out)
+}
@@ -0,0 +1,202 @@
+package pull
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/merge"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repo is one side of a pull
+type repo struct {
+	store *objects.Store
+	refs  *refs.Store
+}
+
+// arrangeRepos creates a remote repository with a commit holding a.txt and
+// b.txt on main, and a repository in the working directory where main is
+// at the same commit, checked out, with the remote recorded as origin
+func arrangeRepos(t *testing.T) (*Pull, *bytes.Buffer, repo, string) {
+	t.Helper()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv("GOT_AUTHOR_NAME", "A U Thor")
+	t.Setenv("GOT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GOT_COMMITTER_NAME", "C O Mitter")
+	t.Setenv("GOT_COMMITTER_EMAIL", "committer@example.com")
+	logger := logger.NewLogger(false, false)
+	remoteDir := t.TempDir()
+	remoteConf := config.NewConfig()
+	remoteConf.GotDir = filepath.Join(remoteDir, config.GOT_DIR)
+	for _, conf := range []*config.Config{config.NewConfig(), remoteConf} {
+		os.MkdirAll(filepath.Join(conf.GotDir, "objects"), 0755)
+		os.MkdirAll(filepath.Join(conf.GotDir, "refs", "heads"), 0755)
+		os.WriteFile(filepath.Join(conf.GotDir, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	}
+	remote := repo{store: objects.NewStore(remoteConf, logger), refs: refs.NewStore(remoteConf, logger)}
+	base := commit(t, remote, map[string]string{"a.txt": "one\ntwo\nthree\n", "b.txt": "b\n"}, "")
+
+	conf := config.NewConfig()
+	if err := conf.AddRemote("origin", remoteDir); err != nil {
+		t.Fatalf("Error adding remote: %v", err)
+	}
+	pl := NewPull(conf, logger)
+	out := &bytes.Buffer{}
+	pl.SetOutput(out)
+	local := repo{store: pl.store, refs: pl.refs}
+	commit(t, local, map[string]string{"a.txt": "one\ntwo\nthree\n", "b.txt": "b\n"}, "")
+	checkout(t, pl, "", base)
+	return pl, out, remote, base
+}
+
+// commit writes a commit of the files on top of the branch main of a
+// repository and moves main to it
+func commit(t *testing.T, r repo, contents map[string]string, message string) string {
+	t.Helper()
+	files := map[string]models.TreeEntry{}
+	for name, content := range contents {
+		blob, _ := r.store.Write(content)
+		files[name] = models.TreeEntry{Mode: "100644", Type: "blob", Hash: blob, Name: name}
+	}
+	tree, _ := r.store.WriteTree(files)
+	parents := []string{}
+	if parent, _, err := r.refs.Resolve("refs/heads/main"); err == nil {
+		parents = append(parents, parent)
+	}
+	hash, err := r.store.WriteCommit(models.CommitData{Tree: tree, Parents: parents, Message: message,
+		AuthorName: "A U Thor", AuthorEmail: "author@example.com", CommitterName: "A U Thor", CommitterEmail: "author@example.com"})
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	r.refs.Update("refs/heads/main", hash, refs.UpdateOptions{})
+	return hash
+}
+
+// checkout replaces the files of a commit, if any, with the files of another
+func checkout(t *testing.T, pl *Pull, previous, hash string) {
+	t.Helper()
+	from := map[string]models.TreeEntry{}
+	if previous != "" {
+		from, _ = pl.worktree.CommitFiles(previous)
+	}
+	files, _ := pl.worktree.CommitFiles(hash)
+	if err := pl.worktree.Checkout(from, files); err != nil {
+		t.Fatalf("Error checking out: %v", err)
+	}
+	pl.worktree.WriteIndex(files)
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Error reading %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestPullFastForward(t *testing.T) {
+	pl, out, remote, base := arrangeRepos(t)
+	if err := pl.Pull("origin", ""); err != nil {
+		t.Fatalf("Pull returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "Already up to date.\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	next := commit(t, remote, map[string]string{"a.txt": "one\ntwo\nthree\nfour\n", "b.txt": "b\n"}, "four")
+	out.Reset()
+	if err := pl.Pull("origin", ""); err != nil {
+		t.Fatalf("Pull returned error: %v", err)
+	}
+	expected := fmt.Sprintf("Updating %s..%s\nFast-forward\n", pl.store.Abbreviate(base, objects.ABBREV_LENGTH), pl.store.Abbreviate(next, objects.ABBREV_LENGTH))
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if hash, _, _ := pl.refs.Resolve(refs.HEAD); hash != next || readFile(t, "a.txt") != "one\ntwo\nthree\nfour\n" {
+		t.Errorf("Expected main and the files to be at %s", next)
+	}
+}
+
+func TestPullMerge(t *testing.T) {
+	pl, out, remote, base := arrangeRepos(t)
+	theirs := commit(t, remote, map[string]string{"a.txt": "one\ntwo\nthree\nfour\n", "b.txt": "b\n"}, "four")
+	ours := commit(t, repo{store: pl.store, refs: pl.refs}, map[string]string{"a.txt": "zero\none\ntwo\nthree\n", "b.txt": "b\n"}, "zero")
+	checkout(t, pl, base, ours)
+
+	if err := pl.Pull("origin", "main"); err != nil {
+		t.Fatalf("Pull returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "Merge made by the three-way strategy.\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	head, _, _ := pl.refs.Resolve(refs.HEAD)
+	merged, err := pl.store.ReadCommit(head)
+	if err != nil {
+		t.Fatalf("Error reading the merge commit: %v", err)
+	}
+	if len(merged.Parents) != 2 || merged.Parents[0] != ours || merged.Parents[1] != theirs {
+		t.Errorf("Unexpected parents: %v", merged.Parents)
+	}
+	if !strings.HasPrefix(merged.Message, "Merge branch 'main' of ") {
+		t.Errorf("Unexpected message: %s", merged.Message)
+	}
+	if content := readFile(t, "a.txt"); content != "zero\none\ntwo\nthree\nfour\n" {
+		t.Errorf("Unexpected merged content: %q", content)
+	}
+}
+
+func TestPullConflict(t *testing.T) {
+	pl, out, remote, base := arrangeRepos(t)
+	theirs := commit(t, remote, map[string]string{"a.txt": "one\n2\nthree\n", "b.txt": "b\n"}, "theirs")
+	ours := commit(t, repo{store: pl.store, refs: pl.refs}, map[string]string{"a.txt": "one\nTWO\nthree\n", "b.txt": "b\n"}, "ours")
+	checkout(t, pl, base, ours)
+
+	if err := pl.Pull("origin", ""); err == nil {
+		t.Fatalf("Expected the merge to fail")
+	}
+	if !strings.Contains(out.String(), "CONFLICT (content): Merge conflict in a.txt\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if content := readFile(t, "a.txt"); !strings.Contains(content, "<<<<<<< HEAD\nTWO\n=======\n2\n>>>>>>> ") {
+		t.Errorf("Expected conflict markers, got %q", content)
+	}
+	mergeHead := readFile(t, filepath.Join(config.GOT_DIR, merge.MERGE_HEAD))
+	if strings.TrimSpace(mergeHead) != theirs {
+		t.Errorf("Expected MERGE_HEAD to hold %s, got %s", theirs, mergeHead)
+	}
+	if head, _, _ := pl.refs.Resolve(refs.HEAD); head != ours {
+		t.Errorf("Expected HEAD to stay at %s", ours)
+	}
+	if err := pl.Pull("origin", ""); err == nil || !strings.Contains(err.Error(), "not concluded your merge") {
+		t.Errorf("Expected the unfinished merge to be reported, got %v", err)
+	}
+}
+
+func TestPullUnknownRemote(t *testing.T) {
+	arrangeRepos(t)
+	if err := Execute([]string{"upstream"}); err == nil {
+		t.Errorf("Expected an error for an unknown remote")
+	}
+}
+
+func TestPullUpstream(t *testing.T) {
+	pl, _, _, _ := arrangeRepos(t)
+	if remote, branch := pl.upstream("refs/heads/main"); remote != "origin" || branch != "main" {
+		t.Errorf("Expected origin main by default, got %s %s", remote, branch)
+	}
+	pl.conf.AddRemote("upstream", "/srv/elsewhere")
+	pl.conf.SetUpstream("main", config.Upstream{Remote: "upstream", Merge: "refs/heads/trunk"})
+	if remote, branch := pl.upstream("refs/heads/main"); remote != "upstream" || branch != "trunk" {
+		t.Errorf("Expected the configured upstream, got %s %s", remote, branch)
+	}
+}
@@ -0,0 +1,297 @@
+package push
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/hooks"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/transport"
+	"io"
+	"os"
+	"strings"
+)
+
+// DEFAULT_REMOTE is pushed to when no remote is given
+const DEFAULT_REMOTE string = "origin"
+
+// LEASE_TRACKING is the value of --force-with-lease given without a ref:
+// every pushed ref is expected to match its remote-tracking branch
+const LEASE_TRACKING string = "*"
+
+type Push struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	out      io.Writer
+}
+
+// PushOptions holds the flags of the push command
+type PushOptions struct {
+	Force          bool
+	ForceWithLease string
+	SetUpstream    bool
+	NoVerify       bool
+}
+
+func NewPush(conf *config.Config, logger *logger.Logger) *Push {
+	return &Push{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute pushes to the remote in args[0], by default the remote of the
+// upstream of the current branch, the refspecs that follow or the current
+// branch
+func Execute(args []string, opts PushOptions) error {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	pu := NewPush(conf, logger)
+
+	remoteName := ""
+	if len(args) > 0 {
+		remoteName = args[0]
+		args = args[1:]
+	}
+	if err := pu.Push(remoteName, args, opts); err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+	return nil
+}
+
+// Push updates the remote refs named by the refspecs with the local refs
+// they map from, sending the objects they need. A remote ref is only moved
+// to a commit that contains it, unless the push is forced; a lease forces
+// it only while the remote ref still has the expected value.
+func (pu *Push) Push(remoteName string, specs []string, opts PushOptions) error {
+	if remoteName == "" {
+		remoteName = pu.defaultRemote()
+	}
+	remote, err := pu.conf.GetRemote(remoteName)
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 {
+		branch, err := pu.refs.CurrentBranch()
+		if err != nil || branch == "" {
+			return fmt.Errorf("you are not currently on a branch")
+		}
+		specs = []string{branch}
+	}
+	leases, err := parseLease(opts.ForceWithLease)
+	if err != nil {
+		return err
+	}
+
+	conn, err := transport.Open(remote.URL, pu.logger)
+	if err != nil {
+		return err
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		return err
+	}
+	if err := transport.CheckObjectFormat(pu.store.Format(), adv); err != nil {
+		return err
+	}
+	updates := []pushUpdate{}
+	for _, spec := range specs {
+		update, err := pu.updateFor(spec, opts.Force, adv)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, update)
+	}
+
+	accepted := []transport.Update{}
+	for i := range updates {
+		update := &updates[i]
+		if update.Old == update.New {
+			update.status = ST_UP_TO_DATE
+			continue
+		}
+		if err := pu.check(update, remoteName, leases); err != nil {
+			return err
+		}
+		if update.status == "" {
+			accepted = append(accepted, update.Update)
+		}
+	}
+	if len(accepted) == 0 && allUpToDate(updates) {
+		fmt.Fprintln(pu.out, "Everything up-to-date")
+		if opts.SetUpstream {
+			return pu.setUpstreams(remoteName, updates)
+		}
+		return nil
+	}
+
+	rejected := map[string]error{}
+	if len(accepted) > 0 && !opts.NoVerify {
+		input := prePushInput(updates, pu.store.Format().ZeroHash())
+		if err := hooks.Run(pu.conf, hooks.PRE_PUSH, []string{remoteName, remote.URL}, strings.NewReader(input)); err != nil {
+			return fmt.Errorf("%w, failed to push some refs to '%s'", err, remote.URL)
+		}
+	}
+	if len(accepted) > 0 {
+		if rejected, err = conn.Push(pu.store, accepted); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(pu.out, "To %s\n", remote.URL)
+	failed := false
+	for _, update := range updates {
+		if reason, found := rejected[update.Name]; found {
+			update.status, update.reason = ST_REMOTE_REJECTED, reason.Error()
+		}
+		if update.status == ST_REJECTED || update.status == ST_REMOTE_REJECTED {
+			failed = true
+		} else if update.status == "" {
+			if err := pu.updateTracking(remoteName, update.Update); err != nil {
+				return err
+			}
+		}
+		if update.status != ST_UP_TO_DATE {
+			fmt.Fprintln(pu.out, pu.formatUpdate(update))
+		}
+	}
+	if failed {
+		return fmt.Errorf("failed to push some refs to '%s'", remote.URL)
+	}
+	if opts.SetUpstream {
+		return pu.setUpstreams(remoteName, updates)
+	}
+	return nil
+}
+
+// updateFor resolves a refspec into the update of a remote ref
+func (pu *Push) updateFor(spec string, force bool, adv transport.Advertisement) (pushUpdate, error) {
+	refspec, err := transport.ParseRefspec(spec)
+	if err != nil {
+		return pushUpdate{}, err
+	}
+	if strings.Contains(refspec.Src, "*") {
+		return pushUpdate{}, fmt.Errorf("wildcard refspecs are not supported by push: '%s'", spec)
+	}
+	update := pushUpdate{src: refspec.Src, force: force || refspec.Force}
+	fullName := ""
+	if refspec.Src != "" {
+		hash, name, err := pu.resolver.ResolveRef(refspec.Src)
+		if err != nil {
+			if hash, err = pu.resolver.Resolve(refspec.Src); err != nil {
+				return pushUpdate{}, fmt.Errorf("src refspec %s does not match any", refspec.Src)
+			}
+		}
+		update.New, fullName = hash, name
+		update.local = refspec.Src
+		if fullName != "" {
+			update.local = fullName
+		}
+	}
+
+	update.Name = refspec.Dst
+	switch {
+	case update.Name == "" && fullName == "":
+		return pushUpdate{}, fmt.Errorf("the destination of '%s' must be a full ref name", spec)
+	case update.Name == "":
+		update.Name = fullName
+	case !strings.HasPrefix(update.Name, "refs/"):
+		update.Name = "refs/heads/" + update.Name
+	}
+	update.Old = adv.Refs[update.Name]
+	if update.New == "" && update.Old == "" {
+		return pushUpdate{}, fmt.Errorf("unable to delete '%s': remote ref does not exist", refs.ShortName(update.Name))
+	}
+	return update, nil
+}
+
+// check rejects the update when it would lose commits of the remote without
+// being forced, or when the lease on the remote ref was broken
+func (pu *Push) check(update *pushUpdate, remoteName string, leases map[string]string) error {
+	expected, leased := leases[update.Name]
+	if _, all := leases[LEASE_TRACKING]; all && !leased {
+		expected, leased = pu.trackingHash(remoteName, update.Name), true
+	}
+	if leased {
+		if update.Old != expected {
+			update.status, update.reason = ST_REJECTED, "stale info"
+		}
+		update.force = true
+		return nil
+	}
+	if update.force || update.Old == "" || update.New == "" {
+		return nil
+	}
+	if !pu.store.Exists(update.Old) {
+		update.status, update.reason = ST_REJECTED, "fetch first"
+		return nil
+	}
+	fastForward, err := pu.isAncestor(update.Old, update.New)
+	if err != nil {
+		return err
+	}
+	if !fastForward {
+		update.status, update.reason = ST_REJECTED, "non-fast-forward"
+	}
+	return nil
+}
+
+// updateTracking moves the remote-tracking branch of a pushed branch
+func (pu *Push) updateTracking(remoteName string, update transport.Update) error {
+	if !strings.HasPrefix(update.Name, "refs/heads/") {
+		return nil
+	}
+	tracking := trackingRef(remoteName, update.Name)
+	if update.New == "" {
+		if !pu.refs.Exists(tracking) {
+			return nil
+		}
+		return pu.refs.Delete(tracking, "")
+	}
+	return pu.refs.Update(tracking, update.New, refs.UpdateOptions{Message: "update by push"})
+}
+
+// trackingHash returns the hash of the remote-tracking branch of a remote
+// ref, empty when it has none
+func (pu *Push) trackingHash(remoteName, name string) string {
+	if !strings.HasPrefix(name, "refs/heads/") {
+		return ""
+	}
+	hash, _, err := pu.refs.Resolve(trackingRef(remoteName, name))
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// isAncestor tells if the commit old is reachable from new
+func (pu *Push) isAncestor(old, new string) (bool, error) {
+	oldCommit, err := pu.resolver.ResolveCommit(old)
+	if err != nil {
+		return false, nil
+	}
+	newCommit, err := pu.resolver.ResolveCommit(new)
+	if err != nil {
+		return false, nil
+	}
+	reachable, err := pu.resolver.Reachable([]string{newCommit})
+	if err != nil {
+		return false, err
+	}
+	return reachable[oldCommit], nil
+}
+
+// SetOutput changes where the output is written
+func (pu *Push) SetOutput(out io.Writer) {
+	pu.out = out
+}
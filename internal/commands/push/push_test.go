@@ -0,0 +1,209 @@
+package push
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepos creates a repository in the working directory with two
+// commits on main and an empty bare repository recorded as its origin, and
+// returns the store and refs of the remote and the commits
+func arrangeRepos(t *testing.T) (*Push, *bytes.Buffer, *objects.Store, *refs.Store, []string) {
+	t.Helper()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	logger := logger.NewLogger(false, false)
+	remoteConf := config.NewConfig()
+	remoteConf.GotDir = filepath.Join(t.TempDir(), "remote.got")
+	for _, conf := range []*config.Config{config.NewConfig(), remoteConf} {
+		os.MkdirAll(filepath.Join(conf.GotDir, "objects"), 0755)
+		os.MkdirAll(filepath.Join(conf.GotDir, "refs", "heads"), 0755)
+		os.WriteFile(filepath.Join(conf.GotDir, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	}
+
+	conf := config.NewConfig()
+	if err := conf.AddRemote("origin", remoteConf.GotDir); err != nil {
+		t.Fatalf("Error adding remote: %v", err)
+	}
+	pu := NewPush(conf, logger)
+	out := &bytes.Buffer{}
+	pu.SetOutput(out)
+	commits := []string{writeCommit(t, pu.store, "version 0\n", "")}
+	commits = append(commits, writeCommit(t, pu.store, "version 1\n", commits[0]))
+	pu.refs.Update("refs/heads/main", commits[1], refs.UpdateOptions{})
+	return pu, out, objects.NewStore(remoteConf, logger), refs.NewStore(remoteConf, logger), commits
+}
+
+// writeCommit writes a commit with one file on top of parent
+func writeCommit(t *testing.T, store *objects.Store, content, parent string) string {
+	t.Helper()
+	blob, _ := store.Write(content)
+	tree, _ := store.Write(fmt.Sprintf("100644 blob %s\tfile.txt\n", blob))
+	header := "tree " + tree + "\n"
+	if parent != "" {
+		header += "parent " + parent + "\n"
+	}
+	commit, err := store.Write(header + "author A U Thor <author@example.com> 1623501234 +0200\n" +
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\n" + content)
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	return commit
+}
+
+func resolve(t *testing.T, store *refs.Store, name string) string {
+	t.Helper()
+	hash, _, err := store.Resolve(name)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+func TestPush(t *testing.T) {
+	pu, out, _, remoteRefs, commits := arrangeRepos(t)
+	if err := pu.Push("origin", nil, PushOptions{}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "To ") || !strings.HasSuffix(out.String(), "\n * [new branch]      main -> main\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if resolve(t, remoteRefs, "refs/heads/main") != commits[1] || resolve(t, pu.refs, "refs/remotes/origin/main") != commits[1] {
+		t.Errorf("Expected main and origin/main at %s", commits[1])
+	}
+
+	out.Reset()
+	if err := pu.Push("origin", nil, PushOptions{}); err != nil || out.String() != "Everything up-to-date\n" {
+		t.Errorf("Unexpected output %q, %v", out.String(), err)
+	}
+
+	third := writeCommit(t, pu.store, "version 2\n", commits[1])
+	pu.refs.Update("refs/heads/main", third, refs.UpdateOptions{})
+	out.Reset()
+	if err := pu.Push("origin", []string{"main", "main:refs/heads/backup"}, PushOptions{}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	short := pu.store.Abbreviate(commits[1], objects.ABBREV_LENGTH) + ".." + pu.store.Abbreviate(third, objects.ABBREV_LENGTH)
+	if !strings.Contains(out.String(), "   "+short+"  main -> main\n * [new branch]      main -> backup\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := pu.Push("origin", []string{":backup"}, PushOptions{}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), " - [deleted]         backup\n") || remoteRefs.Exists("refs/heads/backup") {
+		t.Errorf("Expected backup to be deleted:\n%s", out.String())
+	}
+}
+
+func TestPushNonFastForward(t *testing.T) {
+	pu, out, _, remoteRefs, commits := arrangeRepos(t)
+	pu.Push("origin", nil, PushOptions{})
+	rewritten := writeCommit(t, pu.store, "rewritten\n", commits[0])
+	pu.refs.Update("refs/heads/main", rewritten, refs.UpdateOptions{})
+
+	out.Reset()
+	err := pu.Push("origin", nil, PushOptions{})
+	if err == nil || !strings.HasSuffix(out.String(), " ! [rejected]        main -> main (non-fast-forward)\n") {
+		t.Errorf("Expected the push to be rejected, got %v:\n%s", err, out.String())
+	}
+	if resolve(t, remoteRefs, "refs/heads/main") != commits[1] {
+		t.Errorf("Expected the remote main to stay at %s", commits[1])
+	}
+
+	out.Reset()
+	if err := pu.Push("origin", nil, PushOptions{Force: true}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "main -> main (forced update)\n") || resolve(t, remoteRefs, "refs/heads/main") != rewritten {
+		t.Errorf("Expected a forced update:\n%s", out.String())
+	}
+}
+
+func TestPushForceWithLease(t *testing.T) {
+	pu, out, remoteStore, remoteRefs, commits := arrangeRepos(t)
+	pu.Push("origin", nil, PushOptions{})
+
+	// someone else pushed a commit we have not fetched
+	theirs := writeCommit(t, remoteStore, "theirs\n", commits[1])
+	remoteRefs.Update("refs/heads/main", theirs, refs.UpdateOptions{})
+	rewritten := writeCommit(t, pu.store, "rewritten\n", commits[0])
+	pu.refs.Update("refs/heads/main", rewritten, refs.UpdateOptions{})
+
+	out.Reset()
+	err := pu.Push("origin", nil, PushOptions{ForceWithLease: LEASE_TRACKING})
+	if err == nil || !strings.HasSuffix(out.String(), " ! [rejected]        main -> main (stale info)\n") {
+		t.Errorf("Expected the lease to be broken, got %v:\n%s", err, out.String())
+	}
+	out.Reset()
+	err = pu.Push("origin", nil, PushOptions{})
+	if err == nil || !strings.HasSuffix(out.String(), "main -> main (fetch first)\n") {
+		t.Errorf("Expected the push to be rejected, got %v:\n%s", err, out.String())
+	}
+
+	if err := pu.Push("origin", nil, PushOptions{ForceWithLease: "main:" + theirs}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if resolve(t, remoteRefs, "refs/heads/main") != rewritten {
+		t.Errorf("Expected the lease to allow the update")
+	}
+}
+
+func TestPushSetUpstream(t *testing.T) {
+	pu, out, _, _, _ := arrangeRepos(t)
+	if err := pu.Push("", nil, PushOptions{SetUpstream: true}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "branch 'main' set up to track 'origin/main'.\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	upstream, err := pu.conf.GetUpstream("main")
+	if err != nil || upstream.Remote != "origin" || upstream.Merge != "refs/heads/main" {
+		t.Errorf("Unexpected upstream: %+v, %v", upstream, err)
+	}
+
+	pu.conf.AddRemote("mirror", "/nonexistent")
+	pu.conf.SetUpstream("main", config.Upstream{Remote: "mirror", Merge: "refs/heads/main"})
+	if remote := pu.defaultRemote(); remote != "mirror" {
+		t.Errorf("Expected the remote of the upstream, got %s", remote)
+	}
+}
+
+func TestPushPrePushHook(t *testing.T) {
+	pu, _, _, remoteRefs, commits := arrangeRepos(t)
+	hookDir := filepath.Join(pu.conf.GotDir, "hooks")
+	os.MkdirAll(hookDir, 0755)
+	// the hook records its arguments and input and refuses the push
+	hook := "#!/bin/sh\necho \"$1 $2\" > hook.out\ncat >> hook.out\nexit 1\n"
+	os.WriteFile(filepath.Join(hookDir, "pre-push"), []byte(hook), 0755)
+
+	if err := Execute([]string{"origin"}, PushOptions{}); err == nil {
+		t.Fatalf("Expected the pre-push hook to abort the push")
+	}
+	if hash := resolve(t, remoteRefs, "refs/heads/main"); hash != "" {
+		t.Errorf("Expected the remote to be left alone, got main at %s", hash)
+	}
+	remote, _ := pu.conf.GetRemote("origin")
+	zero := strings.Repeat("0", 40)
+	expected := fmt.Sprintf("origin %s\nrefs/heads/main %s refs/heads/main %s\n", remote.URL, commits[1], zero)
+	if content, _ := os.ReadFile("hook.out"); string(content) != expected {
+		t.Errorf("Expected the hook to get\n%q\ngot\n%q", expected, content)
+	}
+
+	if err := pu.Push("origin", nil, PushOptions{NoVerify: true}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if hash := resolve(t, remoteRefs, "refs/heads/main"); hash != commits[1] {
+		t.Errorf("Expected --no-verify to bypass the hook, got main at %q", hash)
+	}
+}
@@ -0,0 +1,156 @@
+package push
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/transport"
+	"strings"
+)
+
+// Status of a pushed ref; an accepted update has none
+type Status string
+
+const (
+	ST_UP_TO_DATE      Status = "up to date"
+	ST_REJECTED        Status = "rejected"
+	ST_REMOTE_REJECTED Status = "remote rejected"
+)
+
+// pushUpdate is the update of a remote ref with the local ref it comes from
+// and what became of it
+type pushUpdate struct {
+	transport.Update
+	src    string
+	local  string // the full name of src, or the hash it names
+	force  bool
+	status Status
+	reason string
+}
+
+// parseLease reads the value of --force-with-lease: the expected hash of
+// each leased remote ref by full name, or LEASE_TRACKING alone to expect the
+// remote-tracking branches
+func parseLease(lease string) (map[string]string, error) {
+	leases := map[string]string{}
+	if lease == "" {
+		return leases, nil
+	}
+	if lease == LEASE_TRACKING {
+		leases[LEASE_TRACKING] = ""
+		return leases, nil
+	}
+	name, expected, _ := strings.Cut(lease, ":")
+	if name == "" {
+		return nil, fmt.Errorf("invalid lease '%s'", lease)
+	}
+	if !strings.HasPrefix(name, "refs/") {
+		name = "refs/heads/" + name
+	}
+	leases[name] = expected
+	return leases, nil
+}
+
+// trackingRef returns the remote-tracking branch of a remote branch
+func trackingRef(remoteName, name string) string {
+	return "refs/remotes/" + remoteName + "/" + strings.TrimPrefix(name, "refs/heads/")
+}
+
+// allUpToDate tells if no update moves a ref
+func allUpToDate(updates []pushUpdate) bool {
+	for _, update := range updates {
+		if update.status != ST_UP_TO_DATE {
+			return false
+		}
+	}
+	return true
+}
+
+// formatUpdate describes the outcome of an update on one line
+func (pu *Push) formatUpdate(update pushUpdate) string {
+	src, dst := refs.ShortName(update.src), refs.ShortName(update.Name)
+	switch {
+	case update.status == ST_REJECTED:
+		return fmt.Sprintf(" ! %-17s %s -> %s (%s)", "[rejected]", src, dst, update.reason)
+	case update.status == ST_REMOTE_REJECTED:
+		return fmt.Sprintf(" ! %-17s %s -> %s (%s)", "[remote rejected]", src, dst, update.reason)
+	case update.New == "":
+		return fmt.Sprintf(" - %-17s %s", "[deleted]", dst)
+	case update.Old == "":
+		kind := "branch"
+		if strings.HasPrefix(update.Name, "refs/tags/") {
+			kind = "tag"
+		}
+		return fmt.Sprintf(" * %-17s %s -> %s", "[new "+kind+"]", src, dst)
+	}
+	old, new := pu.store.Abbreviate(update.Old, objects.ABBREV_LENGTH), pu.store.Abbreviate(update.New, objects.ABBREV_LENGTH)
+	if update.force && !pu.fastForward(update) {
+		return fmt.Sprintf(" + %-17s %s -> %s (forced update)", old+"..."+new, src, dst)
+	}
+	return fmt.Sprintf("   %-17s %s -> %s", old+".."+new, src, dst)
+}
+
+// fastForward tells if an accepted update only added commits
+func (pu *Push) fastForward(update pushUpdate) bool {
+	if !pu.store.Exists(update.Old) {
+		return false
+	}
+	fastForward, err := pu.isAncestor(update.Old, update.New)
+	return err == nil && fastForward
+}
+
+// defaultRemote returns the remote of the upstream of the current branch,
+// DEFAULT_REMOTE when it has none
+func (pu *Push) defaultRemote() string {
+	current, err := pu.refs.CurrentBranch()
+	if err != nil || current == "" {
+		return DEFAULT_REMOTE
+	}
+	upstream, err := pu.conf.GetUpstream(refs.ShortName(current))
+	if err != nil || upstream.Remote == config.LOCAL_REMOTE {
+		return DEFAULT_REMOTE
+	}
+	return upstream.Remote
+}
+
+// setUpstreams makes the pushed remote branches the upstream of the local
+// branches they were pushed from
+func (pu *Push) setUpstreams(remoteName string, updates []pushUpdate) error {
+	for _, update := range updates {
+		if update.New == "" || !strings.HasPrefix(update.Name, "refs/heads/") {
+			continue
+		}
+		_, fullName, err := pu.resolver.ResolveRef(update.src)
+		if err != nil || !strings.HasPrefix(fullName, "refs/heads/") {
+			continue
+		}
+		branch := refs.ShortName(fullName)
+		if err := pu.conf.SetUpstream(branch, config.Upstream{Remote: remoteName, Merge: update.Name}); err != nil {
+			return err
+		}
+		fmt.Fprintf(pu.out, "branch '%s' set up to track '%s'.\n", branch, refs.ShortName(trackingRef(remoteName, update.Name)))
+	}
+	return nil
+}
+
+// prePushInput lists the accepted updates for the pre-push hook, one per
+// line as "<local ref> <local hash> <remote ref> <remote hash>"; a deleted
+// ref is "(delete)" and a missing hash is all zeros
+func prePushInput(updates []pushUpdate, zeroHash string) string {
+	var input strings.Builder
+	for _, update := range updates {
+		if update.status != "" {
+			continue
+		}
+		local, newHash, oldHash := update.local, update.New, update.Old
+		if newHash == "" {
+			local, newHash = "(delete)", zeroHash
+		}
+		if oldHash == "" {
+			oldHash = zeroHash
+		}
+		fmt.Fprintf(&input, "%s %s %s %s\n", local, newHash, update.Name, oldHash)
+	}
+	return input.String()
+}
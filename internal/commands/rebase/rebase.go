@@ -0,0 +1,527 @@
+package rebase
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/editor"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/sequencer"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Rebase struct {
+	conf      *config.Config
+	logger    *logger.Logger
+	store     *objects.Store
+	refs      *refs.Store
+	resolver  *revision.Resolver
+	worktree  *worktree.Worktree
+	sequencer *sequencer.Sequencer
+	out       io.Writer
+}
+
+// RebaseOptions holds the flags of the rebase command
+type RebaseOptions struct {
+	Interactive bool
+	Onto        string
+	Continue    bool
+	Skip        bool
+	Abort       bool
+	EditTodo    bool
+}
+
+func NewRebase(conf *config.Config, logger *logger.Logger) *Rebase {
+	return &Rebase{
+		conf:      conf,
+		logger:    logger,
+		store:     objects.NewStore(conf, logger),
+		refs:      refs.NewStore(conf, logger),
+		resolver:  revision.NewResolver(conf, logger),
+		worktree:  worktree.NewWorktree(conf, logger),
+		sequencer: sequencer.NewSequencer(conf, logger),
+		out:       os.Stdout,
+	}
+}
+
+// Execute starts a rebase onto the upstream given in args, or goes on with
+// the one in progress
+func Execute(args []string, opts RebaseOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	rb := NewRebase(conf, logger)
+
+	var err error
+	switch {
+	case opts.Continue:
+		err = rb.Continue()
+	case opts.Skip:
+		err = rb.Skip()
+	case opts.Abort:
+		err = rb.Abort()
+	case opts.EditTodo:
+		err = rb.EditTodo()
+	case len(args) != 1:
+		err = fmt.Errorf("exactly one upstream must be given")
+	default:
+		err = rb.Start(args[0], opts)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Start replays the commits of the current branch that are not in upstream
+// on top of upstream, or of the --onto commit. In interactive mode the list
+// of commits is edited first.
+func (rb *Rebase) Start(upstream string, opts RebaseOptions) error {
+	if rb.InProgress() {
+		return fmt.Errorf("a rebase is already in progress (try --continue, --skip or --abort)")
+	}
+	upstreamHash, err := rb.resolver.ResolveCommit(upstream)
+	if err != nil {
+		return err
+	}
+	onto, ontoName := upstreamHash, upstream
+	if opts.Onto != "" {
+		if onto, err = rb.resolver.ResolveCommit(opts.Onto); err != nil {
+			return err
+		}
+		ontoName = opts.Onto
+	}
+	headHash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return fmt.Errorf("cannot rebase a branch without commits")
+	}
+	status, err := rb.worktree.Status()
+	if err != nil {
+		return err
+	}
+	if !status.IsClean() {
+		return fmt.Errorf("cannot rebase: you have staged or unstaged changes, commit or stash them")
+	}
+	headName, err := rb.refs.CurrentBranch()
+	if err != nil {
+		headName = DETACHED_HEAD
+	}
+
+	todo, err := rb.commitsToReplay(upstreamHash, headHash)
+	if err != nil {
+		return err
+	}
+	if !opts.Interactive && onto == upstreamHash {
+		reachable, err := rb.resolver.Reachable([]string{headHash})
+		if err != nil {
+			return err
+		}
+		if reachable[upstreamHash] {
+			fmt.Fprintf(rb.out, "Current branch %s is up to date.\n", strings.TrimPrefix(headName, "refs/heads/"))
+			return nil
+		}
+	}
+
+	st := state{HeadName: headName, OrigHead: headHash, Onto: onto, Interactive: opts.Interactive, Todo: todo}
+	if err := rb.writeState(st); err != nil {
+		return err
+	}
+	if opts.Interactive {
+		if todo, err = rb.editTodo(st); err != nil {
+			rb.removeState()
+			return err
+		}
+		if len(todo) == 0 {
+			fmt.Fprintln(rb.out, "Nothing to do")
+			return rb.removeState()
+		}
+		if err := rb.writeTodo(todo); err != nil {
+			return err
+		}
+	}
+
+	ontoFiles, err := rb.worktree.CommitFiles(onto)
+	if err != nil {
+		return err
+	}
+	if err := rb.worktree.Reset(ontoFiles); err != nil {
+		return err
+	}
+	message := "rebase (start): checkout " + ontoName
+	if err := rb.refs.Update(refs.HEAD, onto, refs.UpdateOptions{NoDeref: true, Message: message}); err != nil {
+		return err
+	}
+	return rb.run()
+}
+
+// Continue goes on after a stop: the resolved conflicts are committed, and
+// after an edit the staged changes are amended into the stopped commit
+func (rb *Rebase) Continue() error {
+	if _, err := rb.readState(); err != nil {
+		return err
+	}
+	stop := rb.readStop()
+	if stop.Line != nil && stop.Unmerged != nil {
+		if err := rb.sequencer.CheckResolved(stop.Unmerged); err != nil {
+			return err
+		}
+	}
+	if err := rb.clearStop(); err != nil {
+		return err
+	}
+	switch {
+	case stop.Line != nil && stop.Unmerged != nil:
+		stopped, err := rb.commit(*stop.Line, stop.Message)
+		if err != nil || stopped {
+			return err
+		}
+	case stop.Amend != "":
+		if err := rb.amendStaged(stop.Amend); err != nil {
+			return err
+		}
+	}
+	return rb.run()
+}
+
+// Skip drops the changes of the stopped commit and goes on
+func (rb *Rebase) Skip() error {
+	if _, err := rb.readState(); err != nil {
+		return err
+	}
+	headFiles, _, err := rb.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	if err := rb.worktree.Reset(headFiles); err != nil {
+		return err
+	}
+	if err := rb.clearStop(); err != nil {
+		return err
+	}
+	return rb.run()
+}
+
+// Abort checks out the original branch as it was before the rebase started
+func (rb *Rebase) Abort() error {
+	st, err := rb.readState()
+	if err != nil {
+		return err
+	}
+	files, err := rb.worktree.CommitFiles(st.OrigHead)
+	if err != nil {
+		return err
+	}
+	if err := rb.worktree.Reset(files); err != nil {
+		return err
+	}
+	message := "rebase (abort): returning to " + st.HeadName
+	if st.HeadName == DETACHED_HEAD {
+		err = rb.refs.Update(refs.HEAD, st.OrigHead, refs.UpdateOptions{NoDeref: true, Message: message})
+	} else {
+		err = rb.refs.SetSymbolic(refs.HEAD, st.HeadName, message)
+	}
+	if err != nil {
+		return err
+	}
+	return rb.removeState()
+}
+
+// EditTodo lets the user edit the rest of the todo list
+func (rb *Rebase) EditTodo() error {
+	st, err := rb.readState()
+	if err != nil {
+		return err
+	}
+	todo, err := rb.editTodo(st)
+	if err != nil {
+		return err
+	}
+	return rb.writeTodo(todo)
+}
+
+// run executes the todo list until it is done or a line stops
+func (rb *Rebase) run() error {
+	for {
+		st, err := rb.readState()
+		if err != nil {
+			return err
+		}
+		if len(st.Todo) == 0 {
+			return rb.finish(st)
+		}
+		line := st.Todo[0]
+		if err := rb.writeTodo(st.Todo[1:]); err != nil {
+			return err
+		}
+		if err := rb.appendDone(line); err != nil {
+			return err
+		}
+		stopped, err := rb.execute(line)
+		if err != nil || stopped {
+			return err
+		}
+	}
+}
+
+// execute runs a line of the todo list and tells if the rebase stopped
+func (rb *Rebase) execute(line TodoLine) (bool, error) {
+	switch line.Command {
+	case CMD_DROP:
+		return false, nil
+	case CMD_EXEC:
+		fmt.Fprintf(rb.out, "Executing: %s\n", line.Exec)
+		cmd := exec.Command("sh", "-c", line.Exec)
+		cmd.Stdout = rb.out
+		cmd.Stderr = rb.out
+		if err := cmd.Run(); err != nil {
+			if err := rb.writeStop(stop{Line: &line}); err != nil {
+				return true, err
+			}
+			return true, fmt.Errorf("execution failed: %s\nfix the problem and run 'got rebase --continue'", line.Exec)
+		}
+		return false, nil
+	}
+
+	headHash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return false, err
+	}
+	commit, err := rb.store.ReadCommit(line.Hash)
+	if err != nil {
+		return false, err
+	}
+	melding := line.Command == CMD_SQUASH || line.Command == CMD_FIXUP
+	if !melding && len(commit.Parents) == 1 && commit.Parents[0] == headHash {
+		return rb.fastForward(line)
+	}
+
+	message, err := rb.messageFor(line, commit.Message, headHash)
+	if err != nil {
+		return false, err
+	}
+	conflicts, err := rb.sequencer.ApplyChanges(sequencer.ACT_PICK, line.Hash)
+	if err != nil {
+		return false, err
+	}
+	if len(conflicts) > 0 {
+		paths := []string{}
+		for _, conflict := range conflicts {
+			fmt.Fprintln(rb.out, conflict)
+			paths = append(paths, conflict.Path)
+		}
+		if err := rb.writeStop(stop{Line: &line, Message: message, Unmerged: paths}); err != nil {
+			return true, err
+		}
+		fmt.Fprintln(rb.out, "hint: resolve all conflicts manually, mark them as resolved with 'got add <paths>'")
+		fmt.Fprintln(rb.out, "hint: and run 'got rebase --continue', or skip this commit with 'got rebase --skip'")
+		return true, fmt.Errorf("could not apply %s... %s", rb.store.Abbreviate(line.Hash, objects.ABBREV_LENGTH), line.Subject)
+	}
+	return rb.commit(line, message)
+}
+
+// fastForward reuses a commit whose parent is already HEAD instead of
+// replaying it
+func (rb *Rebase) fastForward(line TodoLine) (bool, error) {
+	files, err := rb.worktree.CommitFiles(line.Hash)
+	if err != nil {
+		return false, err
+	}
+	if err := rb.worktree.Reset(files); err != nil {
+		return false, err
+	}
+	message := fmt.Sprintf("rebase (%s): %s", line.Command, line.Subject)
+	if err := rb.refs.Update(refs.HEAD, line.Hash, refs.UpdateOptions{NoDeref: true, Message: message}); err != nil {
+		return false, err
+	}
+	switch line.Command {
+	case CMD_REWORD:
+		commit, err := rb.store.ReadCommit(line.Hash)
+		if err != nil {
+			return false, err
+		}
+		reworded, err := rb.editMessage(commit.Message)
+		if err != nil {
+			return false, err
+		}
+		author, err := rb.sequencer.AuthorOf(line.Hash)
+		if err != nil {
+			return false, err
+		}
+		_, err = rb.sequencer.CommitIndex(sequencer.CommitOptions{
+			Message: reworded, Author: &author, Amend: true, Reflog: "rebase (reword): " + subjectOf(reworded),
+		})
+		return false, err
+	case CMD_EDIT:
+		return true, rb.stopForEdit(line)
+	}
+	return false, nil
+}
+
+// commit records the staged result of a line, melding it into HEAD for
+// squash and fixup
+func (rb *Rebase) commit(line TodoLine, message string) (bool, error) {
+	author, err := rb.sequencer.AuthorOf(line.Hash)
+	if err != nil {
+		return false, err
+	}
+	opts := sequencer.CommitOptions{Message: message, Author: &author}
+	switch line.Command {
+	case CMD_SQUASH, CMD_FIXUP:
+		headHash, _, err := rb.refs.Resolve(refs.HEAD)
+		if err != nil {
+			return false, err
+		}
+		if author, err = rb.sequencer.AuthorOf(headHash); err != nil {
+			return false, err
+		}
+		opts.Amend = true
+		if line.Command == CMD_SQUASH {
+			if opts.Message, err = rb.editMessage(message); err != nil {
+				return false, err
+			}
+		}
+	case CMD_REWORD:
+		if opts.Message, err = rb.editMessage(message); err != nil {
+			return false, err
+		}
+	}
+	opts.Reflog = fmt.Sprintf("rebase (%s): %s", line.Command, subjectOf(opts.Message))
+
+	commitHash, err := rb.sequencer.CommitIndex(opts)
+	if err != nil {
+		return false, err
+	}
+	if commitHash == "" {
+		fmt.Fprintf(rb.out, "dropping %s %s -- patch contents already upstream\n", line.Hash, line.Subject)
+		return false, nil
+	}
+	if line.Command == CMD_EDIT {
+		return true, rb.stopForEdit(line)
+	}
+	return false, nil
+}
+
+// stopForEdit stops the rebase after the commit of an edit line
+func (rb *Rebase) stopForEdit(line TodoLine) error {
+	headHash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return err
+	}
+	if err := rb.writeStop(stop{Amend: headHash}); err != nil {
+		return err
+	}
+	fmt.Fprintf(rb.out, "Stopped at %s... %s\n", rb.store.Abbreviate(line.Hash, objects.ABBREV_LENGTH), line.Subject)
+	fmt.Fprintln(rb.out, "You can amend the commit now by staging changes with 'got add',")
+	fmt.Fprintln(rb.out, "then run 'got rebase --continue' once you are satisfied with your changes.")
+	return nil
+}
+
+// amendStaged amends the staged changes into the commit stopped for editing
+func (rb *Rebase) amendStaged(stoppedHash string) error {
+	headFiles, headHash, err := rb.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	indexFiles, err := rb.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	if headHash != stoppedHash || sameFiles(headFiles, indexFiles) {
+		return nil
+	}
+	head, err := rb.store.ReadCommit(headHash)
+	if err != nil {
+		return err
+	}
+	author, err := rb.sequencer.AuthorOf(headHash)
+	if err != nil {
+		return err
+	}
+	_, err = rb.sequencer.CommitIndex(sequencer.CommitOptions{
+		Message: head.Message, Author: &author, Amend: true, Reflog: "rebase (edit): " + subjectOf(head.Message),
+	})
+	return err
+}
+
+// messageFor returns the message of the commit made for a line; squash
+// and fixup add to the message of HEAD
+func (rb *Rebase) messageFor(line TodoLine, message, headHash string) (string, error) {
+	message = strings.TrimRight(message, "\n")
+	if line.Command != CMD_SQUASH && line.Command != CMD_FIXUP {
+		return message, nil
+	}
+	head, err := rb.store.ReadCommit(headHash)
+	if err != nil {
+		return "", err
+	}
+	headMessage := strings.TrimRight(head.Message, "\n")
+	if line.Command == CMD_FIXUP {
+		return headMessage, nil
+	}
+	return headMessage + "\n\n" + message, nil
+}
+
+// editMessage lets the user edit a commit message
+func (rb *Rebase) editMessage(message string) (string, error) {
+	text := strings.TrimRight(message, "\n") + "\n\n" +
+		"# Please enter the commit message for your changes. Lines starting\n" +
+		"# with '#' will be ignored, and an empty message aborts the commit.\n"
+	edited, err := editor.EditText(rb.path("COMMIT_EDITMSG"), text)
+	if err != nil {
+		return "", err
+	}
+	if edited == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	return edited, nil
+}
+
+// finish points the rebased branch to the new commits and checks it out
+func (rb *Rebase) finish(st state) error {
+	headHash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return err
+	}
+	if st.HeadName != DETACHED_HEAD {
+		message := fmt.Sprintf("rebase (finish): %s onto %s", st.HeadName, st.Onto)
+		if err := rb.refs.Update(st.HeadName, headHash, refs.UpdateOptions{Message: message}); err != nil {
+			return err
+		}
+		if err := rb.refs.SetSymbolic(refs.HEAD, st.HeadName, "rebase (finish): returning to "+st.HeadName); err != nil {
+			return err
+		}
+	}
+	if err := rb.removeState(); err != nil {
+		return err
+	}
+	fmt.Fprintf(rb.out, "Successfully rebased and updated %s.\n", st.HeadName)
+	return nil
+}
+
+// commitsToReplay lists the commits of head missing from upstream, oldest
+// first, leaving merges out
+func (rb *Rebase) commitsToReplay(upstream, head string) ([]TodoLine, error) {
+	walker, err := rb.resolver.Walk(revision.Range{Include: []string{head}, Exclude: []string{upstream}}, revision.WalkOptions{TopoOrder: true})
+	if err != nil {
+		return nil, err
+	}
+	todo := []TodoLine{}
+	for commit, ok := walker.Next(); ok; commit, ok = walker.Next() {
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		todo = append([]TodoLine{{Command: CMD_PICK, Hash: commit.Hash, Subject: subjectOf(commit.Message)}}, todo...)
+	}
+	return todo, nil
+}
+
+// SetOutput changes where the output is written
+func (rb *Rebase) SetOutput(out io.Writer) {
+	rb.out = out
+	rb.sequencer.SetOutput(out)
+}
@@ -0,0 +1,212 @@
+package rebase
+
+import (
+	"bytes"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates this history and checks out branch:
+//
+//	base (a.txt, b.txt) - main (b.txt changed)
+//	     \- fix (a.txt changed) - feature (c.txt added) - topic (b.txt changed)
+func arrangeRepo(t *testing.T, branch string) (*Rebase, *bytes.Buffer, map[string]string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	t.Setenv("GOT_COMMITTER_NAME", "C O Mitter")
+	t.Setenv("GOT_COMMITTER_EMAIL", "committer@example.com")
+	t.Setenv("GOT_AUTHOR_NAME", "C O Mitter")
+	t.Setenv("GOT_AUTHOR_EMAIL", "committer@example.com")
+	rb := NewRebase(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	rb.SetOutput(out)
+
+	commits := make(map[string]string)
+	commits["base"] = repo.Commit(map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "b\n"}, "base")
+	commits["main"] = repo.Commit(map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "main\n"}, "main", commits["base"])
+	commits["fix"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "b\n"}, "fix a", commits["base"])
+	commits["feature"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "b\n", "c.txt": "c\n"}, "add c", commits["fix"])
+	commits["topic"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "topic\n", "c.txt": "c\n"}, "change b", commits["feature"])
+	for _, name := range []string{"main", "feature", "topic"} {
+		repo.SetRef("refs/heads/"+name, commits[name])
+	}
+	testrepo.WriteFile(t, filepath.Join(".got", "HEAD"), "ref: refs/heads/"+branch)
+	files, _ := rb.worktree.CommitFiles(commits[branch])
+	if err := rb.worktree.Reset(files); err != nil {
+		t.Fatalf("Error checking out %s: %v", branch, err)
+	}
+	return rb, out, commits
+}
+
+func history(t *testing.T, rb *Rebase, commits map[string]string) []string {
+	t.Helper()
+	hash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		t.Fatalf("Error resolving HEAD: %v", err)
+	}
+	messages := []string{}
+	for hash != commits["main"] {
+		commit, err := rb.store.ReadCommit(hash)
+		if err != nil || len(commit.Parents) == 0 {
+			t.Fatalf("Expected HEAD to be on top of main")
+		}
+		messages = append(messages, commit.Message)
+		hash = commit.Parents[0]
+	}
+	return messages
+}
+
+func headCommit(t *testing.T, rb *Rebase) (string, models.CommitData) {
+	t.Helper()
+	hash, _, err := rb.refs.Resolve(refs.HEAD)
+	if err != nil {
+		t.Fatalf("Error resolving HEAD: %v", err)
+	}
+	commit, err := rb.store.ReadCommit(hash)
+	if err != nil {
+		t.Fatalf("Error reading HEAD: %v", err)
+	}
+	return hash, commit
+}
+
+func stage(t *testing.T, rb *Rebase, paths ...string) {
+	t.Helper()
+	files, _ := rb.worktree.IndexFiles()
+	staged, err := rb.worktree.StoreFiles(paths)
+	if err != nil {
+		t.Fatalf("Error staging %v: %v", paths, err)
+	}
+	for filePath, entry := range staged {
+		files[filePath] = entry
+	}
+	rb.worktree.WriteIndex(files)
+}
+
+func TestRebase(t *testing.T) {
+	rb, out, commits := arrangeRepo(t, "feature")
+	if err := rb.Start("main", RebaseOptions{}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if out.String() != "Successfully rebased and updated refs/heads/feature.\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	testrepo.AssertFile(t, "a.txt", "1\nfixed\n3\n")
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	testrepo.AssertFile(t, "c.txt", "c\n")
+	if messages := history(t, rb, commits); strings.Join(messages, ",") != "add c,fix a" {
+		t.Errorf("Unexpected history: %v", messages)
+	}
+	if branch, _ := rb.refs.CurrentBranch(); branch != "refs/heads/feature" {
+		t.Errorf("Expected HEAD to point to the feature branch again, got %q", branch)
+	}
+	if rb.InProgress() {
+		t.Errorf("Expected the rebase state to be removed")
+	}
+
+	out.Reset()
+	if err := rb.Start("main", RebaseOptions{}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if out.String() != "Current branch feature is up to date.\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	rb, out, commits := arrangeRepo(t, "topic")
+	script := `case "$1" in
+*todo) sed -i -e '1s/^pick/r/' -e '2s/^pick/squash/' -e '3s/^pick/drop/' "$1"; echo "exec echo ran > exec.txt" >> "$1" ;;
+*) sed -i -e '1s/.*/edited/' "$1" ;;
+esac`
+	os.WriteFile("editor.sh", []byte(script), 0755)
+	t.Setenv("GOT_EDITOR", "sh "+filepath.Join(".", "editor.sh"))
+
+	if err := rb.Start("main", RebaseOptions{Interactive: true}); err != nil {
+		t.Fatalf("Start returned error: %v\n%s", err, out.String())
+	}
+	if messages := history(t, rb, commits); len(messages) != 1 || messages[0] != "edited\n\nadd c" {
+		t.Errorf("Expected the reworded and squashed commit alone, got %q", messages)
+	}
+	testrepo.AssertFile(t, "a.txt", "1\nfixed\n3\n")
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	testrepo.AssertFile(t, "c.txt", "c\n")
+	testrepo.AssertFile(t, "exec.txt", "ran\n")
+	_, head := headCommit(t, rb)
+	if head.AuthorName != "A U Thor" || head.CommitterName != "C O Mitter" {
+		t.Errorf("Expected the author to be kept, got %s and %s", head.AuthorName, head.CommitterName)
+	}
+}
+
+func TestFixupAndEdit(t *testing.T) {
+	rb, out, commits := arrangeRepo(t, "topic")
+	t.Setenv("GOT_EDITOR", "sed -i -e '1s/^pick/edit/' -e '2s/^pick/f/' -e '3s/^pick/d/'")
+	if err := rb.Start("main", RebaseOptions{Interactive: true}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Stopped at "+rb.store.Abbreviate(commits["fix"], objects.ABBREV_LENGTH)+"... fix a\n") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if !rb.InProgress() {
+		t.Fatalf("Expected the rebase to stop for editing")
+	}
+
+	os.WriteFile("a.txt", []byte("1\namended\n3\n"), 0644)
+	stage(t, rb, "a.txt")
+	if err := rb.Continue(); err != nil {
+		t.Fatalf("Continue returned error: %v", err)
+	}
+	if messages := history(t, rb, commits); len(messages) != 1 || messages[0] != "fix a" {
+		t.Errorf("Expected the fixup to be melded into fix a, got %q", messages)
+	}
+	testrepo.AssertFile(t, "a.txt", "1\namended\n3\n")
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	testrepo.AssertFile(t, "c.txt", "c\n")
+}
+
+func TestConflictAbortAndContinue(t *testing.T) {
+	rb, out, commits := arrangeRepo(t, "topic")
+	err := rb.Start("main", RebaseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "could not apply") {
+		t.Fatalf("Expected the rebase to stop on conflicts, got %v", err)
+	}
+	if !strings.Contains(out.String(), "CONFLICT (content): Merge conflict in b.txt") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if err := rb.Start("main", RebaseOptions{}); err == nil {
+		t.Errorf("Expected an error starting while a rebase is in progress")
+	}
+	if err := rb.Continue(); err == nil || !strings.Contains(err.Error(), "b.txt") {
+		t.Errorf("Expected continue to refuse the unresolved b.txt, got %v", err)
+	}
+
+	if err := rb.Abort(); err != nil {
+		t.Fatalf("Abort returned error: %v", err)
+	}
+	if hash, _, _ := rb.refs.Resolve(refs.HEAD); hash != commits["topic"] {
+		t.Errorf("Expected HEAD to move back to topic, got %s", hash)
+	}
+	if branch, _ := rb.refs.CurrentBranch(); branch != "refs/heads/topic" {
+		t.Errorf("Expected HEAD to point to the topic branch again, got %q", branch)
+	}
+	testrepo.AssertFile(t, "b.txt", "topic\n")
+	if rb.InProgress() {
+		t.Errorf("Expected the rebase state to be removed")
+	}
+
+	rb.Start("main", RebaseOptions{})
+	os.WriteFile("b.txt", []byte("resolved\n"), 0644)
+	stage(t, rb, "b.txt")
+	if err := rb.Continue(); err != nil {
+		t.Fatalf("Continue returned error: %v", err)
+	}
+	if messages := history(t, rb, commits); strings.Join(messages, ",") != "change b,add c,fix a" {
+		t.Errorf("Unexpected history: %v", messages)
+	}
+	testrepo.AssertFile(t, "b.txt", "resolved\n")
+}
@@ -0,0 +1,309 @@
+package rebase
+
+import (
+	"fmt"
+	"got_it/internal/editor"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// REBASE_DIR holds the state of a rebase in progress
+const REBASE_DIR string = "rebase-merge"
+
+// DETACHED_HEAD is recorded as head-name when the rebase started detached
+const DETACHED_HEAD string = "detached HEAD"
+
+// Command is a command of the todo list
+type Command string
+
+const (
+	CMD_PICK   Command = "pick"
+	CMD_REWORD Command = "reword"
+	CMD_EDIT   Command = "edit"
+	CMD_SQUASH Command = "squash"
+	CMD_FIXUP  Command = "fixup"
+	CMD_DROP   Command = "drop"
+	CMD_EXEC   Command = "exec"
+)
+
+// abbreviations maps the one letter commands to the full ones
+var abbreviations = map[string]Command{
+	"p": CMD_PICK, "r": CMD_REWORD, "e": CMD_EDIT, "s": CMD_SQUASH, "f": CMD_FIXUP, "d": CMD_DROP, "x": CMD_EXEC,
+}
+
+const todoHelp string = `
+# Commands:
+# p, pick <commit> = use commit
+# r, reword <commit> = use commit, but edit the commit message
+# e, edit <commit> = use commit, but stop for amending
+# s, squash <commit> = use commit, but meld into previous commit
+# f, fixup <commit> = like "squash", but discard this commit's log message
+# x, exec <command> = run command (the rest of the line) using shell
+# d, drop <commit> = remove commit
+#
+# These lines can be re-ordered; they are executed from top to bottom.
+# If you remove a line here THAT COMMIT WILL BE LOST.
+# However, if you remove everything, the rebase will be aborted.
+`
+
+// TodoLine is a line of the todo list
+type TodoLine struct {
+	Command Command
+	Hash    string
+	Subject string
+	Exec    string // the shell command of an exec line
+}
+
+// state is what is kept under .got/rebase-merge between two commands
+type state struct {
+	HeadName    string // the branch being rebased, or DETACHED_HEAD
+	OrigHead    string
+	Onto        string
+	Interactive bool
+	Todo        []TodoLine
+}
+
+// stop describes why the rebase stopped: a line that failed, with the
+// message and the unmerged paths of a conflict, or a commit to amend
+type stop struct {
+	Line     *TodoLine
+	Message  string
+	Unmerged []string
+	Amend    string
+}
+
+// InProgress tells if a rebase was started and not finished
+func (rb *Rebase) InProgress() bool {
+	_, err := os.Stat(rb.path(REBASE_DIR))
+	return err == nil
+}
+
+func (rb *Rebase) readState() (state, error) {
+	if !rb.InProgress() {
+		return state{}, fmt.Errorf("no rebase in progress")
+	}
+	st := state{}
+	for name, value := range map[string]*string{"head-name": &st.HeadName, "orig-head": &st.OrigHead, "onto": &st.Onto} {
+		content, err := os.ReadFile(rb.path(REBASE_DIR, name))
+		if err != nil {
+			return state{}, err
+		}
+		*value = strings.TrimSpace(string(content))
+	}
+	_, err := os.Stat(rb.path(REBASE_DIR, "interactive"))
+	st.Interactive = err == nil
+	todo, err := os.ReadFile(rb.path(REBASE_DIR, "todo"))
+	if err != nil && !os.IsNotExist(err) {
+		return state{}, err
+	}
+	if st.Todo, err = rb.parseTodo(string(todo)); err != nil {
+		return state{}, err
+	}
+	return st, nil
+}
+
+func (rb *Rebase) writeState(st state) error {
+	if err := os.MkdirAll(rb.path(REBASE_DIR), 0755); err != nil {
+		return err
+	}
+	for name, value := range map[string]string{"head-name": st.HeadName, "orig-head": st.OrigHead, "onto": st.Onto} {
+		if err := os.WriteFile(rb.path(REBASE_DIR, name), []byte(value+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	if st.Interactive {
+		if err := os.WriteFile(rb.path(REBASE_DIR, "interactive"), nil, 0644); err != nil {
+			return err
+		}
+	}
+	return rb.writeTodo(st.Todo)
+}
+
+func (rb *Rebase) writeTodo(todo []TodoLine) error {
+	return os.WriteFile(rb.path(REBASE_DIR, "todo"), []byte(formatTodo(todo)), 0644)
+}
+
+// appendDone records a line taken from the todo list
+func (rb *Rebase) appendDone(line TodoLine) error {
+	file, err := os.OpenFile(rb.path(REBASE_DIR, "done"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line.String() + "\n")
+	return err
+}
+
+func (rb *Rebase) readStop() stop {
+	st := stop{}
+	if current, err := os.ReadFile(rb.path(REBASE_DIR, "current")); err == nil {
+		if line, err := rb.parseLine(strings.TrimSpace(string(current))); err == nil {
+			st.Line = &line
+		}
+	}
+	if message, err := os.ReadFile(rb.path(REBASE_DIR, "message")); err == nil {
+		st.Message = string(message)
+	}
+	if unmerged, err := os.ReadFile(rb.path(REBASE_DIR, "unmerged")); err == nil {
+		st.Unmerged = strings.Fields(string(unmerged))
+	}
+	if amend, err := os.ReadFile(rb.path(REBASE_DIR, "amend")); err == nil {
+		st.Amend = strings.TrimSpace(string(amend))
+	}
+	return st
+}
+
+func (rb *Rebase) writeStop(st stop) error {
+	files := map[string]string{}
+	if st.Line != nil {
+		files["current"] = st.Line.String() + "\n"
+	}
+	if st.Unmerged != nil {
+		files["message"] = st.Message
+		files["unmerged"] = strings.Join(st.Unmerged, "\n") + "\n"
+	}
+	if st.Amend != "" {
+		files["amend"] = st.Amend + "\n"
+	}
+	for name, content := range files {
+		if err := os.WriteFile(rb.path(REBASE_DIR, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearStop forgets why the rebase stopped
+func (rb *Rebase) clearStop() error {
+	for _, name := range []string{"current", "message", "unmerged", "amend"} {
+		if err := os.Remove(rb.path(REBASE_DIR, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rb *Rebase) removeState() error {
+	return os.RemoveAll(rb.path(REBASE_DIR))
+}
+
+func (rb *Rebase) path(elem ...string) string {
+	return filepath.Join(append([]string{rb.conf.GotDir}, elem...)...)
+}
+
+// editTodo lets the user edit the todo list, with abbreviated hashes and
+// the help appended
+func (rb *Rebase) editTodo(st state) ([]TodoLine, error) {
+	lines := []string{}
+	for _, line := range st.Todo {
+		if line.Command != CMD_EXEC {
+			line.Hash = rb.store.Abbreviate(line.Hash, objects.ABBREV_LENGTH)
+		}
+		lines = append(lines, line.String())
+	}
+	header := fmt.Sprintf("\n# Rebase %s..%s onto %s (%d commands)\n#",
+		rb.store.Abbreviate(st.Onto, objects.ABBREV_LENGTH), rb.store.Abbreviate(st.OrigHead, objects.ABBREV_LENGTH), rb.store.Abbreviate(st.Onto, objects.ABBREV_LENGTH), len(st.Todo))
+	text, err := editor.EditText(rb.path(REBASE_DIR, "got-rebase-todo"), strings.Join(lines, "\n")+"\n"+header+todoHelp)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := rb.parseTodo(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(todo) > 0 && (todo[0].Command == CMD_SQUASH || todo[0].Command == CMD_FIXUP) && !rb.hasDone() {
+		return nil, fmt.Errorf("cannot '%s' without a previous commit", todo[0].Command)
+	}
+	return todo, nil
+}
+
+// parseTodo reads a todo list, skipping blank lines and comments
+func (rb *Rebase) parseTodo(text string) ([]TodoLine, error) {
+	todo := []TodoLine{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parsed, err := rb.parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		todo = append(todo, parsed)
+	}
+	return todo, nil
+}
+
+// parseLine reads "<command> <commit> <subject>" or "exec <command>",
+// expanding abbreviated commands and hashes
+func (rb *Rebase) parseLine(line string) (TodoLine, error) {
+	fields := strings.SplitN(line, " ", 3)
+	command := Command(fields[0])
+	if full, found := abbreviations[fields[0]]; found {
+		command = full
+	}
+	switch command {
+	case CMD_EXEC:
+		if len(fields) < 2 {
+			return TodoLine{}, fmt.Errorf("missing command after exec")
+		}
+		return TodoLine{Command: CMD_EXEC, Exec: strings.TrimSpace(strings.TrimPrefix(line, fields[0]))}, nil
+	case CMD_PICK, CMD_REWORD, CMD_EDIT, CMD_SQUASH, CMD_FIXUP, CMD_DROP:
+	default:
+		return TodoLine{}, fmt.Errorf("invalid line in the todo list: %s", line)
+	}
+	if len(fields) < 2 {
+		return TodoLine{}, fmt.Errorf("missing commit after %s", command)
+	}
+	hash, err := rb.resolver.ResolveCommit(fields[1])
+	if err != nil {
+		return TodoLine{}, fmt.Errorf("invalid commit in the todo list: %s", fields[1])
+	}
+	parsed := TodoLine{Command: command, Hash: hash}
+	if len(fields) == 3 {
+		parsed.Subject = fields[2]
+	}
+	return parsed, nil
+}
+
+// hasDone tells if a line was already taken from the todo list
+func (rb *Rebase) hasDone() bool {
+	done, err := os.ReadFile(rb.path(REBASE_DIR, "done"))
+	return err == nil && len(done) > 0
+}
+
+// String formats the line as it is written in the todo list
+func (l TodoLine) String() string {
+	if l.Command == CMD_EXEC {
+		return fmt.Sprintf("%s %s", l.Command, l.Exec)
+	}
+	return fmt.Sprintf("%s %s %s", l.Command, l.Hash, l.Subject)
+}
+
+func formatTodo(todo []TodoLine) string {
+	var content strings.Builder
+	for _, line := range todo {
+		content.WriteString(line.String() + "\n")
+	}
+	return content.String()
+}
+
+func sameFiles(a, b map[string]models.TreeEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for filePath, entry := range a {
+		if other, found := b[filePath]; !found || other.Hash != entry.Hash || other.Mode != entry.Mode {
+			return false
+		}
+	}
+	return true
+}
+
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n")
+	return subject
+}
@@ -0,0 +1,196 @@
+package reflog
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	reflogs "got_it/internal/reflog"
+	"got_it/internal/revision"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEFAULT_EXPIRE is how old entries must be to be removed by reflog expire
+const DEFAULT_EXPIRE string = "90 days ago"
+
+type Reflog struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	logs     *reflogs.Reflog
+	resolver *revision.Resolver
+	store    *objects.Store
+	out      io.Writer
+}
+
+// ReflogOptions holds the flags of the reflog command
+type ReflogOptions struct {
+	MaxCount int
+	Expire   string
+	All      bool
+}
+
+func NewReflog(conf *config.Config, logger *logger.Logger) *Reflog {
+	return &Reflog{
+		conf:     conf,
+		logger:   logger,
+		logs:     reflogs.NewReflog(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		store:    objects.NewStore(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute runs a reflog subcommand: show (the default), expire or delete
+func Execute(args []string, opts ReflogOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	rf := NewReflog(conf, logger)
+
+	subcommand := "show"
+	if len(args) > 0 && (args[0] == "show" || args[0] == "expire" || args[0] == "delete") {
+		subcommand = args[0]
+		args = args[1:]
+	}
+
+	var err error
+	switch subcommand {
+	case "show":
+		refName := revision.HEAD
+		if len(args) > 0 {
+			refName = args[0]
+		}
+		err = rf.Show(refName, opts.MaxCount)
+	case "expire":
+		err = rf.Expire(args, opts)
+	case "delete":
+		err = rf.Delete(args)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Show writes the entries of the reflog of refName, newest first
+func (rf *Reflog) Show(refName string, maxCount int) error {
+	fullRef, err := rf.fullRefName(refName)
+	if err != nil {
+		return err
+	}
+	entries, err := rf.logs.Read(fullRef)
+	if err != nil {
+		return err
+	}
+	for n := 0; n < len(entries); n++ {
+		if maxCount > 0 && n >= maxCount {
+			break
+		}
+		entry := entries[len(entries)-1-n]
+		fmt.Fprintf(rf.out, "%s %s@{%d}: %s\n", rf.store.Abbreviate(entry.NewHash, objects.ABBREV_LENGTH), refName, n, entry.Message)
+	}
+	return nil
+}
+
+// Expire removes the old entries of the reflogs of the given refs, or of
+// every ref with --all
+func (rf *Reflog) Expire(refNames []string, opts ReflogOptions) error {
+	before, err := expireDate(opts.Expire)
+	if err != nil {
+		return err
+	}
+	fullRefs := []string{}
+	if opts.All {
+		fullRefs, err = rf.logs.List()
+		if err != nil {
+			return err
+		}
+	}
+	for _, refName := range refNames {
+		fullRef, err := rf.fullRefName(refName)
+		if err != nil {
+			return err
+		}
+		fullRefs = append(fullRefs, fullRef)
+	}
+	if len(fullRefs) == 0 {
+		return fmt.Errorf("no reflog specified, use --all or give a ref")
+	}
+	for _, fullRef := range fullRefs {
+		removed, err := rf.logs.Expire(fullRef, before)
+		if err != nil {
+			return err
+		}
+		rf.logger.Debug("Removed %d entries from the reflog of %s", removed, fullRef)
+	}
+	return nil
+}
+
+// Delete removes single entries given as <ref>@{<n>}
+func (rf *Reflog) Delete(selectors []string) error {
+	if len(selectors) == 0 {
+		return fmt.Errorf("no reflog entry specified, use <ref>@{<n>}")
+	}
+	for _, selector := range selectors {
+		at := strings.LastIndex(selector, "@{")
+		if at < 0 || !strings.HasSuffix(selector, "}") {
+			return fmt.Errorf("'%s' is not a reflog entry, use <ref>@{<n>}", selector)
+		}
+		n, err := strconv.Atoi(selector[at+2 : len(selector)-1])
+		if err != nil {
+			return fmt.Errorf("'%s' is not a reflog entry, use <ref>@{<n>}", selector)
+		}
+		fullRef, err := rf.fullRefName(selector[:at])
+		if err != nil {
+			return err
+		}
+		if err := rf.logs.Delete(fullRef, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetOutput changes where the reflog entries are written to
+func (rf *Reflog) SetOutput(out io.Writer) {
+	rf.out = out
+}
+
+// fullRefName finds the ref whose reflog is meant by name; an empty name is
+// the current branch
+func (rf *Reflog) fullRefName(name string) (string, error) {
+	switch name {
+	case "":
+		return rf.resolver.CurrentBranch()
+	case revision.HEAD, "@":
+		return revision.HEAD, nil
+	}
+	if _, fullRef, err := rf.resolver.ResolveRef(name); err == nil {
+		return fullRef, nil
+	}
+	// the ref may be gone while its reflog is still there
+	for _, fullRef := range []string{name, "refs/heads/" + name, "refs/tags/" + name} {
+		if rf.logs.Exists(fullRef) {
+			return fullRef, nil
+		}
+	}
+	return "", fmt.Errorf("no reflog for '%s'", name)
+}
+
+// expireDate turns the value of --expire into the date before which entries
+// are removed
+func expireDate(value string) (time.Time, error) {
+	switch value {
+	case "":
+		value = DEFAULT_EXPIRE
+	case "all":
+		return time.Now().Add(time.Hour), nil
+	case "never", "false":
+		return time.Time{}, nil
+	}
+	return date.Parse(value)
+}
@@ -0,0 +1,71 @@
+package reflog
+
+import (
+	"bytes"
+	"got_it/internal/testrepo"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a repository on main whose reflogs record three commits
+func arrangeRepo(t *testing.T) (*Reflog, []string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	rf := NewReflog(repo.Conf, repo.Logger)
+	t.Setenv("GOT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GOT_COMMITTER_EMAIL", "johndoe@example.com")
+
+	hashes := []string{}
+	previous := ""
+	for i, message := range []string{"commit (initial): one", "commit: two", "commit: three"} {
+		t.Setenv("GOT_COMMITTER_DATE", []string{"2021-01-01", "2021-02-01", "2021-03-01"}[i])
+		hash := repo.Write(message)
+		for _, ref := range []string{"refs/heads/main", "HEAD"} {
+			if err := rf.logs.Append(ref, previous, hash, message); err != nil {
+				t.Fatalf("Error writing reflog: %v", err)
+			}
+		}
+		hashes = append(hashes, hash)
+		previous = hash
+	}
+	repo.SetRef("refs/heads/main", previous)
+	return rf, hashes
+}
+
+func TestShow(t *testing.T) {
+	rf, hashes := arrangeRepo(t)
+	out := &bytes.Buffer{}
+	rf.SetOutput(out)
+	if err := rf.Show("main", 2); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	want := hashes[2][:7] + " main@{0}: commit: three\n" + hashes[1][:7] + " main@{1}: commit: two\n"
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, out.String())
+	}
+}
+
+func TestExpireAndDelete(t *testing.T) {
+	rf, hashes := arrangeRepo(t)
+	if err := rf.Delete([]string{"HEAD@{0}"}); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := rf.Expire(nil, ReflogOptions{All: true, Expire: "2021-01-15"}); err != nil {
+		t.Fatalf("Expire returned error: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	rf.SetOutput(out)
+	rf.Show("HEAD", 0)
+	if out.String() != hashes[1][:7]+" HEAD@{0}: commit: two\n" {
+		t.Errorf("Unexpected HEAD reflog:\n%s", out.String())
+	}
+	out.Reset()
+	rf.Show("main", 0)
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Errorf("Unexpected main reflog:\n%s", out.String())
+	}
+	if err := rf.Delete([]string{"main"}); err == nil {
+		t.Errorf("Expected an error for a selector without @{n}")
+	}
+}
@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"io"
+	"os"
+)
+
+type Remote struct {
+	conf   *config.Config
+	logger *logger.Logger
+	refs   *refs.Store
+	out    io.Writer
+}
+
+// RemoteOptions holds the flags of the remote command
+type RemoteOptions struct {
+	Verbose bool
+}
+
+func NewRemote(conf *config.Config, logger *logger.Logger) *Remote {
+	return &Remote{
+		conf:   conf,
+		logger: logger,
+		refs:   refs.NewStore(conf, logger),
+		out:    os.Stdout,
+	}
+}
+
+// Execute runs a remote subcommand, list when none is given
+func Execute(args []string, opts RemoteOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	rm := NewRemote(conf, logger)
+
+	subcommand := "list"
+	if len(args) > 0 {
+		subcommand = args[0]
+		args = args[1:]
+	}
+	var err error
+	switch {
+	case subcommand == "add" && len(args) == 2:
+		err = rm.Add(args[0], args[1])
+	case (subcommand == "remove" || subcommand == "rm") && len(args) == 1:
+		err = rm.Remove(args[0])
+	case subcommand == "list" && len(args) == 0:
+		err = rm.List(opts)
+	case subcommand == "add" || subcommand == "remove" || subcommand == "rm" || subcommand == "list":
+		err = fmt.Errorf("wrong number of arguments for remote %s", subcommand)
+	default:
+		err = fmt.Errorf("unknown subcommand: %s", subcommand)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Add records a new remote
+func (rm *Remote) Add(name, url string) error {
+	return rm.conf.AddRemote(name, url)
+}
+
+// Remove forgets a remote and deletes its remote-tracking branches
+func (rm *Remote) Remove(name string) error {
+	if err := rm.conf.RemoveRemote(name); err != nil {
+		return err
+	}
+	tracking, err := rm.refs.List("refs/remotes/" + name + "/")
+	if err != nil {
+		return err
+	}
+	for _, ref := range tracking {
+		if err := rm.refs.Delete(ref.Name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List writes the names of the remotes, with their URLs when verbose
+func (rm *Remote) List(opts RemoteOptions) error {
+	remotes, err := rm.conf.Remotes()
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		if opts.Verbose {
+			fmt.Fprintf(rm.out, "%s\t%s (fetch)\n%s\t%s (push)\n", remote.Name, remote.URL, remote.Name, remote.URL)
+		} else {
+			fmt.Fprintln(rm.out, remote.Name)
+		}
+	}
+	return nil
+}
+
+// SetOutput changes where the output is written
+func (rm *Remote) SetOutput(out io.Writer) {
+	rm.out = out
+}
@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"bytes"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemote(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.MkdirAll(filepath.Join(".got", "objects"), 0755)
+	os.WriteFile(filepath.Join(".got", "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	rm := NewRemote(config.NewConfig(), logger.NewLogger(false, false))
+	out := &bytes.Buffer{}
+	rm.SetOutput(out)
+
+	if err := rm.Add("origin", "/srv/project"); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if err := rm.Add("backup", "file:///mnt/project"); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if err := rm.List(RemoteOptions{}); err != nil || out.String() != "origin\nbackup\n" {
+		t.Errorf("Unexpected list %q, %v", out.String(), err)
+	}
+	out.Reset()
+	rm.List(RemoteOptions{Verbose: true})
+	expected := "origin\t/srv/project (fetch)\norigin\t/srv/project (push)\n" +
+		"backup\tfile:///mnt/project (fetch)\nbackup\tfile:///mnt/project (push)\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected verbose list:\n%s", out.String())
+	}
+
+	rm.refs.Update("refs/remotes/origin/main", "0123456789012345678901234567890123456789", refs.UpdateOptions{})
+	if err := rm.Remove("origin"); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if rm.refs.Exists("refs/remotes/origin/main") {
+		t.Errorf("Expected the remote-tracking branches to be deleted")
+	}
+	if err := rm.Remove("origin"); err == nil {
+		t.Errorf("Expected an error removing an unknown remote")
+	}
+}
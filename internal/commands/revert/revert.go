@@ -0,0 +1,61 @@
+package revert
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/sequencer"
+	"io"
+	"os"
+)
+
+type Revert struct {
+	conf      *config.Config
+	logger    *logger.Logger
+	sequencer *sequencer.Sequencer
+}
+
+// RevertOptions holds the flags of the revert command
+type RevertOptions struct {
+	Continue bool
+	Skip     bool
+	Abort    bool
+}
+
+func NewRevert(conf *config.Config, logger *logger.Logger) *Revert {
+	return &Revert{
+		conf:      conf,
+		logger:    logger,
+		sequencer: sequencer.NewSequencer(conf, logger),
+	}
+}
+
+// Execute reverts the commits, or goes on with a stopped revert
+func Execute(revs []string, opts RevertOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	re := NewRevert(conf, logger)
+	if err := re.Revert(revs, opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Revert applies the inverse of the changes introduced by each commit on
+// top of HEAD, creating a new commit for each of them
+func (re *Revert) Revert(revs []string, opts RevertOptions) error {
+	switch {
+	case opts.Continue:
+		return re.sequencer.Continue()
+	case opts.Skip:
+		return re.sequencer.Skip()
+	case opts.Abort:
+		return re.sequencer.Abort()
+	}
+	return re.sequencer.Start(sequencer.ACT_REVERT, revs, sequencer.Options{})
+}
+
+// SetOutput changes where the output is written
+func (re *Revert) SetOutput(out io.Writer) {
+	re.sequencer.SetOutput(out)
+}
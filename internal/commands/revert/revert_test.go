@@ -0,0 +1,47 @@
+package revert
+
+import (
+	"bytes"
+	"got_it/internal/objects"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a root commit adding a.txt and a second commit adding
+// b.txt, and checks out the second one on main
+func arrangeRepo(t *testing.T) (*Revert, *objects.Store) {
+	t.Helper()
+	repo := testrepo.New(t)
+	root := repo.Commit(map[string]string{"a.txt": "a\n"}, "root")
+	second := repo.Commit(map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "add b", root)
+	repo.SetRef("refs/heads/main", second)
+	repo.Checkout(second)
+	return NewRevert(repo.Conf, repo.Logger), repo.Store
+}
+
+func TestRevert(t *testing.T) {
+	re, store := arrangeRepo(t)
+	out := &bytes.Buffer{}
+	re.SetOutput(out)
+
+	if err := re.Revert(nil, RevertOptions{Abort: true}); err == nil {
+		t.Errorf("Expected an error aborting without a revert in progress")
+	}
+	if err := re.Revert([]string{"HEAD"}, RevertOptions{}); err != nil {
+		t.Fatalf("Revert returned error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "] Revert \"add b\"\n") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if _, err := os.Stat("b.txt"); !os.IsNotExist(err) {
+		t.Errorf("Expected b.txt to be removed")
+	}
+	head, _ := os.ReadFile(filepath.Join(".got", "refs", "heads", "main"))
+	commit, err := store.ReadCommit(string(head))
+	if err != nil || !strings.HasPrefix(commit.Message, "Revert \"add b\"\n\nThis reverts commit ") {
+		t.Errorf("Unexpected revert commit %q (%v)", commit.Message, err)
+	}
+}
@@ -0,0 +1,130 @@
+package serve
+
+import (
+	"bufio"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/transport"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DEFAULT_ADDRESS is where the repository is served when no address is given
+const DEFAULT_ADDRESS string = ":8080"
+
+func init() {
+	config.Register(config.KeyInfo{Key: "serve.maxRequestSize", Type: config.TYPE_INT, Default: "1g",
+		Description: "largest request body, pushed pack included, that serve accepts; 0 for no limit"})
+}
+
+type Serve struct {
+	conf   *config.Config
+	logger *logger.Logger
+	out    io.Writer
+}
+
+// ServeOptions holds the flags of the serve command
+type ServeOptions struct {
+	Listen   string
+	ReadOnly bool
+	AuthFile string
+}
+
+func NewServe(conf *config.Config, logger *logger.Logger) *Serve {
+	return &Serve{
+		conf:   conf,
+		logger: logger,
+		out:    os.Stdout,
+	}
+}
+
+// Execute serves the repository at args[0], the current directory by
+// default, until the server fails
+func Execute(args []string, opts ServeOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	sv := NewServe(conf, logger)
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if err := sv.Serve(dir, opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Serve listens on the address of the options and serves the repository
+func (sv *Serve) Serve(dir string, opts ServeOptions) error {
+	handler, err := sv.Handler(dir, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Listen == "" {
+		opts.Listen = DEFAULT_ADDRESS
+	}
+	mode := ""
+	if opts.ReadOnly {
+		mode = " (read-only)"
+	}
+	fmt.Fprintf(sv.out, "Serving %s on %s%s\n", dir, opts.Listen, mode)
+	return http.ListenAndServe(opts.Listen, handler)
+}
+
+// Handler returns the HTTP handler serving the repository at dir
+func (sv *Serve) Handler(dir string, opts ServeOptions) (http.Handler, error) {
+	gotDir, bare, err := transport.FindGotDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	users := map[string]string{}
+	if opts.AuthFile != "" {
+		if users, err = ReadAuthFile(opts.AuthFile); err != nil {
+			return nil, err
+		}
+	}
+	sv.conf.GotDir = gotDir
+	return transport.NewServer(sv.conf, sv.logger, bare, transport.ServerOptions{ReadOnly: opts.ReadOnly, Users: users,
+		MaxRequestSize: sv.conf.Int("serve.maxRequestSize")}), nil
+}
+
+// ReadAuthFile reads the users allowed to reach the server, one
+// "<name>:<password>" line each; empty lines and lines starting with # are
+// skipped
+func ReadAuthFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	users := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, password, found := strings.Cut(line, ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected <name>:<password>", path, number)
+		}
+		users[name] = password
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%s: no users", path)
+	}
+	return users, nil
+}
+
+// SetOutput changes where the output is written
+func (sv *Serve) SetOutput(out io.Writer) {
+	sv.out = out
+}
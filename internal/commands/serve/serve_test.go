@@ -0,0 +1,70 @@
+package serve
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAuthFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	os.WriteFile(path, []byte("# team\nalice:secret\n\nbob:p:ss\n"), 0600)
+	users, err := ReadAuthFile(path)
+	if err != nil {
+		t.Fatalf("ReadAuthFile returned error: %v", err)
+	}
+	if len(users) != 2 || users["alice"] != "secret" || users["bob"] != "p:ss" {
+		t.Errorf("Unexpected users: %v", users)
+	}
+
+	os.WriteFile(path, []byte("alice\n"), 0600)
+	if _, err := ReadAuthFile(path); err == nil {
+		t.Errorf("Expected an error for a line without password")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	sv := NewServe(config.NewConfig(), logger.NewLogger(false, false))
+	if _, err := sv.Handler(dir, ServeOptions{}); err == nil {
+		t.Fatalf("Expected an error serving a directory without repository")
+	}
+
+	os.MkdirAll(filepath.Join(dir, config.GOT_DIR, "objects"), 0755)
+	os.WriteFile(filepath.Join(dir, config.GOT_DIR, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	authFile := filepath.Join(t.TempDir(), "users")
+	os.WriteFile(authFile, []byte("alice:secret\n"), 0600)
+	handler, err := sv.Handler(dir, ServeOptions{ReadOnly: true, AuthFile: authFile})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	for _, tc := range []struct {
+		user     string
+		password string
+		status   int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"alice", "wrong", http.StatusUnauthorized},
+		{"alice", "secret", http.StatusOK},
+	} {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/info/refs", nil)
+		if tc.user != "" {
+			request.SetBasicAuth(tc.user, tc.password)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Error requesting the refs: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != tc.status {
+			t.Errorf("Expected status %d for %s:%s, got %d", tc.status, tc.user, tc.password, response.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package show
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/commands/history"
+	"got_it/internal/date"
+	"got_it/internal/diff"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/revision"
+	"io"
+	"os"
+	"time"
+)
+
+type Show struct {
+	conf   *config.Config
+	logger *logger.Logger
+	store  *objects.Store
+	out    io.Writer
+}
+
+// ShowOptions holds the flags of the show command
+type ShowOptions struct {
+	Stat       bool
+	NameOnly   bool
+	NameStatus bool
+	DateMode   string
+}
+
+func NewShow(conf *config.Config, logger *logger.Logger) *Show {
+	return &Show{
+		conf:   conf,
+		logger: logger,
+		store:  objects.NewStore(conf, logger),
+		out:    os.Stdout,
+	}
+}
+
+// Execute shows every object named by the revisions (HEAD by default)
+func Execute(revisions []string, opts ShowOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	sh := NewShow(conf, logger)
+	if len(revisions) == 0 {
+		revisions = []string{revision.HEAD}
+	}
+	for _, rev := range revisions {
+		if err := sh.Show(rev, opts); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+}
+
+// Show writes the object named by rev: a commit with its changes, the entries
+// of a tree or the content of a blob
+func (sh *Show) Show(rev string, opts ShowOptions) error {
+	resolver := revision.NewResolver(sh.conf, sh.logger)
+	hash, err := resolver.Resolve(rev)
+	if err != nil {
+		return err
+	}
+	content, err := sh.store.Read(hash)
+	if err != nil {
+		return err
+	}
+
+	switch objects.TypeOf(content) {
+	case models.OT_COMMIT:
+		return sh.showCommit(hash, opts)
+	case models.OT_TREE:
+		return sh.showTree(rev, hash)
+	case models.OT_TAG:
+		return sh.showTag(hash, opts)
+	}
+	fmt.Fprint(sh.out, content)
+	return nil
+}
+
+// showTag writes the header and message of an annotated tag followed by the
+// object it points to
+func (sh *Show) showTag(hash string, opts ShowOptions) error {
+	tag, err := sh.store.ReadTag(hash)
+	if err != nil {
+		return err
+	}
+	dateMode, err := date.ParseMode(opts.DateMode)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sh.out, "tag %s\nTagger: %s <%s>\nDate: %s\n\n%s\n\n",
+		tag.Tag, tag.TaggerName, tag.TaggerEmail, date.FormatMode(tag.TaggerDate, dateMode, time.Now()), tag.Message)
+	return sh.Show(tag.Object, opts)
+}
+
+// showCommit writes the commit header and message followed by its changes
+// against the first parent
+func (sh *Show) showCommit(hash string, opts ShowOptions) error {
+	hi := history.NewHistory(sh.conf, sh.logger)
+	hi.SetOutput(sh.out)
+	if err := hi.Log([]string{hash}, nil, history.LogOptions{MaxCount: 1, DateMode: opts.DateMode}); err != nil {
+		return err
+	}
+
+	commit, err := sh.store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+	changes, err := sh.changesFromParent(commit)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	fmt.Fprintln(sh.out)
+
+	switch {
+	case opts.NameOnly:
+		for _, change := range changes {
+			fmt.Fprintln(sh.out, change.Path)
+		}
+	case opts.NameStatus:
+		for _, change := range changes {
+			fmt.Fprintf(sh.out, "%s\t%s\n", change.Status, change.Path)
+		}
+	case opts.Stat:
+		stats := []diff.FileStat{}
+		for _, change := range changes {
+			oldText, newText, err := sh.readChange(&change)
+			if err != nil {
+				return err
+			}
+			stats = append(stats, diff.ComputeStat(change, oldText, newText))
+		}
+		fmt.Fprint(sh.out, diff.FormatStat(stats))
+	default:
+		for _, change := range changes {
+			oldText, newText, err := sh.readChange(&change)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(sh.out, diff.Patch(change, oldText, newText))
+		}
+	}
+	return nil
+}
+
+// changesFromParent compares the tree of the commit with the one of its first parent
+func (sh *Show) changesFromParent(commit models.CommitData) ([]diff.Change, error) {
+	files, err := sh.store.FlattenTree(commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+	parentFiles := map[string]models.TreeEntry{}
+	if commit.Parent != "" {
+		parent, err := sh.store.ReadCommit(commit.Parent)
+		if err != nil {
+			return nil, err
+		}
+		parentFiles, err = sh.store.FlattenTree(parent.Tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diff.CompareTrees(parentFiles, files), nil
+}
+
+// readChange returns the old and new content of a changed file. Files
+// above core.bigFileThreshold are not read, the change is marked binary.
+func (sh *Show) readChange(change *diff.Change) (string, string, error) {
+	if sh.store.IsBig(change.Old.Hash) || sh.store.IsBig(change.New.Hash) {
+		change.Binary = true
+		return "", "", nil
+	}
+	oldText, newText := "", ""
+	var err error
+	if change.Old.Hash != "" {
+		if oldText, err = sh.store.Read(change.Old.Hash); err != nil {
+			return "", "", err
+		}
+	}
+	if change.New.Hash != "" {
+		if newText, err = sh.store.Read(change.New.Hash); err != nil {
+			return "", "", err
+		}
+	}
+	return oldText, newText, nil
+}
+
+// showTree writes the names of the tree entries, directories ending with a slash
+func (sh *Show) showTree(rev, hash string) error {
+	entries, err := sh.store.ReadTree(hash)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sh.out, "tree %s\n\n", rev)
+	for _, entry := range entries {
+		name := entry.Name
+		if entry.Type == string(models.TT_TREE) {
+			name += "/"
+		}
+		fmt.Fprintln(sh.out, name)
+	}
+	return nil
+}
+
+// SetOutput changes where the output is written
+func (sh *Show) SetOutput(out io.Writer) {
+	sh.out = out
+}
@@ -0,0 +1,108 @@
+package show
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/testrepo"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates two commits: the first adds a.txt and b.txt, the
+// second changes a.txt, deletes b.txt and adds dir/c.txt
+func arrangeRepo(t *testing.T) *Show {
+	t.Helper()
+	repo := testrepo.New(t)
+	write := repo.Write
+	tree1 := write(fmt.Sprintf("100644 blob %s\ta.txt\n100644 blob %s\tb.txt\n", write("1\n2\n3\n"), write("b\n")))
+	subTree := fmt.Sprintf("100644 blob %s\tc.txt\n", write("c\n"))
+	tree2 := write(fmt.Sprintf("100644 blob %s\ta.txt\n040000 tree %s\tdir\n%s", write("1\ntwo\n3\n"), write(subTree), subTree))
+	commit1 := write(fmt.Sprintf("tree %s\nauthor A U Thor <author@example.com> 1623501234 +0200\n"+
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\nfirst\n", tree1))
+	commit2 := write(fmt.Sprintf("tree %s\nparent %s\nauthor A U Thor <author@example.com> 1623501300 +0200\n"+
+		"committer A U Thor <author@example.com> 1623501300 +0200\n\nsecond\n", tree2, commit1))
+
+	repo.SetRef("refs/heads/main", commit2)
+	tag := write(fmt.Sprintf("object %s\ntype commit\ntag v1\ntagger A U Thor <author@example.com> 1623501234 +0200\n\nversion one\n", commit1))
+	repo.SetRef("refs/tags/v1", tag)
+	return NewShow(repo.Conf, repo.Logger)
+}
+
+func TestShowCommit(t *testing.T) {
+	sh := arrangeRepo(t)
+	tests := []struct {
+		name     string
+		rev      string
+		opts     ShowOptions
+		contains []string
+	}{
+		{"patch", "HEAD", ShowOptions{}, []string{
+			"(HEAD -> main)",
+			"    second\n",
+			"diff --got a/a.txt b/a.txt\n",
+			"@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n",
+			"deleted file mode 100644\n",
+			"--- a/b.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-b\n",
+			"new file mode 100644\n",
+			"--- /dev/null\n+++ b/dir/c.txt\n",
+		}},
+		{"stat", "HEAD", ShowOptions{Stat: true}, []string{
+			" a.txt     | 2 +-\n b.txt     | 1 -\n dir/c.txt | 1 +\n",
+			" 3 files changed, 2 insertions(+), 2 deletions(-)\n",
+		}},
+		{"name only", "HEAD", ShowOptions{NameOnly: true}, []string{"\na.txt\nb.txt\ndir/c.txt\n"}},
+		{"name status", "HEAD", ShowOptions{NameStatus: true}, []string{"\nM\ta.txt\nD\tb.txt\nA\tdir/c.txt\n"}},
+		{"root commit", "HEAD~1", ShowOptions{NameStatus: true}, []string{"    first\n", "\nA\ta.txt\nA\tb.txt\n"}},
+		{"annotated tag", "v1", ShowOptions{NameOnly: true, DateMode: "iso"}, []string{
+			"tag v1\nTagger: A U Thor <author@example.com>\nDate: 2021-06-12 14:33:54 +0200\n\nversion one\n\n",
+			"(tag: v1)",
+			"    first\n",
+		}},
+		{"tree", "HEAD^{tree}", ShowOptions{}, []string{"tree HEAD^{tree}\n\na.txt\ndir/\n"}},
+		{"blob", "HEAD:dir/c.txt", ShowOptions{}, []string{"c\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			sh.SetOutput(out)
+			if err := sh.Show(tt.rev, tt.opts); err != nil {
+				t.Fatalf("Show returned error: %v", err)
+			}
+			for _, expected := range tt.contains {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestShowBigFile(t *testing.T) {
+	sh := arrangeRepo(t)
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	// the blobs of a.txt are 6 and 8 bytes long
+	sh.conf.SetConfigKeyValue("core.bigFileThreshold", "7")
+
+	out := &bytes.Buffer{}
+	sh.SetOutput(out)
+	if err := sh.Show("HEAD", ShowOptions{}); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Binary files a/a.txt and b/a.txt differ\n") {
+		t.Errorf("Expected a.txt to be shown as binary, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "--- a/b.txt\n+++ /dev/null\n") {
+		t.Errorf("Expected the small files to be diffed, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := sh.Show("HEAD", ShowOptions{Stat: true}); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), " a.txt     | Bin\n") {
+		t.Errorf("Expected a.txt to be counted as binary, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,341 @@
+package stash
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/logger"
+	"got_it/internal/merge"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/reflog"
+	"got_it/internal/refs"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+)
+
+// STASH_REF is the ref pointing to the newest stash; older ones are only
+// reachable through its reflog
+const STASH_REF string = "refs/stash"
+
+type Stash struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	logs     *reflog.Reflog
+	worktree *worktree.Worktree
+	out      io.Writer
+}
+
+// StashOptions holds the flags of the stash command
+type StashOptions struct {
+	Message          string
+	IncludeUntracked bool
+	Patch            bool
+}
+
+func NewStash(conf *config.Config, logger *logger.Logger) *Stash {
+	return &Stash{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		logs:     reflog.NewReflog(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute runs a stash subcommand, push when none is given
+func Execute(args []string, opts StashOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	st := NewStash(conf, logger)
+
+	subcommand := "push"
+	if len(args) > 0 {
+		subcommand = args[0]
+		args = args[1:]
+	}
+	stashName := ""
+	if len(args) > 0 {
+		stashName = args[0]
+	}
+
+	var err error
+	switch subcommand {
+	case "push":
+		err = st.Push(opts)
+	case "list":
+		err = st.List()
+	case "show":
+		err = st.Show(stashName, opts)
+	case "apply":
+		_, err = st.Apply(stashName)
+	case "pop":
+		err = st.Pop(stashName)
+	case "drop":
+		err = st.Drop(stashName)
+	case "clear":
+		err = st.Clear()
+	default:
+		err = fmt.Errorf("unknown subcommand: %s", subcommand)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Push saves the staged and unstaged changes, and the untracked files when
+// asked to, then resets the worktree and the index to HEAD
+func (st *Stash) Push(opts StashOptions) error {
+	headFiles, headHash, err := st.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	if headHash == "" {
+		return fmt.Errorf("you do not have the initial commit yet")
+	}
+	status, err := st.worktree.Status()
+	if err != nil {
+		return err
+	}
+	untracked := []string{}
+	if opts.IncludeUntracked {
+		untracked = status.Untracked
+	}
+	if status.IsClean() && len(untracked) == 0 {
+		fmt.Fprintln(st.out, "No local changes to save")
+		return nil
+	}
+
+	description, err := st.describeHead(headHash)
+	if err != nil {
+		return err
+	}
+	indexFiles, err := st.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	indexCommit, err := st.writeCommit(indexFiles, []string{headHash}, "index on "+description)
+	if err != nil {
+		return err
+	}
+	parents := []string{headHash, indexCommit}
+
+	untrackedFiles, err := st.worktree.StoreFiles(untracked)
+	if err != nil {
+		return err
+	}
+	if len(untrackedFiles) > 0 {
+		untrackedCommit, err := st.writeCommit(untrackedFiles, nil, "untracked files on "+description)
+		if err != nil {
+			return err
+		}
+		parents = append(parents, untrackedCommit)
+	}
+
+	paths := []string{}
+	for filePath := range indexFiles {
+		paths = append(paths, filePath)
+	}
+	workingFiles, err := st.worktree.StoreFiles(paths)
+	if err != nil {
+		return err
+	}
+	message := "WIP on " + description
+	if opts.Message != "" {
+		message = fmt.Sprintf("On %s: %s", st.branchName(), opts.Message)
+	}
+	stashCommit, err := st.writeCommit(workingFiles, parents, message)
+	if err != nil {
+		return err
+	}
+	if err := st.refs.Update(STASH_REF, stashCommit, refs.UpdateOptions{Message: message}); err != nil {
+		return err
+	}
+
+	// everything saved is removed from the worktree, untracked files included
+	for filePath, entry := range untrackedFiles {
+		workingFiles[filePath] = entry
+	}
+	if err := st.worktree.Checkout(workingFiles, headFiles); err != nil {
+		return err
+	}
+	if err := st.worktree.WriteIndex(headFiles); err != nil {
+		return err
+	}
+	fmt.Fprintf(st.out, "Saved working directory and index state %s\n", message)
+	return nil
+}
+
+// List writes the stashes, newest first
+func (st *Stash) List() error {
+	if !st.logs.Exists(STASH_REF) {
+		return nil
+	}
+	entries, err := st.logs.Read(STASH_REF)
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Fprintf(st.out, "stash@{%d}: %s\n", len(entries)-1-i, entries[i].Message)
+	}
+	return nil
+}
+
+// Show writes the changes recorded in a stash against the commit it was
+// made on, as a diffstat or as a patch
+func (st *Stash) Show(stashName string, opts StashOptions) error {
+	_, stash, err := st.readStash(stashName)
+	if err != nil {
+		return err
+	}
+	baseFiles, err := st.worktree.CommitFiles(stash.Parents[0])
+	if err != nil {
+		return err
+	}
+	stashFiles, err := st.store.FlattenTree(stash.Tree)
+	if err != nil {
+		return err
+	}
+
+	stats := []diff.FileStat{}
+	for _, change := range diff.CompareTrees(baseFiles, stashFiles) {
+		oldText, newText, err := st.readChange(&change)
+		if err != nil {
+			return err
+		}
+		if opts.Patch {
+			fmt.Fprint(st.out, diff.Patch(change, oldText, newText))
+		} else {
+			stats = append(stats, diff.ComputeStat(change, oldText, newText))
+		}
+	}
+	if !opts.Patch {
+		fmt.Fprint(st.out, diff.FormatStat(stats))
+	}
+	return nil
+}
+
+// Apply merges the changes of a stash into the worktree. Files the stash
+// added are staged and its untracked files are restored. It returns whether
+// some files were left with conflicts.
+func (st *Stash) Apply(stashName string) (bool, error) {
+	_, stash, err := st.readStash(stashName)
+	if err != nil {
+		return false, err
+	}
+	baseFiles, err := st.worktree.CommitFiles(stash.Parents[0])
+	if err != nil {
+		return false, err
+	}
+	stashFiles, err := st.store.FlattenTree(stash.Tree)
+	if err != nil {
+		return false, err
+	}
+	headFiles, _, err := st.worktree.HeadFiles()
+	if err != nil {
+		return false, err
+	}
+	untrackedFiles := map[string]models.TreeEntry{}
+	if len(stash.Parents) > 2 {
+		if untrackedFiles, err = st.worktree.CommitFiles(stash.Parents[2]); err != nil {
+			return false, err
+		}
+	}
+
+	labels := merge.Labels{Ours: "Updated upstream", Theirs: "Stashed changes"}
+	result, err := merge.Trees(st.store, baseFiles, headFiles, stashFiles, labels)
+	if err != nil {
+		return false, err
+	}
+	if err := st.worktree.CheckConflicts(headFiles, result.Files); err != nil {
+		return false, err
+	}
+	for filePath := range untrackedFiles {
+		if _, err := st.worktree.ReadFile(filePath); err == nil {
+			return false, fmt.Errorf("%s already exists, no checkout", filePath)
+		}
+	}
+
+	indexFiles, err := st.worktree.IndexFiles()
+	if err != nil {
+		return false, err
+	}
+	if err := st.worktree.Checkout(headFiles, result.Files); err != nil {
+		return false, err
+	}
+	for filePath, entry := range result.Files {
+		if _, inHead := headFiles[filePath]; !inHead {
+			indexFiles[filePath] = entry
+		}
+	}
+	if err := st.worktree.WriteIndex(indexFiles); err != nil {
+		return false, err
+	}
+	for filePath, entry := range untrackedFiles {
+		if err := st.worktree.WriteFile(filePath, entry); err != nil {
+			return false, err
+		}
+	}
+
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintln(st.out, conflict)
+	}
+	return len(result.Conflicts) > 0, nil
+}
+
+// Pop applies a stash and drops it, unless applying it left conflicts
+func (st *Stash) Pop(stashName string) error {
+	conflicted, err := st.Apply(stashName)
+	if err != nil {
+		return err
+	}
+	if conflicted {
+		fmt.Fprintln(st.out, "The stash entry is kept in case you need it again.")
+		return nil
+	}
+	return st.Drop(stashName)
+}
+
+// Drop removes a stash from the list
+func (st *Stash) Drop(stashName string) error {
+	n, stashHash, err := st.resolveStash(stashName)
+	if err != nil {
+		return err
+	}
+	if err := st.logs.Delete(STASH_REF, n); err != nil {
+		return err
+	}
+	entries, err := st.logs.Read(STASH_REF)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		err = st.refs.Delete(STASH_REF, "")
+	} else {
+		err = st.refs.Update(STASH_REF, entries[len(entries)-1].NewHash, refs.UpdateOptions{NoLog: true})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(st.out, "Dropped stash@{%d} (%s)\n", n, stashHash)
+	return nil
+}
+
+// Clear removes every stash
+func (st *Stash) Clear() error {
+	if !st.refs.Exists(STASH_REF) {
+		return nil
+	}
+	return st.refs.Delete(STASH_REF, "")
+}
+
+// SetOutput changes where the output is written
+func (st *Stash) SetOutput(out io.Writer) {
+	st.out = out
+}
@@ -0,0 +1,199 @@
+package stash
+
+import (
+	"bytes"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a commit holding a.txt and b.txt on main and returns
+// a stash command writing to out
+func arrangeRepo(t *testing.T) (*Stash, *bytes.Buffer) {
+	t.Helper()
+	repo := testrepo.New(t)
+	t.Setenv("GOT_AUTHOR_NAME", "A U Thor")
+	t.Setenv("GOT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GOT_COMMITTER_NAME", "A U Thor")
+	t.Setenv("GOT_COMMITTER_EMAIL", "author@example.com")
+
+	st := NewStash(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	st.SetOutput(out)
+	testrepo.WriteFile(t, "a.txt", "1\n2\n3\n")
+	testrepo.WriteFile(t, "b.txt", "b\n")
+	commitAll(t, st, []string{"a.txt", "b.txt"}, "first")
+	return st, out
+}
+
+// commitAll stages the files and commits them on top of HEAD
+func commitAll(t *testing.T, st *Stash, paths []string, message string) string {
+	t.Helper()
+	files, err := st.worktree.StoreFiles(paths)
+	if err != nil {
+		t.Fatalf("Error storing files: %v", err)
+	}
+	if err := st.worktree.WriteIndex(files); err != nil {
+		t.Fatalf("Error writing index: %v", err)
+	}
+	parents := []string{}
+	if head, _, err := st.refs.Resolve(refs.HEAD); err == nil {
+		parents = append(parents, head)
+	}
+	hash, err := st.writeCommit(files, parents, message)
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	if err := st.refs.Update(refs.HEAD, hash, refs.UpdateOptions{Message: "commit: " + message}); err != nil {
+		t.Fatalf("Error updating HEAD: %v", err)
+	}
+	return hash
+}
+
+func TestPushAndPop(t *testing.T) {
+	st, out := arrangeRepo(t)
+	testrepo.WriteFile(t, "a.txt", "1\n2\nthree\n")
+	os.Remove("b.txt")
+	testrepo.WriteFile(t, "dir/new.txt", "new\n")
+	testrepo.WriteFile(t, "untracked.txt", "u\n")
+	// new.txt is staged, untracked.txt is not
+	files, _ := st.worktree.IndexFiles()
+	staged, _ := st.worktree.StoreFiles([]string{"dir/new.txt"})
+	files["dir/new.txt"] = staged["dir/new.txt"]
+	st.worktree.WriteIndex(files)
+
+	if err := st.Push(StashOptions{IncludeUntracked: true}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Saved working directory and index state WIP on main: ") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	testrepo.AssertFile(t, "a.txt", "1\n2\n3\n")
+	testrepo.AssertFile(t, "b.txt", "b\n")
+	testrepo.AssertMissing(t, "dir/new.txt")
+	testrepo.AssertMissing(t, "dir")
+	testrepo.AssertMissing(t, "untracked.txt")
+	status, _ := st.worktree.Status()
+	if !status.IsClean() || len(status.Untracked) != 0 {
+		t.Errorf("Expected a clean worktree after push, got %+v", status)
+	}
+
+	// HEAD moves on before the stash is applied back
+	testrepo.WriteFile(t, "a.txt", "one\n2\n3\n")
+	commitAll(t, st, []string{"a.txt", "b.txt"}, "second")
+
+	out.Reset()
+	if err := st.Pop(""); err != nil {
+		t.Fatalf("Pop returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Dropped stash@{0} (") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	testrepo.AssertFile(t, "a.txt", "one\n2\nthree\n")
+	testrepo.AssertMissing(t, "b.txt")
+	testrepo.AssertFile(t, "dir/new.txt", "new\n")
+	testrepo.AssertFile(t, "untracked.txt", "u\n")
+	index, _ := st.worktree.IndexFiles()
+	if _, found := index["dir/new.txt"]; !found {
+		t.Errorf("Expected dir/new.txt to be staged, got %v", index)
+	}
+	if _, found := index["untracked.txt"]; found {
+		t.Errorf("Expected untracked.txt to stay untracked")
+	}
+	if st.refs.Exists(STASH_REF) {
+		t.Errorf("Expected %s to be deleted with the last stash", STASH_REF)
+	}
+}
+
+func TestListShowDropClear(t *testing.T) {
+	st, out := arrangeRepo(t)
+	if err := st.Push(StashOptions{}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if out.String() != "No local changes to save\n" {
+		t.Errorf("Unexpected output for a clean worktree: %q", out.String())
+	}
+
+	testrepo.WriteFile(t, "a.txt", "1\n2\n3\n4\n")
+	st.Push(StashOptions{})
+	testrepo.WriteFile(t, "b.txt", "bb\n")
+	st.Push(StashOptions{Message: "change b"})
+
+	out.Reset()
+	st.List()
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "stash@{0}: On main: change b" || !strings.HasPrefix(lines[1], "stash@{1}: WIP on main: ") ||
+		!strings.HasSuffix(lines[1], " first") {
+		t.Errorf("Unexpected list:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := st.Show("stash@{1}", StashOptions{}); err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), " a.txt | 1 +\n 1 file changed, 1 insertion(+)\n") {
+		t.Errorf("Unexpected stat:\n%s", out.String())
+	}
+	out.Reset()
+	st.Show("", StashOptions{Patch: true})
+	if !strings.Contains(out.String(), "--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+bb\n") {
+		t.Errorf("Unexpected patch:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := st.Drop("stash@{0}"); err != nil {
+		t.Fatalf("Drop returned error: %v", err)
+	}
+	out.Reset()
+	st.List()
+	if !strings.HasPrefix(out.String(), "stash@{0}: WIP on main: ") {
+		t.Errorf("Expected the older stash to move up, got:\n%s", out.String())
+	}
+	if _, err := st.Apply("stash@{1}"); err == nil {
+		t.Errorf("Expected an error applying a missing stash")
+	}
+
+	if err := st.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	out.Reset()
+	st.List()
+	if out.String() != "" {
+		t.Errorf("Expected no stashes after clear, got:\n%s", out.String())
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	st, out := arrangeRepo(t)
+	testrepo.WriteFile(t, "a.txt", "1\nstashed\n3\n")
+	st.Push(StashOptions{})
+	testrepo.WriteFile(t, "a.txt", "1\ncommitted\n3\n")
+	commitAll(t, st, []string{"a.txt", "b.txt"}, "second")
+
+	out.Reset()
+	if err := st.Pop(""); err != nil {
+		t.Fatalf("Pop returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "CONFLICT (content): Merge conflict in a.txt\n") ||
+		!strings.Contains(out.String(), "The stash entry is kept") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	testrepo.AssertFile(t, "a.txt", "1\n<<<<<<< Updated upstream\ncommitted\n=======\nstashed\n>>>>>>> Stashed changes\n3\n")
+	if !st.refs.Exists(STASH_REF) {
+		t.Errorf("Expected the stash to be kept after a conflict")
+	}
+}
+
+func TestApplyRefusesLocalChanges(t *testing.T) {
+	st, _ := arrangeRepo(t)
+	testrepo.WriteFile(t, "a.txt", "stashed\n")
+	st.Push(StashOptions{})
+	testrepo.WriteFile(t, "a.txt", "local\n")
+
+	if _, err := st.Apply(""); err == nil || !strings.Contains(err.Error(), "a.txt") {
+		t.Errorf("Expected an error naming a.txt, got %v", err)
+	}
+	testrepo.AssertFile(t, "a.txt", "local\n")
+}
@@ -0,0 +1,124 @@
+package stash
+
+import (
+	"fmt"
+	"got_it/internal/diff"
+	"got_it/internal/ident"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var stashNamePattern = regexp.MustCompile(`^(?:(?:refs/)?stash@\{(\d+)\}|(\d+))$`)
+
+// resolveStash returns the position of a stash in the list and its hash;
+// an empty name is the newest stash
+func (st *Stash) resolveStash(stashName string) (int, string, error) {
+	n := 0
+	if stashName != "" {
+		match := stashNamePattern.FindStringSubmatch(stashName)
+		if match == nil {
+			return 0, "", fmt.Errorf("'%s' is not a stash reference", stashName)
+		}
+		n, _ = strconv.Atoi(match[1] + match[2])
+	}
+	if !st.refs.Exists(STASH_REF) {
+		return 0, "", fmt.Errorf("no stash entries found")
+	}
+	entries, err := st.logs.Read(STASH_REF)
+	if err != nil {
+		return 0, "", err
+	}
+	if n >= len(entries) {
+		return 0, "", fmt.Errorf("stash@{%d} does not exist, there are only %d stash entries", n, len(entries))
+	}
+	return n, entries[len(entries)-1-n].NewHash, nil
+}
+
+// readStash returns the position and the commit of a stash, checking it has
+// the parents a stash is made of
+func (st *Stash) readStash(stashName string) (int, models.CommitData, error) {
+	n, stashHash, err := st.resolveStash(stashName)
+	if err != nil {
+		return 0, models.CommitData{}, err
+	}
+	stash, err := st.store.ReadCommit(stashHash)
+	if err != nil {
+		return 0, models.CommitData{}, err
+	}
+	if len(stash.Parents) < 2 {
+		return 0, models.CommitData{}, fmt.Errorf("stash@{%d} is not a stash-like commit", n)
+	}
+	return n, stash, nil
+}
+
+// writeCommit stores the files as a tree and a commit on top of parents
+func (st *Stash) writeCommit(files map[string]models.TreeEntry, parents []string, message string) (string, error) {
+	tree, err := st.store.WriteTree(files)
+	if err != nil {
+		return "", err
+	}
+	author, err := ident.Author(st.conf)
+	if err != nil {
+		return "", err
+	}
+	committer, err := ident.Committer(st.conf)
+	if err != nil {
+		return "", err
+	}
+	return st.store.WriteCommit(models.CommitData{
+		Tree:           tree,
+		Parents:        parents,
+		AuthorName:     author.Name,
+		AuthorEmail:    author.Email,
+		AuthorDate:     author.Date,
+		CommitterName:  committer.Name,
+		CommitterEmail: committer.Email,
+		CommitterDate:  committer.Date,
+		Message:        message,
+	})
+}
+
+// describeHead returns "<branch>: <short hash> <subject>" for HEAD, as used
+// in the stash messages
+func (st *Stash) describeHead(headHash string) (string, error) {
+	head, err := st.store.ReadCommit(headHash)
+	if err != nil {
+		return "", err
+	}
+	subject, _, _ := strings.Cut(head.Message, "\n")
+	return fmt.Sprintf("%s: %s %s", st.branchName(), st.store.Abbreviate(headHash, objects.ABBREV_LENGTH), subject), nil
+}
+
+// branchName returns the short name of the current branch
+func (st *Stash) branchName() string {
+	branch, err := st.refs.CurrentBranch()
+	if err != nil {
+		return "(no branch)"
+	}
+	return strings.TrimPrefix(branch, "refs/heads/")
+}
+
+// readChange returns the old and new content of a changed file. Files
+// above core.bigFileThreshold are not read, the change is marked binary.
+func (st *Stash) readChange(change *diff.Change) (string, string, error) {
+	if st.store.IsBig(change.Old.Hash) || st.store.IsBig(change.New.Hash) {
+		change.Binary = true
+		return "", "", nil
+	}
+	oldText, newText := "", ""
+	var err error
+	if change.Old.Hash != "" {
+		if oldText, err = st.store.Read(change.Old.Hash); err != nil {
+			return "", "", err
+		}
+	}
+	if change.New.Hash != "" {
+		if newText, err = st.store.Read(change.New.Hash); err != nil {
+			return "", "", err
+		}
+	}
+	return oldText, newText, nil
+}
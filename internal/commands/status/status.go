@@ -0,0 +1,202 @@
+package status
+
+import (
+	"fmt"
+	"got_it/internal/commands/branch"
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type Status struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	worktree *worktree.Worktree
+	branch   *branch.Branch
+	out      io.Writer
+}
+
+// StatusOptions holds the flags of the status command
+type StatusOptions struct {
+	Short  bool
+	Branch bool // show the branch and its tracking in the short format
+}
+
+func NewStatus(conf *config.Config, logger *logger.Logger) *Status {
+	return &Status{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		branch:   branch.NewBranch(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Execute shows the status of the working tree
+func Execute(opts StatusOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	st := NewStatus(conf, logger)
+
+	if err := st.Status(opts); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Status writes the current branch, how it compares with its upstream and
+// the staged, modified and untracked files
+func (st *Status) Status(opts StatusOptions) error {
+	status, err := st.worktree.Status()
+	if err != nil {
+		return err
+	}
+	if opts.Short {
+		if opts.Branch {
+			if err := st.writeShortBranch(); err != nil {
+				return err
+			}
+		}
+		st.writeShort(status)
+		return nil
+	}
+	if err := st.writeBranch(); err != nil {
+		return err
+	}
+	st.writeLong(status)
+	return nil
+}
+
+// writeBranch writes the branch HEAD is on and how it compares with its
+// upstream
+func (st *Status) writeBranch() error {
+	current, err := st.refs.CurrentBranch()
+	if err != nil || current == "" {
+		hash, _, _ := st.refs.Resolve(refs.HEAD)
+		fmt.Fprintf(st.out, "HEAD detached at %s\n", st.store.Abbreviate(hash, objects.ABBREV_LENGTH))
+		return nil
+	}
+	name := strings.TrimPrefix(current, branch.BRANCH_PREFIX)
+	fmt.Fprintf(st.out, "On branch %s\n", name)
+	tracking, found, err := st.branch.Tracking(name)
+	if err != nil {
+		return err
+	}
+	if found {
+		fmt.Fprint(st.out, trackingMessage(tracking))
+	}
+	if !st.refs.Exists(current) {
+		fmt.Fprintln(st.out, "\nNo commits yet")
+	}
+	return nil
+}
+
+// writeShortBranch writes "## <branch>...<upstream> [ahead N, behind M]"
+func (st *Status) writeShortBranch() error {
+	current, err := st.refs.CurrentBranch()
+	if err != nil || current == "" {
+		fmt.Fprintln(st.out, "## HEAD (no branch)")
+		return nil
+	}
+	name := strings.TrimPrefix(current, branch.BRANCH_PREFIX)
+	line := "## " + name
+	if !st.refs.Exists(current) {
+		line = "## No commits yet on " + name
+	}
+	tracking, found, err := st.branch.Tracking(name)
+	if err != nil {
+		return err
+	}
+	if found {
+		line += "..." + tracking.Upstream
+		if summary := tracking.Summary(false); summary != "" {
+			line += " [" + summary + "]"
+		}
+	}
+	fmt.Fprintln(st.out, line)
+	return nil
+}
+
+// writeLong writes the changes grouped by where they are
+func (st *Status) writeLong(status worktree.Status) {
+	if len(status.Staged) > 0 {
+		fmt.Fprintln(st.out, "\nChanges to be committed:")
+		writeChanges(st.out, status.Staged)
+	}
+	if len(status.Unstaged) > 0 {
+		fmt.Fprintln(st.out, "\nChanges not staged for commit:")
+		writeChanges(st.out, status.Unstaged)
+	}
+	if len(status.Untracked) > 0 {
+		fmt.Fprintln(st.out, "\nUntracked files:")
+		for _, path := range status.Untracked {
+			fmt.Fprintf(st.out, "\t%s\n", path)
+		}
+	}
+	fmt.Fprintln(st.out)
+	switch {
+	case len(status.Staged) > 0:
+	case len(status.Unstaged) > 0:
+		fmt.Fprintln(st.out, `no changes added to commit (use "got add")`)
+	case len(status.Untracked) > 0:
+		fmt.Fprintln(st.out, `nothing added to commit but untracked files present (use "got add" to track)`)
+	default:
+		fmt.Fprintln(st.out, "nothing to commit, working tree clean")
+	}
+}
+
+// writeShort writes a "XY path" line per file, X for the index and Y for
+// the files, and "?? path" for the untracked ones
+func (st *Status) writeShort(status worktree.Status) {
+	codes := map[string][]byte{}
+	paths := []string{}
+	code := func(path string) []byte {
+		if _, found := codes[path]; !found {
+			codes[path] = []byte("  ")
+			paths = append(paths, path)
+		}
+		return codes[path]
+	}
+	for _, change := range status.Staged {
+		code(change.Path)[0] = change.Status[0]
+	}
+	for _, change := range status.Unstaged {
+		code(change.Path)[1] = change.Status[0]
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(st.out, "%s %s\n", codes[path], path)
+	}
+	for _, path := range status.Untracked {
+		fmt.Fprintf(st.out, "?? %s\n", path)
+	}
+}
+
+// SetOutput changes where the output is written
+func (st *Status) SetOutput(out io.Writer) {
+	st.out = out
+}
+
+// changeLabels name the kinds of changes in the long format
+var changeLabels = map[diff.Status]string{
+	diff.ST_ADDED:    "new file:",
+	diff.ST_MODIFIED: "modified:",
+	diff.ST_DELETED:  "deleted:",
+}
+
+func writeChanges(out io.Writer, changes []diff.Change) {
+	for _, change := range changes {
+		fmt.Fprintf(out, "\t%-12s%s\n", changeLabels[change.Status], change.Path)
+	}
+}
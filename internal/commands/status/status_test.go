@@ -0,0 +1,92 @@
+package status
+
+import (
+	"bytes"
+	"got_it/internal/commands/config"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a repository in the working directory with a.txt
+// committed on main, and origin/main at the parent of main
+func arrangeRepo(t *testing.T) (*Status, *bytes.Buffer) {
+	t.Helper()
+	repo := testrepo.New(t)
+	repo.Conf.AddRemote("origin", "/srv/project")
+	st := NewStatus(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	st.SetOutput(out)
+
+	parent := repo.Commit(map[string]string{"a.txt": "a\n"}, "first")
+	head := repo.Commit(map[string]string{"a.txt": "a\n"}, "second", parent)
+	repo.Checkout(head)
+	st.refs.Update("refs/heads/main", head, refs.UpdateOptions{})
+	st.refs.Update("refs/remotes/origin/main", parent, refs.UpdateOptions{})
+	return st, out
+}
+
+func TestStatusLong(t *testing.T) {
+	st, out := arrangeRepo(t)
+	if err := st.Status(StatusOptions{}); err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if out.String() != "On branch main\n\nnothing to commit, working tree clean\n" {
+		t.Errorf("Unexpected status without upstream:\n%s", out.String())
+	}
+
+	st.conf.SetUpstream("main", config.Upstream{Remote: "origin", Merge: "refs/heads/main"})
+	os.WriteFile("a.txt", []byte("changed\n"), 0644)
+	os.WriteFile("b.txt", []byte("b\n"), 0644)
+	out.Reset()
+	st.Status(StatusOptions{})
+	expected := "On branch main\n" +
+		"Your branch is ahead of 'origin/main' by 1 commit.\n" +
+		"\nChanges not staged for commit:\n\tmodified:   a.txt\n" +
+		"\nUntracked files:\n\tb.txt\n" +
+		"\nno changes added to commit (use \"got add\")\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected status:\n%s", out.String())
+	}
+}
+
+func TestStatusShort(t *testing.T) {
+	st, out := arrangeRepo(t)
+	st.conf.SetUpstream("main", config.Upstream{Remote: "origin", Merge: "refs/heads/main"})
+	os.WriteFile("a.txt", []byte("changed\n"), 0644)
+	files, _ := st.worktree.StoreFiles([]string{"a.txt"})
+	st.worktree.WriteIndex(files)
+	os.WriteFile("a.txt", []byte("changed again\n"), 0644)
+	os.WriteFile("b.txt", []byte("b\n"), 0644)
+
+	if err := st.Status(StatusOptions{Short: true, Branch: true}); err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	expected := "## main...origin/main [ahead 1]\nMM a.txt\n?? b.txt\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected short status:\n%s", out.String())
+	}
+}
+
+func TestTrackingMessage(t *testing.T) {
+	st, out := arrangeRepo(t)
+	st.conf.SetUpstream("main", config.Upstream{Remote: "origin", Merge: "refs/heads/main"})
+	head, _, _ := st.refs.Resolve("refs/heads/main")
+	commit, _ := st.store.ReadCommit(head)
+	commit.Message = "other"
+	other, _ := st.store.WriteCommit(commit)
+	st.refs.Update("refs/remotes/origin/main", other, refs.UpdateOptions{})
+
+	st.Status(StatusOptions{})
+	if !strings.Contains(out.String(), "Your branch and 'origin/main' have diverged,\nand have 1 and 1 different commits each, respectively.\n") {
+		t.Errorf("Expected the branches to have diverged:\n%s", out.String())
+	}
+	st.refs.Update("refs/remotes/origin/main", head, refs.UpdateOptions{})
+	out.Reset()
+	st.Status(StatusOptions{})
+	if !strings.Contains(out.String(), "Your branch is up to date with 'origin/main'.\n") {
+		t.Errorf("Expected the branch to be up to date:\n%s", out.String())
+	}
+}
@@ -0,0 +1,31 @@
+package status
+
+import (
+	"fmt"
+	"got_it/internal/commands/branch"
+)
+
+// trackingMessage tells how the current branch compares with its upstream
+func trackingMessage(tracking branch.Tracking) string {
+	upstream := tracking.Upstream
+	switch {
+	case tracking.Gone:
+		return fmt.Sprintf("Your branch is based on '%s', but the upstream is gone.\n", upstream)
+	case tracking.Ahead > 0 && tracking.Behind > 0:
+		return fmt.Sprintf("Your branch and '%s' have diverged,\nand have %d and %d different commits each, respectively.\n",
+			upstream, tracking.Ahead, tracking.Behind)
+	case tracking.Ahead > 0:
+		return fmt.Sprintf("Your branch is ahead of '%s' by %s.\n", upstream, commits(tracking.Ahead))
+	case tracking.Behind > 0:
+		return fmt.Sprintf("Your branch is behind '%s' by %s, and can be fast-forwarded.\n", upstream, commits(tracking.Behind))
+	default:
+		return fmt.Sprintf("Your branch is up to date with '%s'.\n", upstream)
+	}
+}
+
+func commits(n int) string {
+	if n == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", n)
+}
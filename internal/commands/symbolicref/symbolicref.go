@@ -0,0 +1,92 @@
+package symbolicref
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"io"
+	"os"
+)
+
+type SymbolicRef struct {
+	conf   *config.Config
+	logger *logger.Logger
+	refs   *refs.Store
+	out    io.Writer
+}
+
+// SymbolicRefOptions holds the flags of the symbolic-ref command
+type SymbolicRefOptions struct {
+	Message string
+	Quiet   bool
+	Short   bool
+	Delete  bool
+}
+
+func NewSymbolicRef(conf *config.Config, logger *logger.Logger) *SymbolicRef {
+	return &SymbolicRef{
+		conf:   conf,
+		logger: logger,
+		refs:   refs.NewStore(conf, logger),
+		out:    os.Stdout,
+	}
+}
+
+// Execute reads, sets or deletes a symbolic ref depending on the arguments
+func Execute(args []string, opts SymbolicRefOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	sr := NewSymbolicRef(conf, logger)
+
+	var err error
+	switch {
+	case opts.Delete && len(args) == 1:
+		err = sr.Delete(args[0])
+	case !opts.Delete && len(args) == 1:
+		err = sr.Read(args[0], opts)
+	case !opts.Delete && len(args) == 2:
+		err = sr.Set(args[0], args[1], opts.Message)
+	default:
+		err = fmt.Errorf("usage: got symbolic-ref [-m <reason>] <name> <ref> | [-q] [--short] <name> | -d <name>")
+	}
+	// with -q a name that is not a symbolic ref is not reported
+	if err != nil && !(opts.Quiet && len(args) == 1) {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Read writes the ref the symbolic ref points to
+func (sr *SymbolicRef) Read(name string, opts SymbolicRefOptions) error {
+	target, err := sr.refs.ReadSymbolic(name)
+	if err != nil {
+		return err
+	}
+	if opts.Short {
+		target = refs.ShortName(target)
+	}
+	fmt.Fprintln(sr.out, target)
+	return nil
+}
+
+// Set makes name a symbolic ref pointing to target
+func (sr *SymbolicRef) Set(name, target, message string) error {
+	return sr.refs.SetSymbolic(name, target, message)
+}
+
+// Delete removes a symbolic ref; HEAD can not be deleted
+func (sr *SymbolicRef) Delete(name string) error {
+	if name == refs.HEAD {
+		return fmt.Errorf("deleting '%s' is not allowed", name)
+	}
+	if _, err := sr.refs.ReadSymbolic(name); err != nil {
+		return err
+	}
+	return sr.refs.Delete(name, "")
+}
+
+// SetOutput changes where the target of the symbolic ref is written to
+func (sr *SymbolicRef) SetOutput(out io.Writer) {
+	sr.out = out
+}
@@ -0,0 +1,56 @@
+package symbolicref
+
+import (
+	"bytes"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func arrangeRepo(t *testing.T) *SymbolicRef {
+	t.Helper()
+	repo := testrepo.New(t)
+	repo.SetRef("refs/heads/main", strings.Repeat("a", 40))
+	return NewSymbolicRef(repo.Conf, repo.Logger)
+}
+
+func TestSymbolicRef(t *testing.T) {
+	sr := arrangeRepo(t)
+	out := &bytes.Buffer{}
+	sr.SetOutput(out)
+
+	sr.Read("HEAD", SymbolicRefOptions{})
+	sr.Read("HEAD", SymbolicRefOptions{Short: true})
+	if out.String() != "refs/heads/main\nmain\n" {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	if err := sr.Set("HEAD", "refs/heads/unborn", ""); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(".got", "HEAD"))
+	if string(content) != "ref: refs/heads/unborn" {
+		t.Errorf("Unexpected HEAD content: %s", content)
+	}
+	if err := sr.Set("refs/heads/alias", "HEAD", ""); err == nil {
+		t.Errorf("Expected an error pointing a ref outside of refs/")
+	}
+	if err := sr.Set("HEAD", "refs/heads/bad..name", ""); err == nil {
+		t.Errorf("Expected an error for an invalid target")
+	}
+
+	if err := sr.Set("refs/remotes/origin/HEAD", "refs/remotes/origin/main", ""); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := sr.Delete("refs/remotes/origin/HEAD"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := sr.Delete("HEAD"); err == nil {
+		t.Errorf("Expected an error deleting HEAD")
+	}
+	if err := sr.Delete("refs/heads/main"); err == nil {
+		t.Errorf("Expected an error deleting a ref that is not symbolic")
+	}
+}
@@ -0,0 +1,195 @@
+package tag
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/ident"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/signing"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+type Tag struct {
+	conf   *config.Config
+	logger *logger.Logger
+	store  *objects.Store
+	refs   *refs.Store
+	out    io.Writer
+}
+
+// TagOptions holds the flags of the tag command
+type TagOptions struct {
+	Annotate  bool
+	Message   string
+	Force     bool
+	Delete    bool
+	List      bool
+	Sign      bool
+	LocalUser string // the key to sign with, implies Sign
+}
+
+func init() {
+	config.Register(config.KeyInfo{Key: "tag.gpgSign", Type: config.TYPE_BOOL, Default: "false",
+		Description: "sign every annotated tag"})
+}
+
+func NewTag(conf *config.Config, logger *logger.Logger) *Tag {
+	return &Tag{
+		conf:   conf,
+		logger: logger,
+		store:  objects.NewStore(conf, logger),
+		refs:   refs.NewStore(conf, logger),
+		out:    os.Stdout,
+	}
+}
+
+// Execute creates, deletes or lists tags depending on the options
+func Execute(args []string, opts TagOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	ta := NewTag(conf, logger)
+
+	var err error
+	switch {
+	case opts.Delete:
+		err = ta.Delete(args)
+	case opts.List || len(args) == 0:
+		err = ta.List(args)
+	case len(args) > 2:
+		err = fmt.Errorf("too many arguments")
+	default:
+		target := revision.HEAD
+		if len(args) == 2 {
+			target = args[1]
+		}
+		err = ta.Create(args[0], target, opts)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Create adds the tag name pointing to target. With a message, or when
+// annotation is asked for, a tag object is stored and the tag points to it.
+func (ta *Tag) Create(name, target string, opts TagOptions) error {
+	if err := refs.ValidateTagName(name); err != nil {
+		return err
+	}
+	fullRef := "refs/tags/" + name
+	if ta.refs.Exists(fullRef) && !opts.Force {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	resolver := revision.NewResolver(ta.conf, ta.logger)
+	hash, err := resolver.Resolve(target)
+	if err != nil {
+		return err
+	}
+
+	sign := opts.Sign || opts.LocalUser != ""
+	if opts.Annotate || opts.Message != "" || sign {
+		if strings.TrimSpace(opts.Message) == "" {
+			return fmt.Errorf("no tag message given, use -m <message>")
+		}
+		sign = sign || ta.conf.Bool("tag.gpgSign")
+		hash, err = ta.writeTagObject(name, hash, opts.Message, sign, opts.LocalUser)
+		if err != nil {
+			return err
+		}
+	}
+	return ta.refs.Update(fullRef, hash, refs.UpdateOptions{})
+}
+
+// writeTagObject stores an annotated tag for the object and returns its
+// hash. A signed tag has the signature of its content appended, made with
+// key or by default with user.signingKey.
+func (ta *Tag) writeTagObject(name, hash, message string, sign bool, key string) (string, error) {
+	content, err := ta.store.Read(hash)
+	if err != nil {
+		return "", err
+	}
+	tagger, err := ident.Committer(ta.conf)
+	if err != nil {
+		return "", err
+	}
+	var tagObject strings.Builder
+	tagObject.WriteString(fmt.Sprintf("object %s\n", hash))
+	tagObject.WriteString(fmt.Sprintf("type %s\n", objects.TypeOf(content)))
+	tagObject.WriteString(fmt.Sprintf("tag %s\n", name))
+	tagObject.WriteString(fmt.Sprintf("tagger %s\n", tagger))
+	tagObject.WriteString("\n")
+	tagObject.WriteString(strings.TrimSpace(message) + "\n")
+	if sign {
+		signer := fmt.Sprintf("%s <%s>", tagger.Name, tagger.Email)
+		signature, err := signing.Sign(ta.conf, tagObject.String(), key, signer)
+		if err != nil {
+			return "", err
+		}
+		tagObject.WriteString(signature)
+	}
+	ta.logger.Debug("Tag object:\n%s", tagObject.String())
+	return ta.store.Write(tagObject.String())
+}
+
+// Delete removes the tags
+func (ta *Tag) Delete(names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no tag name given")
+	}
+	for _, name := range names {
+		fullRef := "refs/tags/" + name
+		ref, err := ta.refs.Read(fullRef)
+		if err != nil {
+			return fmt.Errorf("tag '%s' not found", name)
+		}
+		if err := ta.refs.Delete(fullRef, ref.Hash); err != nil {
+			return err
+		}
+		fmt.Fprintf(ta.out, "Deleted tag '%s' (was %s)\n", name, ta.store.Abbreviate(ref.Hash, objects.ABBREV_LENGTH))
+	}
+	return nil
+}
+
+// List writes the names of the tags matching any of the patterns, or every
+// tag when no pattern is given
+func (ta *Tag) List(patterns []string) error {
+	tags, err := ta.refs.List("refs/tags/")
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, tag := range tags {
+		name := strings.TrimPrefix(tag.Name, "refs/tags/")
+		if matchesAny(name, patterns) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		fmt.Fprintln(ta.out, name)
+	}
+	return nil
+}
+
+// SetOutput changes where the tag list is written to
+func (ta *Tag) SetOutput(out io.Writer) {
+	ta.out = out
+}
+
+func matchesAny(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/revision"
+	"got_it/internal/testrepo"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates a repository with a single commit on main
+func arrangeRepo(t *testing.T) (*Tag, string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	tree := repo.Write(fmt.Sprintf("100644 blob %s\ta.txt\n", repo.Write("a\n")))
+	commit := repo.Write(fmt.Sprintf("tree %s\nauthor A U Thor <author@example.com> 1623501234 +0200\n"+
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\nfirst\n", tree))
+	repo.SetRef("refs/heads/main", commit)
+	return NewTag(repo.Conf, repo.Logger), commit
+}
+
+func TestCreateLightweightTag(t *testing.T) {
+	ta, commit := arrangeRepo(t)
+	if err := ta.Create("v1", "main", TagOptions{}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	ref, err := ta.refs.Read("refs/tags/v1")
+	if err != nil || ref.Hash != commit {
+		t.Errorf("Expected refs/tags/v1 to be %s, got %s (%v)", commit, ref.Hash, err)
+	}
+	if err := ta.Create("v1", "main", TagOptions{}); err == nil {
+		t.Errorf("Expected an error when the tag already exists")
+	}
+	if err := ta.Create("v1", "main", TagOptions{Force: true}); err != nil {
+		t.Errorf("Expected --force to replace the tag, got %v", err)
+	}
+}
+
+func TestCreateAnnotatedTag(t *testing.T) {
+	ta, commit := arrangeRepo(t)
+	t.Setenv("GOT_COMMITTER_NAME", "Tag Ger")
+	t.Setenv("GOT_COMMITTER_EMAIL", "tagger@example.com")
+	t.Setenv("GOT_COMMITTER_DATE", "1623509999 -0300")
+
+	if err := ta.Create("v1.0", "HEAD", TagOptions{Message: "Release 1.0"}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	ref, _ := ta.refs.Read("refs/tags/v1.0")
+	hash := ref.Hash
+	content, err := ta.store.Read(hash)
+	if err != nil {
+		t.Fatalf("Error reading tag object: %v", err)
+	}
+	want := fmt.Sprintf("object %s\ntype commit\ntag v1.0\ntagger Tag Ger <tagger@example.com> 1623509999 -0300\n\nRelease 1.0\n", commit)
+	if content != want {
+		t.Errorf("Expected tag object:\n%s\nGot:\n%s", want, content)
+	}
+	if objects.TypeOf(content) != models.OT_TAG {
+		t.Errorf("Expected the object to be a tag")
+	}
+
+	resolver := revision.NewResolver(ta.conf, ta.logger)
+	for _, rev := range []string{"v1.0^{}", "v1.0^{commit}", "v1.0~0"} {
+		if got, err := resolver.Resolve(rev); err != nil || got != commit {
+			t.Errorf("Expected %s to resolve to %s, got %s (%v)", rev, commit, got, err)
+		}
+	}
+	if got, err := resolver.Resolve("v1.0"); err != nil || got != hash {
+		t.Errorf("Expected v1.0 to resolve to the tag object %s, got %s (%v)", hash, got, err)
+	}
+
+	if err := ta.Create("v2", "HEAD", TagOptions{Annotate: true}); err == nil {
+		t.Errorf("Expected an error for an annotated tag without message")
+	}
+}
+
+func TestListAndDeleteTags(t *testing.T) {
+	ta, commit := arrangeRepo(t)
+	for _, name := range []string{"v2.0", "v1.0", "release/v1.1"} {
+		if err := ta.Create(name, "HEAD", TagOptions{}); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	ta.SetOutput(out)
+	ta.List(nil)
+	if out.String() != "release/v1.1\nv1.0\nv2.0\n" {
+		t.Errorf("Unexpected tag list:\n%s", out.String())
+	}
+	out.Reset()
+	ta.List([]string{"v1*"})
+	if out.String() != "v1.0\n" {
+		t.Errorf("Unexpected tag list for v1*:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := ta.Delete([]string{"v1.0"}); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Deleted tag 'v1.0' (was "+commit[:7]) {
+		t.Errorf("Unexpected delete message: %s", out.String())
+	}
+	if err := ta.Delete([]string{"v1.0"}); err == nil {
+		t.Errorf("Expected an error deleting a missing tag")
+	}
+}
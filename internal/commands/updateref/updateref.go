@@ -0,0 +1,97 @@
+package updateref
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/utils"
+	"os"
+)
+
+type UpdateRef struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	refs     *refs.Store
+	resolver *revision.Resolver
+}
+
+// UpdateRefOptions holds the flags of the update-ref command
+type UpdateRefOptions struct {
+	Message string
+	Delete  bool
+	NoDeref bool
+}
+
+func NewUpdateRef(conf *config.Config, logger *logger.Logger) *UpdateRef {
+	return &UpdateRef{
+		conf:     conf,
+		logger:   logger,
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+	}
+}
+
+// Execute runs update-ref <ref> <new-value> [<old-value>] or update-ref -d <ref> [<old-value>]
+func Execute(args []string, opts UpdateRefOptions) {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	ur := NewUpdateRef(conf, logger)
+
+	var err error
+	switch {
+	case opts.Delete && (len(args) == 1 || len(args) == 2):
+		oldValue := ""
+		if len(args) == 2 {
+			oldValue = args[1]
+		}
+		err = ur.Delete(args[0], oldValue)
+	case !opts.Delete && (len(args) == 2 || len(args) == 3):
+		oldValue := ""
+		if len(args) == 3 {
+			oldValue = args[2]
+		}
+		err = ur.Update(args[0], args[1], oldValue, opts)
+	default:
+		err = fmt.Errorf("usage: got update-ref [-m <reason>] (-d <ref> [<old-value>] | <ref> <new-value> [<old-value>])")
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// Update points the ref to newValue, checking first that it still has
+// oldValue when given. An old value of zeros means the ref must not exist.
+func (ur *UpdateRef) Update(name, newValue, oldValue string, opts UpdateRefOptions) error {
+	newHash, err := ur.resolver.Resolve(newValue)
+	if err != nil {
+		return err
+	}
+	oldHash, err := ur.resolveOldValue(oldValue)
+	if err != nil {
+		return err
+	}
+	return ur.refs.Update(name, newHash, refs.UpdateOptions{
+		OldHash: oldHash,
+		Message: opts.Message,
+		NoDeref: opts.NoDeref,
+	})
+}
+
+// Delete removes the ref, checking first that it still has oldValue when given
+func (ur *UpdateRef) Delete(name, oldValue string) error {
+	oldHash, err := ur.resolveOldValue(oldValue)
+	if err != nil {
+		return err
+	}
+	return ur.refs.Delete(name, oldHash)
+}
+
+func (ur *UpdateRef) resolveOldValue(oldValue string) (string, error) {
+	if oldValue == "" || utils.IsZeroHash(oldValue) {
+		return oldValue, nil
+	}
+	return ur.resolver.Resolve(oldValue)
+}
@@ -0,0 +1,55 @@
+package updateref
+
+import (
+	"got_it/internal/testrepo"
+	"got_it/internal/utils"
+	"testing"
+)
+
+// arrangeRepo creates two objects with main pointing to the first one
+func arrangeRepo(t *testing.T) (*UpdateRef, string, string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	first := repo.Write("first\n")
+	second := repo.Write("second\n")
+	repo.SetRef("refs/heads/main", first)
+	return NewUpdateRef(repo.Conf, repo.Logger), first, second
+}
+
+func TestUpdate(t *testing.T) {
+	ur, first, second := arrangeRepo(t)
+	if err := ur.Update("refs/heads/main", second, "main~0", UpdateRefOptions{}); err == nil {
+		t.Errorf("Expected an error for an unknown old value")
+	}
+	if err := ur.Update("refs/heads/main", second, second, UpdateRefOptions{}); err == nil {
+		t.Errorf("Expected an error when the old value does not match")
+	}
+	if err := ur.Update("refs/heads/main", second, first[:7], UpdateRefOptions{Message: "moved"}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if hash, _, _ := ur.refs.Resolve("refs/heads/main"); hash != second {
+		t.Errorf("Expected main at %s, got %s", second, hash)
+	}
+	if err := ur.Update("refs/heads/topic", "main", utils.OF_SHA1.ZeroHash(), UpdateRefOptions{}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if err := ur.Update("HEAD", first, "", UpdateRefOptions{NoDeref: true}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if !ur.refs.IsDetached() {
+		t.Errorf("Expected --no-deref to detach HEAD")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ur, first, second := arrangeRepo(t)
+	if err := ur.Delete("refs/heads/main", second); err == nil {
+		t.Errorf("Expected an error when the old value does not match")
+	}
+	if err := ur.Delete("refs/heads/main", first); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if ur.refs.Exists("refs/heads/main") {
+		t.Errorf("Expected main to be deleted")
+	}
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/revision"
+	"got_it/internal/signing"
+	"io"
+	"os"
+	"time"
+)
+
+type Verify struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	resolver *revision.Resolver
+	out      io.Writer
+}
+
+// VerifyOptions holds the flags of the verify-commit and verify-tag commands
+type VerifyOptions struct {
+	Verbose bool // show the content of the objects
+}
+
+func NewVerify(conf *config.Config, logger *logger.Logger) *Verify {
+	return &Verify{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// ExecuteCommit checks the signatures of the commits in args, and fails
+// when any of them cannot be verified
+func ExecuteCommit(args []string, opts VerifyOptions) error {
+	return execute(args, opts, (*Verify).Commit)
+}
+
+// ExecuteTag checks the signatures of the tags in args, and fails when any
+// of them cannot be verified
+func ExecuteTag(args []string, opts VerifyOptions) error {
+	return execute(args, opts, (*Verify).Tag)
+}
+
+// execute checks every object of args, printing why those that fail do, and
+// returns an error when one did so the exit status tells scripts about it
+func execute(args []string, opts VerifyOptions, check func(*Verify, string, VerifyOptions) error) error {
+	debug := os.Getenv("GOT_DEBUG") == "true"
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, debug)
+	ve := NewVerify(conf, logger)
+
+	failed := 0
+	for _, name := range args {
+		if err := check(ve, name, opts); err != nil {
+			fmt.Println("Error:", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d signatures could not be verified", failed, len(args))
+	}
+	return nil
+}
+
+// Commit checks the signature of a commit: it must be good and made by a
+// trusted key
+func (ve *Verify) Commit(name string, opts VerifyOptions) error {
+	hash, err := ve.resolver.ResolveCommit(name)
+	if err != nil {
+		return err
+	}
+	content, err := ve.store.Read(hash)
+	if err != nil {
+		return err
+	}
+	commit, err := ve.store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+	payload, signature := signing.SplitCommit(content)
+	if opts.Verbose {
+		fmt.Fprint(ve.out, payload)
+	}
+	return ve.check(name, payload, signature, commit.CommitterDate)
+}
+
+// Tag checks the signature of an annotated tag
+func (ve *Verify) Tag(name string, opts VerifyOptions) error {
+	hash, err := ve.resolver.Resolve(name)
+	if err != nil {
+		return err
+	}
+	content, err := ve.store.Read(hash)
+	if err != nil {
+		return err
+	}
+	if objects.TypeOf(content) != models.OT_TAG {
+		return fmt.Errorf("%s: cannot verify a non-tag object of type %s", name, objects.TypeOf(content))
+	}
+	tag, err := ve.store.ReadTag(hash)
+	if err != nil {
+		return err
+	}
+	payload, signature := signing.SplitTag(content)
+	if opts.Verbose {
+		fmt.Fprint(ve.out, payload)
+	}
+	return ve.check(name, payload, signature, tag.TaggerDate)
+}
+
+// check verifies the signature made at the given time and writes what was
+// found about it
+func (ve *Verify) check(name, payload, signature string, when time.Time) error {
+	if signature == "" {
+		return fmt.Errorf("%s: no signature found", name)
+	}
+	result, err := signing.Verify(ve.conf, payload, signature, when)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ve.out, result.Message)
+	if !result.Valid() {
+		return fmt.Errorf("%s: could not verify the signature", name)
+	}
+	return nil
+}
+
+// SetOutput changes where the output is written
+func (ve *Verify) SetOutput(out io.Writer) {
+	ve.out = out
+}
@@ -0,0 +1,250 @@
+package date
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEFAULT_LAYOUT is the layout used to show dates, the same as Git's default
+const DEFAULT_LAYOUT string = "Mon Jan 2 15:04:05 2006 -0700"
+
+// Mode is a way of showing dates, as in log --date=<mode>
+type Mode string
+
+const (
+	MODE_DEFAULT    Mode = "default"
+	MODE_RELATIVE   Mode = "relative"
+	MODE_LOCAL      Mode = "local"
+	MODE_ISO        Mode = "iso"
+	MODE_ISO_STRICT Mode = "iso-strict"
+	MODE_SHORT      Mode = "short"
+	MODE_RFC        Mode = "rfc"
+	MODE_UNIX       Mode = "unix"
+	MODE_RAW        Mode = "raw"
+)
+
+var modeLayouts = map[Mode]string{
+	MODE_DEFAULT:    DEFAULT_LAYOUT,
+	MODE_LOCAL:      "Mon Jan 2 15:04:05 2006",
+	MODE_ISO:        "2006-01-02 15:04:05 -0700",
+	MODE_ISO_STRICT: time.RFC3339,
+	MODE_SHORT:      "2006-01-02",
+	MODE_RFC:        "Mon, 2 Jan 2006 15:04:05 -0700",
+}
+
+// inputLayouts are the absolute date formats accepted from the user
+var inputLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC1123,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006.01.02",
+	"01/02/2006",
+	DEFAULT_LAYOUT,
+}
+
+var relativePattern = regexp.MustCompile(`^(\d+|an?|one)[ .]+(second|minute|hour|day|week|month|year)s?[ .]+ago$`)
+
+// ParseMode validates the name of a date mode
+func ParseMode(value string) (Mode, error) {
+	if value == "" {
+		return MODE_DEFAULT, nil
+	}
+	mode := Mode(value)
+	if _, found := modeLayouts[mode]; found || mode == MODE_RELATIVE || mode == MODE_UNIX || mode == MODE_RAW {
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown date format '%s'", value)
+}
+
+// ParseCommitDate parses the date stored in the author and committer lines
+// of a commit. Dates are stored as "<unix-seconds> <+zzzz>"; RFC3339 dates
+// written by older versions are accepted as well.
+func ParseCommitDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	fields := strings.Fields(value)
+	if len(fields) == 2 {
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err == nil {
+			location, err := parseOffset(fields[1])
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(seconds, 0).In(location), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid commit date '%s'", value)
+}
+
+// FormatCommitDate returns the date as it is stored in commits: "<unix-seconds> <+zzzz>"
+func FormatCommitDate(t time.Time) string {
+	return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700"))
+}
+
+// Parse parses a date given by the user, in GOT_AUTHOR_DATE or log --since
+func Parse(value string) (time.Time, error) {
+	return ParseRelativeTo(value, time.Now())
+}
+
+// ParseRelativeTo parses a date given by the user. Relative dates such as
+// "2 days ago", "yesterday" or "now" are computed from now.
+func ParseRelativeTo(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+
+	switch lower {
+	case "now":
+		return now, nil
+	case "today":
+		year, month, day := now.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+	if match := relativePattern.FindStringSubmatch(lower); match != nil {
+		count := 1
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			count = n
+		}
+		return subtract(now, match[2], count), nil
+	}
+
+	if fields := strings.Fields(strings.TrimPrefix(value, "@")); strings.HasPrefix(value, "@") && len(fields) > 0 {
+		if seconds, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			if len(fields) == 1 {
+				return time.Unix(seconds, 0).UTC(), nil
+			}
+			return ParseCommitDate(value[1:])
+		}
+	}
+	if t, err := ParseCommitDate(value); err == nil {
+		return t, nil
+	}
+	for _, layout := range inputLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+}
+
+// Format shows the date in the default layout, keeping its own time zone
+func Format(t time.Time) string {
+	return t.Format(DEFAULT_LAYOUT)
+}
+
+// FormatMode shows the date in the given mode; relative dates are computed from now
+func FormatMode(t time.Time, mode Mode, now time.Time) string {
+	switch mode {
+	case MODE_RELATIVE:
+		return relative(t, now)
+	case MODE_UNIX:
+		return strconv.FormatInt(t.Unix(), 10)
+	case MODE_RAW:
+		return FormatCommitDate(t)
+	case MODE_LOCAL:
+		return t.Local().Format(modeLayouts[MODE_LOCAL])
+	}
+	layout, found := modeLayouts[mode]
+	if !found {
+		layout = DEFAULT_LAYOUT
+	}
+	return t.Format(layout)
+}
+
+// relative describes how long ago t happened, like "3 days ago"
+func relative(t, now time.Time) string {
+	elapsed := now.Sub(t)
+	if elapsed < 0 {
+		return "in the future"
+	}
+	seconds := int64(elapsed.Seconds())
+	switch {
+	case seconds < 90:
+		return ago(seconds, "second")
+	case seconds < 90*60:
+		return ago((seconds+30)/60, "minute")
+	case seconds < 36*3600:
+		return ago((seconds+1800)/3600, "hour")
+	}
+	days := (seconds + 43200) / 86400
+	switch {
+	case days < 14:
+		return ago(days, "day")
+	case days < 70:
+		return ago((days+3)/7, "week")
+	case days < 365:
+		return ago((days+15)/30, "month")
+	}
+	totalMonths := (days*12*2 + 365) / (365 * 2)
+	years := totalMonths / 12
+	months := totalMonths % 12
+	if years < 5 && months > 0 {
+		return fmt.Sprintf("%s, %s", count(years, "year"), ago(months, "month"))
+	}
+	return ago(years, "year")
+}
+
+func ago(n int64, unit string) string {
+	return count(n, unit) + " ago"
+}
+
+func count(n int64, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// subtract goes back count units of time from now
+func subtract(now time.Time, unit string, count int) time.Time {
+	switch unit {
+	case "second":
+		return now.Add(-time.Duration(count) * time.Second)
+	case "minute":
+		return now.Add(-time.Duration(count) * time.Minute)
+	case "hour":
+		return now.Add(-time.Duration(count) * time.Hour)
+	case "day":
+		return now.AddDate(0, 0, -count)
+	case "week":
+		return now.AddDate(0, 0, -7*count)
+	case "month":
+		return now.AddDate(0, -count, 0)
+	}
+	return now.AddDate(-count, 0, 0)
+}
+
+// parseOffset converts "+hhmm" or "-hhmm" into a time zone
+func parseOffset(offset string) (*time.Location, error) {
+	if len(offset) != 5 || (offset[0] != '+' && offset[0] != '-') {
+		return nil, fmt.Errorf("invalid time zone offset '%s'", offset)
+	}
+	hours, err := strconv.Atoi(offset[1:3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone offset '%s'", offset)
+	}
+	minutes, err := strconv.Atoi(offset[3:5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone offset '%s'", offset)
+	}
+	seconds := hours*3600 + minutes*60
+	if offset[0] == '-' {
+		seconds = -seconds
+	}
+	return time.FixedZone("", seconds), nil
+}
@@ -0,0 +1,79 @@
+package date
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeTo(t *testing.T) {
+	now := time.Date(2021, time.June, 12, 14, 0, 0, 0, time.FixedZone("", 2*3600))
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1623501234 +0200", "2021-06-12T14:33:54+02:00"},
+		{"@1623501234", "2021-06-12T12:33:54Z"},
+		{"2021-06-12T14:33:54+02:00", "2021-06-12T14:33:54+02:00"},
+		{"Sat, 12 Jun 2021 14:33:54 +0200", "2021-06-12T14:33:54+02:00"},
+		{"2021-06-12 14:33:54 -0300", "2021-06-12T14:33:54-03:00"},
+		{"2021-06-10", "2021-06-10T00:00:00+02:00"},
+		{"now", "2021-06-12T14:00:00+02:00"},
+		{"yesterday", "2021-06-11T14:00:00+02:00"},
+		{"3 hours ago", "2021-06-12T11:00:00+02:00"},
+		{"2.weeks.ago", "2021-05-29T14:00:00+02:00"},
+		{"a month ago", "2021-05-12T14:00:00+02:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRelativeTo(tt.value, now)
+			if err != nil {
+				t.Fatalf("ParseRelativeTo returned error: %v", err)
+			}
+			if got.Format(time.RFC3339) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got.Format(time.RFC3339))
+			}
+		})
+	}
+	if _, err := ParseRelativeTo("next tuesday", now); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestCommitDateRoundTrip(t *testing.T) {
+	stored := "1623501234 -0430"
+	parsed, err := ParseCommitDate(stored)
+	if err != nil {
+		t.Fatalf("ParseCommitDate returned error: %v", err)
+	}
+	if FormatCommitDate(parsed) != stored {
+		t.Errorf("Expected %s, got %s", stored, FormatCommitDate(parsed))
+	}
+}
+
+func TestFormatMode(t *testing.T) {
+	commitDate := time.Unix(1623501234, 0).In(time.FixedZone("", 2*3600))
+	now := commitDate.Add(3 * 24 * time.Hour)
+	tests := []struct {
+		mode Mode
+		want string
+	}{
+		{MODE_DEFAULT, "Sat Jun 12 14:33:54 2021 +0200"},
+		{MODE_ISO, "2021-06-12 14:33:54 +0200"},
+		{MODE_ISO_STRICT, "2021-06-12T14:33:54+02:00"},
+		{MODE_SHORT, "2021-06-12"},
+		{MODE_RFC, "Sat, 12 Jun 2021 14:33:54 +0200"},
+		{MODE_UNIX, "1623501234"},
+		{MODE_RAW, "1623501234 +0200"},
+		{MODE_RELATIVE, "3 days ago"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			if got := FormatMode(commitDate, tt.mode, now); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+	if _, err := ParseMode("fancy"); err == nil {
+		t.Errorf("Expected an error for an unknown date mode")
+	}
+}
@@ -0,0 +1,199 @@
+package diff
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"sort"
+	"strings"
+)
+
+// Operation is what happens to a line between two versions of a file
+type Operation int
+
+const (
+	OP_EQUAL Operation = iota
+	OP_INSERT
+	OP_DELETE
+)
+
+// DEFAULT_CONTEXT is the number of unchanged lines shown around a change
+const DEFAULT_CONTEXT int = 3
+
+// Edit is a line of the edit script turning the old text into the new one
+type Edit struct {
+	Op   Operation
+	Line string // with its trailing new line, if any
+}
+
+// Hunk is a group of edits with their surrounding context
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Edits    []Edit
+}
+
+// Status tells how a file changed between two trees
+type Status string
+
+const (
+	ST_ADDED    Status = "A"
+	ST_MODIFIED Status = "M"
+	ST_DELETED  Status = "D"
+)
+
+// Change is a file that differs between two trees
+type Change struct {
+	Path   string
+	Status Status
+	Old    models.TreeEntry
+	New    models.TreeEntry
+	Binary bool // the content is not diffed, being too big to be read
+}
+
+// SplitLines splits the text in lines keeping the trailing new lines
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the line by line edit script from oldText to newText
+func Lines(oldText, newText string) []Edit {
+	return myers(SplitLines(oldText), SplitLines(newText))
+}
+
+// Hunks groups the edits in hunks with context unchanged lines around them
+func Hunks(edits []Edit, context int) []Hunk {
+	hunks := []Hunk{}
+	var current *Hunk
+	oldLine, newLine := 1, 1
+	lastChange := -1
+
+	for i, edit := range edits {
+		if edit.Op != OP_EQUAL {
+			if current == nil || i-lastChange-1 > 2*context {
+				if current != nil {
+					hunks = append(hunks, closeHunk(*current, edits, lastChange, context))
+				}
+				start := max(0, i-context)
+				current = &Hunk{
+					OldStart: oldLine - (i - start),
+					NewStart: newLine - (i - start),
+					Edits:    append([]Edit{}, edits[start:i]...),
+				}
+			} else {
+				current.Edits = append(current.Edits, edits[lastChange+1:i]...)
+			}
+			current.Edits = append(current.Edits, edit)
+			lastChange = i
+		}
+		if edit.Op != OP_INSERT {
+			oldLine++
+		}
+		if edit.Op != OP_DELETE {
+			newLine++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, closeHunk(*current, edits, lastChange, context))
+	}
+	return hunks
+}
+
+// closeHunk adds the trailing context and counts the lines of the hunk
+func closeHunk(hunk Hunk, edits []Edit, lastChange, context int) Hunk {
+	end := min(len(edits), lastChange+1+context)
+	hunk.Edits = append(hunk.Edits, edits[lastChange+1:end]...)
+	for _, edit := range hunk.Edits {
+		if edit.Op != OP_INSERT {
+			hunk.OldLines++
+		}
+		if edit.Op != OP_DELETE {
+			hunk.NewLines++
+		}
+	}
+	return hunk
+}
+
+// Unified returns the hunks of the unified diff between two texts
+func Unified(oldText, newText string, context int) string {
+	var out strings.Builder
+	for _, hunk := range Hunks(Lines(oldText, newText), context) {
+		out.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines)))
+		for _, edit := range hunk.Edits {
+			prefix := " "
+			switch edit.Op {
+			case OP_INSERT:
+				prefix = "+"
+			case OP_DELETE:
+				prefix = "-"
+			}
+			out.WriteString(prefix + edit.Line)
+			if !strings.HasSuffix(edit.Line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// an empty range points to the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// CountChanges returns the number of inserted and deleted lines
+func CountChanges(edits []Edit) (int, int) {
+	insertions, deletions := 0, 0
+	for _, edit := range edits {
+		switch edit.Op {
+		case OP_INSERT:
+			insertions++
+		case OP_DELETE:
+			deletions++
+		}
+	}
+	return insertions, deletions
+}
+
+// IsBinary tells if the content looks like binary data
+func IsBinary(content string) bool {
+	return strings.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// CompareTrees returns the files that differ between two flattened trees,
+// sorted by path
+func CompareTrees(oldFiles, newFiles map[string]models.TreeEntry) []Change {
+	changes := []Change{}
+	for path, newEntry := range newFiles {
+		oldEntry, found := oldFiles[path]
+		if !found {
+			changes = append(changes, Change{Path: path, Status: ST_ADDED, New: newEntry})
+		} else if oldEntry.Hash != newEntry.Hash || oldEntry.Mode != newEntry.Mode {
+			changes = append(changes, Change{Path: path, Status: ST_MODIFIED, Old: oldEntry, New: newEntry})
+		}
+	}
+	for path, oldEntry := range oldFiles {
+		if _, found := newFiles[path]; !found {
+			changes = append(changes, Change{Path: path, Status: ST_DELETED, Old: oldEntry})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"got_it/internal/models"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{"new file", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"deleted file", "a\n", "", "@@ -1 +0,0 @@\n-a\n"},
+		{"no change", "a\nb\n", "a\nb\n", ""},
+		{"modified line", "a\nb\nc\n", "a\nB\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"missing new line", "a\n", "a\nb", "@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n"},
+		{"two hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n"},
+		{"merged hunks",
+			"1\n2\n3\n4\n5\n6\n7\n",
+			"one\n2\n3\n4\n5\n6\nseven\n",
+			"@@ -1,7 +1,7 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n-7\n+seven\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified(tt.oldText, tt.newText, DEFAULT_CONTEXT)
+			if got != tt.want {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCountChanges(t *testing.T) {
+	insertions, deletions := CountChanges(Lines("a\nb\nc\n", "a\nc\nd\ne\n"))
+	if insertions != 2 || deletions != 1 {
+		t.Errorf("Expected 2 insertions and 1 deletion, got %d and %d", insertions, deletions)
+	}
+}
+
+func TestCompareTrees(t *testing.T) {
+	oldFiles := map[string]models.TreeEntry{
+		"same.txt":    {Mode: "100644", Hash: "1"},
+		"changed.txt": {Mode: "100644", Hash: "2"},
+		"gone.txt":    {Mode: "100644", Hash: "3"},
+	}
+	newFiles := map[string]models.TreeEntry{
+		"same.txt":    {Mode: "100644", Hash: "1"},
+		"changed.txt": {Mode: "100644", Hash: "4"},
+		"new.txt":     {Mode: "100644", Hash: "5"},
+	}
+	changes := CompareTrees(oldFiles, newFiles)
+	got := []string{}
+	for _, change := range changes {
+		got = append(got, string(change.Status)+" "+change.Path)
+	}
+	want := "M changed.txt,D gone.txt,A new.txt"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+// TestLinesRebuildsBothTexts checks the edit script on random texts
+func TestLinesRebuildsBothTexts(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		var text strings.Builder
+		for i := random.Intn(30); i > 0; i-- {
+			text.WriteString(string(rune('a'+random.Intn(4))) + "\n")
+		}
+		return text.String()
+	}
+	for i := 0; i < 200; i++ {
+		oldText, newText := randomText(), randomText()
+		var rebuiltOld, rebuiltNew strings.Builder
+		for _, edit := range Lines(oldText, newText) {
+			if edit.Op != OP_INSERT {
+				rebuiltOld.WriteString(edit.Line)
+			}
+			if edit.Op != OP_DELETE {
+				rebuiltNew.WriteString(edit.Line)
+			}
+		}
+		if rebuiltOld.String() != oldText || rebuiltNew.String() != newText {
+			t.Fatalf("Edit script does not rebuild %q -> %q", oldText, newText)
+		}
+	}
+}
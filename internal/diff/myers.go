@@ -0,0 +1,131 @@
+package diff
+
+// myers returns the shortest edit script turning the old lines into the new
+// ones, using the O(ND) algorithm by Eugene W. Myers
+func myers(oldLines, newLines []string) []Edit {
+	// skip the common prefix and suffix, the search only needs the middle
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(oldLines)+len(newLines))
+	for _, line := range oldLines[:prefix] {
+		edits = append(edits, Edit{Op: OP_EQUAL, Line: line})
+	}
+	edits = append(edits, shortestEdit(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		edits = append(edits, Edit{Op: OP_EQUAL, Line: line})
+	}
+	return edits
+}
+
+func shortestEdit(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max
+	v := make([]int, 2*max+2)
+	trace := [][]int{}
+
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	trace = append(trace, append([]int{}, v...))
+
+	// walk the trace backwards to recover the path
+	edits := []Edit{}
+	x, y := n, m
+	for d := len(trace) - 2; d >= 0 && (x > 0 || y > 0); d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Op: OP_EQUAL, Line: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Op: OP_INSERT, Line: b[y]})
+		} else {
+			x--
+			edits = append(edits, Edit{Op: OP_DELETE, Line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, Edit{Op: OP_EQUAL, Line: a[x]})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return groupChanges(edits)
+}
+
+// groupChanges moves the deletions before the insertions inside every run of
+// changed lines, so they read like a replaced block
+func groupChanges(edits []Edit) []Edit {
+	grouped := make([]Edit, 0, len(edits))
+	for i := 0; i < len(edits); {
+		if edits[i].Op == OP_EQUAL {
+			grouped = append(grouped, edits[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(edits) && edits[j].Op != OP_EQUAL {
+			j++
+		}
+		for _, edit := range edits[i:j] {
+			if edit.Op == OP_DELETE {
+				grouped = append(grouped, edit)
+			}
+		}
+		for _, edit := range edits[i:j] {
+			if edit.Op == OP_INSERT {
+				grouped = append(grouped, edit)
+			}
+		}
+		i = j
+	}
+	return grouped
+}
@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileStat is the number of changed lines of a file, for diffstats
+type FileStat struct {
+	Path       string
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+// Patch returns the unified diff of a changed file with its header
+func Patch(change Change, oldText, newText string) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("diff --got a/%s b/%s\n", change.Path, change.Path))
+	oldName, newName := "a/"+change.Path, "b/"+change.Path
+	switch change.Status {
+	case ST_ADDED:
+		out.WriteString(fmt.Sprintf("new file mode %s\n", change.New.Mode))
+		oldName = "/dev/null"
+	case ST_DELETED:
+		out.WriteString(fmt.Sprintf("deleted file mode %s\n", change.Old.Mode))
+		newName = "/dev/null"
+	default:
+		if change.Old.Mode != change.New.Mode {
+			out.WriteString(fmt.Sprintf("old mode %s\nnew mode %s\n", change.Old.Mode, change.New.Mode))
+		}
+	}
+	out.WriteString(fmt.Sprintf("index %s..%s\n", shortHash(change.Old.Hash), shortHash(change.New.Hash)))
+	if change.Old.Hash == change.New.Hash || !change.Binary && oldText == newText {
+		return out.String()
+	}
+	if change.Binary || IsBinary(oldText) || IsBinary(newText) {
+		out.WriteString(fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName))
+		return out.String()
+	}
+	out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	out.WriteString(Unified(oldText, newText, DEFAULT_CONTEXT))
+	return out.String()
+}
+
+// ComputeStat counts the changed lines between two versions of a file
+func ComputeStat(change Change, oldText, newText string) FileStat {
+	if change.Binary || IsBinary(oldText) || IsBinary(newText) {
+		return FileStat{Path: change.Path, Binary: true}
+	}
+	insertions, deletions := CountChanges(Lines(oldText, newText))
+	return FileStat{Path: change.Path, Insertions: insertions, Deletions: deletions}
+}
+
+// FormatStat returns a diffstat like " file | 3 ++-" followed by a summary line
+func FormatStat(stats []FileStat) string {
+	var out strings.Builder
+	nameWidth, countWidth, maxCount := 0, 1, 0
+	for _, stat := range stats {
+		nameWidth = max(nameWidth, len(stat.Path))
+		maxCount = max(maxCount, stat.Insertions+stat.Deletions)
+	}
+	countWidth = max(countWidth, len(fmt.Sprint(maxCount)))
+
+	// scale the bars so the widest one fits in 50 columns
+	const barWidth = 50
+	totalInsertions, totalDeletions := 0, 0
+	for _, stat := range stats {
+		totalInsertions += stat.Insertions
+		totalDeletions += stat.Deletions
+		if stat.Binary {
+			out.WriteString(fmt.Sprintf(" %-*s | %*s\n", nameWidth, stat.Path, countWidth, "Bin"))
+			continue
+		}
+		insertions, deletions := stat.Insertions, stat.Deletions
+		if maxCount > barWidth {
+			insertions = insertions * barWidth / maxCount
+			deletions = deletions * barWidth / maxCount
+		}
+		bar := strings.Repeat("+", insertions) + strings.Repeat("-", deletions)
+		out.WriteString(strings.TrimRight(fmt.Sprintf(" %-*s | %*d %s", nameWidth, stat.Path,
+			countWidth, stat.Insertions+stat.Deletions, bar), " ") + "\n")
+	}
+
+	summary := fmt.Sprintf(" %d file%s changed", len(stats), plural(len(stats)))
+	if totalInsertions > 0 || totalDeletions == 0 {
+		summary += fmt.Sprintf(", %d insertion%s(+)", totalInsertions, plural(totalInsertions))
+	}
+	if totalDeletions > 0 {
+		summary += fmt.Sprintf(", %d deletion%s(-)", totalDeletions, plural(totalDeletions))
+	}
+	out.WriteString(summary + "\n")
+	return out.String()
+}
+
+func plural(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+	return hash[:min(len(hash), 7)]
+}
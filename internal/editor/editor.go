@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DEFAULT_EDITOR is used when no editor is configured
+const DEFAULT_EDITOR string = "vi"
+
+// Command returns the editor to run: GOT_EDITOR, VISUAL or EDITOR, in that
+// order, falling back to vi
+func Command() string {
+	for _, name := range []string{"GOT_EDITOR", "VISUAL", "EDITOR"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return DEFAULT_EDITOR
+}
+
+// Edit opens the file in the editor and waits for it to exit. The editor is
+// run by the shell, so it may hold arguments like "code --wait".
+func Edit(filePath string) error {
+	editor := Command()
+	if editor == ":" {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, filePath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// EditText writes text to filePath, lets the user edit it and returns the
+// result without its comment lines
+func EditText(filePath, text string) (string, error) {
+	if err := os.WriteFile(filePath, []byte(text), 0644); err != nil {
+		return "", err
+	}
+	if err := Edit(filePath); err != nil {
+		return "", err
+	}
+	edited, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return StripComments(string(edited)), nil
+}
+
+// StripComments removes the lines starting with # and the blank lines left
+// at both ends
+func StripComments(text string) string {
+	kept := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n")
+}
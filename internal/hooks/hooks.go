@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HOOKS_DIR is the directory of the hooks in .got, unless core.hooksPath
+// names another one
+const HOOKS_DIR string = "hooks"
+
+// The hooks run by got; a pre-* hook that fails stops what it runs before
+const (
+	PRE_COMMIT  string = "pre-commit"
+	COMMIT_MSG  string = "commit-msg"
+	POST_COMMIT string = "post-commit"
+	PRE_PUSH    string = "pre-push"
+)
+
+func init() {
+	config.Register(config.KeyInfo{Key: "core.hooksPath", Type: config.TYPE_PATH,
+		Description: "directory of the hooks, relative to the top of the worktree", Validate: config.NotEmpty})
+}
+
+// Dir returns the directory the hooks are read from
+func Dir(conf *config.Config) string {
+	root := filepath.Dir(absolute(conf.GotDir))
+	dir, err := conf.GetConfigKeyValue("core.hooksPath")
+	if err != nil {
+		return filepath.Join(absolute(conf.GotDir), HOOKS_DIR)
+	}
+	if rest, found := strings.CutPrefix(dir, "~/"); found {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir
+}
+
+// Run runs the hook with the arguments and the input, from the top of the
+// worktree. A missing hook is not an error, one that is not executable is
+// ignored with a hint; a hook that fails gives an error.
+func Run(conf *config.Config, name string, args []string, stdin io.Reader) error {
+	path := filepath.Join(Dir(conf), name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	if info.Mode()&0111 == 0 {
+		fmt.Fprintf(os.Stderr, "hint: The '%s' hook was ignored because it's not set as executable.\n", path)
+		return nil
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Dir = filepath.Dir(absolute(conf.GotDir))
+	cmd.Stdin = stdin
+	// as with Git, the output of the hooks goes to stderr
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("%s hook exited with status %d", name, exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("could not run the %s hook: %w", name, err)
+	}
+	return nil
+}
+
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
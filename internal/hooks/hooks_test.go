@@ -0,0 +1,48 @@
+package hooks
+
+import (
+	"got_it/internal/commands/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	os.MkdirAll(filepath.Join(".got", HOOKS_DIR), 0755)
+	conf := config.NewConfig()
+
+	if err := Run(conf, PRE_COMMIT, nil, nil); err != nil {
+		t.Errorf("Expected a missing hook to be skipped, got %v", err)
+	}
+	hook := filepath.Join(".got", HOOKS_DIR, PRE_COMMIT)
+	os.WriteFile(hook, []byte("#!/bin/sh\nexit 2\n"), 0644)
+	if err := Run(conf, PRE_COMMIT, nil, nil); err != nil {
+		t.Errorf("Expected a hook that is not executable to be ignored, got %v", err)
+	}
+	os.Chmod(hook, 0755)
+	if err := Run(conf, PRE_COMMIT, nil, nil); err == nil || !strings.Contains(err.Error(), "status 2") {
+		t.Errorf("Expected the exit status of the hook, got %v", err)
+	}
+
+	// core.hooksPath is relative to the top of the worktree
+	conf.SetConfigKeyValue("core.hooksPath", "githooks")
+	if hooksDir := Dir(conf); hooksDir != filepath.Join(dir, "githooks") {
+		t.Errorf("Expected the hooks in %s, got %s", filepath.Join(dir, "githooks"), hooksDir)
+	}
+	os.Mkdir("githooks", 0755)
+	os.WriteFile(filepath.Join("githooks", COMMIT_MSG), []byte("#!/bin/sh\ngrep -q GOT- \"$1\"\n"), 0755)
+	os.WriteFile("message", []byte("GOT-12 fix\n"), 0644)
+	if err := Run(conf, COMMIT_MSG, []string{"message"}, nil); err != nil {
+		t.Errorf("Expected the commit-msg hook to accept the message, got %v", err)
+	}
+	if err := Run(conf, PRE_COMMIT, nil, nil); err != nil {
+		t.Errorf("Expected the hooks of .got to be left out, got %v", err)
+	}
+}
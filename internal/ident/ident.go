@@ -0,0 +1,56 @@
+package ident
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"os"
+	"time"
+)
+
+// Ident is who did something and when: the author or committer of a commit,
+// the tagger of a tag or the person updating a ref
+type Ident struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+// Author returns the user from the config, overridden by GOT_AUTHOR_NAME,
+// GOT_AUTHOR_EMAIL and GOT_AUTHOR_DATE
+func Author(conf *config.Config) (Ident, error) {
+	return fromEnv(conf, "GOT_AUTHOR")
+}
+
+// Committer returns the user from the config, overridden by
+// GOT_COMMITTER_NAME, GOT_COMMITTER_EMAIL and GOT_COMMITTER_DATE
+func Committer(conf *config.Config) (Ident, error) {
+	return fromEnv(conf, "GOT_COMMITTER")
+}
+
+func fromEnv(conf *config.Config, prefix string) (Ident, error) {
+	who := Ident{
+		Name:  conf.GetUserName(),
+		Email: conf.GetUserEmail(),
+		Date:  time.Now(),
+	}
+	if name := os.Getenv(prefix + "_NAME"); name != "" {
+		who.Name = name
+	}
+	if email := os.Getenv(prefix + "_EMAIL"); email != "" {
+		who.Email = email
+	}
+	if value := os.Getenv(prefix + "_DATE"); value != "" {
+		t, err := date.Parse(value)
+		if err != nil {
+			return Ident{}, fmt.Errorf("%s_DATE: %w", prefix, err)
+		}
+		who.Date = t
+	}
+	return who, nil
+}
+
+// String formats the ident as in commit headers: "Name <email> <seconds> <zone>"
+func (i Ident) String() string {
+	return fmt.Sprintf("%s <%s> %s", i.Name, i.Email, date.FormatCommitDate(i.Date))
+}
@@ -0,0 +1,97 @@
+package index
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Index reads and writes the staging area in .got/index. Paths are
+// returned relative to the root of the repository, slash separated, even
+// when add recorded them as absolute paths.
+type Index struct {
+	conf   *config.Config
+	logger *logger.Logger
+}
+
+func NewIndex(conf *config.Config, logger *logger.Logger) *Index {
+	return &Index{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// Read returns the staged files mapped to their blob hashes
+func (ix *Index) Read() (map[string]string, error) {
+	stagedFiles, err := utils.ReadIndex(ix.conf.GetIndexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]string), nil
+		}
+		return nil, err
+	}
+	root := ix.root()
+	files := make(map[string]string, len(stagedFiles))
+	for stagedPath, hash := range stagedFiles {
+		files[RelativePath(root, stagedPath)] = hash
+	}
+	return files, nil
+}
+
+// Write replaces the content of the index with the files
+func (ix *Index) Write(files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var content strings.Builder
+	for _, filePath := range paths {
+		var entryLine [2]string
+		entryLine[models.IndexKeyValue[models.PATH_KEY]] = filePath
+		entryLine[models.IndexKeyValue[models.HASH_KEY]] = files[filePath]
+		content.WriteString(strings.Join(entryLine[:], " ") + " \n")
+	}
+
+	indexPath := ix.conf.GetIndexPath()
+	tmpPath := indexPath + ".lock"
+	if err := os.WriteFile(tmpPath, []byte(content.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, indexPath)
+}
+
+// FromTree returns the index content matching a flattened tree
+func FromTree(files map[string]models.TreeEntry) map[string]string {
+	staged := make(map[string]string, len(files))
+	for filePath, entry := range files {
+		staged[filePath] = entry.Hash
+	}
+	return staged
+}
+
+// root is the absolute path of the directory holding .got
+func (ix *Index) root() string {
+	gotDir, err := filepath.Abs(ix.conf.GotDir)
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(gotDir)
+}
+
+// RelativePath turns a path recorded in the index into a slash separated
+// path relative to root
+func RelativePath(root, stagedPath string) string {
+	if filepath.IsAbs(stagedPath) {
+		if relative, err := filepath.Rel(root, stagedPath); err == nil {
+			stagedPath = relative
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(stagedPath))
+}
@@ -0,0 +1,112 @@
+package lfs
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ATTRIBUTES_FILE lists, at the top of the worktree, the patterns of the
+	// files tracked by lfs
+	ATTRIBUTES_FILE string = ".gotattributes"
+	// LFS_ATTRIBUTES follow the pattern of a file tracked by lfs
+	LFS_ATTRIBUTES string = "filter=lfs diff=lfs merge=lfs -text"
+)
+
+// Patterns returns the patterns tracked by lfs in the .gotattributes of
+// the worktree at root, in the order they were written
+func Patterns(root string) ([]string, error) {
+	file, err := os.Open(filepath.Join(root, ATTRIBUTES_FILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attribute := range fields[1:] {
+			if attribute == "filter=lfs" {
+				patterns = append(patterns, fields[0])
+				break
+			}
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// Track adds the pattern to .gotattributes, and tells if it was not
+// tracked yet
+func Track(root, pattern string) (bool, error) {
+	patterns, err := Patterns(root)
+	if err != nil {
+		return false, err
+	}
+	for _, tracked := range patterns {
+		if tracked == pattern {
+			return false, nil
+		}
+	}
+	file, err := os.OpenFile(filepath.Join(root, ATTRIBUTES_FILE), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	if _, err := file.WriteString(pattern + " " + LFS_ATTRIBUTES + "\n"); err != nil {
+		file.Close()
+		return false, err
+	}
+	return true, file.Close()
+}
+
+// Untrack removes the lines of the pattern from .gotattributes, and tells
+// if it was tracked
+func Untrack(root, pattern string) (bool, error) {
+	attributesPath := filepath.Join(root, ATTRIBUTES_FILE)
+	content, err := os.ReadFile(attributesPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	kept := []string{}
+	found := false
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == pattern {
+			found = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if !found {
+		return false, nil
+	}
+	return true, os.WriteFile(attributesPath, []byte(strings.Join(kept, "")), 0644)
+}
+
+// IsTracked tells if a slash separated path relative to the root matches
+// one of the patterns. As in .gotignore, patterns with a slash match the
+// whole path and the others its base name.
+func IsTracked(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		subject := path.Base(filePath)
+		if strings.Contains(pattern, "/") {
+			subject = filePath
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+		if matched, _ := path.Match(pattern, subject); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Large files tracked by lfs are kept out of the object database: their
+// content goes to .got/lfs/objects, named by its SHA-256, and a small
+// pointer blob naming it is committed instead. Checking out a pointer
+// writes the content back ("smudges" it).
+
+const (
+	// POINTER_VERSION is the first line of a pointer, as git-lfs writes it
+	POINTER_VERSION string = "version https://git-lfs.github.com/spec/v1"
+	// OID_PREFIX names the hash algorithm of the oid of a pointer
+	OID_PREFIX string = "sha256:"
+	// MAX_POINTER_SIZE is the size above which a blob cannot be a pointer
+	MAX_POINTER_SIZE int64 = 1024
+	// LFS_DIR is the directory of .got holding the large files
+	LFS_DIR string = "lfs"
+)
+
+func init() {
+	config.Register(
+		config.KeyInfo{Key: "lfs.url", Type: config.TYPE_PATH,
+			Description: "directory shared as LFS server, where lfs push and lfs fetch copy the large files"},
+	)
+}
+
+// Pointer is what gets committed for a large file: the SHA-256 of its
+// content, the oid, and its size
+type Pointer struct {
+	Oid  string
+	Size int64
+}
+
+// String returns the content of the pointer blob
+func (p Pointer) String() string {
+	return fmt.Sprintf("%s\noid %s%s\nsize %d\n", POINTER_VERSION, OID_PREFIX, p.Oid, p.Size)
+}
+
+// ParsePointer reads the content of a pointer blob, false when it is not one
+func ParsePointer(content string) (Pointer, bool) {
+	if int64(len(content)) > MAX_POINTER_SIZE {
+		return Pointer{}, false
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) != 3 || lines[0] != POINTER_VERSION {
+		return Pointer{}, false
+	}
+	oid, found := strings.CutPrefix(lines[1], "oid "+OID_PREFIX)
+	if !found || !isOid(oid) {
+		return Pointer{}, false
+	}
+	sizeField, found := strings.CutPrefix(lines[2], "size ")
+	size, err := strconv.ParseInt(sizeField, 10, 64)
+	if !found || err != nil || size < 0 {
+		return Pointer{}, false
+	}
+	return Pointer{Oid: oid, Size: size}, true
+}
+
+// isOid tells if s is a SHA-256 in lowercase hex
+func isOid(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Store gives access to the large files saved in .got/lfs/objects
+type Store struct {
+	conf   *config.Config
+	logger *logger.Logger
+}
+
+func NewStore(conf *config.Config, logger *logger.Logger) *Store {
+	return &Store{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// Dir returns the directory holding the large files
+func (s *Store) Dir() string {
+	return filepath.Join(s.conf.GotDir, LFS_DIR, "objects")
+}
+
+// objectPath returns the path of a large file in a directory laid out like
+// .got/lfs/objects: the oid under two levels of its first hex digits
+func objectPath(dir, oid string) string {
+	return filepath.Join(dir, oid[:2], oid[2:4], oid)
+}
+
+// Exists tells if the content of the oid is in the store
+func (s *Store) Exists(oid string) bool {
+	_, err := os.Stat(objectPath(s.Dir(), oid))
+	return err == nil
+}
+
+// List returns the oids of the large files in the store
+func (s *Store) List() ([]string, error) {
+	oids := []string{}
+	err := filepath.WalkDir(s.Dir(), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() && isOid(entry.Name()) {
+			oids = append(oids, entry.Name())
+		}
+		return nil
+	})
+	return oids, err
+}
+
+// Clean returns the pointer of the file, saving its content in the store
+// when asked to. A file that holds a pointer already, its content never
+// fetched, keeps it.
+func (s *Store) Clean(filePath string, store bool) (Pointer, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Pointer{}, err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() <= MAX_POINTER_SIZE {
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return Pointer{}, err
+		}
+		if pointer, ok := ParsePointer(string(content)); ok {
+			return pointer, nil
+		}
+		file.Seek(0, io.SeekStart)
+	}
+	if !store {
+		hasher := sha256.New()
+		size, err := io.Copy(hasher, file)
+		if err != nil {
+			return Pointer{}, err
+		}
+		return Pointer{Oid: fmt.Sprintf("%x", hasher.Sum(nil)), Size: size}, nil
+	}
+	return s.save(s.Dir(), file, "")
+}
+
+// save copies r into the directory, hashing it on the way, and returns its
+// pointer. When the oid is known, content that does not match it is
+// refused. The temporary file is made in the directory itself so that
+// renaming it never crosses file systems, the directory of lfs.url being
+// often a mount of its own.
+func (s *Store) save(dir string, r io.Reader, oid string) (Pointer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Pointer{}, err
+	}
+	temp, err := os.CreateTemp(dir, "tmp_object_*")
+	if err != nil {
+		return Pointer{}, err
+	}
+	defer os.Remove(temp.Name())
+	hasher := sha256.New()
+	size, err := io.Copy(temp, io.TeeReader(r, hasher))
+	if err != nil {
+		temp.Close()
+		return Pointer{}, err
+	}
+	if err := temp.Close(); err != nil {
+		return Pointer{}, err
+	}
+	pointer := Pointer{Oid: fmt.Sprintf("%x", hasher.Sum(nil)), Size: size}
+	if oid != "" && pointer.Oid != oid {
+		return Pointer{}, fmt.Errorf("corrupt LFS object %s: its content hashes to %s", oid, pointer.Oid)
+	}
+	target := objectPath(dir, pointer.Oid)
+	if _, err := os.Stat(target); err == nil {
+		return pointer, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return Pointer{}, err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return Pointer{}, err
+	}
+	return pointer, os.Rename(temp.Name(), target)
+}
+
+// Smudge writes the content of the pointer to w, fetching it from lfs.url
+// first when the store lacks it
+func (s *Store) Smudge(pointer Pointer, w io.Writer) error {
+	if !s.Exists(pointer.Oid) {
+		if _, err := s.Fetch([]string{pointer.Oid}); err != nil {
+			return err
+		}
+	}
+	file, err := os.Open(objectPath(s.Dir(), pointer.Oid))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
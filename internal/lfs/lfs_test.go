@@ -0,0 +1,196 @@
+package lfs
+
+import (
+	"bytes"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const content = "layered design\n"
+
+// contentOid is the SHA-256 of content
+const contentOid = "7d675f873cc3e7ed61941427abe24a385e6e838c854be86d17dbb300cd968fa5"
+
+// arrangeStore creates a repository with a file holding content and returns
+// its lfs store
+func arrangeStore(t *testing.T) *Store {
+	t.Helper()
+	repo := testrepo.New(t)
+	testrepo.WriteFile(t, "logo.psd", content)
+	return NewStore(repo.Conf, repo.Logger)
+}
+
+func TestPointer(t *testing.T) {
+	pointer := Pointer{Oid: contentOid, Size: 15}
+	expected := "version https://git-lfs.github.com/spec/v1\noid sha256:" + contentOid + "\nsize 15\n"
+	if pointer.String() != expected {
+		t.Errorf("Unexpected pointer:\n%s", pointer.String())
+	}
+	if parsed, ok := ParsePointer(pointer.String()); !ok || parsed != pointer {
+		t.Errorf("Expected the pointer to parse back, got %+v, %v", parsed, ok)
+	}
+	for _, notPointer := range []string{
+		"",
+		content,
+		strings.Replace(expected, "spec/v1", "spec/v2", 1),
+		strings.Replace(expected, "sha256:", "sha1:", 1),
+		strings.Replace(expected, contentOid, contentOid[:40], 1),
+		strings.Replace(expected, "size 15", "size -1", 1),
+		expected + "extra\n",
+	} {
+		if _, ok := ParsePointer(notPointer); ok {
+			t.Errorf("Expected %q not to be a pointer", notPointer)
+		}
+	}
+}
+
+func TestCleanAndSmudge(t *testing.T) {
+	store := arrangeStore(t)
+
+	pointer, err := store.Clean("logo.psd", false)
+	if err != nil {
+		t.Fatalf("Clean returned error: %v", err)
+	}
+	if pointer != (Pointer{Oid: contentOid, Size: int64(len(content))}) {
+		t.Errorf("Unexpected pointer %+v", pointer)
+	}
+	if store.Exists(pointer.Oid) {
+		t.Fatalf("Expected hashing alone not to store the content")
+	}
+	if stored, err := store.Clean("logo.psd", true); err != nil || stored != pointer {
+		t.Fatalf("Expected the stored pointer to be %+v, got %+v, %v", pointer, stored, err)
+	}
+	if !store.Exists(pointer.Oid) {
+		t.Errorf("Expected the content to be stored")
+	}
+	stored, err := os.ReadFile(filepath.Join(".got", "lfs", "objects", pointer.Oid[:2], pointer.Oid[2:4], pointer.Oid))
+	if err != nil || string(stored) != content {
+		t.Errorf("Unexpected stored content %q, %v", stored, err)
+	}
+	if oids, err := store.List(); err != nil || len(oids) != 1 || oids[0] != pointer.Oid {
+		t.Errorf("Unexpected list %v, %v", oids, err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := store.Smudge(pointer, out); err != nil || out.String() != content {
+		t.Errorf("Unexpected smudged content %q, %v", out.String(), err)
+	}
+
+	// a file holding its pointer cleans to the same pointer
+	os.WriteFile("copy.psd", []byte(pointer.String()), 0644)
+	if cleaned, err := store.Clean("copy.psd", true); err != nil || cleaned != pointer {
+		t.Errorf("Expected a pointer file to keep its pointer, got %+v, %v", cleaned, err)
+	}
+}
+
+func TestSmudgeMissing(t *testing.T) {
+	store := arrangeStore(t)
+	err := store.Smudge(Pointer{Oid: contentOid, Size: 15}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "lfs.url is not set") {
+		t.Errorf("Expected an error about lfs.url, got %v", err)
+	}
+}
+
+func TestPushAndFetch(t *testing.T) {
+	store := arrangeStore(t)
+	server := t.TempDir()
+	store.conf.SetConfigKeyValue("lfs.url", server)
+	pointer, _ := store.Clean("logo.psd", true)
+
+	if sent, err := store.Push([]string{pointer.Oid}); err != nil || sent != 1 {
+		t.Fatalf("Expected one object pushed, got %d, %v", sent, err)
+	}
+	if sent, err := store.Push([]string{pointer.Oid}); err != nil || sent != 0 {
+		t.Errorf("Expected nothing left to push, got %d, %v", sent, err)
+	}
+	if _, err := os.Stat(objectPath(server, pointer.Oid)); err != nil {
+		t.Fatalf("Expected the object on the server: %v", err)
+	}
+
+	os.RemoveAll(store.Dir())
+	out := &bytes.Buffer{}
+	if err := store.Smudge(pointer, out); err != nil || out.String() != content {
+		t.Errorf("Expected smudge to fetch the content, got %q, %v", out.String(), err)
+	}
+	if received, err := store.Fetch([]string{pointer.Oid}); err != nil || received != 0 {
+		t.Errorf("Expected nothing left to fetch, got %d, %v", received, err)
+	}
+
+	// content not matching its oid is refused
+	os.RemoveAll(store.Dir())
+	os.WriteFile(objectPath(server, pointer.Oid), []byte("tampered\n"), 0644)
+	if _, err := store.Fetch([]string{pointer.Oid}); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Expected a corrupt object error, got %v", err)
+	}
+	if store.Exists(pointer.Oid) {
+		t.Errorf("Expected the corrupt object not to be stored")
+	}
+}
+
+func TestTrack(t *testing.T) {
+	root := t.TempDir()
+	if added, err := Track(root, "*.psd"); err != nil || !added {
+		t.Fatalf("Expected *.psd to be tracked, got %v, %v", added, err)
+	}
+	if added, _ := Track(root, "*.psd"); added {
+		t.Errorf("Expected *.psd to be tracked once")
+	}
+	Track(root, "assets/*.png")
+	content, _ := os.ReadFile(filepath.Join(root, ATTRIBUTES_FILE))
+	expected := "*.psd filter=lfs diff=lfs merge=lfs -text\nassets/*.png filter=lfs diff=lfs merge=lfs -text\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected %s:\n%s", ATTRIBUTES_FILE, content)
+	}
+
+	patterns, err := Patterns(root)
+	if err != nil {
+		t.Fatalf("Patterns returned error: %v", err)
+	}
+	for filePath, tracked := range map[string]bool{
+		"logo.psd":            true,
+		"art/logo.psd":        true,
+		"assets/icon.png":     true,
+		"assets/big/icon.png": false,
+		"icon.png":            false,
+		"logo.psd.txt":        false,
+	} {
+		if IsTracked(patterns, filePath) != tracked {
+			t.Errorf("Expected IsTracked(%s) to be %v", filePath, tracked)
+		}
+	}
+
+	if removed, err := Untrack(root, "*.psd"); err != nil || !removed {
+		t.Fatalf("Expected *.psd to be untracked, got %v, %v", removed, err)
+	}
+	if removed, _ := Untrack(root, "*.psd"); removed {
+		t.Errorf("Expected *.psd to be untracked once")
+	}
+	if patterns, _ := Patterns(root); len(patterns) != 1 || patterns[0] != "assets/*.png" {
+		t.Errorf("Unexpected patterns %v", patterns)
+	}
+}
+
+func TestPushToAnotherFileSystem(t *testing.T) {
+	store := arrangeStore(t)
+	// /dev/shm is a tmpfs, apart from the file system of the repository
+	server, err := os.MkdirTemp("/dev/shm", "lfs_server_*")
+	if err != nil {
+		t.Skip("no /dev/shm to hold the LFS server")
+	}
+	t.Cleanup(func() { os.RemoveAll(server) })
+	store.conf.SetConfigKeyValue("lfs.url", server)
+	pointer, _ := store.Clean("logo.psd", true)
+
+	if sent, err := store.Push([]string{pointer.Oid}); err != nil || sent != 1 {
+		t.Fatalf("Expected one object pushed, got %d, %v", sent, err)
+	}
+	if stored, err := os.ReadFile(objectPath(server, pointer.Oid)); err != nil || string(stored) != content {
+		t.Errorf("Unexpected content on the server %q, %v", stored, err)
+	}
+	if entries, _ := os.ReadDir(server); len(entries) != 1 {
+		t.Errorf("Expected no temporary file left on the server, got %v", entries)
+	}
+}
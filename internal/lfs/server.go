@@ -0,0 +1,80 @@
+package lfs
+
+import (
+	"fmt"
+	"got_it/internal/transport"
+	"os"
+)
+
+// The LFS server is a directory laid out like .got/lfs/objects, named by
+// lfs.url, that repositories push their large files to and fetch them from
+
+// serverDir returns the directory of lfs.url
+func (s *Store) serverDir() (string, error) {
+	url, err := s.conf.GetConfigKeyValue("lfs.url")
+	if err != nil {
+		return "", fmt.Errorf("lfs.url is not set, there is no LFS server to transfer the large files with")
+	}
+	path, isLocal := transport.LocalPath(url)
+	if !isLocal {
+		return "", fmt.Errorf("unsupported LFS server '%s': only directories can serve large files", url)
+	}
+	return path, nil
+}
+
+// Push copies to the server the large files it lacks among the oids, and
+// returns how many were sent
+func (s *Store) Push(oids []string) (int, error) {
+	dir, err := s.serverDir()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, oid := range oids {
+		if _, err := os.Stat(objectPath(dir, oid)); err == nil {
+			continue
+		}
+		if err := s.copyObject(objectPath(s.Dir(), oid), dir, oid); err != nil {
+			return sent, err
+		}
+		s.logger.Debug("uploaded LFS object %s", oid)
+		sent++
+	}
+	return sent, nil
+}
+
+// Fetch copies from the server the large files of the oids the store
+// lacks, and returns how many were received
+func (s *Store) Fetch(oids []string) (int, error) {
+	dir, err := s.serverDir()
+	if err != nil {
+		return 0, err
+	}
+	received := 0
+	for _, oid := range oids {
+		if s.Exists(oid) {
+			continue
+		}
+		if err := s.copyObject(objectPath(dir, oid), s.Dir(), oid); err != nil {
+			return received, err
+		}
+		s.logger.Debug("downloaded LFS object %s", oid)
+		received++
+	}
+	return received, nil
+}
+
+// copyObject copies the large file at path into the directory, checking
+// its content matches the oid
+func (s *Store) copyObject(path, dir, oid string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("LFS object %s not found in %s", oid, path)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = s.save(dir, file, oid)
+	return err
+}
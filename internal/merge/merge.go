@@ -0,0 +1,128 @@
+package merge
+
+import (
+	"got_it/internal/diff"
+	"strings"
+)
+
+// MARKER_SIZE is the length of the conflict markers
+const MARKER_SIZE int = 7
+
+// MERGE_HEAD holds the commit being merged while its conflicts are resolved,
+// and MERGE_MSG the message of the merge commit
+const (
+	MERGE_HEAD string = "MERGE_HEAD"
+	MERGE_MSG  string = "MERGE_MSG"
+)
+
+// Labels name the two sides in the conflict markers
+type Labels struct {
+	Ours   string
+	Theirs string
+}
+
+// Files merges the changes made from base to ours and from base to theirs.
+// Overlapping changes are kept between conflict markers and reported by the
+// second return value.
+func Files(base, ours, theirs string, labels Labels) (string, bool) {
+	baseLines := diff.SplitLines(base)
+	ourLines := diff.SplitLines(ours)
+	theirLines := diff.SplitLines(theirs)
+	toOurs := matchLines(diff.Lines(base, ours), len(baseLines))
+	toTheirs := matchLines(diff.Lines(base, theirs), len(baseLines))
+
+	var result strings.Builder
+	conflicted := false
+	i, j, k := 0, 0, 0
+	for i < len(baseLines) || j < len(ourLines) || k < len(theirLines) {
+		if i < len(baseLines) && toOurs[i] == j && toTheirs[i] == k {
+			result.WriteString(baseLines[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+		// the chunk runs up to the next base line kept by both sides
+		next := i
+		for next < len(baseLines) && (toOurs[next] < 0 || toTheirs[next] < 0) {
+			next++
+		}
+		nextOurs, nextTheirs := len(ourLines), len(theirLines)
+		if next < len(baseLines) {
+			nextOurs, nextTheirs = toOurs[next], toTheirs[next]
+		}
+		baseChunk := baseLines[i:next]
+		ourChunk := ourLines[j:nextOurs]
+		theirChunk := theirLines[k:nextTheirs]
+
+		switch {
+		case equalLines(ourChunk, baseChunk):
+			writeLines(&result, theirChunk)
+		case equalLines(theirChunk, baseChunk), equalLines(ourChunk, theirChunk):
+			writeLines(&result, ourChunk)
+		default:
+			conflicted = true
+			writeConflict(&result, ourChunk, theirChunk, labels)
+		}
+		i, j, k = next, nextOurs, nextTheirs
+	}
+	return result.String(), conflicted
+}
+
+// matchLines maps every base line to its index in the other version, or -1
+// when it was deleted
+func matchLines(edits []diff.Edit, baseLength int) []int {
+	matches := make([]int, baseLength)
+	i, j := 0, 0
+	for _, edit := range edits {
+		switch edit.Op {
+		case diff.OP_EQUAL:
+			matches[i] = j
+			i++
+			j++
+		case diff.OP_DELETE:
+			matches[i] = -1
+			i++
+		case diff.OP_INSERT:
+			j++
+		}
+	}
+	return matches
+}
+
+func writeConflict(result *strings.Builder, ours, theirs []string, labels Labels) {
+	result.WriteString(marker("<", labels.Ours))
+	writeLines(result, ours)
+	terminate(result)
+	result.WriteString(strings.Repeat("=", MARKER_SIZE) + "\n")
+	writeLines(result, theirs)
+	terminate(result)
+	result.WriteString(marker(">", labels.Theirs))
+}
+
+// terminate ends the last line written, so a marker can follow it
+func terminate(result *strings.Builder) {
+	if result.Len() > 0 && !strings.HasSuffix(result.String(), "\n") {
+		result.WriteString("\n")
+	}
+}
+
+func marker(char, label string) string {
+	return strings.TrimRight(strings.Repeat(char, MARKER_SIZE)+" "+label, " ") + "\n"
+}
+
+func writeLines(result *strings.Builder, lines []string) {
+	for _, line := range lines {
+		result.WriteString(line)
+	}
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
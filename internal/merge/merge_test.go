@@ -0,0 +1,131 @@
+package merge
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	labels := Labels{Ours: "HEAD", Theirs: "other"}
+	tests := []struct {
+		name       string
+		base       string
+		ours       string
+		theirs     string
+		want       string
+		conflicted bool
+	}{
+		{"only ours changed", "a\nb\nc\n", "a\nB\nc\n", "a\nb\nc\n", "a\nB\nc\n", false},
+		{"only theirs changed", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nC\n", "a\nb\nC\n", false},
+		{"separate changes", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", false},
+		{"same change", "a\nb\n", "a\nx\n", "a\nx\n", "a\nx\n", false},
+		{"insertions at both ends", "m\n", "top\nm\n", "m\nbottom\n", "top\nm\nbottom\n", false},
+		{"conflict", "a\nb\nc\n", "a\nours\nc\n", "a\ntheirs\nc\n",
+			"a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> other\nc\n", true},
+		{"conflict without final new line", "a", "b", "c", "<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> other\n", true},
+		{"add/add", "", "x\n", "y\n", "<<<<<<< HEAD\nx\n=======\ny\n>>>>>>> other\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicted := Files(tt.base, tt.ours, tt.theirs, labels)
+			if got != tt.want || conflicted != tt.conflicted {
+				t.Errorf("Expected (conflicted %v):\n%s\nGot (conflicted %v):\n%s", tt.conflicted, tt.want, conflicted, got)
+			}
+		})
+	}
+}
+
+func TestTrees(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	store := objects.NewStore(config.NewConfig(), logger.NewLogger(false, false))
+	blob := func(content string) models.TreeEntry {
+		hash, err := store.Write(content)
+		if err != nil {
+			t.Fatalf("Error writing object: %v", err)
+		}
+		return models.TreeEntry{Mode: "100644", Type: string(models.TT_BLOB), Hash: hash}
+	}
+	base := map[string]models.TreeEntry{
+		"kept.txt":     blob("kept\n"),
+		"ours.txt":     blob("1\n2\n3\n"),
+		"both.txt":     blob("1\n2\n3\n"),
+		"deleted.txt":  blob("gone\n"),
+		"modified.txt": blob("old\n"),
+	}
+	ours := map[string]models.TreeEntry{
+		"kept.txt":    base["kept.txt"],
+		"ours.txt":    blob("one\n2\n3\n"),
+		"both.txt":    blob("one\n2\n3\n"),
+		"deleted.txt": base["deleted.txt"],
+		"new.txt":     blob("new\n"),
+	}
+	theirs := map[string]models.TreeEntry{
+		"kept.txt":     base["kept.txt"],
+		"ours.txt":     base["ours.txt"],
+		"both.txt":     blob("1\n2\nthree\n"),
+		"modified.txt": blob("new\n"),
+	}
+	result, err := Trees(store, base, ours, theirs, Labels{Ours: "ours", Theirs: "theirs"})
+	if err != nil {
+		t.Fatalf("Trees returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"kept.txt":     "kept\n",
+		"ours.txt":     "one\n2\n3\n",
+		"both.txt":     "one\n2\nthree\n",
+		"new.txt":      "new\n",
+		"modified.txt": "new\n",
+	}
+	if len(result.Files) != len(expected) {
+		t.Errorf("Expected %d files, got %v", len(expected), result.Files)
+	}
+	for filePath, content := range expected {
+		got, err := store.Read(result.Files[filePath].Hash)
+		if err != nil || got != content {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", filePath, content, got, err)
+		}
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != (Conflict{Path: "modified.txt", Kind: CK_MODIFY_DELETE}) {
+		t.Errorf("Expected a modify/delete conflict on modified.txt, got %v", result.Conflicts)
+	}
+}
+
+func TestTreesBigFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	os.Mkdir(".got", 0755)
+	conf := config.NewConfig()
+	conf.SetConfigKeyValue("core.bigFileThreshold", "16")
+	store := objects.NewStore(conf, logger.NewLogger(false, false))
+	blob := func(content string) models.TreeEntry {
+		hash, _ := store.Write(content)
+		return models.TreeEntry{Mode: "100644", Type: string(models.TT_BLOB), Hash: hash}
+	}
+	base := map[string]models.TreeEntry{"big.bin": blob("1\n2\n3\n4\n5\n6\n7\n8\n9\n")}
+	ours := map[string]models.TreeEntry{"big.bin": blob("one\n2\n3\n4\n5\n6\n7\n8\n9\n")}
+	theirs := map[string]models.TreeEntry{"big.bin": blob("1\n2\n3\n4\n5\n6\n7\n8\nnine\n")}
+
+	result, err := Trees(store, base, ours, theirs, Labels{Ours: "ours", Theirs: "theirs"})
+	if err != nil {
+		t.Fatalf("Trees returned error: %v", err)
+	}
+	// the changes do not overlap, yet a big file is never merged line by line
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != (Conflict{Path: "big.bin", Kind: CK_BINARY}) {
+		t.Errorf("Expected a binary conflict on big.bin, got %v", result.Conflicts)
+	}
+	if result.Files["big.bin"] != ours["big.bin"] {
+		t.Errorf("Expected our version of big.bin to be kept, got %v", result.Files["big.bin"])
+	}
+}
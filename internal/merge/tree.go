@@ -0,0 +1,142 @@
+package merge
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"sort"
+)
+
+// ConflictKind tells why a file could not be merged
+type ConflictKind string
+
+const (
+	CK_CONTENT       ConflictKind = "content"
+	CK_ADD_ADD       ConflictKind = "add/add"
+	CK_MODIFY_DELETE ConflictKind = "modify/delete"
+	CK_BINARY        ConflictKind = "binary"
+)
+
+// Conflict is a file whose changes overlap
+type Conflict struct {
+	Path string
+	Kind ConflictKind
+}
+
+// Result is the outcome of merging two trees
+type Result struct {
+	Files     map[string]models.TreeEntry
+	Conflicts []Conflict
+}
+
+// String describes the conflict as merge reports it
+func (c Conflict) String() string {
+	if c.Kind == CK_MODIFY_DELETE {
+		return fmt.Sprintf("CONFLICT (%s): %s deleted in one side and modified in the other", c.Kind, c.Path)
+	}
+	if c.Kind == CK_BINARY {
+		return fmt.Sprintf("CONFLICT (%s): Cannot merge binary files in %s, our version is kept", c.Kind, c.Path)
+	}
+	return fmt.Sprintf("CONFLICT (%s): Merge conflict in %s", c.Kind, c.Path)
+}
+
+// Trees merges the flattened trees ours and theirs, both coming from base.
+// Merged and conflicted blobs are written to the store; conflicted files
+// hold conflict markers, or the modified version for modify/delete, or our
+// version for files above core.bigFileThreshold.
+func Trees(store *objects.Store, base, ours, theirs map[string]models.TreeEntry, labels Labels) (Result, error) {
+	result := Result{Files: make(map[string]models.TreeEntry)}
+	paths := make(map[string]bool)
+	for _, files := range []map[string]models.TreeEntry{base, ours, theirs} {
+		for filePath := range files {
+			paths[filePath] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for filePath := range paths {
+		sorted = append(sorted, filePath)
+	}
+	sort.Strings(sorted)
+
+	for _, filePath := range sorted {
+		baseEntry, inBase := base[filePath]
+		ourEntry, inOurs := ours[filePath]
+		theirEntry, inTheirs := theirs[filePath]
+
+		switch {
+		case sameEntry(ourEntry, inOurs, theirEntry, inTheirs),
+			sameEntry(theirEntry, inTheirs, baseEntry, inBase):
+			if inOurs {
+				result.Files[filePath] = ourEntry
+			}
+		case sameEntry(ourEntry, inOurs, baseEntry, inBase):
+			if inTheirs {
+				result.Files[filePath] = theirEntry
+			}
+		case !inOurs || !inTheirs:
+			result.Conflicts = append(result.Conflicts, Conflict{Path: filePath, Kind: CK_MODIFY_DELETE})
+			if inOurs {
+				result.Files[filePath] = ourEntry
+			} else {
+				result.Files[filePath] = theirEntry
+			}
+		case store.IsBig(ourEntry.Hash) || store.IsBig(theirEntry.Hash) || (inBase && store.IsBig(baseEntry.Hash)):
+			// too big to be read in memory and merged line by line
+			result.Conflicts = append(result.Conflicts, Conflict{Path: filePath, Kind: CK_BINARY})
+			result.Files[filePath] = ourEntry
+		default:
+			entry, conflicted, err := mergeBlobs(store, baseEntry, inBase, ourEntry, theirEntry, labels)
+			if err != nil {
+				return Result{}, err
+			}
+			result.Files[filePath] = entry
+			if conflicted {
+				kind := CK_CONTENT
+				if !inBase {
+					kind = CK_ADD_ADD
+				}
+				result.Conflicts = append(result.Conflicts, Conflict{Path: filePath, Kind: kind})
+			}
+		}
+	}
+	return result, nil
+}
+
+// mergeBlobs merges the contents of a file changed on both sides
+func mergeBlobs(store *objects.Store, base models.TreeEntry, inBase bool, ours, theirs models.TreeEntry, labels Labels) (models.TreeEntry, bool, error) {
+	baseContent := ""
+	if inBase {
+		var err error
+		if baseContent, err = store.Read(base.Hash); err != nil {
+			return models.TreeEntry{}, false, err
+		}
+	}
+	ourContent, err := store.Read(ours.Hash)
+	if err != nil {
+		return models.TreeEntry{}, false, err
+	}
+	theirContent, err := store.Read(theirs.Hash)
+	if err != nil {
+		return models.TreeEntry{}, false, err
+	}
+	merged, conflicted := Files(baseContent, ourContent, theirContent, labels)
+	hash, err := store.Write(merged)
+	if err != nil {
+		return models.TreeEntry{}, false, err
+	}
+	entry := ours
+	entry.Hash = hash
+	if inBase && ours.Mode == base.Mode {
+		entry.Mode = theirs.Mode
+	}
+	return entry, conflicted, nil
+}
+
+// sameEntry tells if two versions of a file are identical, both missing
+// counting as identical
+func sameEntry(a models.TreeEntry, inA bool, b models.TreeEntry, inB bool) bool {
+	if !inA || !inB {
+		return inA == inB
+	}
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
@@ -2,19 +2,23 @@ package models
 
 import (
 	"fmt"
+	"got_it/internal/date"
 	"got_it/internal/logger"
 	"strings"
+	"time"
 )
 
 type CommitData struct {
 	Tree           string
 	Parent         string
+	Parents        []string
 	AuthorName     string
 	AuthorEmail    string
-	AuthorDate     string
+	AuthorDate     time.Time
 	CommitterName  string
 	CommitterEmail string
-	CommitterDate  string
+	CommitterDate  time.Time
+	Signature      string // the armored signature of the gpgsig header
 	Message        string
 }
 
@@ -30,6 +34,7 @@ const (
 	AUTHOR    CommitKey = "author"
 	COMMITTER CommitKey = "committer"
 	MESSAGE   CommitKey = "message"
+	GPGSIG    CommitKey = "gpgsig"
 )
 
 var commitKeys = map[CommitKey]string{
@@ -38,6 +43,7 @@ var commitKeys = map[CommitKey]string{
 	AUTHOR:    "author",
 	COMMITTER: "committer",
 	MESSAGE:   "message",
+	GPGSIG:    "gpgsig",
 }
 
 func NewCommitDataParser(logger *logger.Logger) CommitDataParser {
@@ -47,75 +53,111 @@ func NewCommitDataParser(logger *logger.Logger) CommitDataParser {
 }
 
 func (cp *CommitDataParser) Parse(commitMetadata string) (CommitData, error) {
-	commitData := &CommitData{}
-	commitData, error := parseCommitMetadata(cp.logger, commitMetadata, commitData)
-	if error != nil {
-		cp.logger.Debug("Error parsing commit metadata: %s", error)
-		return *commitData, nil
+	commitData, err := parseCommitMetadata(cp.logger, commitMetadata, &CommitData{})
+	if err != nil {
+		cp.logger.Debug("Error parsing commit metadata: %s", err)
+		return CommitData{}, err
 	}
 	return *commitData, nil
 }
 
-// fetchValueFromKeyInCommitMetadata returns the value of the key in the commit metadata
+// FormatCommit returns the content of the commit object described by cd
+func FormatCommit(cd CommitData) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("tree %s\n", cd.Tree))
+	parents := cd.Parents
+	if len(parents) == 0 && cd.Parent != "" {
+		parents = []string{cd.Parent}
+	}
+	for _, parent := range parents {
+		content.WriteString(fmt.Sprintf("parent %s\n", parent))
+	}
+	content.WriteString(fmt.Sprintf("author %s <%s> %s\n", cd.AuthorName, cd.AuthorEmail, date.FormatCommitDate(cd.AuthorDate)))
+	content.WriteString(fmt.Sprintf("committer %s <%s> %s\n", cd.CommitterName, cd.CommitterEmail, date.FormatCommitDate(cd.CommitterDate)))
+	if cd.Signature != "" {
+		// the lines of the signature go on the header with a leading space
+		signature := strings.ReplaceAll(strings.TrimRight(cd.Signature, "\n"), "\n", "\n ")
+		content.WriteString(fmt.Sprintf("%s %s\n", commitKeys[GPGSIG], signature))
+	}
+	content.WriteString("\n")
+	content.WriteString(fmt.Sprintf("%s\n", cd.Message))
+	return content.String()
+}
+
+// parseCommitMetadata fills cd with the header fields and the message of the commit
 func parseCommitMetadata(logger *logger.Logger, commitContent string, cd *CommitData) (*CommitData, error) {
-	// read the commit metadata, line by line until find the line with 'tree' prefix
+	// read the header line by line until the first empty line after it,
+	// everything after that line is the commit message
 	lines := strings.Split(commitContent, "\n")
-	fieldsFilled := 0
+	headerStarted := false
 	flagNextlineIsMessage := false
 	var commitMessage string
+	inSignature := false
 	for _, line := range lines {
+		if inSignature && strings.HasPrefix(line, " ") {
+			cd.Signature += line[1:] + "\n"
+			continue
+		}
+		inSignature = false
 		line = strings.TrimSpace(line)
 		logger.Debug("Line: %s", line)
 
-		parts := strings.Split(line, " ")
-		if len(parts) >= 2 && fieldsFilled < 4 {
-			value := strings.Join(parts[1:], " ")
-			switch parts[0] {
-
-			case commitKeys[TREE]:
-				cd.Tree = value
-				fieldsFilled++
-
-			case commitKeys[PARENT]:
-				cd.Parent = value
-				fieldsFilled++
-
-			case commitKeys[AUTHOR]:
-				name, email, date, err := parseAuthoOrCommiterLine(line)
-				if err != nil {
-					return nil, err
-				}
-				cd.AuthorName = name
-				cd.AuthorEmail = email
-				cd.AuthorDate = date
-				fieldsFilled++
-
-			case commitKeys[COMMITTER]:
-				name, email, date, err := parseAuthoOrCommiterLine(line)
-				if err != nil {
-					return nil, err
-				}
-				cd.CommitterName = name
-				cd.CommitterEmail = email
-				cd.CommitterDate = date
-				fieldsFilled++
-
-			default:
-				continue
-			}
+		if flagNextlineIsMessage {
+			logger.Debug("Getting commit message: %s", line)
+			commitMessage += line + "\n"
+			continue
 		}
-		// if fieeldsFilled = 4 means the next should be the message
 		if line == "" {
-			flagNextlineIsMessage = true
-			logger.Debug("Next line should be the message")
+			if headerStarted {
+				flagNextlineIsMessage = true
+				logger.Debug("Next line should be the message")
+			}
 			continue
 		}
-		if flagNextlineIsMessage {
-			logger.Debug("Getting commit message: %s", line)
-			commitMessage += line + "\n"
+		headerStarted = true
+
+		parts := strings.Split(line, " ")
+		if len(parts) < 2 {
 			continue
 		}
+		value := strings.Join(parts[1:], " ")
+		switch parts[0] {
+
+		case commitKeys[TREE]:
+			cd.Tree = value
+
+		case commitKeys[PARENT]:
+			if cd.Parent == "" {
+				cd.Parent = value
+			}
+			cd.Parents = append(cd.Parents, value)
 
+		case commitKeys[AUTHOR]:
+			name, email, rawDate, err := parseAuthoOrCommiterLine(line)
+			if err != nil {
+				return nil, err
+			}
+			cd.AuthorName = name
+			cd.AuthorEmail = email
+			cd.AuthorDate = parseDate(logger, rawDate)
+
+		case commitKeys[COMMITTER]:
+			name, email, rawDate, err := parseAuthoOrCommiterLine(line)
+			if err != nil {
+				return nil, err
+			}
+			cd.CommitterName = name
+			cd.CommitterEmail = email
+			cd.CommitterDate = parseDate(logger, rawDate)
+
+		case commitKeys[GPGSIG]:
+			// the next lines starting with a space go on with the signature
+			cd.Signature = value + "\n"
+			inSignature = true
+
+		default:
+			continue
+		}
 	}
 	if commitMessage != "" {
 		logger.Debug("Commit message: %s", commitMessage)
@@ -159,6 +201,16 @@ func GetKeyCommitMetadata(logger *logger.Logger, commitContent string, key Commi
 	return "", fmt.Errorf("key %s not found in commit", commitKeys[key])
 }
 
+// parseDate parses the date of an author or committer line, an invalid date
+// becomes the zero time
+func parseDate(logger *logger.Logger, rawDate string) time.Time {
+	t, err := date.ParseCommitDate(rawDate)
+	if err != nil {
+		logger.Debug("Error parsing commit date: %s", err)
+	}
+	return t
+}
+
 func parseAuthoOrCommiterLine(line string) (string, string, string, error) {
 	parts := strings.Split(line, " ")
 	if len(parts) < 3 {
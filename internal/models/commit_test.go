@@ -2,7 +2,9 @@ package models
 
 import (
 	"got_it/internal/logger"
+	"strings"
 	"testing"
+	"time"
 )
 
 var mockCommitContent string = `tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904
@@ -35,10 +37,10 @@ func TestParseCommitMetadata(t *testing.T) {
 		Parent:         "3b18e56521f7048abf1ab774cfb4f882c7e61fe4",
 		AuthorName:     "John Doe",
 		AuthorEmail:    "johndoe@example.com",
-		AuthorDate:     "1623501234 +0200",
+		AuthorDate:     time.Unix(1623501234, 0).In(time.FixedZone("", 2*3600)),
 		CommitterName:  "John Doe",
 		CommitterEmail: "johndoe@example.com",
-		CommitterDate:  "1623501234 +0200",
+		CommitterDate:  time.Unix(1623501234, 0).In(time.FixedZone("", 2*3600)),
 		Message:        "Initial commit with file.txt and README.md",
 	}
 	commitData, err := parseCommitMetadata(logger, commitContent, &CommitData{})
@@ -58,7 +60,7 @@ func TestParseCommitMetadata(t *testing.T) {
 	if commitData.AuthorEmail != expectedCommitData.AuthorEmail {
 		t.Errorf("Expected author email %s, got %s", expectedCommitData.AuthorEmail, commitData.AuthorEmail)
 	}
-	if commitData.AuthorDate != expectedCommitData.AuthorDate {
+	if !commitData.AuthorDate.Equal(expectedCommitData.AuthorDate) || commitData.AuthorDate.Format("-0700") != "+0200" {
 		t.Errorf("Expected author date %s, got %s", expectedCommitData.AuthorDate, commitData.AuthorDate)
 	}
 	if commitData.CommitterName != expectedCommitData.CommitterName {
@@ -67,10 +69,36 @@ func TestParseCommitMetadata(t *testing.T) {
 	if commitData.CommitterEmail != expectedCommitData.CommitterEmail {
 		t.Errorf("Expected committer email %s, got %s", expectedCommitData.CommitterEmail, commitData.CommitterEmail)
 	}
-	if commitData.CommitterDate != expectedCommitData.CommitterDate {
+	if !commitData.CommitterDate.Equal(expectedCommitData.CommitterDate) || commitData.CommitterDate.Format("-0700") != "+0200" {
 		t.Errorf("Expected committer date %s, got %s", expectedCommitData.CommitterDate, commitData.CommitterDate)
 	}
 	if commitData.Message != expectedCommitData.Message {
 		t.Errorf("Expected message \"%s\", got \"%s\"", expectedCommitData.Message, commitData.Message)
 	}
 }
+
+// TestSignedCommit tests that the gpgsig header survives formatting and
+// parsing
+func TestSignedCommit(t *testing.T) {
+	logger := logger.NewLogger(false, false)
+	signature := "-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n"
+	commitData, err := parseCommitMetadata(logger, mockCommitContent, &CommitData{})
+	if err != nil {
+		t.Fatalf("Error parsing commit metadata: %v", err)
+	}
+	commitData.Signature = signature
+	content := FormatCommit(*commitData)
+	if !strings.Contains(content, "\ngpgsig -----BEGIN SSH SIGNATURE-----\n U1NIU0lH\n -----END SSH SIGNATURE-----\n\n") {
+		t.Errorf("Expected the signature in a gpgsig header, got:\n%s", content)
+	}
+	parsed, err := parseCommitMetadata(logger, content, &CommitData{})
+	if err != nil {
+		t.Fatalf("Error parsing signed commit: %v", err)
+	}
+	if parsed.Signature != signature {
+		t.Errorf("Expected signature %q, got %q", signature, parsed.Signature)
+	}
+	if parsed.Message != commitData.Message || parsed.CommitterName != commitData.CommitterName {
+		t.Errorf("Expected the rest of the commit to be kept, got %+v", parsed)
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+// ObjectType is the kind of content stored in an object file
+type ObjectType string
+
+const (
+	OT_BLOB   ObjectType = "blob"
+	OT_TREE   ObjectType = "tree"
+	OT_COMMIT ObjectType = "commit"
+	OT_TAG    ObjectType = "tag"
+)
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"got_it/internal/logger"
+	"strings"
+	"time"
+)
+
+// TagData is the content of an annotated tag object
+type TagData struct {
+	Object      string
+	Type        ObjectType
+	Tag         string
+	TaggerName  string
+	TaggerEmail string
+	TaggerDate  time.Time
+	Message     string
+	Signature   string // the armored signature following the message
+}
+
+// signatureMarkers start the signature appended to the message of a tag
+var signatureMarkers = []string{"-----BEGIN PGP SIGNATURE-----", "-----BEGIN SSH SIGNATURE-----"}
+
+// ParseTag reads the header fields and the message of an annotated tag
+func ParseTag(logger *logger.Logger, content string) (TagData, error) {
+	td := TagData{}
+	header, message, _ := strings.Cut(content, "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		switch key {
+		case "object":
+			td.Object = value
+		case "type":
+			td.Type = ObjectType(value)
+		case "tag":
+			td.Tag = value
+		case "tagger":
+			name, email, rawDate, err := parseAuthoOrCommiterLine(line)
+			if err != nil {
+				return TagData{}, err
+			}
+			td.TaggerName = name
+			td.TaggerEmail = email
+			td.TaggerDate = parseDate(logger, rawDate)
+		}
+	}
+	if td.Object == "" || td.Tag == "" {
+		return TagData{}, fmt.Errorf("invalid tag object")
+	}
+	for _, marker := range signatureMarkers {
+		if i := strings.LastIndex("\n"+message, "\n"+marker); i >= 0 {
+			message, td.Signature = message[:i], message[i:]
+			break
+		}
+	}
+	td.Message = strings.TrimSpace(message)
+	return td, nil
+}
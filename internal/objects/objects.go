@@ -0,0 +1,270 @@
+package objects
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ABBREV_LENGTH is the minimum length of abbreviated hashes
+const ABBREV_LENGTH int = 7
+
+// DEFAULT_BIG_FILE_THRESHOLD is the size above which blobs are not loaded
+// in memory to be diffed
+const DEFAULT_BIG_FILE_THRESHOLD string = "512m"
+
+func init() {
+	config.Register(
+		config.KeyInfo{Key: "core.bigFileThreshold", Type: config.TYPE_INT, Default: DEFAULT_BIG_FILE_THRESHOLD,
+			Description: "size above which files are shown as binary instead of being diffed"},
+	)
+}
+
+// Store gives access to the objects saved in the .got/objects directory
+type Store struct {
+	conf   *config.Config
+	logger *logger.Logger
+}
+
+func NewStore(conf *config.Config, logger *logger.Logger) *Store {
+	return &Store{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// Format returns the hash algorithm naming the objects of the store
+func (s *Store) Format() utils.ObjectFormat {
+	return s.conf.ObjectFormat()
+}
+
+// ObjectPath returns the path of the object file for the given hash
+func (s *Store) ObjectPath(hash string) string {
+	return filepath.Join(s.conf.GotDir, "objects", hash[:2], hash[2:])
+}
+
+// Exists tells if the object is present in the store
+func (s *Store) Exists(hash string) bool {
+	if len(hash) < 3 {
+		return false
+	}
+	_, err := os.Stat(s.ObjectPath(hash))
+	return err == nil
+}
+
+// Read returns the content of the object
+func (s *Store) Read(hash string) (string, error) {
+	if !s.Format().IsHash(hash) {
+		return "", fmt.Errorf("invalid object name %s", hash)
+	}
+	content, err := os.ReadFile(s.ObjectPath(hash))
+	if err != nil {
+		s.logger.Debug("Error reading object file: %s", err)
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("object %s not found", hash)
+		}
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Write hashes the content, stores it and returns its hash
+func (s *Store) Write(content string) (string, error) {
+	hash := s.Format().HashContent(content)
+	objectPath := s.ObjectPath(hash)
+	if _, err := os.Stat(objectPath); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return "", err
+	}
+	return hash, os.WriteFile(objectPath, []byte(content), 0644)
+}
+
+// WriteFrom stores the content read from r and returns its hash. The content
+// is hashed as it is copied to a temporary file, renamed to the object once
+// the hash is known, so memory stays bounded whatever its size.
+func (s *Store) WriteFrom(r io.Reader) (string, error) {
+	dir := filepath.Join(s.conf.GotDir, "objects")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	temp, err := os.CreateTemp(dir, "tmp_obj_*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+	hasher := s.Format().NewHasher()
+	if _, err := io.Copy(temp, io.TeeReader(r, hasher)); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+	hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	objectPath := s.ObjectPath(hash)
+	if _, err := os.Stat(objectPath); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(temp.Name(), objectPath)
+}
+
+// WriteFile stores the content of the file at filePath, read in chunks, and
+// returns its hash
+func (s *Store) WriteFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return s.WriteFrom(file)
+}
+
+// Open returns a reader of the content of the object, for the blobs too big
+// to be read at once
+func (s *Store) Open(hash string) (*os.File, error) {
+	if !s.Format().IsHash(hash) {
+		return nil, fmt.Errorf("invalid object name %s", hash)
+	}
+	file, err := os.Open(s.ObjectPath(hash))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("object %s not found", hash)
+	}
+	return file, err
+}
+
+// Size returns the size of the content of the object
+func (s *Store) Size(hash string) (int64, error) {
+	if !s.Format().IsHash(hash) {
+		return 0, fmt.Errorf("invalid object name %s", hash)
+	}
+	info, err := os.Stat(s.ObjectPath(hash))
+	if os.IsNotExist(err) {
+		return 0, fmt.Errorf("object %s not found", hash)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// IsBig tells if the object is larger than core.bigFileThreshold, too big
+// to be loaded in memory to be diffed
+func (s *Store) IsBig(hash string) bool {
+	if hash == "" {
+		return false
+	}
+	size, err := s.Size(hash)
+	return err == nil && size > s.conf.Int("core.bigFileThreshold")
+}
+
+// WriteCommit stores the commit object described by cd and returns its hash
+func (s *Store) WriteCommit(cd models.CommitData) (string, error) {
+	return s.Write(models.FormatCommit(cd))
+}
+
+// FindByPrefix returns the hashes of every object starting with prefix
+func (s *Store) FindByPrefix(prefix string) ([]string, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < 2 || !utils.IsHexString(prefix) {
+		return nil, fmt.Errorf("invalid object prefix %s", prefix)
+	}
+	dir := filepath.Join(s.conf.GotDir, "objects", prefix[:2])
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	matches := []string{}
+	for _, entry := range entries {
+		hash := prefix[:2] + entry.Name()
+		if !entry.IsDir() && s.Format().IsHash(hash) && strings.HasPrefix(hash, prefix) {
+			matches = append(matches, hash)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// ReadCommit reads and parses a commit object
+func (s *Store) ReadCommit(hash string) (models.CommitData, error) {
+	content, err := s.Read(hash)
+	if err != nil {
+		return models.CommitData{}, err
+	}
+	if TypeOf(content) != models.OT_COMMIT {
+		return models.CommitData{}, fmt.Errorf("object %s is not a commit", hash)
+	}
+	parser := models.NewCommitDataParser(s.logger)
+	return parser.Parse(content)
+}
+
+// ReadTag reads and parses an annotated tag object
+func (s *Store) ReadTag(hash string) (models.TagData, error) {
+	content, err := s.Read(hash)
+	if err != nil {
+		return models.TagData{}, err
+	}
+	if TypeOf(content) != models.OT_TAG {
+		return models.TagData{}, fmt.Errorf("object %s is not a tag", hash)
+	}
+	return models.ParseTag(s.logger, content)
+}
+
+// TypeOf guesses the type of an object from its content
+func TypeOf(content string) models.ObjectType {
+	firstLine := strings.SplitN(content, "\n", 2)[0]
+	fields := strings.Fields(firstLine)
+	if len(fields) == 2 && utils.IsHash(fields[1]) {
+		switch fields[0] {
+		case "tree":
+			return models.OT_COMMIT
+		case "object":
+			return models.OT_TAG
+		}
+	}
+	if content == "" {
+		return models.OT_BLOB
+	}
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if _, err := ParseTreeLine(line); err != nil {
+			return models.OT_BLOB
+		}
+	}
+	return models.OT_TREE
+}
+
+// Abbreviate returns the shortest prefix of hash, at least minLength long,
+// that does not match any other object
+func (s *Store) Abbreviate(hash string, minLength int) string {
+	if len(hash) <= minLength {
+		return hash
+	}
+	matches, err := s.FindByPrefix(hash[:minLength])
+	if err != nil {
+		return hash[:minLength]
+	}
+	length := minLength
+	for _, match := range matches {
+		for match != hash && length < len(hash) && strings.HasPrefix(match, hash[:length]) {
+			length++
+		}
+	}
+	return hash[:length]
+}
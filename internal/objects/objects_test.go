@@ -0,0 +1,134 @@
+package objects
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func arrangeStore(t *testing.T) *Store {
+	t.Helper()
+	tempDir := t.TempDir()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	return NewStore(config.NewConfig(), logger.NewLogger(false, false))
+}
+
+// TestFlattenTree checks that subtree content repeated inline is not read twice
+func TestFlattenTree(t *testing.T) {
+	s := arrangeStore(t)
+	blob1, _ := s.Write("one\n")
+	blob2, _ := s.Write("two\n")
+	subTreeContent := fmt.Sprintf("100644 blob %s\ttext2.txt\n", blob2)
+	subTree, _ := s.Write(subTreeContent)
+	treeContent := fmt.Sprintf("100644 blob %s\ttext1.txt\n040000 tree %s\tsubdir\n%s", blob1, subTree, subTreeContent)
+	tree, err := s.Write(treeContent)
+	if err != nil {
+		t.Fatalf("Error writing tree: %v", err)
+	}
+
+	entries, err := s.ReadTree(tree)
+	if err != nil {
+		t.Fatalf("Error reading tree: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 entries, got %d: %v", len(entries), entries)
+	}
+
+	files, err := s.FlattenTree(tree)
+	if err != nil {
+		t.Fatalf("Error flattening tree: %v", err)
+	}
+	expected := map[string]string{"text1.txt": blob1, "subdir/text2.txt": blob2}
+	if len(files) != len(expected) {
+		t.Errorf("Expected %d files, got %v", len(expected), files)
+	}
+	for name, hash := range expected {
+		if files[name].Hash != hash {
+			t.Errorf("Expected %s for %s, got %s", hash, name, files[name].Hash)
+		}
+	}
+
+	entry, err := s.FindInTree(tree, "subdir/text2.txt")
+	if err != nil || entry.Hash != blob2 {
+		t.Errorf("FindInTree returned %v, %v", entry, err)
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	hash := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	tests := []struct {
+		content string
+		want    models.ObjectType
+	}{
+		{"just some text\n", models.OT_BLOB},
+		{"tree " + hash + "\nauthor A <a@b> 1 +0000\n\nmsg\n", models.OT_COMMIT},
+		{"object " + hash + "\ntype commit\ntag v1\n", models.OT_TAG},
+		{"100644 blob " + hash + "\tfile.txt\n", models.OT_TREE},
+	}
+	for _, tt := range tests {
+		if got := TypeOf(tt.content); got != tt.want {
+			t.Errorf("TypeOf(%q) = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	s := arrangeStore(t)
+	content := "start\n" + strings.Repeat("x", 1<<20) + "end\n"
+	os.WriteFile("file.bin", []byte(content), 0644)
+
+	hash, err := s.WriteFile("file.bin")
+	if err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if hash != s.Format().HashContent(content) {
+		t.Errorf("Expected hash %s, got %s", s.Format().HashContent(content), hash)
+	}
+	if size, err := s.Size(hash); err != nil || size != int64(len(content)) {
+		t.Errorf("Expected an object of %d bytes, got %d (%v)", len(content), size, err)
+	}
+	blob, err := s.Open(hash)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	start := make([]byte, 6)
+	io.ReadFull(blob, start)
+	blob.Close()
+	if string(start) != "start\n" {
+		t.Errorf("Unexpected start of the object %q", start)
+	}
+	entries, _ := os.ReadDir(filepath.Join(".got", "objects"))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			t.Errorf("Expected the temporary file to be removed, found %s", entry.Name())
+		}
+	}
+
+	// the same content again is not stored twice
+	if again, err := s.WriteFrom(strings.NewReader("small\n")); err != nil || again != s.Format().HashContent("small\n") {
+		t.Errorf("Expected the hash of the content, got %s (%v)", again, err)
+	}
+}
+
+func TestIsBig(t *testing.T) {
+	s := arrangeStore(t)
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	small, _ := s.Write("small\n")
+	big, _ := s.Write(strings.Repeat("x", 2048))
+	if s.IsBig(big) {
+		t.Errorf("Expected 2k to be below the default threshold")
+	}
+	s.conf.SetConfigKeyValue("core.bigFileThreshold", "1k")
+	if !s.IsBig(big) || s.IsBig(small) || s.IsBig("") {
+		t.Errorf("Expected only the 2k blob to be above a 1k threshold")
+	}
+}
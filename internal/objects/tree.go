@@ -0,0 +1,205 @@
+package objects
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/utils"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ParseTreeLine parses a line of a tree object.
+// Lines have the form "<mode> <type> <hash>\t<name>"; the name may also be
+// separated by a space.
+func ParseTreeLine(line string) (models.TreeEntry, error) {
+	var parts []string
+	if head, name, found := strings.Cut(line, "\t"); found {
+		parts = append(strings.Split(head, " "), name)
+	} else {
+		parts = strings.SplitN(line, " ", 4)
+	}
+	if len(parts) != 4 {
+		return models.TreeEntry{}, fmt.Errorf("invalid tree entry: %s", line)
+	}
+	entry := models.TreeEntry{
+		Mode: parts[models.TreeFormatMap[models.TK_MODE]],
+		Type: parts[models.TreeFormatMap[models.TK_TYPE]],
+		Hash: parts[models.TreeFormatMap[models.TK_HASH]],
+		Name: parts[models.TreeFormatMap[models.TK_NAME]],
+	}
+	switch models.TreeEntryType(entry.Type) {
+	case models.TT_BLOB, models.TT_TREE, models.TT_DELTA:
+	default:
+		return models.TreeEntry{}, fmt.Errorf("invalid tree entry type: %s", line)
+	}
+	if !utils.IsHash(entry.Hash) || entry.Name == "" {
+		return models.TreeEntry{}, fmt.Errorf("invalid tree entry: %s", line)
+	}
+	return entry, nil
+}
+
+// ReadTree returns the direct entries of a tree object.
+// Trees written by commit repeat the content of every subtree right after
+// its entry, those lines are skipped as they belong to the subtree.
+func (s *Store) ReadTree(hash string) ([]models.TreeEntry, error) {
+	content, err := s.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+	lines := splitLines(content)
+	entries := []models.TreeEntry{}
+	for i := 0; i < len(lines); i++ {
+		entry, err := ParseTreeLine(lines[i])
+		if err != nil {
+			return nil, fmt.Errorf("object %s is not a tree: %s", hash, err)
+		}
+		entries = append(entries, entry)
+		if entry.Type != string(models.TT_TREE) {
+			continue
+		}
+		i += s.inlinedLines(entry.Hash, lines[i+1:])
+	}
+	return entries, nil
+}
+
+// inlinedLines returns how many of the following lines are a copy of the subtree
+func (s *Store) inlinedLines(subTreeHash string, following []string) int {
+	subContent, err := s.Read(subTreeHash)
+	if err != nil {
+		return 0
+	}
+	subLines := splitLines(subContent)
+	if len(subLines) > len(following) {
+		return 0
+	}
+	for i, line := range subLines {
+		if following[i] != line {
+			return 0
+		}
+	}
+	return len(subLines)
+}
+
+// FlattenTree returns every blob reachable from the tree indexed by its path
+func (s *Store) FlattenTree(hash string) (map[string]models.TreeEntry, error) {
+	files := make(map[string]models.TreeEntry)
+	err := s.flattenTree(hash, "", files)
+	return files, err
+}
+
+func (s *Store) flattenTree(hash, prefix string, files map[string]models.TreeEntry) error {
+	entries, err := s.ReadTree(hash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := path.Join(prefix, entry.Name)
+		if entry.Type == string(models.TT_TREE) {
+			if err := s.flattenTree(entry.Hash, name, files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[name] = entry
+	}
+	return nil
+}
+
+// WriteTree stores the tree objects for files, indexed by their slash
+// separated paths as returned by FlattenTree, and returns the root tree hash.
+// Entries are sorted by name, and subtrees are repeated after their entry
+// like commit writes them.
+func (s *Store) WriteTree(files map[string]models.TreeEntry) (string, error) {
+	content, err := s.treeContent(files)
+	if err != nil {
+		return "", err
+	}
+	return s.Write(content)
+}
+
+func (s *Store) treeContent(files map[string]models.TreeEntry) (string, error) {
+	blobs := make(map[string]models.TreeEntry)
+	dirs := make(map[string]map[string]models.TreeEntry)
+	for filePath, entry := range files {
+		dir, rest, nested := strings.Cut(filePath, "/")
+		if !nested {
+			blobs[filePath] = entry
+			continue
+		}
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]models.TreeEntry)
+		}
+		dirs[dir][rest] = entry
+	}
+
+	names := make([]string, 0, len(blobs)+len(dirs))
+	for name := range blobs {
+		names = append(names, name)
+	}
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var content strings.Builder
+	for _, name := range names {
+		if entry, found := blobs[name]; found {
+			mode := entry.Mode
+			if mode == "" {
+				mode = "100644"
+			}
+			content.WriteString(fmt.Sprintf("%s %s %s\t%s\n", mode, models.TT_BLOB, entry.Hash, name))
+			continue
+		}
+		subContent, err := s.treeContent(dirs[name])
+		if err != nil {
+			return "", err
+		}
+		subHash, err := s.Write(subContent)
+		if err != nil {
+			return "", err
+		}
+		content.WriteString(fmt.Sprintf("040000 %s %s\t%s\n", models.TT_TREE, subHash, name))
+		content.WriteString(subContent)
+	}
+	return content.String(), nil
+}
+
+// FindInTree returns the entry at the given slash separated path of the tree
+func (s *Store) FindInTree(treeHash, filePath string) (models.TreeEntry, error) {
+	filePath = strings.Trim(path.Clean("/"+filePath), "/")
+	current := models.TreeEntry{Mode: "040000", Type: string(models.TT_TREE), Hash: treeHash}
+	if filePath == "" {
+		return current, nil
+	}
+	for _, name := range strings.Split(filePath, "/") {
+		if current.Type != string(models.TT_TREE) {
+			return models.TreeEntry{}, fmt.Errorf("path %s does not exist", filePath)
+		}
+		entries, err := s.ReadTree(current.Hash)
+		if err != nil {
+			return models.TreeEntry{}, err
+		}
+		found := false
+		for _, entry := range entries {
+			if entry.Name == name {
+				current = entry
+				found = true
+				break
+			}
+		}
+		if !found {
+			return models.TreeEntry{}, fmt.Errorf("path %s does not exist", filePath)
+		}
+	}
+	return current, nil
+}
+
+func splitLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
@@ -0,0 +1,223 @@
+package reflog
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/ident"
+	"got_it/internal/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is a line of a reflog: a ref moving from OldHash to NewHash
+type Entry struct {
+	OldHash string
+	NewHash string
+	Name    string
+	Email   string
+	Date    time.Time
+	Message string
+}
+
+// Reflog reads and writes the logs of ref updates kept in .got/logs
+type Reflog struct {
+	conf   *config.Config
+	logger *logger.Logger
+}
+
+func NewReflog(conf *config.Config, logger *logger.Logger) *Reflog {
+	return &Reflog{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// Path returns the file holding the reflog of fullRef
+func (rl *Reflog) Path(fullRef string) string {
+	return filepath.Join(rl.conf.GotDir, "logs", filepath.FromSlash(fullRef))
+}
+
+// Exists tells if fullRef has a reflog
+func (rl *Reflog) Exists(fullRef string) bool {
+	info, err := os.Stat(rl.Path(fullRef))
+	return err == nil && !info.IsDir()
+}
+
+// Read returns the entries of the reflog of fullRef, oldest first
+func (rl *Reflog) Read(fullRef string) ([]Entry, error) {
+	content, err := os.ReadFile(rl.Path(fullRef))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no reflog for '%s'", fullRef)
+		}
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		entry, err := ParseEntry(line)
+		if err != nil {
+			rl.logger.Debug("Skipping reflog line of %s: %s", fullRef, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Append records that fullRef moved from oldHash to newHash. The identity
+// and the date are the ones of the committer.
+func (rl *Reflog) Append(fullRef, oldHash, newHash, message string) error {
+	entry, err := rl.newEntry(oldHash, newHash, message)
+	if err != nil {
+		return err
+	}
+	logPath := rl.Path(fullRef)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(entry.String() + "\n")
+	return err
+}
+
+// Write replaces the reflog of fullRef with the entries
+func (rl *Reflog) Write(fullRef string, entries []Entry) error {
+	var content strings.Builder
+	for _, entry := range entries {
+		content.WriteString(entry.String() + "\n")
+	}
+	logPath := rl.Path(fullRef)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(logPath, []byte(content.String()), 0644)
+}
+
+// Expire removes the entries older than before and returns how many were removed
+func (rl *Reflog) Expire(fullRef string, before time.Time) (int, error) {
+	entries, err := rl.Read(fullRef)
+	if err != nil {
+		return 0, err
+	}
+	kept := []Entry{}
+	for _, entry := range entries {
+		if !entry.Date.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) {
+		return 0, nil
+	}
+	return len(entries) - len(kept), rl.Write(fullRef, kept)
+}
+
+// Delete removes the n-th newest entry of the reflog, the one <ref>@{n} names
+func (rl *Reflog) Delete(fullRef string, n int) error {
+	entries, err := rl.Read(fullRef)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n >= len(entries) {
+		return fmt.Errorf("reflog of '%s' has no entry %d", fullRef, n)
+	}
+	index := len(entries) - 1 - n
+	entries = append(entries[:index], entries[index+1:]...)
+	return rl.Write(fullRef, entries)
+}
+
+// Remove deletes the whole reflog of fullRef
+func (rl *Reflog) Remove(fullRef string) error {
+	err := os.Remove(rl.Path(fullRef))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List returns the names of the refs having a reflog, sorted
+func (rl *Reflog) List() ([]string, error) {
+	logsDir := filepath.Join(rl.conf.GotDir, "logs")
+	refs := []string{}
+	err := filepath.Walk(logsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			name, _ := filepath.Rel(logsDir, path)
+			refs = append(refs, filepath.ToSlash(name))
+		}
+		return nil
+	})
+	sort.Strings(refs)
+	return refs, err
+}
+
+// newEntry builds an entry dated now, or GOT_COMMITTER_DATE when it is set
+func (rl *Reflog) newEntry(oldHash, newHash, message string) (Entry, error) {
+	if oldHash == "" {
+		oldHash = rl.conf.ObjectFormat().ZeroHash()
+	}
+	if newHash == "" {
+		newHash = rl.conf.ObjectFormat().ZeroHash()
+	}
+	who, err := ident.Committer(rl.conf)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{
+		OldHash: oldHash,
+		NewHash: newHash,
+		Name:    who.Name,
+		Email:   who.Email,
+		Date:    who.Date,
+		// the message must stay on the line of the entry
+		Message: strings.Join(strings.Fields(message), " "),
+	}
+	return entry, nil
+}
+
+// ParseEntry reads a reflog line: "<old> <new> <name> <<email>> <seconds> <zone>\t<message>"
+func ParseEntry(line string) (Entry, error) {
+	header, message, _ := strings.Cut(line, "\t")
+	fields := strings.SplitN(header, " ", 3)
+	if len(fields) < 3 {
+		return Entry{}, fmt.Errorf("invalid reflog line '%s'", line)
+	}
+	ident := fields[2]
+	emailStart := strings.Index(ident, "<")
+	emailEnd := strings.LastIndex(ident, ">")
+	if emailStart < 0 || emailEnd < emailStart {
+		return Entry{}, fmt.Errorf("invalid reflog line '%s'", line)
+	}
+	when, err := date.ParseCommitDate(ident[emailEnd+1:])
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		OldHash: fields[0],
+		NewHash: fields[1],
+		Name:    strings.TrimSpace(ident[:emailStart]),
+		Email:   ident[emailStart+1 : emailEnd],
+		Date:    when,
+		Message: message,
+	}, nil
+}
+
+// String formats the entry as a reflog line, without the trailing new line
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %s %s <%s> %s\t%s", e.OldHash, e.NewHash, e.Name, e.Email, date.FormatCommitDate(e.Date), e.Message)
+}
@@ -0,0 +1,82 @@
+package reflog
+
+import (
+	"got_it/internal/testrepo"
+	"got_it/internal/utils"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func arrangeReflog(t *testing.T) *Reflog {
+	t.Helper()
+	repo := testrepo.New(t)
+	t.Setenv("GOT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GOT_COMMITTER_EMAIL", "johndoe@example.com")
+	return NewReflog(repo.Conf, repo.Logger)
+}
+
+func TestAppendAndRead(t *testing.T) {
+	rl := arrangeReflog(t)
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+
+	t.Setenv("GOT_COMMITTER_DATE", "1623500000 +0200")
+	if err := rl.Append("refs/heads/main", "", a, "commit (initial): first"); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	t.Setenv("GOT_COMMITTER_DATE", "1623600000 +0200")
+	if err := rl.Append("refs/heads/main", a, b, "commit: second\nline"); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	content, _ := os.ReadFile(rl.Path("refs/heads/main"))
+	want := utils.OF_SHA1.ZeroHash() + " " + a + " John Doe <johndoe@example.com> 1623500000 +0200\tcommit (initial): first\n" +
+		a + " " + b + " John Doe <johndoe@example.com> 1623600000 +0200\tcommit: second line\n"
+	if string(content) != want {
+		t.Errorf("Expected reflog:\n%s\nGot:\n%s", want, content)
+	}
+
+	entries, err := rl.Read("refs/heads/main")
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].OldHash != a || entries[1].NewHash != b ||
+		entries[1].Name != "John Doe" || entries[1].Date.Unix() != 1623600000 {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+	if refs, _ := rl.List(); strings.Join(refs, ",") != "refs/heads/main" {
+		t.Errorf("Expected one reflog, got %v", refs)
+	}
+}
+
+func TestExpireAndDelete(t *testing.T) {
+	rl := arrangeReflog(t)
+	for i, seconds := range []string{"1623500000", "1623600000", "1623700000"} {
+		t.Setenv("GOT_COMMITTER_DATE", seconds+" +0000")
+		hash := strings.Repeat(string(rune('a'+i)), 40)
+		if err := rl.Append("HEAD", "", hash, "entry "+seconds); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	if err := rl.Delete("HEAD", 1); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	entries, _ := rl.Read("HEAD")
+	if len(entries) != 2 || entries[0].Message != "entry 1623500000" || entries[1].Message != "entry 1623700000" {
+		t.Errorf("Unexpected entries after delete: %+v", entries)
+	}
+	if err := rl.Delete("HEAD", 2); err == nil {
+		t.Errorf("Expected an error deleting a missing entry")
+	}
+
+	removed, err := rl.Expire("HEAD", time.Unix(1623650000, 0))
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected one entry removed, got %d (%v)", removed, err)
+	}
+	entries, _ = rl.Read("HEAD")
+	if len(entries) != 1 || entries[0].Message != "entry 1623700000" {
+		t.Errorf("Unexpected entries after expire: %+v", entries)
+	}
+}
@@ -0,0 +1,349 @@
+package refs
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/reflog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const HEAD string = "HEAD"
+
+// SYMREF_PREFIX starts the content of a symbolic ref file
+const SYMREF_PREFIX string = "ref: "
+
+// maxSymrefDepth is how many symbolic refs are followed before giving up
+const maxSymrefDepth = 5
+
+// Ref is a named pointer to an object, or to another ref when it is symbolic
+type Ref struct {
+	Name   string
+	Hash   string // the object the ref resolves to, empty for a dangling symbolic ref
+	Target string // the ref pointed to by a symbolic ref
+}
+
+// IsSymbolic tells if the ref points to another ref
+func (r Ref) IsSymbolic() bool {
+	return r.Target != ""
+}
+
+// UpdateOptions tune how a ref is updated
+type UpdateOptions struct {
+	// OldHash is the value the ref must have for the update to happen:
+	// empty to skip the check, a zero hash when the ref must not exist
+	OldHash string
+	// Message is recorded in the reflog
+	Message string
+	// NoDeref updates a symbolic ref itself instead of the ref it points to,
+	// which is how HEAD is detached
+	NoDeref bool
+	// NoLog leaves the reflogs untouched, for callers rewriting them
+	NoLog bool
+}
+
+// Store reads and writes refs in loose files under .got and in .got/packed-refs
+type Store struct {
+	conf   *config.Config
+	logger *logger.Logger
+	logs   *reflog.Reflog
+}
+
+func NewStore(conf *config.Config, logger *logger.Logger) *Store {
+	return &Store{
+		conf:   conf,
+		logger: logger,
+		logs:   reflog.NewReflog(conf, logger),
+	}
+}
+
+// Read returns the ref without following it when it is symbolic
+func (s *Store) Read(name string) (Ref, error) {
+	if err := checkFullName(name); err != nil {
+		return Ref{}, err
+	}
+	content, err := os.ReadFile(s.path(name))
+	if err == nil {
+		value := strings.TrimSpace(string(content))
+		if strings.HasPrefix(value, SYMREF_PREFIX) {
+			return Ref{Name: name, Target: strings.TrimSpace(value[len(SYMREF_PREFIX):])}, nil
+		}
+		if value == "" {
+			return Ref{}, fmt.Errorf("ref %s is empty", name)
+		}
+		return Ref{Name: name, Hash: value}, nil
+	}
+	if !os.IsNotExist(err) && !s.isDir(name) {
+		return Ref{}, err
+	}
+	packed, err := s.readPacked()
+	if err != nil {
+		return Ref{}, err
+	}
+	if hash, found := packed[name]; found {
+		return Ref{Name: name, Hash: hash}, nil
+	}
+	return Ref{}, fmt.Errorf("ref %s not found", name)
+}
+
+// Exists tells if the ref is present, as a loose or a packed ref
+func (s *Store) Exists(name string) bool {
+	_, err := s.Read(name)
+	return err == nil
+}
+
+// Resolve follows symbolic refs and returns the hash name points to and the
+// name of the last ref of the chain
+func (s *Store) Resolve(name string) (string, string, error) {
+	for depth := 0; depth <= maxSymrefDepth; depth++ {
+		ref, err := s.Read(name)
+		if err != nil {
+			return "", name, err
+		}
+		if !ref.IsSymbolic() {
+			return ref.Hash, name, nil
+		}
+		name = ref.Target
+	}
+	return "", name, fmt.Errorf("too many levels of symbolic refs at %s", name)
+}
+
+// ReadSymbolic returns the ref a symbolic ref points to. It fails when the
+// ref is a direct one, as HEAD is when detached.
+func (s *Store) ReadSymbolic(name string) (string, error) {
+	ref, err := s.Read(name)
+	if err != nil {
+		return "", err
+	}
+	if !ref.IsSymbolic() {
+		return "", fmt.Errorf("ref %s is not a symbolic ref", name)
+	}
+	return ref.Target, nil
+}
+
+// CurrentBranch returns the full name of the branch HEAD points to
+func (s *Store) CurrentBranch() (string, error) {
+	target, err := s.ReadSymbolic(HEAD)
+	if err != nil {
+		return "", fmt.Errorf("HEAD does not point to a branch")
+	}
+	return target, nil
+}
+
+// IsDetached tells if HEAD points directly to a commit
+func (s *Store) IsDetached() bool {
+	ref, err := s.Read(HEAD)
+	return err == nil && !ref.IsSymbolic()
+}
+
+// Update points the ref to newHash. Symbolic refs are followed unless
+// NoDeref is set, and the update only happens if the ref still has the
+// value given in OldHash.
+func (s *Store) Update(name, newHash string, opts UpdateOptions) error {
+	if err := checkFullName(name); err != nil {
+		return err
+	}
+	target := name
+	chain := []string{}
+	if !opts.NoDeref {
+		var err error
+		chain, target, err = s.followChain(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	lock, err := s.lock(target)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	oldHash := ""
+	if ref, err := s.Read(target); err == nil && !ref.IsSymbolic() {
+		oldHash = ref.Hash
+	}
+	if err := checkOldHash(target, oldHash, opts.OldHash); err != nil {
+		return err
+	}
+	if err := lock.commit(newHash); err != nil {
+		return err
+	}
+	if !opts.NoLog {
+		s.logUpdate(target, chain, oldHash, newHash, opts.Message)
+	}
+	return nil
+}
+
+// Delete removes the ref, loose and packed, with its reflog. Symbolic refs
+// are deleted themselves, not followed.
+func (s *Store) Delete(name, expectedOldHash string) error {
+	ref, err := s.Read(name)
+	if err != nil {
+		return err
+	}
+	if expectedOldHash != "" {
+		hash, _, _ := s.Resolve(name)
+		if err := checkOldHash(name, hash, expectedOldHash); err != nil {
+			return err
+		}
+	}
+	lock, err := s.lock(name)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if err := s.removePacked(name); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.removeEmptyParents(name)
+	s.logger.Debug("Deleted ref %s (was %s%s)", name, ref.Hash, ref.Target)
+	return s.logs.Remove(name)
+}
+
+// SetSymbolic makes name point to the target ref
+func (s *Store) SetSymbolic(name, target, message string) error {
+	if err := checkFullName(name); err != nil {
+		return err
+	}
+	if err := ValidateName(target); err != nil {
+		return err
+	}
+	if name != HEAD && !strings.HasPrefix(target, "refs/") {
+		return fmt.Errorf("refusing to point %s outside of refs/", name)
+	}
+	lock, err := s.lock(name)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	oldHash, _, _ := s.Resolve(name)
+	if err := lock.commit(SYMREF_PREFIX + target); err != nil {
+		return err
+	}
+	newHash, _, _ := s.Resolve(target)
+	if message != "" && name == HEAD && newHash != "" {
+		if err := s.logs.Append(HEAD, oldHash, newHash, message); err != nil {
+			s.logger.Debug("Error writing the reflog of HEAD: %s", err)
+		}
+	}
+	return nil
+}
+
+// List returns the refs whose names start with prefix, loose and packed,
+// sorted by name. Symbolic refs are listed with the hash they resolve to.
+func (s *Store) List(prefix string) ([]Ref, error) {
+	found := make(map[string]Ref)
+	packed, err := s.readPacked()
+	if err != nil {
+		return nil, err
+	}
+	for name, hash := range packed {
+		found[name] = Ref{Name: name, Hash: hash}
+	}
+
+	refsDir := filepath.Join(s.conf.GotDir, "refs")
+	err = filepath.Walk(refsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, LOCK_SUFFIX) {
+			return nil
+		}
+		relative, _ := filepath.Rel(s.conf.GotDir, path)
+		name := filepath.ToSlash(relative)
+		ref, err := s.Read(name)
+		if err != nil {
+			s.logger.Debug("Skipping ref %s: %s", name, err)
+			return nil
+		}
+		if ref.IsSymbolic() {
+			ref.Hash, _, _ = s.Resolve(name)
+		}
+		found[name] = ref
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []Ref{}
+	for name, ref := range found {
+		if strings.HasPrefix(name, prefix) {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+	return refs, nil
+}
+
+// followChain returns the symbolic refs crossed from name and the ref at the
+// end of the chain, which may not exist yet
+func (s *Store) followChain(name string) ([]string, string, error) {
+	chain := []string{}
+	for depth := 0; depth <= maxSymrefDepth; depth++ {
+		ref, err := s.Read(name)
+		if err != nil || !ref.IsSymbolic() {
+			return chain, name, nil
+		}
+		chain = append(chain, name)
+		name = ref.Target
+	}
+	return nil, "", fmt.Errorf("too many levels of symbolic refs at %s", name)
+}
+
+// logUpdate records the update in the reflogs of the ref, of the symbolic
+// refs it was reached through and of HEAD when it points to the ref
+func (s *Store) logUpdate(target string, chain []string, oldHash, newHash, message string) {
+	logged := append([]string{target}, chain...)
+	if headTarget, err := s.ReadSymbolic(HEAD); err == nil && headTarget == target && !contains(chain, HEAD) {
+		logged = append(logged, HEAD)
+	}
+	for _, name := range logged {
+		if !s.shouldLog(name) {
+			continue
+		}
+		if err := s.logs.Append(name, oldHash, newHash, message); err != nil {
+			s.logger.Debug("Error writing the reflog of %s: %s", name, err)
+		}
+	}
+}
+
+// shouldLog tells if updates of the ref are recorded: HEAD, branches,
+// remote-tracking branches, the stash and refs already having a reflog are
+func (s *Store) shouldLog(name string) bool {
+	return name == HEAD ||
+		strings.HasPrefix(name, "refs/heads/") ||
+		strings.HasPrefix(name, "refs/remotes/") ||
+		name == "refs/stash" ||
+		s.logs.Exists(name)
+}
+
+// path returns the file of a loose ref
+func (s *Store) path(name string) string {
+	return filepath.Join(s.conf.GotDir, filepath.FromSlash(name))
+}
+
+// removeEmptyParents removes the directories left empty by a deleted ref
+func (s *Store) removeEmptyParents(name string) {
+	refsDir := filepath.Join(s.conf.GotDir, "refs")
+	for dir := filepath.Dir(s.path(name)); strings.HasPrefix(dir, refsDir+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		// the top level directories like refs/heads are kept
+		if filepath.Dir(dir) == refsDir || os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package refs
+
+import (
+	"got_it/internal/testrepo"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	hashA = strings.Repeat("a", 40)
+	hashB = strings.Repeat("b", 40)
+	hashC = strings.Repeat("c", 40)
+)
+
+// arrangeStore creates a repository whose HEAD points to main, at hashA
+func arrangeStore(t *testing.T) *Store {
+	t.Helper()
+	repo := testrepo.New(t)
+	t.Setenv("GOT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GOT_COMMITTER_EMAIL", "johndoe@example.com")
+	repo.SetRef("refs/heads/main", hashA)
+	return NewStore(repo.Conf, repo.Logger)
+}
+
+func TestReadAndResolve(t *testing.T) {
+	s := arrangeStore(t)
+	head, err := s.Read(HEAD)
+	if err != nil || !head.IsSymbolic() || head.Target != "refs/heads/main" {
+		t.Errorf("Expected HEAD to point to refs/heads/main, got %+v (%v)", head, err)
+	}
+	hash, fullRef, err := s.Resolve(HEAD)
+	if err != nil || hash != hashA || fullRef != "refs/heads/main" {
+		t.Errorf("Expected HEAD to resolve to %s through refs/heads/main, got %s %s (%v)", hashA, hash, fullRef, err)
+	}
+	if branch, err := s.CurrentBranch(); err != nil || branch != "refs/heads/main" {
+		t.Errorf("Expected the current branch to be refs/heads/main, got %s (%v)", branch, err)
+	}
+	if _, err := s.Read("refs/heads/missing"); err == nil {
+		t.Errorf("Expected an error for a missing ref")
+	}
+	if _, err := s.Read("main"); err == nil {
+		t.Errorf("Expected an error for a short ref name")
+	}
+	if _, err := s.Read("refs/heads"); err == nil {
+		t.Errorf("Expected an error for a directory")
+	}
+}
+
+func TestDetachedHead(t *testing.T) {
+	s := arrangeStore(t)
+	if err := s.Update(HEAD, hashB, UpdateOptions{NoDeref: true, Message: "checkout: moving from main to " + hashB}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if !s.IsDetached() {
+		t.Errorf("Expected HEAD to be detached")
+	}
+	if _, err := s.ReadSymbolic(HEAD); err == nil {
+		t.Errorf("Expected an error reading a detached HEAD as a symbolic ref")
+	}
+	if _, err := s.CurrentBranch(); err == nil {
+		t.Errorf("Expected no current branch with a detached HEAD")
+	}
+	if hash, _, _ := s.Resolve("refs/heads/main"); hash != hashA {
+		t.Errorf("Expected main to stay at %s, got %s", hashA, hash)
+	}
+
+	// a commit on a detached HEAD moves HEAD only
+	if err := s.Update(HEAD, hashC, UpdateOptions{OldHash: hashB}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if hash, fullRef, _ := s.Resolve(HEAD); hash != hashC || fullRef != HEAD {
+		t.Errorf("Expected the detached HEAD at %s, got %s %s", hashC, hash, fullRef)
+	}
+
+	if err := s.SetSymbolic(HEAD, "refs/heads/main", "checkout: moving from "+hashC+" to main"); err != nil {
+		t.Fatalf("SetSymbolic returned error: %v", err)
+	}
+	if s.IsDetached() {
+		t.Errorf("Expected HEAD to be attached again")
+	}
+	entries, _ := s.logs.Read(HEAD)
+	if len(entries) != 3 || entries[2].OldHash != hashC || entries[2].NewHash != hashA {
+		t.Errorf("Unexpected HEAD reflog: %+v", entries)
+	}
+}
+
+func TestUpdateCompareAndSwap(t *testing.T) {
+	s := arrangeStore(t)
+	if err := s.Update(HEAD, hashB, UpdateOptions{OldHash: hashC}); err == nil {
+		t.Errorf("Expected an error when the old value does not match")
+	}
+	if err := s.Update("refs/heads/main", hashB, UpdateOptions{OldHash: utils.OF_SHA1.ZeroHash()}); err == nil {
+		t.Errorf("Expected an error creating a ref that exists")
+	}
+	if err := s.Update(HEAD, hashB, UpdateOptions{OldHash: hashA, Message: "commit: b"}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if hash, _, _ := s.Resolve("refs/heads/main"); hash != hashB {
+		t.Errorf("Expected main to move to %s, got %s", hashB, hash)
+	}
+	if err := s.Update("refs/heads/topic", hashC, UpdateOptions{OldHash: utils.OF_SHA1.ZeroHash(), Message: "branch: Created from main"}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	// both the branch and HEAD record the update made through HEAD
+	for _, name := range []string{"refs/heads/main", HEAD} {
+		entries, err := s.logs.Read(name)
+		if err != nil || len(entries) != 1 || entries[0].Message != "commit: b" {
+			t.Errorf("Unexpected reflog of %s: %+v (%v)", name, entries, err)
+		}
+	}
+
+	// a held lock makes the update fail
+	lockPath := filepath.Join(".got", "refs", "heads", "main.lock")
+	os.WriteFile(lockPath, nil, 0644)
+	if err := s.Update("refs/heads/main", hashC, UpdateOptions{}); err == nil || !strings.Contains(err.Error(), "unable to lock") {
+		t.Errorf("Expected a lock error, got %v", err)
+	}
+	os.Remove(lockPath)
+}
+
+func TestPackedRefs(t *testing.T) {
+	s := arrangeStore(t)
+	os.WriteFile(filepath.Join(".got", PACKED_REFS), []byte("# pack-refs with: peeled sorted\n"+
+		hashB+" refs/tags/v1\n^"+hashC+"\n"+hashC+" refs/heads/old\n"), 0644)
+
+	if ref, err := s.Read("refs/tags/v1"); err != nil || ref.Hash != hashB {
+		t.Errorf("Expected the packed tag at %s, got %+v (%v)", hashB, ref, err)
+	}
+	refs, err := s.List("refs/")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	names := []string{}
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	if strings.Join(names, ",") != "refs/heads/main,refs/heads/old,refs/tags/v1" {
+		t.Errorf("Unexpected refs: %v", names)
+	}
+
+	// a loose ref shadows the packed one
+	if err := s.Update("refs/heads/old", hashA, UpdateOptions{OldHash: hashC}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if hash, _, _ := s.Resolve("refs/heads/old"); hash != hashA {
+		t.Errorf("Expected the loose ref to win, got %s", hash)
+	}
+	if err := s.Delete("refs/heads/old", ""); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if s.Exists("refs/heads/old") {
+		t.Errorf("Expected refs/heads/old to be deleted from both places")
+	}
+
+	if err := s.Pack(true); err != nil {
+		t.Fatalf("Pack returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(".got", "refs", "heads", "main")); !os.IsNotExist(err) {
+		t.Errorf("Expected the loose main to be packed")
+	}
+	packed, _ := os.ReadFile(filepath.Join(".got", PACKED_REFS))
+	if string(packed) != "# pack-refs with: sorted\n"+hashA+" refs/heads/main\n"+hashB+" refs/tags/v1\n" {
+		t.Errorf("Unexpected packed-refs:\n%s", packed)
+	}
+	if hash, _, _ := s.Resolve(HEAD); hash != hashA {
+		t.Errorf("Expected HEAD to resolve through the packed main, got %s", hash)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"refs/heads/main", "refs/tags/v1.0", "refs/heads/feature/x-1"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "@", "refs/heads/a..b", "refs/heads/x.lock", "refs/heads/with space",
+		"refs/heads/a^", "refs/heads/.hidden", "refs/heads/dir/", "refs/heads/a@{1}", "refs//heads", "refs/heads/a:b"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("Expected %q to be invalid", name)
+		}
+	}
+	if err := ValidateBranchName("HEAD"); err == nil {
+		t.Errorf("Expected HEAD to be an invalid branch name")
+	}
+	if err := ValidateTagName("-v1"); err == nil {
+		t.Errorf("Expected -v1 to be an invalid tag name")
+	}
+}
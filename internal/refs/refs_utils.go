@@ -0,0 +1,271 @@
+package refs
+
+import (
+	"bufio"
+	"fmt"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LOCK_SUFFIX is added to the name of a file while it is being updated
+const LOCK_SUFFIX string = ".lock"
+
+// PACKED_REFS is the file, in .got, holding the packed refs
+const PACKED_REFS string = "packed-refs"
+
+// ValidateName checks a ref name against the rules Git applies to them: no
+// component starts with a dot or ends with ".lock", no "..", "@{", "//",
+// control characters, spaces or any of ~^:?*[\ and no trailing slash or dot
+func ValidateName(name string) error {
+	invalid := name == "" || name == "@" ||
+		strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+		strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "//") ||
+		strings.Contains(name, "@{") || strings.ContainsAny(name, " ~^:?*[\\\x7f")
+	for _, c := range name {
+		if c < 0x20 {
+			invalid = true
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, LOCK_SUFFIX) {
+			invalid = true
+		}
+	}
+	if invalid {
+		return fmt.Errorf("'%s' is not a valid ref name", name)
+	}
+	return nil
+}
+
+// ValidateBranchName checks the short name of a branch
+func ValidateBranchName(name string) error {
+	if name == HEAD || strings.HasPrefix(name, "-") || ValidateName("refs/heads/"+name) != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", name)
+	}
+	return nil
+}
+
+// ValidateTagName checks the short name of a tag
+func ValidateTagName(name string) error {
+	if strings.HasPrefix(name, "-") || ValidateName("refs/tags/"+name) != nil {
+		return fmt.Errorf("'%s' is not a valid tag name", name)
+	}
+	return nil
+}
+
+// IsPseudoRef tells if the name is a top level ref like HEAD or ORIG_HEAD
+func IsPseudoRef(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFullName accepts the pseudo refs and valid names under refs/
+func checkFullName(name string) error {
+	if IsPseudoRef(name) {
+		return nil
+	}
+	if !strings.HasPrefix(name, "refs/") {
+		return fmt.Errorf("'%s' is not a full ref name", name)
+	}
+	return ValidateName(name)
+}
+
+// checkOldHash makes sure the ref still has the expected value
+func checkOldHash(name, current, expected string) error {
+	switch {
+	case expected == "":
+		return nil
+	case utils.IsZeroHash(expected) && current != "":
+		return fmt.Errorf("cannot update ref '%s': it already exists", name)
+	case !utils.IsZeroHash(expected) && current != expected:
+		if current == "" {
+			return fmt.Errorf("cannot update ref '%s': it does not exist, expected %s", name, expected)
+		}
+		return fmt.Errorf("cannot update ref '%s': is at %s but expected %s", name, current, expected)
+	}
+	return nil
+}
+
+// lockFile holds <file>.lock while a ref, or packed-refs, is rewritten
+type lockFile struct {
+	path string
+	file *os.File
+	done bool
+}
+
+// lock creates the lock file of the ref, failing when someone else holds it
+func (s *Store) lock(name string) (*lockFile, error) {
+	path := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path+LOCK_SUFFIX, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("unable to lock ref '%s': %s%s exists", name, path, LOCK_SUFFIX)
+		}
+		return nil, err
+	}
+	return &lockFile{path: path, file: file}, nil
+}
+
+// commit writes the new content and moves it in place of the locked file
+func (l *lockFile) commit(content string) error {
+	if _, err := l.file.WriteString(content); err != nil {
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path+LOCK_SUFFIX, l.path); err != nil {
+		return err
+	}
+	l.done = true
+	return nil
+}
+
+// release drops the lock when it was not committed
+func (l *lockFile) release() {
+	if l.done {
+		return
+	}
+	l.file.Close()
+	os.Remove(l.path + LOCK_SUFFIX)
+}
+
+// readPacked returns the refs of the packed-refs file
+func (s *Store) readPacked() (map[string]string, error) {
+	packed := make(map[string]string)
+	file, err := os.Open(s.path(PACKED_REFS))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return packed, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// comments hold the traits of the file, ^ lines the peeled tags
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		hash, name, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid line in %s: %s", PACKED_REFS, line)
+		}
+		packed[name] = hash
+	}
+	return packed, scanner.Err()
+}
+
+// writePacked replaces the packed-refs file, whose lock the caller holds
+func writePacked(lock *lockFile, packed map[string]string) error {
+	names := make([]string, 0, len(packed))
+	for name := range packed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var content strings.Builder
+	content.WriteString("# pack-refs with: sorted\n")
+	for _, name := range names {
+		content.WriteString(packed[name] + " " + name + "\n")
+	}
+	return lock.commit(content.String())
+}
+
+// removePacked drops the ref from packed-refs, if it is there
+func (s *Store) removePacked(name string) error {
+	packed, err := s.readPacked()
+	if err != nil {
+		return err
+	}
+	if _, found := packed[name]; !found {
+		return nil
+	}
+	lock, err := s.lock(PACKED_REFS)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	delete(packed, name)
+	return writePacked(lock, packed)
+}
+
+// Pack moves the loose tags, and every other ref under refs/ when all is
+// set, into the packed-refs file. Symbolic refs are never packed.
+func (s *Store) Pack(all bool) error {
+	lock, err := s.lock(PACKED_REFS)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	packed, err := s.readPacked()
+	if err != nil {
+		return err
+	}
+	refs, err := s.List("refs/")
+	if err != nil {
+		return err
+	}
+	loose := []string{}
+	for _, ref := range refs {
+		if ref.IsSymbolic() || (!all && !strings.HasPrefix(ref.Name, "refs/tags/")) {
+			continue
+		}
+		if _, err := os.Stat(s.path(ref.Name)); err != nil {
+			continue
+		}
+		packed[ref.Name] = ref.Hash
+		loose = append(loose, ref.Name)
+	}
+	if err := writePacked(lock, packed); err != nil {
+		return err
+	}
+	for _, name := range loose {
+		if err := os.Remove(s.path(name)); err != nil {
+			return err
+		}
+		s.removeEmptyParents(name)
+	}
+	return nil
+}
+
+// isDir tells if the path of the ref is a directory, like refs/heads
+func (s *Store) isDir(name string) bool {
+	info, err := os.Stat(s.path(name))
+	return err == nil && info.IsDir()
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortName strips the well known prefixes of a full ref name, so that
+// refs/heads/main becomes main and refs/remotes/origin/main origin/main
+func ShortName(name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
@@ -0,0 +1,365 @@
+package revision
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/date"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/reflog"
+	"got_it/internal/refs"
+	"got_it/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minAbbrevLength is the minimum number of hex digits accepted as a short hash
+const minAbbrevLength = 4
+
+// Resolver turns revision expressions into object hashes.
+//
+// Supported expressions:
+//
+//	<hash>, <short-hash>     full or abbreviated object names
+//	HEAD, @, <branch>, <tag> refs, searched like Git does
+//	<rev>~<n>, <rev>^<n>     n-th first parent ancestor, n-th parent
+//	<rev>^{}, <rev>^{tree}   peeled object
+//	<ref>@{<n>}, @{<n>}      n-th prior value of a ref from its reflog
+//	<ref>@{<date>}           value of a ref at a date, like @{yesterday}
+//	@{-<n>}                  n-th branch checked out before the current one
+//	<rev>:<path>, :<path>    object at path in the tree of rev, or in the index
+type Resolver struct {
+	conf   *config.Config
+	logger *logger.Logger
+	store  *objects.Store
+	logs   *reflog.Reflog
+	refs   *refs.Store
+}
+
+// Range is a set of commits described by revision range arguments:
+// every commit reachable from Include and not reachable from Exclude.
+type Range struct {
+	Include []string
+	Exclude []string
+}
+
+func NewResolver(conf *config.Config, logger *logger.Logger) *Resolver {
+	return &Resolver{
+		conf:   conf,
+		logger: logger,
+		store:  objects.NewStore(conf, logger),
+		logs:   reflog.NewReflog(conf, logger),
+		refs:   refs.NewStore(conf, logger),
+	}
+}
+
+// Resolve returns the hash of the object named by rev
+func (r *Resolver) Resolve(rev string) (string, error) {
+	if rev == "" {
+		return "", fmt.Errorf("empty revision")
+	}
+	if base, filePath, found := cutPath(rev); found {
+		return r.resolvePath(base, filePath)
+	}
+
+	baseEnd := suffixStart(rev)
+	hash, err := r.resolveBase(rev[:baseEnd])
+	if err != nil {
+		return "", err
+	}
+	suffixes := rev[baseEnd:]
+	for suffixes != "" {
+		hash, suffixes, err = r.applySuffix(hash, suffixes)
+		if err != nil {
+			return "", fmt.Errorf("bad revision '%s': %s", rev, err)
+		}
+	}
+	return hash, nil
+}
+
+// ResolveCommit returns the hash of the commit named by rev, peeling tags
+func (r *Resolver) ResolveCommit(rev string) (string, error) {
+	hash, err := r.Resolve(rev)
+	if err != nil {
+		return "", err
+	}
+	commitHash, err := r.peel(hash, models.OT_COMMIT)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+	return commitHash, nil
+}
+
+// ResolveRange parses revision range arguments (A..B, A...B, ^A, A) into a Range.
+// An empty side of ".." or "..." stands for HEAD.
+func (r *Resolver) ResolveRange(args []string) (Range, error) {
+	rng := Range{}
+	for _, arg := range args {
+		if left, right, found := strings.Cut(arg, "..."); found {
+			leftHash, rightHash, err := r.resolveSides(left, right)
+			if err != nil {
+				return Range{}, err
+			}
+			bases, err := r.MergeBases(leftHash, rightHash)
+			if err != nil {
+				return Range{}, err
+			}
+			rng.Include = append(rng.Include, leftHash, rightHash)
+			rng.Exclude = append(rng.Exclude, bases...)
+			continue
+		}
+		if left, right, found := strings.Cut(arg, ".."); found {
+			leftHash, rightHash, err := r.resolveSides(left, right)
+			if err != nil {
+				return Range{}, err
+			}
+			rng.Include = append(rng.Include, rightHash)
+			rng.Exclude = append(rng.Exclude, leftHash)
+			continue
+		}
+		if strings.HasPrefix(arg, "^") {
+			hash, err := r.ResolveCommit(arg[1:])
+			if err != nil {
+				return Range{}, err
+			}
+			rng.Exclude = append(rng.Exclude, hash)
+			continue
+		}
+		hash, err := r.ResolveCommit(arg)
+		if err != nil {
+			return Range{}, err
+		}
+		rng.Include = append(rng.Include, hash)
+	}
+	return rng, nil
+}
+
+func (r *Resolver) resolveSides(left, right string) (string, string, error) {
+	if left == "" {
+		left = HEAD
+	}
+	if right == "" {
+		right = HEAD
+	}
+	leftHash, err := r.ResolveCommit(left)
+	if err != nil {
+		return "", "", err
+	}
+	rightHash, err := r.ResolveCommit(right)
+	if err != nil {
+		return "", "", err
+	}
+	return leftHash, rightHash, nil
+}
+
+// resolveBase resolves the part of the expression before any ~ or ^ suffix
+func (r *Resolver) resolveBase(base string) (string, error) {
+	if base == "" {
+		return "", fmt.Errorf("empty revision")
+	}
+	if at := strings.Index(base, "@{"); at >= 0 && strings.HasSuffix(base, "}") {
+		return r.resolveReflog(base[:at], base[at+2:len(base)-1])
+	}
+	if base == "@" {
+		base = HEAD
+	}
+	if r.store.Format().IsHash(base) && r.store.Exists(base) {
+		return base, nil
+	}
+	if hash, _, err := r.ResolveRef(base); err == nil {
+		return hash, nil
+	}
+	if len(base) >= minAbbrevLength && utils.IsHexString(base) {
+		return r.resolveShortHash(base)
+	}
+	return "", fmt.Errorf("unknown revision '%s'", base)
+}
+
+// resolveShortHash returns the only object whose hash starts with prefix
+func (r *Resolver) resolveShortHash(prefix string) (string, error) {
+	matches, err := r.store.FindByPrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown revision '%s'", prefix)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := []string{}
+	for _, match := range matches {
+		candidates = append(candidates, "  "+match)
+	}
+	return "", fmt.Errorf("short object ID %s is ambiguous, candidates are:\n%s",
+		prefix, strings.Join(candidates, "\n"))
+}
+
+// resolveReflog resolves <ref>@{<n>}, <ref>@{<date>} and @{-<n>}
+func (r *Resolver) resolveReflog(refName, selector string) (string, error) {
+	n, err := strconv.Atoi(selector)
+	var at time.Time
+	if err != nil {
+		at, err = date.Parse(selector)
+		if err != nil {
+			return "", fmt.Errorf("invalid reflog selector '@{%s}'", selector)
+		}
+	}
+	if at.IsZero() && n < 0 {
+		if refName != "" {
+			return "", fmt.Errorf("invalid reflog selector '%s@{%s}'", refName, selector)
+		}
+		branch, err := r.PreviousBranch(-n)
+		if err != nil {
+			return "", err
+		}
+		return r.resolveBase(branch)
+	}
+
+	fullRef := ""
+	switch refName {
+	case "":
+		fullRef, err = r.CurrentBranch()
+		if err != nil {
+			return "", err
+		}
+	case HEAD, "@":
+		fullRef = HEAD
+	default:
+		_, fullRef, err = r.ResolveRef(refName)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !at.IsZero() {
+		return r.reflogAt(fullRef, at)
+	}
+	return r.reflogEntry(fullRef, n)
+}
+
+// applySuffix applies the first ~ or ^ suffix and returns the rest of them
+func (r *Resolver) applySuffix(hash, suffixes string) (string, string, error) {
+	op := suffixes[0]
+	rest := suffixes[1:]
+
+	if op == '^' && strings.HasPrefix(rest, "{") {
+		end := strings.Index(rest, "}")
+		if end < 0 {
+			return "", "", fmt.Errorf("missing '}'")
+		}
+		peeled, err := r.peelTo(hash, rest[1:end])
+		return peeled, rest[end+1:], err
+	}
+
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	n := 1
+	if digits > 0 {
+		n, _ = strconv.Atoi(rest[:digits])
+	}
+	rest = rest[digits:]
+
+	commitHash, err := r.peel(hash, models.OT_COMMIT)
+	if err != nil {
+		return "", "", err
+	}
+	if op == '^' {
+		if n == 0 {
+			return commitHash, rest, nil
+		}
+		commit, err := r.store.ReadCommit(commitHash)
+		if err != nil {
+			return "", "", err
+		}
+		if n > len(commit.Parents) {
+			return "", "", fmt.Errorf("commit %s has no parent %d", commitHash, n)
+		}
+		return commit.Parents[n-1], rest, nil
+	}
+	for i := 0; i < n; i++ {
+		commit, err := r.store.ReadCommit(commitHash)
+		if err != nil {
+			return "", "", err
+		}
+		if commit.Parent == "" {
+			return "", "", fmt.Errorf("commit %s has no parent", commitHash)
+		}
+		commitHash = commit.Parent
+	}
+	return commitHash, rest, nil
+}
+
+// peelTo handles the ^{<type>} suffix; an empty type peels tags away
+func (r *Resolver) peelTo(hash, objectType string) (string, error) {
+	switch objectType {
+	case "":
+		return r.peel(hash, "")
+	case string(models.OT_COMMIT), string(models.OT_TREE), string(models.OT_BLOB), string(models.OT_TAG):
+		return r.peel(hash, models.ObjectType(objectType))
+	}
+	return "", fmt.Errorf("unknown object type '%s'", objectType)
+}
+
+// peel follows tags (and commits, when a tree is wanted) until it reaches an
+// object of the wanted type. With an empty type it stops at the first non tag.
+func (r *Resolver) peel(hash string, want models.ObjectType) (string, error) {
+	for {
+		content, err := r.store.Read(hash)
+		if err != nil {
+			return "", err
+		}
+		objectType := objects.TypeOf(content)
+		if objectType == want || (want == "" && objectType != models.OT_TAG) {
+			return hash, nil
+		}
+		switch {
+		case objectType == models.OT_TAG:
+			hash = strings.TrimPrefix(strings.SplitN(content, "\n", 2)[0], "object ")
+		case objectType == models.OT_COMMIT && want == models.OT_TREE:
+			commit, err := r.store.ReadCommit(hash)
+			if err != nil {
+				return "", err
+			}
+			hash = commit.Tree
+		default:
+			return "", fmt.Errorf("object %s is a %s, not a %s", hash, objectType, want)
+		}
+	}
+}
+
+// resolvePath resolves <rev>:<path>, or :<path> for the staged version
+func (r *Resolver) resolvePath(base, filePath string) (string, error) {
+	if base == "" {
+		return r.resolveIndexPath(filePath)
+	}
+	baseHash, err := r.Resolve(base)
+	if err != nil {
+		return "", err
+	}
+	treeHash, err := r.peel(baseHash, models.OT_TREE)
+	if err != nil {
+		return "", err
+	}
+	entry, err := r.store.FindInTree(treeHash, filePath)
+	if err != nil {
+		return "", fmt.Errorf("path '%s' does not exist in '%s'", filePath, base)
+	}
+	return entry.Hash, nil
+}
+
+func (r *Resolver) resolveIndexPath(filePath string) (string, error) {
+	stagedFiles, err := utils.ReadIndex(r.conf.GetIndexPath())
+	if err != nil {
+		return "", err
+	}
+	for stagedPath, hash := range stagedFiles {
+		if utils.SlashPath(stagedPath) == utils.SlashPath(filePath) {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("path '%s' is not in the index", filePath)
+}
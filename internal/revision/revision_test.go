@@ -0,0 +1,307 @@
+package revision
+
+import (
+	"fmt"
+	"got_it/internal/objects"
+	"got_it/internal/testrepo"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testRepo is a repository with the history
+//
+//	c1 -- c2 -- c3 (main, tag v1 on c2)
+//	        \
+//	         f1 -- m (feature, merge of f1 and c3)
+type testRepo struct {
+	resolver *Resolver
+	store    *objects.Store
+	blob     string
+	subTree  string
+	tree     string
+	commits  map[string]string
+}
+
+func arrangeRepo(t *testing.T) *testRepo {
+	t.Helper()
+	got := testrepo.New(t)
+	repo := &testRepo{
+		resolver: NewResolver(got.Conf, got.Logger),
+		store:    got.Store,
+		commits:  make(map[string]string),
+	}
+
+	repo.blob = repo.write(t, "hello\n")
+	repo.subTree = repo.write(t, fmt.Sprintf("100644 blob %s\tinner.txt\n", repo.blob))
+	repo.tree = repo.write(t, fmt.Sprintf("100644 blob %s\tfile.txt\n040000 tree %s\tdir\n100644 blob %s\tinner.txt\n",
+		repo.blob, repo.subTree, repo.blob))
+
+	repo.commit(t, "c1")
+	repo.commit(t, "c2", "c1")
+	repo.commit(t, "c3", "c2")
+	repo.commit(t, "f1", "c2")
+	repo.commit(t, "m", "f1", "c3")
+
+	got.SetRef("refs/heads/main", repo.commits["c3"])
+	got.SetRef("refs/heads/feature", repo.commits["m"])
+	got.SetRef("refs/tags/v1", repo.commits["c2"])
+	return repo
+}
+
+func (repo *testRepo) write(t *testing.T, content string) string {
+	t.Helper()
+	hash, err := repo.store.Write(content)
+	if err != nil {
+		t.Fatalf("Error writing object: %v", err)
+	}
+	return hash
+}
+
+func (repo *testRepo) commit(t *testing.T, name string, parents ...string) {
+	t.Helper()
+	content := fmt.Sprintf("tree %s\n", repo.tree)
+	for _, parent := range parents {
+		content += fmt.Sprintf("parent %s\n", repo.commits[parent])
+	}
+	content += "author John Doe <johndoe@example.com> 1623501234 +0200\n"
+	content += "committer John Doe <johndoe@example.com> 1623501234 +0200\n\n" + name + "\n"
+	repo.commits[name] = repo.write(t, content)
+}
+
+func TestResolve(t *testing.T) {
+	repo := arrangeRepo(t)
+	c := repo.commits
+	tests := []struct {
+		rev  string
+		want string
+	}{
+		{"HEAD", c["c3"]},
+		{"@", c["c3"]},
+		{"main", c["c3"]},
+		{"refs/heads/main", c["c3"]},
+		{"heads/feature", c["m"]},
+		{"v1", c["c2"]},
+		{c["f1"], c["f1"]},
+		{c["f1"][:7], c["f1"]},
+		{"HEAD~1", c["c2"]},
+		{"HEAD~2", c["c1"]},
+		{"HEAD^^", c["c1"]},
+		{"HEAD~", c["c2"]},
+		{"feature^", c["f1"]},
+		{"feature^2", c["c3"]},
+		{"feature^2~1", c["c2"]},
+		{"feature^0", c["m"]},
+		{"main^{tree}", repo.tree},
+		{"main:file.txt", repo.blob},
+		{"main:dir/inner.txt", repo.blob},
+		{"v1~1:dir", repo.subTree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rev, func(t *testing.T) {
+			got, err := repo.resolver.Resolve(tt.rev)
+			if err != nil {
+				t.Fatalf("Resolve(%s) returned error: %v", tt.rev, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%s) = %s, want %s", tt.rev, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	repo := arrangeRepo(t)
+	tests := []string{
+		"unknown",
+		"HEAD~5",
+		"main^2",
+		"main:missing.txt",
+		"main^{nothing}",
+	}
+	for _, rev := range tests {
+		t.Run(rev, func(t *testing.T) {
+			if hash, err := repo.resolver.Resolve(rev); err == nil {
+				t.Errorf("Resolve(%s) = %s, expected an error", rev, hash)
+			}
+		})
+	}
+}
+
+func TestResolveAmbiguousShortHash(t *testing.T) {
+	repo := arrangeRepo(t)
+	seen := make(map[string]string)
+	prefix := ""
+	for i := 0; prefix == "" && i < 10000; i++ {
+		hash := repo.write(t, fmt.Sprintf("blob %d\n", i))
+		if _, found := seen[hash[:4]]; found {
+			prefix = hash[:4]
+		}
+		seen[hash[:4]] = hash
+	}
+	if prefix == "" {
+		t.Skip("no colliding prefix found")
+	}
+	_, err := repo.resolver.Resolve(prefix)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected an ambiguity error for %s, got %v", prefix, err)
+	}
+}
+
+func TestResolveReflog(t *testing.T) {
+	repo := arrangeRepo(t)
+	c := repo.commits
+	log := fmt.Sprintf("%s %s John Doe <johndoe@example.com> 1623500000 +0200\tcommit (initial): c1\n", utils.OF_SHA1.ZeroHash(), c["c1"])
+	log += fmt.Sprintf("%s %s John Doe <johndoe@example.com> 1623600000 +0200\tcommit: c2\n", c["c1"], c["c2"])
+	log += fmt.Sprintf("%s %s John Doe <johndoe@example.com> 1623700000 +0200\tcommit: c3\n", c["c2"], c["c3"])
+	testrepo.WriteFile(t, ".got/logs/refs/heads/main", log)
+	headLog := fmt.Sprintf("%s %s John Doe <johndoe@example.com> 1623501234 +0200\tcheckout: moving from feature to main\n", c["m"], c["c3"])
+	testrepo.WriteFile(t, ".got/logs/HEAD", headLog)
+
+	tests := []struct {
+		rev  string
+		want string
+	}{
+		{"main@{0}", c["c3"]},
+		{"main@{1}", c["c2"]},
+		{"@{2}", c["c1"]},
+		{"main@{1}~1", c["c1"]},
+		{"HEAD@{0}", c["c3"]},
+		{"@{-1}", c["m"]},
+		{"main@{2021-06-13T12:00:00Z}", c["c1"]},
+		{"@{2021-06-14T00:00:00Z}", c["c2"]},
+		{"main@{yesterday}", c["c3"]},
+		{"main@{1.year.ago}~1", c["c2"]},
+		{"main@{2000-01-01}", c["c1"]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rev, func(t *testing.T) {
+			got, err := repo.resolver.Resolve(tt.rev)
+			if err != nil {
+				t.Fatalf("Resolve(%s) returned error: %v", tt.rev, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%s) = %s, want %s", tt.rev, got, tt.want)
+			}
+		})
+	}
+	if _, err := repo.resolver.Resolve("main@{4}"); err == nil {
+		t.Errorf("Expected an error for an entry beyond the reflog")
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	repo := arrangeRepo(t)
+	c := repo.commits
+	tests := []struct {
+		args    []string
+		include []string
+		exclude []string
+	}{
+		{[]string{"main"}, []string{c["c3"]}, nil},
+		{[]string{"v1..feature"}, []string{c["m"]}, []string{c["c2"]}},
+		{[]string{"feature.."}, []string{c["c3"]}, []string{c["m"]}},
+		{[]string{"feature", "^main"}, []string{c["m"]}, []string{c["c3"]}},
+		{[]string{"main...feature^"}, []string{c["c3"], c["f1"]}, []string{c["c2"]}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			rng, err := repo.resolver.ResolveRange(tt.args)
+			if err != nil {
+				t.Fatalf("ResolveRange(%v) returned error: %v", tt.args, err)
+			}
+			if strings.Join(rng.Include, ",") != strings.Join(tt.include, ",") {
+				t.Errorf("Include = %v, want %v", rng.Include, tt.include)
+			}
+			if strings.Join(rng.Exclude, ",") != strings.Join(tt.exclude, ",") {
+				t.Errorf("Exclude = %v, want %v", rng.Exclude, tt.exclude)
+			}
+		})
+	}
+}
+
+func TestMergeBases(t *testing.T) {
+	repo := arrangeRepo(t)
+	// criss-cross merges of x1 and y1 have both as best common ancestors
+	repo.commit(t, "x1", "c1")
+	repo.commit(t, "y1", "c1")
+	repo.commit(t, "x2", "x1", "y1")
+	repo.commit(t, "y2", "y1", "x1")
+	c := repo.commits
+	tests := []struct {
+		a, b string
+		want []string
+	}{
+		{"c3", "c3", []string{c["c3"]}},
+		{"c3", "f1", []string{c["c2"]}},
+		{"m", "c3", []string{c["c3"]}},
+		{"c1", "m", []string{c["c1"]}},
+		{"x2", "c3", []string{c["c1"]}},
+		{"x2", "y2", []string{c["x1"], c["y1"]}},
+	}
+	for _, tt := range tests {
+		bases, err := repo.resolver.MergeBases(c[tt.a], c[tt.b])
+		if err != nil {
+			t.Fatalf("MergeBases(%s, %s) returned error: %v", tt.a, tt.b, err)
+		}
+		sort.Strings(bases)
+		sort.Strings(tt.want)
+		if strings.Join(bases, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("MergeBases(%s, %s) = %v, want %v", tt.a, tt.b, bases, tt.want)
+		}
+	}
+}
+
+func TestWalkReadsCommitsAsNeeded(t *testing.T) {
+	repo := arrangeRepo(t)
+	c := repo.commits
+	// c1 cannot be read, only the commits above it are shown
+	os.Remove(filepath.Join(".got", "objects", c["c1"][:2], c["c1"][2:]))
+	rng := Range{Include: []string{c["c3"]}}
+
+	walker, err := repo.resolver.Walk(rng, WalkOptions{})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	if commit, ok := walker.Next(); !ok || commit.Hash != c["c3"] {
+		t.Fatalf("Expected c3 first, got %v (%v)", commit, walker.Err())
+	}
+	if commit, ok := walker.Next(); ok {
+		t.Errorf("Expected the walk to stop on c1, got %s", commit.Hash)
+	}
+	if walker.Err() == nil {
+		t.Errorf("Expected an error reading c1")
+	}
+
+	if _, err := repo.resolver.Walk(rng, WalkOptions{TopoOrder: true}); err == nil {
+		t.Errorf("Expected a topological walk to read c1 up front")
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	repo := arrangeRepo(t)
+	c := repo.commits
+	tests := []struct {
+		a, b   string
+		ahead  int
+		behind int
+	}{
+		{"c3", "c3", 0, 0},
+		{"c3", "c1", 2, 0},
+		{"c1", "c3", 0, 2},
+		{"f1", "c3", 1, 1},
+		{"m", "c3", 2, 0},
+	}
+	for _, tt := range tests {
+		ahead, behind, err := repo.resolver.AheadBehind(c[tt.a], c[tt.b])
+		if err != nil {
+			t.Fatalf("AheadBehind(%s, %s) returned error: %v", tt.a, tt.b, err)
+		}
+		if ahead != tt.ahead || behind != tt.behind {
+			t.Errorf("AheadBehind(%s, %s) = %d, %d, want %d, %d", tt.a, tt.b, ahead, behind, tt.ahead, tt.behind)
+		}
+	}
+}
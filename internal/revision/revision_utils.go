@@ -0,0 +1,278 @@
+package revision
+
+import (
+	"container/heap"
+	"fmt"
+	"got_it/internal/date"
+	"got_it/internal/refs"
+	"got_it/internal/utils"
+	"strings"
+	"time"
+)
+
+const HEAD string = refs.HEAD
+
+// refSearchRules are the places where a short ref name is looked for, in order
+var refSearchRules = []string{
+	"%s",
+	"refs/%s",
+	"refs/tags/%s",
+	"refs/heads/%s",
+	"refs/remotes/%s",
+	"refs/remotes/%s/HEAD",
+}
+
+// ResolveRef returns the commit a ref points to and the full name of the ref
+func (r *Resolver) ResolveRef(name string) (string, string, error) {
+	if name == "" || strings.Contains(name, "..") {
+		return "", "", fmt.Errorf("invalid ref name '%s'", name)
+	}
+	for _, rule := range refSearchRules {
+		fullRef := fmt.Sprintf(rule, name)
+		if !refs.IsPseudoRef(fullRef) && !strings.HasPrefix(fullRef, "refs/") {
+			continue
+		}
+		hash, _, err := r.refs.Resolve(fullRef)
+		if err == nil {
+			return hash, fullRef, nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown ref '%s'", name)
+}
+
+// CurrentBranch returns the full name of the branch HEAD points to
+func (r *Resolver) CurrentBranch() (string, error) {
+	return r.refs.CurrentBranch()
+}
+
+// PreviousBranch returns the n-th branch checked out before the current one,
+// as recorded by "checkout: moving from <a> to <b>" entries in the HEAD reflog
+func (r *Resolver) PreviousBranch(n int) (string, error) {
+	entries, err := r.logs.Read(HEAD)
+	if err != nil {
+		return "", err
+	}
+	found := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		message := entries[i].Message
+		if !strings.HasPrefix(message, "checkout: moving from ") {
+			continue
+		}
+		found++
+		if found == n {
+			from := strings.TrimPrefix(message, "checkout: moving from ")
+			from, _, _ = strings.Cut(from, " to ")
+			return from, nil
+		}
+	}
+	return "", fmt.Errorf("no %d-th previous branch found in the reflog", n)
+}
+
+// reflogEntry returns the value the ref had n updates ago
+func (r *Resolver) reflogEntry(fullRef string, n int) (string, error) {
+	entries, err := r.logs.Read(fullRef)
+	if err != nil {
+		return "", err
+	}
+	if n < len(entries) {
+		return entries[len(entries)-1-n].NewHash, nil
+	}
+	if n == len(entries) && len(entries) > 0 && !utils.IsZeroHash(entries[0].OldHash) {
+		return entries[0].OldHash, nil
+	}
+	return "", fmt.Errorf("log for '%s' only has %d entries", fullRef, len(entries))
+}
+
+// reflogAt returns the value the ref had at the given time
+func (r *Resolver) reflogAt(fullRef string, at time.Time) (string, error) {
+	entries, err := r.logs.Read(fullRef)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("log for '%s' is empty", fullRef)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Date.After(at) {
+			return entries[i].NewHash, nil
+		}
+	}
+	// the log does not go back that far, use its oldest value
+	r.logger.Debug("log for '%s' only goes back to %s", fullRef, date.Format(entries[0].Date))
+	if !utils.IsZeroHash(entries[0].OldHash) {
+		return entries[0].OldHash, nil
+	}
+	return entries[0].NewHash, nil
+}
+
+// Reachable returns every commit reachable from the given commits
+func (r *Resolver) Reachable(hashes []string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	queue := append([]string{}, hashes...)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		commit, err := r.store.ReadCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, commit.Parents...)
+	}
+	return seen, nil
+}
+
+// Paint of the commits reached by the MergeBases walk
+const (
+	paintA = 1 << iota
+	paintB
+	paintStale
+	paintBase
+)
+
+// MergeBases returns the best common ancestors of two commits. Both sides
+// are painted down in one walk, newest commit first: a commit reached from
+// both is a common ancestor, and the commits below it are painted stale as
+// they cannot be best ones. The walk stops when only stale commits are left.
+func (r *Resolver) MergeBases(a, b string) ([]string, error) {
+	if a == b {
+		return []string{a}, nil
+	}
+	paint := make(map[string]int)
+	queued := make(map[string]int)
+	commits := make(map[string]*Commit)
+	queue := &commitQueue{}
+	// active counts the commits in the queue that are not stale
+	active := 0
+	isActive := func(hash string) bool {
+		return queued[hash] > 0 && paint[hash]&paintStale == 0
+	}
+	visit := func(hash string, flags int) error {
+		if paint[hash]&flags == flags {
+			return nil
+		}
+		commit := commits[hash]
+		if commit == nil {
+			data, err := r.store.ReadCommit(hash)
+			if err != nil {
+				return err
+			}
+			commit = &Commit{CommitData: data, Hash: hash}
+			commits[hash] = commit
+		}
+		if isActive(hash) {
+			active--
+		}
+		paint[hash] |= flags
+		queued[hash]++
+		if isActive(hash) {
+			active++
+		}
+		heap.Push(queue, commit)
+		return nil
+	}
+	if err := visit(a, paintA); err != nil {
+		return nil, err
+	}
+	if err := visit(b, paintB); err != nil {
+		return nil, err
+	}
+
+	candidates := []string{}
+	for active > 0 {
+		commit := heap.Pop(queue).(*Commit)
+		if isActive(commit.Hash) {
+			active--
+		}
+		queued[commit.Hash]--
+		if isActive(commit.Hash) {
+			active++
+		}
+		flags := paint[commit.Hash]
+		if flags&(paintA|paintB) == paintA|paintB {
+			if flags&(paintStale|paintBase) == 0 {
+				paint[commit.Hash] |= paintBase
+				candidates = append(candidates, commit.Hash)
+			}
+			flags |= paintStale
+		}
+		for _, parent := range commit.Parents {
+			if err := visit(parent, flags); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// with clock skew, a candidate can be found before a common ancestor
+	// it descends from
+	bases := []string{}
+	for _, hash := range candidates {
+		if paint[hash]&paintStale == 0 {
+			bases = append(bases, hash)
+		}
+	}
+	return bases, nil
+}
+
+// AheadBehind counts the commits reachable from a but not from b, and the
+// ones reachable from b but not from a
+func (r *Resolver) AheadBehind(a, b string) (int, int, error) {
+	reachableA, err := r.Reachable([]string{a})
+	if err != nil {
+		return 0, 0, err
+	}
+	reachableB, err := r.Reachable([]string{b})
+	if err != nil {
+		return 0, 0, err
+	}
+	ahead, behind := 0, 0
+	for hash := range reachableA {
+		if !reachableB[hash] {
+			ahead++
+		}
+	}
+	for hash := range reachableB {
+		if !reachableA[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// cutPath splits <rev>:<path>, ignoring colons inside @{...}
+func cutPath(rev string) (string, string, bool) {
+	depth := 0
+	for i, c := range rev {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				return rev[:i], rev[i+1:], true
+			}
+		}
+	}
+	return rev, "", false
+}
+
+// suffixStart returns the index of the first ~ or ^ suffix of rev
+func suffixStart(rev string) int {
+	depth := 0
+	for i, c := range rev {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '~', '^':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(rev)
+}
@@ -0,0 +1,173 @@
+package revision
+
+import (
+	"container/heap"
+	"got_it/internal/models"
+	"sort"
+)
+
+// Commit is a commit visited by a Walker
+type Commit struct {
+	models.CommitData
+	Hash string
+}
+
+// commitQueue is a heap of commits, the newest by committer date on top and
+// the smallest hash first among commits of the same date
+type commitQueue []*Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	if q[i].CommitterDate.Equal(q[j].CommitterDate) {
+		return q[i].Hash < q[j].Hash
+	}
+	return q[i].CommitterDate.After(q[j].CommitterDate)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(*Commit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// WalkOptions tune the order of a walk
+type WalkOptions struct {
+	// TopoOrder shows no commit before all of its children in the range,
+	// which takes reading the whole range before the first commit
+	TopoOrder bool
+}
+
+// Walker iterates over the commits of a Range, newest first by committer
+// date. Commits are read as they are needed, unless the walk is in
+// topological order.
+type Walker struct {
+	resolver *Resolver
+	excluded map[string]bool
+	seen     map[string]bool
+	queue    commitQueue
+	sorted   []*Commit // the whole range, in topological order
+	topo     bool
+	err      error
+}
+
+// Walk returns an iterator over the commits of the range
+func (r *Resolver) Walk(rng Range, opts WalkOptions) (*Walker, error) {
+	excluded, err := r.Reachable(rng.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	w := &Walker{resolver: r, excluded: excluded, seen: make(map[string]bool), topo: opts.TopoOrder}
+	for _, hash := range rng.Include {
+		if err := w.push(hash); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.TopoOrder {
+		return w, nil
+	}
+	commits := make(map[string]*Commit)
+	for commit, ok := w.pop(); ok; commit, ok = w.pop() {
+		commits[commit.Hash] = commit
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	w.sorted = sortTopologically(commits)
+	return w, nil
+}
+
+// Next returns the next commit, or false when there are no more commits or
+// reading one failed, which Err tells
+func (w *Walker) Next() (*Commit, bool) {
+	if !w.topo {
+		return w.pop()
+	}
+	if len(w.sorted) == 0 {
+		return nil, false
+	}
+	commit := w.sorted[0]
+	w.sorted = w.sorted[1:]
+	return commit, true
+}
+
+// Err returns the error that stopped the walk, if any
+func (w *Walker) Err() error {
+	return w.err
+}
+
+// push reads a commit of the range and queues it, once
+func (w *Walker) push(hash string) error {
+	if w.excluded[hash] || w.seen[hash] {
+		return nil
+	}
+	w.seen[hash] = true
+	data, err := w.resolver.store.ReadCommit(hash)
+	if err != nil {
+		return err
+	}
+	heap.Push(&w.queue, &Commit{CommitData: data, Hash: hash})
+	return nil
+}
+
+// pop returns the newest queued commit, queueing its parents
+func (w *Walker) pop() (*Commit, bool) {
+	if w.err != nil || w.queue.Len() == 0 {
+		return nil, false
+	}
+	commit := heap.Pop(&w.queue).(*Commit)
+	for _, parent := range commit.Parents {
+		if err := w.push(parent); err != nil {
+			w.err = err
+			return nil, false
+		}
+	}
+	return commit, true
+}
+
+// sortTopologically orders the commits so that children come before their
+// parents, picking the newest commit whenever there is a choice
+func sortTopologically(commits map[string]*Commit) []*Commit {
+	children := make(map[string]int)
+	for _, commit := range commits {
+		for _, parent := range commit.Parents {
+			if commits[parent] != nil {
+				children[parent]++
+			}
+		}
+	}
+	ready := []*Commit{}
+	for hash, commit := range commits {
+		if children[hash] == 0 {
+			ready = append(ready, commit)
+		}
+	}
+
+	sorted := make([]*Commit, 0, len(commits))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			if ready[i].CommitterDate.Equal(ready[j].CommitterDate) {
+				return ready[i].Hash < ready[j].Hash
+			}
+			return ready[i].CommitterDate.After(ready[j].CommitterDate)
+		})
+		commit := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, commit)
+		for _, parent := range commit.Parents {
+			if commits[parent] == nil {
+				continue
+			}
+			children[parent]--
+			if children[parent] == 0 {
+				ready = append(ready, commits[parent])
+			}
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,467 @@
+package sequencer
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/ident"
+	"got_it/internal/logger"
+	"got_it/internal/merge"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/revision"
+	"got_it/internal/worktree"
+	"io"
+	"os"
+	"strings"
+)
+
+// Action is what is done with a commit of the todo list
+type Action string
+
+const (
+	ACT_PICK   Action = "pick"
+	ACT_REVERT Action = "revert"
+)
+
+// Step is a line of the todo list
+type Step struct {
+	Action  Action
+	Hash    string
+	Subject string
+}
+
+// Options tune how the commits are applied
+type Options struct {
+	// RecordOrigin appends "(cherry picked from commit <hash>)" to the messages
+	RecordOrigin bool
+}
+
+// Sequencer applies a list of commits on top of HEAD one after the other,
+// for cherry-pick and revert. When a commit does not apply cleanly it stops,
+// leaving its state under .got/sequencer so the user can resolve the
+// conflicts and continue, skip the commit or abort.
+type Sequencer struct {
+	conf     *config.Config
+	logger   *logger.Logger
+	store    *objects.Store
+	refs     *refs.Store
+	resolver *revision.Resolver
+	worktree *worktree.Worktree
+	out      io.Writer
+}
+
+func NewSequencer(conf *config.Config, logger *logger.Logger) *Sequencer {
+	return &Sequencer{
+		conf:     conf,
+		logger:   logger,
+		store:    objects.NewStore(conf, logger),
+		refs:     refs.NewStore(conf, logger),
+		resolver: revision.NewResolver(conf, logger),
+		worktree: worktree.NewWorktree(conf, logger),
+		out:      os.Stdout,
+	}
+}
+
+// Start applies the commits named by revs, ranges included, on top of HEAD
+func (sq *Sequencer) Start(action Action, revs []string, opts Options) error {
+	if sq.InProgress() {
+		return fmt.Errorf("a cherry-pick or revert is already in progress (try --continue, --skip or --abort)")
+	}
+	if len(revs) == 0 {
+		return fmt.Errorf("no commits given")
+	}
+	headHash, _, err := sq.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return fmt.Errorf("cannot %s onto a branch without commits", commandName(action))
+	}
+	status, err := sq.worktree.Status()
+	if err != nil {
+		return err
+	}
+	if !status.IsClean() {
+		return fmt.Errorf("your local changes would be overwritten by %s, commit your changes or stash them to proceed", commandName(action))
+	}
+	todo, err := sq.todoFor(action, revs)
+	if err != nil {
+		return err
+	}
+	if err := sq.writeState(state{Head: headHash, Options: opts, Todo: todo}); err != nil {
+		return err
+	}
+	return sq.run()
+}
+
+// Continue commits the resolved conflicts of the stopped commit and applies
+// the rest of the todo list
+func (sq *Sequencer) Continue() error {
+	st, err := sq.readState()
+	if err != nil {
+		return err
+	}
+	if st.Current != nil {
+		if err := sq.CheckResolved(st.Unmerged); err != nil {
+			return err
+		}
+		if err := sq.commitStep(*st.Current, st.Message); err != nil {
+			return err
+		}
+		if err := sq.clearCurrent(); err != nil {
+			return err
+		}
+	}
+	return sq.run()
+}
+
+// Skip drops the changes of the stopped commit and applies the rest of the
+// todo list
+func (sq *Sequencer) Skip() error {
+	st, err := sq.readState()
+	if err != nil {
+		return err
+	}
+	if st.Current == nil {
+		return fmt.Errorf("there is no stopped commit to skip")
+	}
+	headFiles, _, err := sq.worktree.HeadFiles()
+	if err != nil {
+		return err
+	}
+	if err := sq.worktree.Reset(headFiles); err != nil {
+		return err
+	}
+	if err := sq.clearCurrent(); err != nil {
+		return err
+	}
+	return sq.run()
+}
+
+// Abort moves HEAD, the index and the files back to where they were before
+// the sequence started
+func (sq *Sequencer) Abort() error {
+	st, err := sq.readState()
+	if err != nil {
+		return err
+	}
+	files, err := sq.worktree.CommitFiles(st.Head)
+	if err != nil {
+		return err
+	}
+	if err := sq.worktree.Reset(files); err != nil {
+		return err
+	}
+	action := ACT_PICK
+	if st.Current != nil {
+		action = st.Current.Action
+	}
+	message := commandName(action) + ": aborting"
+	if err := sq.refs.Update(refs.HEAD, st.Head, refs.UpdateOptions{Message: message}); err != nil {
+		return err
+	}
+	return sq.removeState()
+}
+
+// run applies the steps of the todo list until it is empty or a step stops
+// on conflicts
+func (sq *Sequencer) run() error {
+	for {
+		st, err := sq.readState()
+		if err != nil {
+			return err
+		}
+		if len(st.Todo) == 0 {
+			return sq.removeState()
+		}
+		step := st.Todo[0]
+		if err := sq.writeTodo(st.Todo[1:]); err != nil {
+			return err
+		}
+		conflicts, message, err := sq.applyStep(step, st.Options)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return sq.stop(step, conflicts, message)
+		}
+	}
+}
+
+// applyStep merges the changes of the step into HEAD and commits them. When
+// some files conflict, nothing is committed and the conflicts are returned
+// with the message the commit should have.
+func (sq *Sequencer) applyStep(step Step, opts Options) ([]merge.Conflict, string, error) {
+	commit, err := sq.store.ReadCommit(step.Hash)
+	if err != nil {
+		return nil, "", err
+	}
+	message := messageFor(step, commit, opts)
+	conflicts, err := sq.ApplyChanges(step.Action, step.Hash)
+	if err != nil || len(conflicts) > 0 {
+		return conflicts, message, err
+	}
+	return nil, message, sq.commitStep(step, message)
+}
+
+// commitStep commits the staged files with the message of the step, keeping
+// the author of picked commits
+func (sq *Sequencer) commitStep(step Step, message string) error {
+	opts := CommitOptions{
+		Message: message,
+		Reflog:  commandName(step.Action) + ": " + subjectOf(message),
+	}
+	if step.Action == ACT_PICK {
+		author, err := sq.AuthorOf(step.Hash)
+		if err != nil {
+			return err
+		}
+		opts.Author = &author
+	}
+	commitHash, err := sq.CommitIndex(opts)
+	if err != nil {
+		return err
+	}
+	if commitHash == "" {
+		fmt.Fprintf(sq.out, "Skipping %s... %s, its changes are already in HEAD\n",
+			sq.store.Abbreviate(step.Hash, objects.ABBREV_LENGTH), step.Subject)
+		return nil
+	}
+	fmt.Fprintf(sq.out, "[%s %s] %s\n", sq.branchName(), sq.store.Abbreviate(commitHash, objects.ABBREV_LENGTH), subjectOf(message))
+	return nil
+}
+
+// ApplyChanges merges the changes introduced by a commit, or their inverse
+// for a revert, into the index and the files. Conflicted files hold conflict
+// markers and keep their HEAD version in the index until the user adds them.
+func (sq *Sequencer) ApplyChanges(action Action, hash string) ([]merge.Conflict, error) {
+	commit, err := sq.store.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(commit.Parents) > 1 {
+		return nil, fmt.Errorf("commit %s is a merge, which %s does not support", hash, commandName(action))
+	}
+	parentFiles := map[string]models.TreeEntry{}
+	if len(commit.Parents) == 1 {
+		if parentFiles, err = sq.worktree.CommitFiles(commit.Parents[0]); err != nil {
+			return nil, err
+		}
+	}
+	commitFiles, err := sq.store.FlattenTree(commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+	headFiles, _, err := sq.worktree.HeadFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	short := sq.store.Abbreviate(hash, objects.ABBREV_LENGTH)
+	subject := subjectOf(commit.Message)
+	base, theirs := parentFiles, commitFiles
+	labels := merge.Labels{Ours: "HEAD", Theirs: fmt.Sprintf("%s (%s)", short, subject)}
+	if action == ACT_REVERT {
+		base, theirs = commitFiles, parentFiles
+		labels.Theirs = fmt.Sprintf("parent of %s (%s)", short, subject)
+	}
+	result, err := merge.Trees(sq.store, base, headFiles, theirs, labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := sq.worktree.CheckConflicts(headFiles, result.Files); err != nil {
+		return nil, err
+	}
+	if err := sq.worktree.Checkout(headFiles, result.Files); err != nil {
+		return nil, err
+	}
+
+	staged := result.Files
+	for _, conflict := range result.Conflicts {
+		if entry, found := headFiles[conflict.Path]; found {
+			staged[conflict.Path] = entry
+		} else {
+			delete(staged, conflict.Path)
+		}
+	}
+	return result.Conflicts, sq.worktree.WriteIndex(staged)
+}
+
+// CommitOptions tune the commit made from the index
+type CommitOptions struct {
+	Message string
+	Author  *ident.Ident // nil for the current user
+	Reflog  string       // the message recorded in the reflogs
+	Amend   bool         // replace HEAD instead of adding a child to it
+}
+
+// CommitIndex commits the staged files on top of HEAD, or in place of it when
+// amending, and moves HEAD to the new commit. Without amending, nothing is
+// committed when the index matches HEAD and an empty hash is returned.
+func (sq *Sequencer) CommitIndex(opts CommitOptions) (string, error) {
+	files, err := sq.worktree.IndexFiles()
+	if err != nil {
+		return "", err
+	}
+	headFiles, headHash, err := sq.worktree.HeadFiles()
+	if err != nil {
+		return "", err
+	}
+	parents := []string{headHash}
+	if opts.Amend {
+		head, err := sq.store.ReadCommit(headHash)
+		if err != nil {
+			return "", err
+		}
+		parents = head.Parents
+	} else if len(diff.CompareTrees(headFiles, files)) == 0 {
+		return "", nil
+	}
+	tree, err := sq.store.WriteTree(files)
+	if err != nil {
+		return "", err
+	}
+
+	author := opts.Author
+	if author == nil {
+		current, err := ident.Author(sq.conf)
+		if err != nil {
+			return "", err
+		}
+		author = &current
+	}
+	committer, err := ident.Committer(sq.conf)
+	if err != nil {
+		return "", err
+	}
+	commitHash, err := sq.store.WriteCommit(models.CommitData{
+		Tree:           tree,
+		Parents:        parents,
+		AuthorName:     author.Name,
+		AuthorEmail:    author.Email,
+		AuthorDate:     author.Date,
+		CommitterName:  committer.Name,
+		CommitterEmail: committer.Email,
+		CommitterDate:  committer.Date,
+		Message:        opts.Message,
+	})
+	if err != nil {
+		return "", err
+	}
+	update := refs.UpdateOptions{OldHash: headHash, Message: opts.Reflog}
+	return commitHash, sq.refs.Update(refs.HEAD, commitHash, update)
+}
+
+// AuthorOf returns the author of a commit, to keep it when the commit is
+// replayed
+func (sq *Sequencer) AuthorOf(hash string) (ident.Ident, error) {
+	commit, err := sq.store.ReadCommit(hash)
+	if err != nil {
+		return ident.Ident{}, err
+	}
+	return ident.Ident{Name: commit.AuthorName, Email: commit.AuthorEmail, Date: commit.AuthorDate}, nil
+}
+
+// stop records the stopped step and tells the user how to go on
+func (sq *Sequencer) stop(step Step, conflicts []merge.Conflict, message string) error {
+	paths := []string{}
+	for _, conflict := range conflicts {
+		fmt.Fprintln(sq.out, conflict)
+		paths = append(paths, conflict.Path)
+	}
+	if err := sq.writeCurrent(step, message, paths); err != nil {
+		return err
+	}
+	name := commandName(step.Action)
+	fmt.Fprintf(sq.out, "hint: after resolving the conflicts, mark the corrected paths with 'got add <paths>'\n")
+	fmt.Fprintf(sq.out, "hint: and run 'got %s --continue', or skip this commit with 'got %s --skip'\n", name, name)
+	return fmt.Errorf("could not apply %s... %s", sq.store.Abbreviate(step.Hash, objects.ABBREV_LENGTH), step.Subject)
+}
+
+// CheckResolved makes sure the conflicted files were edited and staged
+func (sq *Sequencer) CheckResolved(unmerged []string) error {
+	indexFiles, err := sq.worktree.IndexFiles()
+	if err != nil {
+		return err
+	}
+	unresolved := []string{}
+	for _, filePath := range unmerged {
+		content, err := sq.worktree.ReadFile(filePath)
+		if err != nil {
+			// a deleted file is resolved once it is gone from the index too
+			if _, staged := indexFiles[filePath]; staged {
+				unresolved = append(unresolved, filePath)
+			}
+			continue
+		}
+		working, err := sq.worktree.WorkingFiles([]string{filePath})
+		if err != nil {
+			return err
+		}
+		if hasConflictMarkers(content) || indexFiles[filePath].Hash != working[filePath].Hash {
+			unresolved = append(unresolved, filePath)
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("you must edit all merge conflicts and then mark them as resolved using 'got add':\n\t%s",
+			strings.Join(unresolved, "\n\t"))
+	}
+	return nil
+}
+
+// todoFor lists the commits named by revs. Ranges are picked oldest first
+// and reverted newest first.
+func (sq *Sequencer) todoFor(action Action, revs []string) ([]Step, error) {
+	todo := []Step{}
+	for _, rev := range revs {
+		hashes := []string{}
+		if strings.Contains(rev, "..") || strings.HasPrefix(rev, "^") {
+			rng, err := sq.resolver.ResolveRange([]string{rev})
+			if err != nil {
+				return nil, err
+			}
+			walker, err := sq.resolver.Walk(rng, revision.WalkOptions{TopoOrder: true})
+			if err != nil {
+				return nil, err
+			}
+			for commit, ok := walker.Next(); ok; commit, ok = walker.Next() {
+				hashes = append(hashes, commit.Hash)
+			}
+			if action == ACT_PICK {
+				for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+					hashes[i], hashes[j] = hashes[j], hashes[i]
+				}
+			}
+		} else {
+			hash, err := sq.resolver.ResolveCommit(rev)
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash)
+		}
+		for _, hash := range hashes {
+			commit, err := sq.store.ReadCommit(hash)
+			if err != nil {
+				return nil, err
+			}
+			todo = append(todo, Step{Action: action, Hash: hash, Subject: subjectOf(commit.Message)})
+		}
+	}
+	if len(todo) == 0 {
+		return nil, fmt.Errorf("empty commit set passed")
+	}
+	return todo, nil
+}
+
+// branchName returns the short name of the current branch
+func (sq *Sequencer) branchName() string {
+	branch, err := sq.refs.CurrentBranch()
+	if err != nil {
+		return "detached HEAD"
+	}
+	return strings.TrimPrefix(branch, "refs/heads/")
+}
+
+// SetOutput changes where the output is written
+func (sq *Sequencer) SetOutput(out io.Writer) {
+	sq.out = out
+}
@@ -0,0 +1,174 @@
+package sequencer
+
+import (
+	"bytes"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/testrepo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeRepo creates this history and checks out main:
+//
+//	base (a.txt, b.txt) - main (b.txt changed)
+//	     \- fix (a.txt changed) - feature (c.txt added) - clash (b.txt changed)
+func arrangeRepo(t *testing.T) (*Sequencer, *bytes.Buffer, map[string]string) {
+	t.Helper()
+	repo := testrepo.New(t)
+	t.Setenv("GOT_COMMITTER_NAME", "C O Mitter")
+	t.Setenv("GOT_COMMITTER_EMAIL", "committer@example.com")
+	t.Setenv("GOT_AUTHOR_NAME", "C O Mitter")
+	t.Setenv("GOT_AUTHOR_EMAIL", "committer@example.com")
+	sq := NewSequencer(repo.Conf, repo.Logger)
+	out := &bytes.Buffer{}
+	sq.SetOutput(out)
+
+	commits := make(map[string]string)
+	commits["base"] = repo.Commit(map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "b\n"}, "base")
+	commits["main"] = repo.Commit(map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "main\n"}, "main", commits["base"])
+	commits["fix"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "b\n"}, "fix a", commits["base"])
+	commits["feature"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "b\n", "c.txt": "c\n"}, "add c", commits["fix"])
+	commits["clash"] = repo.Commit(map[string]string{"a.txt": "1\nfixed\n3\n", "b.txt": "clash\n", "c.txt": "c\n"}, "change b", commits["feature"])
+	repo.SetRef("refs/heads/topic", commits["clash"])
+	if err := sq.refs.Update(refs.HEAD, commits["main"], refs.UpdateOptions{Message: "commit: main"}); err != nil {
+		t.Fatalf("Error updating HEAD: %v", err)
+	}
+	files, _ := sq.worktree.CommitFiles(commits["main"])
+	if err := sq.worktree.Reset(files); err != nil {
+		t.Fatalf("Error checking out main: %v", err)
+	}
+	return sq, out, commits
+}
+
+func headCommit(t *testing.T, sq *Sequencer) (string, models.CommitData) {
+	t.Helper()
+	hash, _, err := sq.refs.Resolve(refs.HEAD)
+	if err != nil {
+		t.Fatalf("Error resolving HEAD: %v", err)
+	}
+	commit, err := sq.store.ReadCommit(hash)
+	if err != nil {
+		t.Fatalf("Error reading HEAD: %v", err)
+	}
+	return hash, commit
+}
+
+func TestPickRange(t *testing.T) {
+	sq, out, commits := arrangeRepo(t)
+	if err := sq.Start(ACT_PICK, []string{"main..topic~1"}, Options{RecordOrigin: true}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	testrepo.AssertFile(t, "a.txt", "1\nfixed\n3\n")
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	testrepo.AssertFile(t, "c.txt", "c\n")
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 ||
+		!strings.HasSuffix(lines[0], "] fix a") || !strings.HasSuffix(lines[1], "] add c") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	_, head := headCommit(t, sq)
+	if head.AuthorName != "A U Thor" || head.CommitterName != "C O Mitter" {
+		t.Errorf("Expected the author to be kept and the committer to change, got %s and %s", head.AuthorName, head.CommitterName)
+	}
+	if head.Message != "add c\n\n(cherry picked from commit "+commits["feature"]+")" {
+		t.Errorf("Unexpected message: %q", head.Message)
+	}
+	parent, _ := sq.store.ReadCommit(head.Parents[0])
+	if parent.Parents[0] != commits["main"] {
+		t.Errorf("Expected the picked commits to be on top of main")
+	}
+	if sq.InProgress() {
+		t.Errorf("Expected the sequencer state to be removed")
+	}
+}
+
+func TestRevert(t *testing.T) {
+	sq, _, commits := arrangeRepo(t)
+	if err := sq.Start(ACT_REVERT, []string{"HEAD"}, Options{}); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	testrepo.AssertFile(t, "b.txt", "b\n")
+	_, head := headCommit(t, sq)
+	expected := "Revert \"main\"\n\nThis reverts commit " + commits["main"] + "."
+	if head.Message != expected || head.AuthorName != "C O Mitter" {
+		t.Errorf("Unexpected revert commit %q by %s", head.Message, head.AuthorName)
+	}
+}
+
+func TestConflictContinue(t *testing.T) {
+	sq, out, commits := arrangeRepo(t)
+	err := sq.Start(ACT_PICK, []string{commits["clash"], commits["fix"]}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "could not apply") {
+		t.Fatalf("Expected the pick to stop on conflicts, got %v", err)
+	}
+	if !strings.Contains(out.String(), "CONFLICT (content): Merge conflict in b.txt") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	testrepo.AssertFile(t, "b.txt", "<<<<<<< HEAD\nmain\n=======\nclash\n>>>>>>> "+sq.store.Abbreviate(commits["clash"], objects.ABBREV_LENGTH)+" (change b)\n")
+	if _, err := os.Stat(filepath.Join(".got", CHERRY_PICK_HEAD)); err != nil {
+		t.Errorf("Expected %s to be written", CHERRY_PICK_HEAD)
+	}
+	if err := sq.Start(ACT_PICK, []string{commits["fix"]}, Options{}); err == nil {
+		t.Errorf("Expected an error starting while a cherry-pick is in progress")
+	}
+	if err := sq.Continue(); err == nil || !strings.Contains(err.Error(), "b.txt") {
+		t.Errorf("Expected continue to refuse the unresolved b.txt, got %v", err)
+	}
+
+	// resolve and stage b.txt
+	os.WriteFile("b.txt", []byte("resolved\n"), 0644)
+	files, _ := sq.worktree.IndexFiles()
+	staged, _ := sq.worktree.StoreFiles([]string{"b.txt"})
+	files["b.txt"] = staged["b.txt"]
+	sq.worktree.WriteIndex(files)
+	if err := sq.Continue(); err != nil {
+		t.Fatalf("Continue returned error: %v", err)
+	}
+	testrepo.AssertFile(t, "b.txt", "resolved\n")
+	testrepo.AssertFile(t, "a.txt", "1\nfixed\n3\n")
+	_, head := headCommit(t, sq)
+	parent, _ := sq.store.ReadCommit(head.Parents[0])
+	if head.Message != "fix a" || parent.Message != "change b" {
+		t.Errorf("Expected both commits to be picked, got %q on %q", head.Message, parent.Message)
+	}
+	if sq.InProgress() {
+		t.Errorf("Expected the sequencer state to be removed")
+	}
+}
+
+func TestConflictSkipAndAbort(t *testing.T) {
+	sq, _, commits := arrangeRepo(t)
+	sq.Start(ACT_PICK, []string{commits["clash"], commits["fix"]}, Options{})
+	if err := sq.Skip(); err != nil {
+		t.Fatalf("Skip returned error: %v", err)
+	}
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	testrepo.AssertFile(t, "a.txt", "1\nfixed\n3\n")
+	_, head := headCommit(t, sq)
+	if head.Message != "fix a" || head.Parents[0] != commits["main"] {
+		t.Errorf("Expected only fix a to be picked, got %q", head.Message)
+	}
+
+	before, _ := headCommit(t, sq)
+	sq.Start(ACT_PICK, []string{commits["feature"], commits["clash"]}, Options{})
+	if !sq.InProgress() {
+		t.Fatalf("Expected the pick of clash to stop")
+	}
+	if err := sq.Abort(); err != nil {
+		t.Fatalf("Abort returned error: %v", err)
+	}
+	if after, _ := headCommit(t, sq); after != before {
+		t.Errorf("Expected HEAD to move back to %s, got %s", before, after)
+	}
+	testrepo.AssertFile(t, "b.txt", "main\n")
+	if _, err := os.Stat("c.txt"); !os.IsNotExist(err) {
+		t.Errorf("Expected c.txt to be removed by abort")
+	}
+	if sq.InProgress() {
+		t.Errorf("Expected the sequencer state to be removed")
+	}
+}
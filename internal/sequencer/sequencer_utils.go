@@ -0,0 +1,193 @@
+package sequencer
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SEQUENCER_DIR holds the state of a cherry-pick or revert in progress
+const SEQUENCER_DIR string = "sequencer"
+
+// CHERRY_PICK_HEAD and REVERT_HEAD name the commit that stopped on conflicts
+const CHERRY_PICK_HEAD string = "CHERRY_PICK_HEAD"
+const REVERT_HEAD string = "REVERT_HEAD"
+
+// MERGE_MSG holds the message of the commit that stopped on conflicts
+const MERGE_MSG string = "MERGE_MSG"
+
+// state is what is kept under .got/sequencer between two commands
+type state struct {
+	Head     string // HEAD before the sequence started, for --abort
+	Options  Options
+	Todo     []Step
+	Current  *Step // the step stopped on conflicts, if any
+	Message  string
+	Unmerged []string
+}
+
+// InProgress tells if a cherry-pick or revert was stopped
+func (sq *Sequencer) InProgress() bool {
+	_, err := os.Stat(sq.path(SEQUENCER_DIR))
+	return err == nil
+}
+
+func (sq *Sequencer) readState() (state, error) {
+	if !sq.InProgress() {
+		return state{}, fmt.Errorf("no cherry-pick or revert in progress")
+	}
+	st := state{}
+	head, err := os.ReadFile(sq.path(SEQUENCER_DIR, "head"))
+	if err != nil {
+		return state{}, err
+	}
+	st.Head = strings.TrimSpace(string(head))
+	if opts, err := os.ReadFile(sq.path(SEQUENCER_DIR, "opts")); err == nil {
+		st.Options.RecordOrigin = strings.Contains(string(opts), "record-origin = true")
+	}
+	todo, err := os.ReadFile(sq.path(SEQUENCER_DIR, "todo"))
+	if err != nil && !os.IsNotExist(err) {
+		return state{}, err
+	}
+	for _, line := range strings.Split(string(todo), "\n") {
+		if line == "" {
+			continue
+		}
+		step, err := parseStep(line)
+		if err != nil {
+			return state{}, err
+		}
+		st.Todo = append(st.Todo, step)
+	}
+
+	for _, action := range []Action{ACT_PICK, ACT_REVERT} {
+		hash, err := os.ReadFile(sq.path(stoppedHeadName(action)))
+		if err != nil {
+			continue
+		}
+		step := Step{Action: action, Hash: strings.TrimSpace(string(hash))}
+		message, _ := os.ReadFile(sq.path(MERGE_MSG))
+		st.Message = string(message)
+		step.Subject = subjectOf(st.Message)
+		st.Current = &step
+		unmerged, _ := os.ReadFile(sq.path(SEQUENCER_DIR, "unmerged"))
+		st.Unmerged = strings.Fields(string(unmerged))
+	}
+	return st, nil
+}
+
+func (sq *Sequencer) writeState(st state) error {
+	if err := os.MkdirAll(sq.path(SEQUENCER_DIR), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sq.path(SEQUENCER_DIR, "head"), []byte(st.Head+"\n"), 0644); err != nil {
+		return err
+	}
+	opts := fmt.Sprintf("record-origin = %t\n", st.Options.RecordOrigin)
+	if err := os.WriteFile(sq.path(SEQUENCER_DIR, "opts"), []byte(opts), 0644); err != nil {
+		return err
+	}
+	return sq.writeTodo(st.Todo)
+}
+
+func (sq *Sequencer) writeTodo(todo []Step) error {
+	var content strings.Builder
+	for _, step := range todo {
+		content.WriteString(step.String() + "\n")
+	}
+	return os.WriteFile(sq.path(SEQUENCER_DIR, "todo"), []byte(content.String()), 0644)
+}
+
+// writeCurrent records the step stopped on conflicts
+func (sq *Sequencer) writeCurrent(step Step, message string, unmerged []string) error {
+	if err := os.WriteFile(sq.path(stoppedHeadName(step.Action)), []byte(step.Hash+"\n"), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sq.path(MERGE_MSG), []byte(message), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(sq.path(SEQUENCER_DIR, "unmerged"), []byte(strings.Join(unmerged, "\n")+"\n"), 0644)
+}
+
+// clearCurrent forgets the step stopped on conflicts
+func (sq *Sequencer) clearCurrent() error {
+	for _, name := range []string{CHERRY_PICK_HEAD, REVERT_HEAD, MERGE_MSG, filepath.Join(SEQUENCER_DIR, "unmerged")} {
+		if err := os.Remove(sq.path(name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sq *Sequencer) removeState() error {
+	if err := sq.clearCurrent(); err != nil {
+		return err
+	}
+	return os.RemoveAll(sq.path(SEQUENCER_DIR))
+}
+
+func (sq *Sequencer) path(elem ...string) string {
+	return filepath.Join(append([]string{sq.conf.GotDir}, elem...)...)
+}
+
+// String formats the step as a line of the todo list
+func (s Step) String() string {
+	return fmt.Sprintf("%s %s %s", s.Action, s.Hash, s.Subject)
+}
+
+// parseStep reads a line of the todo list: "<action> <hash> <subject>"
+func parseStep(line string) (Step, error) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) < 2 || (Action(fields[0]) != ACT_PICK && Action(fields[0]) != ACT_REVERT) {
+		return Step{}, fmt.Errorf("invalid line in the todo list: %s", line)
+	}
+	step := Step{Action: Action(fields[0]), Hash: fields[1]}
+	if len(fields) == 3 {
+		step.Subject = fields[2]
+	}
+	return step, nil
+}
+
+// messageFor returns the message of the commit made by a step
+func messageFor(step Step, commit models.CommitData, opts Options) string {
+	message := strings.TrimRight(commit.Message, "\n")
+	if step.Action == ACT_REVERT {
+		return fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", subjectOf(message), step.Hash)
+	}
+	if opts.RecordOrigin {
+		message += fmt.Sprintf("\n\n(cherry picked from commit %s)", step.Hash)
+	}
+	return message
+}
+
+// stoppedHeadName is the pseudo-ref naming the commit stopped by action
+func stoppedHeadName(action Action) string {
+	if action == ACT_REVERT {
+		return REVERT_HEAD
+	}
+	return CHERRY_PICK_HEAD
+}
+
+// commandName is the command performing the action
+func commandName(action Action) string {
+	if action == ACT_REVERT {
+		return "revert"
+	}
+	return "cherry-pick"
+}
+
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return true
+		}
+	}
+	return false
+}
+
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n")
+	return subject
+}
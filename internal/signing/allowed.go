@@ -0,0 +1,170 @@
+package signing
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// allowedSigner is a line of an allowed signers file:
+// "<principals> [<options>] <key type> <key> [<comment>]"
+type allowedSigner struct {
+	principals  string
+	namespaces  []string
+	validAfter  time.Time
+	validBefore time.Time
+	key         sshPublicKey
+}
+
+type allowedSigners []allowedSigner
+
+// readAllowedSigners reads the file in the format of ssh-keygen: the
+// options namespaces, valid-after and valid-before are honored, lines of
+// certificate authorities and of unsupported key types are skipped
+func readAllowedSigners(filePath string) (allowedSigners, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the allowed signers: %w", err)
+	}
+	defer file.Close()
+
+	signers := allowedSigners{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s:%d: invalid allowed signer", filePath, number)
+		}
+		signer := allowedSigner{principals: fields[0]}
+		keyText := strings.Join(fields[1:], " ")
+		if !isKeyType(fields[1]) {
+			skip, err := signer.parseOptions(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", filePath, number, err)
+			}
+			if skip {
+				continue
+			}
+			keyText = strings.Join(fields[2:], " ")
+		}
+		if signer.key, err = parseSSHPublicKey(keyText); err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers, scanner.Err()
+}
+
+// parseOptions reads the comma separated options of a line and tells if
+// the line is to be skipped
+func (s *allowedSigner) parseOptions(options string) (bool, error) {
+	for _, option := range splitOptions(options) {
+		name, value, _ := strings.Cut(option, "=")
+		value = strings.Trim(value, `"`)
+		var err error
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			return true, nil
+		case "namespaces":
+			s.namespaces = strings.Split(value, ",")
+		case "valid-after":
+			s.validAfter, err = parseValidity(value)
+		case "valid-before":
+			s.validBefore, err = parseValidity(value)
+		default:
+			return false, fmt.Errorf("unknown option '%s'", name)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// find returns the principals of the key when it may sign in the
+// namespace at the given time
+func (signers allowedSigners) find(key sshPublicKey, namespace string, when time.Time) (string, bool) {
+	for _, signer := range signers {
+		if !signer.key.key.Equal(key.key) {
+			continue
+		}
+		if len(signer.namespaces) > 0 && !matchesAnyPattern(namespace, signer.namespaces) {
+			continue
+		}
+		if !signer.validAfter.IsZero() && when.Before(signer.validAfter) {
+			continue
+		}
+		if !signer.validBefore.IsZero() && !when.Before(signer.validBefore) {
+			continue
+		}
+		return signer.principals, true
+	}
+	return "", false
+}
+
+// parseValidity reads YYYYMMDD[HHMM[SS]], in UTC with a trailing Z
+func parseValidity(value string) (time.Time, error) {
+	location := time.Local
+	if trimmed, utc := strings.CutSuffix(strings.ToUpper(value), "Z"); utc {
+		value, location = trimmed, time.UTC
+	}
+	for _, layout := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(value) == len(layout) {
+			return time.ParseInLocation(layout, value, location)
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid validity time '%s'", value)
+}
+
+// splitOptions splits options at the commas outside of double quotes
+func splitOptions(options string) []string {
+	parts := []string{}
+	quoted := false
+	start := 0
+	for i, c := range options {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, options[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, options[start:])
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.TrimSpace(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeyType(field string) bool {
+	for _, prefix := range []string{"ssh-", "ecdsa-", "sk-"} {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(filePath string) string {
+	if rest, found := strings.CutPrefix(filePath, "~/"); found {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return filePath
+}
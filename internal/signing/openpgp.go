@@ -0,0 +1,72 @@
+package signing
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// OpenPGP signatures are made and checked by gpg, as Git does
+
+// DEFAULT_GPG_PROGRAM runs when gpg.program is not set
+const DEFAULT_GPG_PROGRAM string = "gpg"
+
+const PGP_SIGNATURE_BEGIN string = "-----BEGIN PGP SIGNATURE-----"
+
+// signGPG makes a detached armored signature of the payload with the key
+func signGPG(program, key string, payload []byte) (string, error) {
+	cmd := exec.Command(program, "--status-fd=2", "-bsau", key)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if err != nil || !strings.Contains("\n"+stderr.String(), "\n[GNUPG:] SIG_CREATED ") {
+		return "", fmt.Errorf("gpg failed to sign the data:\n%s", strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// verifyGPG checks a detached signature of the payload; the result holds
+// what gpg says about it
+func verifyGPG(program string, payload []byte, signature string) (Verification, error) {
+	file, err := os.CreateTemp("", "got-signature-")
+	if err != nil {
+		return Verification{}, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(signature); err != nil {
+		file.Close()
+		return Verification{}, err
+	}
+	file.Close()
+
+	cmd := exec.Command(program, "--status-fd=1", "--keyid-format=long", "--verify", file.Name(), "-")
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if _, exited := err.(*exec.ExitError); !exited {
+			return Verification{}, fmt.Errorf("could not run %s: %w", program, err)
+		}
+	}
+
+	result := Verification{Format: FORMAT_OPENPGP, Message: strings.TrimRight(stderr.String(), "\n")}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "[GNUPG:] "))
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "GOODSIG":
+			result.Good, result.Trusted = true, true
+			result.Signer = strings.Join(fields[2:], " ")
+		case "VALIDSIG":
+			result.Fingerprint = fields[1]
+		case "TRUST_NEVER":
+			result.Trusted = false
+		}
+	}
+	return result, nil
+}
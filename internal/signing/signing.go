@@ -0,0 +1,155 @@
+package signing
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"strings"
+	"time"
+)
+
+// Formats of the signatures, chosen by gpg.format
+const (
+	FORMAT_OPENPGP string = "openpgp"
+	FORMAT_SSH     string = "ssh"
+)
+
+// GPGSIG_HEADER is the header of a commit holding its signature
+const GPGSIG_HEADER string = "gpgsig"
+
+func init() {
+	config.Register(
+		config.KeyInfo{Key: "gpg.format", Default: FORMAT_OPENPGP, Description: "format of the signatures made",
+			Validate: config.OneOf(FORMAT_OPENPGP, FORMAT_SSH)},
+		config.KeyInfo{Key: "gpg.program", Default: DEFAULT_GPG_PROGRAM, Description: "program making and checking OpenPGP signatures"},
+		config.KeyInfo{Key: "gpg.ssh.allowedSignersFile", Type: config.TYPE_PATH, Description: "SSH keys trusted to sign, with their principals"},
+		config.KeyInfo{Key: "user.signingKey", Description: "OpenPGP key id, or path of the SSH key, to sign with"},
+	)
+}
+
+// Verification is the result of checking a signature
+type Verification struct {
+	Format      string
+	Good        bool   // the signature matches the signed content
+	Trusted     bool   // the key is one of the allowed signers
+	Signer      string // the principal or user id of the key, when known
+	Fingerprint string
+	Message     string // what log --show-signature shows, one line or more
+}
+
+// Valid tells if the signature is good and made by a trusted key
+func (v Verification) Valid() bool {
+	return v.Good && v.Trusted
+}
+
+// Sign signs the payload in the format of gpg.format, with the key given,
+// else user.signingKey or, for OpenPGP, the key of the signer ident
+// "Name <email>"
+func Sign(conf *config.Config, payload, key, signer string) (string, error) {
+	if key == "" {
+		key, _ = conf.GetConfigKeyValue("user.signingKey")
+	}
+	switch conf.String("gpg.format") {
+	case FORMAT_SSH:
+		if key == "" {
+			return "", fmt.Errorf("user.signingKey needs to be set for ssh signing")
+		}
+		return signSSH(expandHome(key), []byte(payload))
+	default:
+		if key == "" {
+			key = signer
+		}
+		return signGPG(conf.String("gpg.program"), key, []byte(payload))
+	}
+}
+
+// Verify checks the signature of the payload, made at the given time, in
+// the format its armor tells. SSH keys are trusted when they appear in
+// gpg.ssh.allowedSignersFile.
+func Verify(conf *config.Config, payload, signature string, when time.Time) (Verification, error) {
+	switch {
+	case strings.HasPrefix(signature, SSH_SIGNATURE_BEGIN):
+		return verifySSHSignature(conf, payload, signature, when)
+	case strings.HasPrefix(signature, PGP_SIGNATURE_BEGIN):
+		return verifyGPG(conf.String("gpg.program"), []byte(payload), signature)
+	}
+	return Verification{}, fmt.Errorf("unknown signature format")
+}
+
+// verifySSHSignature checks the signature and looks its key up in the
+// allowed signers
+func verifySSHSignature(conf *config.Config, payload, signature string, when time.Time) (Verification, error) {
+	path, err := conf.GetConfigKeyValue("gpg.ssh.allowedSignersFile")
+	if err != nil {
+		return Verification{}, fmt.Errorf("gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification")
+	}
+	signers, err := readAllowedSigners(expandHome(path))
+	if err != nil {
+		return Verification{}, err
+	}
+
+	result := Verification{Format: FORMAT_SSH}
+	key, err := verifySSH(signature, []byte(payload))
+	if key.key == nil {
+		result.Message = fmt.Sprintf("Could not verify signature: %s", err)
+		return result, nil
+	}
+	result.Fingerprint = key.Fingerprint()
+	if err != nil {
+		result.Message = fmt.Sprintf("Bad \"%s\" signature with ED25519 key %s: %s", SSH_NAMESPACE, result.Fingerprint, err)
+		return result, nil
+	}
+	result.Good = true
+	result.Signer, result.Trusted = signers.find(key, SSH_NAMESPACE, when)
+	if result.Trusted {
+		result.Message = fmt.Sprintf("Good \"%s\" signature for %s with ED25519 key %s", SSH_NAMESPACE, result.Signer, result.Fingerprint)
+	} else {
+		result.Message = fmt.Sprintf("Good \"%s\" signature with ED25519 key %s\nNo principal matched.", SSH_NAMESPACE, result.Fingerprint)
+	}
+	return result, nil
+}
+
+// SplitCommit separates the content of a commit into the signed payload,
+// the commit without its gpgsig header, and the signature, if any
+func SplitCommit(content string) (string, string) {
+	header, message, found := strings.Cut(content, "\n\n")
+	if !found {
+		return content, ""
+	}
+	var payload, signature strings.Builder
+	inSignature := false
+	for _, line := range strings.SplitAfter(header+"\n", "\n") {
+		switch {
+		case strings.HasPrefix(line, GPGSIG_HEADER+" "):
+			inSignature = true
+			signature.WriteString(strings.TrimPrefix(line, GPGSIG_HEADER+" "))
+		case inSignature && strings.HasPrefix(line, " "):
+			signature.WriteString(line[1:])
+		default:
+			inSignature = false
+			payload.WriteString(line)
+		}
+	}
+	if signature.Len() == 0 {
+		return content, ""
+	}
+	sig := signature.String()
+	if !strings.HasSuffix(sig, "\n") {
+		sig += "\n"
+	}
+	return payload.String() + "\n" + message, sig
+}
+
+// SplitTag separates the content of a tag into the signed payload and the
+// signature appended to its message, if any
+func SplitTag(content string) (string, string) {
+	at := -1
+	for _, begin := range []string{PGP_SIGNATURE_BEGIN, SSH_SIGNATURE_BEGIN} {
+		if i := strings.LastIndex(content, "\n"+begin); i > at {
+			at = i
+		}
+	}
+	if at < 0 {
+		return content, ""
+	}
+	return content[:at+1], content[at+1:]
+}
@@ -0,0 +1,298 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SSH signatures follow the SSHSIG format of ssh-keygen -Y sign, with
+// Ed25519 keys
+
+const (
+	SSH_SIGNATURE_BEGIN string = "-----BEGIN SSH SIGNATURE-----"
+	SSH_SIGNATURE_END   string = "-----END SSH SIGNATURE-----"
+	// SSH_NAMESPACE is the namespace of the signatures of commits and tags
+	SSH_NAMESPACE string = "git"
+	// SSH_ED25519 is the only key type supported
+	SSH_ED25519 string = "ssh-ed25519"
+)
+
+const (
+	sshsigMagic      = "SSHSIG"
+	sshsigVersion    = 1
+	sshsigHash       = "sha512"
+	sshsigLineLength = 70
+	opensshKeyMagic  = "openssh-key-v1\x00"
+)
+
+// sshPublicKey is an Ed25519 public key in the SSH wire format
+type sshPublicKey struct {
+	key ed25519.PublicKey
+}
+
+// blob returns the key in the SSH wire format
+func (k sshPublicKey) blob() []byte {
+	var out bytes.Buffer
+	writeString(&out, []byte(SSH_ED25519))
+	writeString(&out, k.key)
+	return out.Bytes()
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, as ssh-keygen -l
+// shows it
+func (k sshPublicKey) Fingerprint() string {
+	sum := sha256.Sum256(k.blob())
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// signSSH signs the payload with the private key in the OpenSSH file at
+// path, or at path without .pub when it names the public key
+func signSSH(path string, payload []byte) (string, error) {
+	key, err := readSSHPrivateKey(path)
+	if err != nil {
+		return "", err
+	}
+	public := sshPublicKey{key: key.Public().(ed25519.PublicKey)}
+	signed := sshsigSignedData(SSH_NAMESPACE, payload)
+
+	var signature bytes.Buffer
+	writeString(&signature, []byte(SSH_ED25519))
+	writeString(&signature, ed25519.Sign(key, signed))
+
+	var blob bytes.Buffer
+	blob.WriteString(sshsigMagic)
+	binary.Write(&blob, binary.BigEndian, uint32(sshsigVersion))
+	writeString(&blob, public.blob())
+	writeString(&blob, []byte(SSH_NAMESPACE))
+	writeString(&blob, nil)
+	writeString(&blob, []byte(sshsigHash))
+	writeString(&blob, signature.Bytes())
+	return armorSSH(blob.Bytes()), nil
+}
+
+// verifySSH checks an armored SSH signature of the payload and returns the
+// key that made it
+func verifySSH(signature string, payload []byte) (sshPublicKey, error) {
+	blob, err := dearmorSSH(signature)
+	if err != nil {
+		return sshPublicKey{}, err
+	}
+	if !bytes.HasPrefix(blob, []byte(sshsigMagic)) {
+		return sshPublicKey{}, fmt.Errorf("not an SSH signature")
+	}
+	r := &wireReader{data: blob[len(sshsigMagic):]}
+	version := r.uint32()
+	keyBlob := r.string()
+	namespace := string(r.string())
+	r.string() // reserved
+	hashName := string(r.string())
+	sigBlob := r.string()
+	if r.err != nil {
+		return sshPublicKey{}, fmt.Errorf("invalid SSH signature: %w", r.err)
+	}
+	if version != sshsigVersion {
+		return sshPublicKey{}, fmt.Errorf("unsupported SSH signature version %d", version)
+	}
+	key, err := parseSSHPublicBlob(keyBlob)
+	if err != nil {
+		return sshPublicKey{}, err
+	}
+	if namespace != SSH_NAMESPACE {
+		return key, fmt.Errorf("signature namespace '%s' is not '%s'", namespace, SSH_NAMESPACE)
+	}
+
+	sig := &wireReader{data: sigBlob}
+	sigType := string(sig.string())
+	raw := sig.string()
+	if sig.err != nil || sigType != SSH_ED25519 {
+		return key, fmt.Errorf("unsupported signature type '%s'", sigType)
+	}
+	var signed []byte
+	switch hashName {
+	case "sha512":
+		signed = sshsigSignedData(namespace, payload)
+	case "sha256":
+		sum := sha256.Sum256(payload)
+		signed = sshsigSignedDataWithHash(namespace, hashName, sum[:])
+	default:
+		return key, fmt.Errorf("unsupported signature hash '%s'", hashName)
+	}
+	if !ed25519.Verify(key.key, signed, raw) {
+		return key, fmt.Errorf("incorrect signature")
+	}
+	return key, nil
+}
+
+// sshsigSignedData returns what is signed for the payload: the preamble,
+// the namespace and the SHA-512 hash of the payload
+func sshsigSignedData(namespace string, payload []byte) []byte {
+	sum := sha512.Sum512(payload)
+	return sshsigSignedDataWithHash(namespace, sshsigHash, sum[:])
+}
+
+func sshsigSignedDataWithHash(namespace, hashName string, sum []byte) []byte {
+	var signed bytes.Buffer
+	signed.WriteString(sshsigMagic)
+	writeString(&signed, []byte(namespace))
+	writeString(&signed, nil)
+	writeString(&signed, []byte(hashName))
+	writeString(&signed, sum)
+	return signed.Bytes()
+}
+
+// parseSSHPublicKey reads a key as written in .pub and allowed signers
+// files: "ssh-ed25519 <base64> [comment]"
+func parseSSHPublicKey(text string) (sshPublicKey, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return sshPublicKey{}, fmt.Errorf("invalid SSH public key")
+	}
+	if fields[0] != SSH_ED25519 {
+		return sshPublicKey{}, fmt.Errorf("unsupported SSH key type '%s'", fields[0])
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return sshPublicKey{}, fmt.Errorf("invalid SSH public key: %w", err)
+	}
+	return parseSSHPublicBlob(blob)
+}
+
+func parseSSHPublicBlob(blob []byte) (sshPublicKey, error) {
+	r := &wireReader{data: blob}
+	keyType := string(r.string())
+	key := r.string()
+	if r.err != nil {
+		return sshPublicKey{}, fmt.Errorf("invalid SSH public key: %w", r.err)
+	}
+	if keyType != SSH_ED25519 {
+		return sshPublicKey{}, fmt.Errorf("unsupported SSH key type '%s'", keyType)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return sshPublicKey{}, fmt.Errorf("invalid Ed25519 key length %d", len(key))
+	}
+	return sshPublicKey{key: ed25519.PublicKey(key)}, nil
+}
+
+// readSSHPrivateKey reads an unencrypted Ed25519 key in the OpenSSH format;
+// the path of the public key gives the private key next to it
+func readSSHPrivateKey(path string) (ed25519.PrivateKey, error) {
+	path = strings.TrimSuffix(path, ".pub")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the signing key: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		return nil, fmt.Errorf("%s is not an OpenSSH private key", path)
+	}
+	if !bytes.HasPrefix(block.Bytes, []byte(opensshKeyMagic)) {
+		return nil, fmt.Errorf("%s is not an OpenSSH private key", path)
+	}
+	r := &wireReader{data: block.Bytes[len(opensshKeyMagic):]}
+	cipher := string(r.string())
+	r.string() // kdf name
+	r.string() // kdf options
+	if count := r.uint32(); r.err == nil && count != 1 {
+		return nil, fmt.Errorf("%s holds %d keys, expected one", path, count)
+	}
+	r.string() // public key
+	private := &wireReader{data: r.string()}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid OpenSSH private key: %w", r.err)
+	}
+	if cipher != "none" {
+		return nil, fmt.Errorf("%s is encrypted, only keys without passphrase can sign", path)
+	}
+	// the two check numbers match once decrypted
+	check1, check2 := private.uint32(), private.uint32()
+	if check1 != check2 {
+		return nil, fmt.Errorf("invalid OpenSSH private key")
+	}
+	keyType := string(private.string())
+	private.string() // public part
+	key := private.string()
+	if private.err != nil {
+		return nil, fmt.Errorf("invalid OpenSSH private key: %w", private.err)
+	}
+	if keyType != SSH_ED25519 {
+		return nil, fmt.Errorf("unsupported SSH key type '%s', only %s keys can sign", keyType, SSH_ED25519)
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 key length %d", len(key))
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// armorSSH wraps the signature blob between the SSH signature markers
+func armorSSH(blob []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var out strings.Builder
+	out.WriteString(SSH_SIGNATURE_BEGIN + "\n")
+	for len(encoded) > sshsigLineLength {
+		out.WriteString(encoded[:sshsigLineLength] + "\n")
+		encoded = encoded[sshsigLineLength:]
+	}
+	out.WriteString(encoded + "\n")
+	out.WriteString(SSH_SIGNATURE_END + "\n")
+	return out.String()
+}
+
+func dearmorSSH(signature string) ([]byte, error) {
+	text := strings.TrimSpace(signature)
+	if !strings.HasPrefix(text, SSH_SIGNATURE_BEGIN) || !strings.HasSuffix(text, SSH_SIGNATURE_END) {
+		return nil, fmt.Errorf("not an SSH signature")
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, SSH_SIGNATURE_BEGIN), SSH_SIGNATURE_END)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	return blob, nil
+}
+
+// writeString writes data as an SSH string: its length, then its bytes
+func writeString(out *bytes.Buffer, data []byte) {
+	binary.Write(out, binary.BigEndian, uint32(len(data)))
+	out.Write(data)
+}
+
+// wireReader reads the SSH wire format, keeping the first error
+type wireReader struct {
+	data []byte
+	err  error
+}
+
+func (r *wireReader) uint32() uint32 {
+	if r.err != nil || len(r.data) < 4 {
+		r.fail()
+		return 0
+	}
+	value := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return value
+}
+
+func (r *wireReader) string() []byte {
+	length := r.uint32()
+	if r.err != nil || uint32(len(r.data)) < length {
+		r.fail()
+		return nil
+	}
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value
+}
+
+func (r *wireReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("truncated data")
+	}
+}
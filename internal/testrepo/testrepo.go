@@ -0,0 +1,185 @@
+// Package testrepo creates the repositories tests run against: a .got
+// directory in a temporary working directory, and commits written straight
+// to the object store.
+package testrepo
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/index"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// DATE is when the commits written by Commit are authored and committed:
+// 1623501234 seconds after the epoch, at +0200
+var DATE = time.Unix(1623501234, 0).In(time.FixedZone("", 2*60*60))
+
+// Author of the commits written by Commit
+const (
+	AUTHOR_NAME  string = "A U Thor"
+	AUTHOR_EMAIL string = "author@example.com"
+)
+
+// Repo is a repository in the temporary directory the test runs in
+type Repo struct {
+	t      *testing.T
+	Dir    string
+	Conf   *config.Config
+	Logger *logger.Logger
+	Store  *objects.Store
+}
+
+// New changes to a new temporary directory and creates a repository in it,
+// with HEAD on the unborn main branch. The global and system configuration
+// files are left out so that the settings of the user cannot change the
+// outcome of the test.
+func New(t *testing.T) *Repo {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(t.TempDir(), "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "1")
+	for _, sub := range []string{"objects", filepath.Join("refs", "heads"), filepath.Join("refs", "tags")} {
+		if err := os.MkdirAll(filepath.Join(config.GOT_DIR, sub), 0755); err != nil {
+			t.Fatalf("Error creating %s: %v", sub, err)
+		}
+	}
+	WriteFile(t, filepath.Join(config.GOT_DIR, "HEAD"), "ref: refs/heads/main")
+
+	conf := config.NewConfig()
+	logger := logger.NewLogger(false, false)
+	return &Repo{t: t, Dir: dir, Conf: conf, Logger: logger, Store: objects.NewStore(conf, logger)}
+}
+
+// Write stores an object and returns its hash
+func (r *Repo) Write(content string) string {
+	r.t.Helper()
+	hash, err := r.Store.Write(content)
+	if err != nil {
+		r.t.Fatalf("Error writing object: %v", err)
+	}
+	return hash
+}
+
+// Files stores the blobs of the files, slash separated paths to contents,
+// and returns their tree entries
+func (r *Repo) Files(contents map[string]string) map[string]models.TreeEntry {
+	r.t.Helper()
+	files := make(map[string]models.TreeEntry, len(contents))
+	for filePath, content := range contents {
+		files[filePath] = models.TreeEntry{Mode: "100644", Type: string(models.TT_BLOB), Hash: r.Write(content), Name: path.Base(filePath)}
+	}
+	return files
+}
+
+// Commit stores a commit of the files on top of the parents and returns its
+// hash. It is authored and committed by A U Thor at DATE.
+func (r *Repo) Commit(contents map[string]string, message string, parents ...string) string {
+	r.t.Helper()
+	return r.CommitData(models.CommitData{Parents: parents, Message: message}, contents)
+}
+
+// CommitData stores a commit of the files described by cd and returns its
+// hash. The tree is filled in; an empty author is A U Thor, an empty author
+// date DATE, and an empty committer or committer date those of the author.
+func (r *Repo) CommitData(cd models.CommitData, contents map[string]string) string {
+	r.t.Helper()
+	tree, err := r.Store.WriteTree(r.Files(contents))
+	if err != nil {
+		r.t.Fatalf("Error writing tree: %v", err)
+	}
+	cd.Tree = tree
+	if cd.AuthorName == "" {
+		cd.AuthorName, cd.AuthorEmail = AUTHOR_NAME, AUTHOR_EMAIL
+	}
+	if cd.AuthorDate.IsZero() {
+		cd.AuthorDate = DATE
+	}
+	if cd.CommitterName == "" {
+		cd.CommitterName, cd.CommitterEmail = cd.AuthorName, cd.AuthorEmail
+	}
+	if cd.CommitterDate.IsZero() {
+		cd.CommitterDate = cd.AuthorDate
+	}
+	hash, err := r.Store.WriteCommit(cd)
+	if err != nil {
+		r.t.Fatalf("Error writing commit: %v", err)
+	}
+	return hash
+}
+
+// SetRef points the ref, like refs/heads/topic, at the hash without
+// touching its reflog
+func (r *Repo) SetRef(name, hash string) {
+	r.t.Helper()
+	refPath := filepath.Join(config.GOT_DIR, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(refPath), 0755); err != nil {
+		r.t.Fatalf("Error creating %s: %v", name, err)
+	}
+	WriteFile(r.t, refPath, hash)
+}
+
+// Checkout writes the files of the commit to the index and the working
+// directory, without moving HEAD
+func (r *Repo) Checkout(hash string) {
+	r.t.Helper()
+	commit, err := r.Store.ReadCommit(hash)
+	if err != nil {
+		r.t.Fatalf("Error reading commit: %v", err)
+	}
+	files, err := r.Store.FlattenTree(commit.Tree)
+	if err != nil {
+		r.t.Fatalf("Error reading tree: %v", err)
+	}
+	staged := make(map[string]string, len(files))
+	for filePath, entry := range files {
+		content, err := r.Store.Read(entry.Hash)
+		if err != nil {
+			r.t.Fatalf("Error reading blob: %v", err)
+		}
+		WriteFile(r.t, filePath, content)
+		staged[filePath] = entry.Hash
+	}
+	if err := index.NewIndex(r.Conf, r.Logger).Write(staged); err != nil {
+		r.t.Fatalf("Error writing index: %v", err)
+	}
+}
+
+// WriteFile writes the file, creating its directory
+func WriteFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatalf("Error creating the directory of %s: %v", name, err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatalf("Error writing %s: %v", name, err)
+	}
+}
+
+// AssertFile fails the test unless the file holds the expected content
+func AssertFile(t *testing.T, name, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Error reading %s: %v", name, err)
+	}
+	if string(content) != expected {
+		t.Errorf("Expected %s to contain %q, got %q", name, expected, content)
+	}
+}
+
+// AssertMissing fails the test if the file exists
+func AssertMissing(t *testing.T, name string) {
+	t.Helper()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be missing", name)
+	}
+}
@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"fmt"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+)
+
+// pending is an object to copy with the type its referrer gave it
+type pending struct {
+	hash       string
+	objectType models.ObjectType
+}
+
+// CopyObjects copies to dst the objects reachable from wants that dst does
+// not have. An object is only written after everything it points to, so an
+// interrupted copy never leaves a commit without its history.
+func CopyObjects(src, dst *objects.Store, wants []string) error {
+	missing, err := MissingObjects(src, dst, wants)
+	if err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		content, err := src.Read(missing[i])
+		if err != nil {
+			return err
+		}
+		if err := WriteObject(dst, missing[i], content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MissingObjects lists the objects reachable from wants in src that dst
+// does not have, each one before the objects it points to. The history
+// behind an object dst has is not walked.
+func MissingObjects(src, dst *objects.Store, wants []string) ([]string, error) {
+	return walkObjects(src, wants, dst.Exists)
+}
+
+// walkObjects lists the objects reachable from wants in store, each one
+// before the objects it points to, without walking past the known ones
+func walkObjects(store *objects.Store, wants []string, known func(string) bool) ([]string, error) {
+	queue := []pending{}
+	for _, want := range wants {
+		queue = append(queue, pending{hash: want})
+	}
+	seen := map[string]bool{}
+	found := []string{}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current.hash] || known(current.hash) {
+			continue
+		}
+		seen[current.hash] = true
+		content, err := store.Read(current.hash)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, current.hash)
+
+		if current.objectType == "" {
+			current.objectType = objects.TypeOf(content)
+		}
+		switch current.objectType {
+		case models.OT_COMMIT:
+			commit, err := store.ReadCommit(current.hash)
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, pending{hash: commit.Tree, objectType: models.OT_TREE})
+			for _, parent := range commit.Parents {
+				queue = append(queue, pending{hash: parent, objectType: models.OT_COMMIT})
+			}
+		case models.OT_TREE:
+			entries, err := store.ReadTree(current.hash)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				queue = append(queue, pending{hash: entry.Hash, objectType: models.ObjectType(entry.Type)})
+			}
+		case models.OT_TAG:
+			tag, err := store.ReadTag(current.hash)
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, pending{hash: tag.Object, objectType: tag.Type})
+		}
+	}
+	return found, nil
+}
+
+// ObjectsToSend lists the objects reachable from wants that are not
+// reachable from haves, the commits the receiver already has. The haves
+// store lacks are ignored.
+func ObjectsToSend(store *objects.Store, wants, haves []string) ([]string, error) {
+	common := []string{}
+	for _, have := range haves {
+		if store.Exists(have) {
+			common = append(common, have)
+		}
+	}
+	shared, err := walkObjects(store, common, func(string) bool { return false })
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, hash := range shared {
+		known[hash] = true
+	}
+	return walkObjects(store, wants, func(hash string) bool { return known[hash] })
+}
+
+// WriteObject stores content received for hash, checking it was not
+// corrupted on the way
+func WriteObject(dst *objects.Store, hash, content string) error {
+	written, err := dst.Write(content)
+	if err != nil {
+		return err
+	}
+	if written != hash {
+		return fmt.Errorf("object %s is corrupt", hash)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// The environment variables holding the credentials of the HTTP transport
+// when the URL has none
+const (
+	HTTP_USER_ENV     string = "GOT_HTTP_USER"
+	HTTP_PASSWORD_ENV string = "GOT_HTTP_PASSWORD"
+)
+
+// httpTransport reaches a repository served by got serve
+type httpTransport struct {
+	logger   *logger.Logger
+	client   *http.Client
+	base     string // the URL without credentials nor trailing slash
+	user     string
+	password string
+	adv      *Advertisement // the refs listed last, the haves of a push
+}
+
+// openHTTP prepares the requests to the repository at rawURL; the
+// credentials come from the URL or from the environment
+func openHTTP(rawURL string, logger *logger.Logger) (*httpTransport, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL '%s': %v", rawURL, err)
+	}
+	t := &httpTransport{
+		logger:   logger,
+		client:   http.DefaultClient,
+		user:     os.Getenv(HTTP_USER_ENV),
+		password: os.Getenv(HTTP_PASSWORD_ENV),
+	}
+	if parsed.User != nil {
+		t.user = parsed.User.Username()
+		t.password, _ = parsed.User.Password()
+		parsed.User = nil
+	}
+	t.base = strings.TrimSuffix(parsed.String(), "/")
+	return t, nil
+}
+
+// IsHTTP tells if the URL of a remote uses the HTTP transport
+func IsHTTP(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+func (t *httpTransport) ListRefs() (Advertisement, error) {
+	response, err := t.request(http.MethodGet, INFO_REFS_PATH, "", nil)
+	if err != nil {
+		return Advertisement{}, err
+	}
+	defer response.Body.Close()
+	adv, err := readAdvertisement(response.Body)
+	if err != nil {
+		return Advertisement{}, err
+	}
+	t.adv = &adv
+	return adv, nil
+}
+
+// Fetch asks for the wants, telling the commits dst already has so only
+// the objects it lacks are sent
+func (t *httpTransport) Fetch(dst *objects.Store, wants, haves []string) error {
+	var body bytes.Buffer
+	for _, want := range wants {
+		fmt.Fprintf(&body, "%s %s\n", KW_WANT, want)
+	}
+	for _, have := range haves {
+		fmt.Fprintf(&body, "%s %s\n", KW_HAVE, have)
+	}
+	fmt.Fprintln(&body, KW_DONE)
+
+	response, err := t.request(http.MethodPost, UPLOAD_PACK_PATH, PACK_CONTENT_TYPE, &body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	received, err := ReadPack(response.Body, dst)
+	if err != nil {
+		return err
+	}
+	t.logger.Debug("Received %d objects", len(received))
+	return nil
+}
+
+// Push sends the updates with the objects they need that the remote refs
+// listed last do not already reach
+func (t *httpTransport) Push(src *objects.Store, updates []Update) (map[string]error, error) {
+	if t.adv == nil {
+		if _, err := t.ListRefs(); err != nil {
+			return nil, err
+		}
+	}
+	wants, haves := []string{}, []string{}
+	for _, update := range updates {
+		if update.New != "" {
+			wants = append(wants, update.New)
+		}
+	}
+	for _, hash := range t.adv.Refs {
+		haves = append(haves, hash)
+	}
+
+	var body bytes.Buffer
+	if err := writeCommands(&body, updates, src.Format()); err != nil {
+		return nil, err
+	}
+	if len(wants) > 0 {
+		hashes, err := ObjectsToSend(src, wants, haves)
+		if err != nil {
+			return nil, err
+		}
+		if err := WritePack(&body, src, hashes); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := t.request(http.MethodPost, RECEIVE_PACK_PATH, PACK_CONTENT_TYPE, &body)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	rejected := map[string]error{}
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) == 3 && fields[0] == KW_NG {
+			rejected[fields[1]] = fmt.Errorf("%s", fields[2])
+		} else if len(fields) != 2 || fields[0] != KW_OK {
+			return nil, fmt.Errorf("unexpected push status '%s'", scanner.Text())
+		}
+	}
+	return rejected, scanner.Err()
+}
+
+// request sends a request to a path of the repository and fails on any
+// status but 200 with the message of the server
+func (t *httpTransport) request(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	request, err := http.NewRequest(method, t.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if t.user != "" {
+		request.SetBasicAuth(t.user, t.password)
+	}
+	t.logger.Debug("%s %s", method, t.base+path)
+	response, err := t.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusOK {
+		return response, nil
+	}
+	defer response.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("authentication failed for '%s'", t.base)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("repository '%s' not found", t.base)
+	}
+	return nil, fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(message)))
+}
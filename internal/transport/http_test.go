@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"bytes"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// arrangeServer serves a bare repository with two commits on main and
+// returns the test server, the store and refs of the repository and the
+// commits
+func arrangeServer(t *testing.T, opts ServerOptions) (*httptest.Server, *objects.Store, *refs.Store, []string) {
+	t.Helper()
+	store, dir := newStore(t)
+	conf := config.NewConfig()
+	conf.GotDir = filepath.Join(dir, config.GOT_DIR)
+	logger := logger.NewLogger(false, false)
+	refStore := refs.NewStore(conf, logger)
+	first := writeCommit(t, store, "one\n", "")
+	second := writeCommit(t, store, "two\n", first)
+	refStore.Update("refs/heads/main", second, refs.UpdateOptions{})
+
+	server := httptest.NewServer(NewServer(conf, logger, true, opts))
+	t.Cleanup(server.Close)
+	return server, store, refStore, []string{first, second}
+}
+
+func TestHTTPFetch(t *testing.T) {
+	server, _, _, commits := arrangeServer(t, ServerOptions{})
+	conn, err := Open(server.URL+"/project.got/", logger.NewLogger(false, false))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		t.Fatalf("ListRefs returned error: %v", err)
+	}
+	if adv.Head != "refs/heads/main" || adv.Refs["refs/heads/main"] != commits[1] {
+		t.Errorf("Unexpected advertisement: %+v", adv)
+	}
+
+	// the client has the first commit, only the second one is sent
+	dst, _ := newStore(t)
+	src, _ := newStore(t)
+	writeCommit(t, src, "one\n", "")
+	CopyObjects(src, dst, []string{commits[0]})
+	if err := conn.Fetch(dst, []string{commits[1]}, []string{commits[0]}); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	commit, err := dst.ReadCommit(commits[1])
+	if err != nil || !dst.Exists(commit.Tree) {
+		t.Errorf("Expected the second commit to be fetched: %v", err)
+	}
+
+	if err := conn.Fetch(dst, []string{strings.Repeat("a", 40)}, nil); err == nil {
+		t.Errorf("Expected an error fetching an object no ref points to")
+	}
+}
+
+func TestHTTPPush(t *testing.T) {
+	server, store, refStore, commits := arrangeServer(t, ServerOptions{})
+	conn, _ := Open(server.URL, logger.NewLogger(false, false))
+	conn.ListRefs()
+
+	src, _ := newStore(t)
+	writeCommit(t, src, "one\n", "")
+	writeCommit(t, src, "two\n", commits[0])
+	third := writeCommit(t, src, "three\n", commits[1])
+	rejected, err := conn.Push(src, []Update{
+		{Name: "refs/heads/main", Old: commits[1], New: third},
+		{Name: "refs/heads/stale", Old: commits[0], New: third},
+		{Name: "refs/tags/v1", New: commits[0]},
+	})
+	if err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if len(rejected) != 1 || rejected["refs/heads/stale"] == nil {
+		t.Errorf("Expected only the stale update to be rejected: %v", rejected)
+	}
+	if hash, _, _ := refStore.Resolve("refs/heads/main"); hash != third || !store.Exists(third) {
+		t.Errorf("Expected main to be updated to %s, got %s", third, hash)
+	}
+	if hash, _, _ := refStore.Resolve("refs/tags/v1"); hash != commits[0] {
+		t.Errorf("Expected the tag to be created")
+	}
+
+	rejected, err = conn.Push(src, []Update{{Name: "refs/tags/v1", Old: commits[0]}})
+	if err != nil || len(rejected) > 0 || refStore.Exists("refs/tags/v1") {
+		t.Errorf("Expected the tag to be deleted: %v, %v", rejected, err)
+	}
+}
+
+func TestHTTPPushMissingObjects(t *testing.T) {
+	server, _, refStore, commits := arrangeServer(t, ServerOptions{})
+
+	// a pack without the tree of the pushed commit
+	src, _ := newStore(t)
+	orphan := writeCommit(t, src, "orphan\n", commits[1])
+	var body bytes.Buffer
+	writeCommands(&body, []Update{{Name: "refs/heads/main", Old: commits[1], New: orphan}}, src.Format())
+	WritePack(&body, src, []string{orphan})
+
+	response, err := http.Post(server.URL+RECEIVE_PACK_PATH, PACK_CONTENT_TYPE, &body)
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	defer response.Body.Close()
+	status, _ := io.ReadAll(response.Body)
+	if string(status) != "ng refs/heads/main missing necessary objects\n" {
+		t.Errorf("Unexpected status: %q", status)
+	}
+	if hash, _, _ := refStore.Resolve("refs/heads/main"); hash != commits[1] {
+		t.Errorf("Expected main to stay at %s", commits[1])
+	}
+}
+
+func TestHTTPMaxRequestSize(t *testing.T) {
+	server, _, refStore, commits := arrangeServer(t, ServerOptions{MaxRequestSize: 256})
+
+	src, _ := newStore(t)
+	pushed := writeCommit(t, src, strings.Repeat("large content\n", 100), commits[1])
+	var body bytes.Buffer
+	writeCommands(&body, []Update{{Name: "refs/heads/main", Old: commits[1], New: pushed}}, src.Format())
+	WritePack(&body, src, []string{pushed})
+
+	response, err := http.Post(server.URL+RECEIVE_PACK_PATH, PACK_CONTENT_TYPE, &body)
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, response.StatusCode)
+	}
+	if hash, _, _ := refStore.Resolve("refs/heads/main"); hash != commits[1] {
+		t.Errorf("Expected main to stay at %s", commits[1])
+	}
+}
+
+func TestHTTPAuthAndReadOnly(t *testing.T) {
+	server, _, _, commits := arrangeServer(t, ServerOptions{ReadOnly: true, Users: map[string]string{"alice": "secret"}})
+	logger := logger.NewLogger(false, false)
+
+	conn, _ := Open(server.URL, logger)
+	if _, err := conn.ListRefs(); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("Expected the authentication to fail, got %v", err)
+	}
+	wrongURL := strings.Replace(server.URL, "http://", "http://alice:wrong@", 1)
+	conn, _ = Open(wrongURL, logger)
+	if _, err := conn.ListRefs(); err == nil {
+		t.Errorf("Expected the authentication to fail with a wrong password")
+	}
+
+	t.Setenv(HTTP_USER_ENV, "alice")
+	t.Setenv(HTTP_PASSWORD_ENV, "secret")
+	conn, _ = Open(server.URL, logger)
+	if _, err := conn.ListRefs(); err != nil {
+		t.Fatalf("ListRefs returned error: %v", err)
+	}
+	src, _ := newStore(t)
+	writeCommit(t, src, "one\n", "")
+	_, err := conn.Push(src, []Update{{Name: "refs/heads/topic", New: commits[0]}})
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("Expected the push to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localTransport reaches a repository on the filesystem, a network share
+// included, by reading and writing its files directly
+type localTransport struct {
+	store *objects.Store
+	refs  *refs.Store
+	bare  bool
+}
+
+// openLocal opens the repository at path: a worktree holding .got, or the
+// bare repository itself
+func openLocal(path string, logger *logger.Logger) (*localTransport, error) {
+	gotDir, bare, err := FindGotDir(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := config.NewConfig()
+	conf.GotDir = gotDir
+	return &localTransport{
+		store: objects.NewStore(conf, logger),
+		refs:  refs.NewStore(conf, logger),
+		bare:  bare,
+	}, nil
+}
+
+// FindGotDir returns the directory holding the objects and refs of the
+// repository at path, and whether the repository is bare
+func FindGotDir(path string) (string, bool, error) {
+	if isGotDir(filepath.Join(path, config.GOT_DIR)) {
+		return filepath.Join(path, config.GOT_DIR), false, nil
+	}
+	if isGotDir(path) {
+		return path, true, nil
+	}
+	return "", false, fmt.Errorf("'%s' does not appear to be a got repository", path)
+}
+
+func isGotDir(path string) bool {
+	for _, name := range []string{"HEAD", "objects"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *localTransport) ListRefs() (Advertisement, error) {
+	return ListRefs(t.refs, t.store.Format())
+}
+
+func (t *localTransport) Fetch(dst *objects.Store, wants, haves []string) error {
+	return CopyObjects(t.store, dst, wants)
+}
+
+func (t *localTransport) Push(src *objects.Store, updates []Update) (map[string]error, error) {
+	return ApplyUpdates(src, t.store, t.refs, t.bare, updates)
+}
+
+// ListRefs returns the branches and tags of a repository, the branch its
+// HEAD points to and the format of its objects
+func ListRefs(store *refs.Store, format utils.ObjectFormat) (Advertisement, error) {
+	adv := Advertisement{Refs: map[string]string{}, ObjectFormat: format}
+	list, err := store.List("refs/")
+	if err != nil {
+		return Advertisement{}, err
+	}
+	for _, ref := range list {
+		if ref.Hash != "" && (strings.HasPrefix(ref.Name, "refs/heads/") || strings.HasPrefix(ref.Name, "refs/tags/")) {
+			adv.Refs[ref.Name] = ref.Hash
+		}
+	}
+	adv.Head, _ = store.CurrentBranch()
+	return adv, nil
+}
+
+// ApplyUpdates copies the objects the updates need from src to the store of
+// a repository and moves its refs, each one only if it still has its old
+// value. The branch checked out in a repository with a worktree is not
+// updated, as that would leave the worktree behind.
+func ApplyUpdates(src, dst *objects.Store, store *refs.Store, bare bool, updates []Update) (map[string]error, error) {
+	rejected := map[string]error{}
+	checkedOut, _ := store.CurrentBranch()
+	for _, update := range updates {
+		if !bare && update.Name == checkedOut {
+			rejected[update.Name] = fmt.Errorf("refusing to update checked out branch")
+			continue
+		}
+		if err := refs.ValidateName(update.Name); err != nil || !strings.HasPrefix(update.Name, "refs/") {
+			rejected[update.Name] = fmt.Errorf("invalid ref name")
+			continue
+		}
+		if update.New == "" {
+			if err := store.Delete(update.Name, update.Old); err != nil {
+				rejected[update.Name] = err
+			}
+			continue
+		}
+		if err := CopyObjects(src, dst, []string{update.New}); err != nil {
+			return nil, err
+		}
+		old := update.Old
+		if old == "" {
+			old = dst.Format().ZeroHash()
+		}
+		if err := store.Update(update.Name, update.New, refs.UpdateOptions{OldHash: old, Message: "push"}); err != nil {
+			rejected[update.Name] = err
+		}
+	}
+	return rejected, nil
+}
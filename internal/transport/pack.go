@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"bufio"
+	"compress/zlib"
+	"fmt"
+	"got_it/internal/objects"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PACK_SIGNATURE starts the header of a pack, followed by the object count
+const PACK_SIGNATURE string = "PACK"
+
+// WritePack sends objects of the store as a pack: a zlib stream holding a
+// "PACK <count>" line, then each object as a "<hash> <size>" line followed
+// by its content. Objects are written in the reverse order of the list, so
+// the ones listed first by walkObjects arrive after what they point to.
+func WritePack(w io.Writer, store *objects.Store, hashes []string) error {
+	zw := zlib.NewWriter(w)
+	if _, err := fmt.Fprintf(zw, "%s %d\n", PACK_SIGNATURE, len(hashes)); err != nil {
+		return err
+	}
+	for i := len(hashes) - 1; i >= 0; i-- {
+		content, err := store.Read(hashes[i])
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(zw, "%s %d\n%s", hashes[i], len(content), content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ReadPack stores the objects of a pack, checking each one against its
+// hash, and returns their hashes in the order they were received
+func ReadPack(r io.Reader, dst *objects.Store) ([]string, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack: %w", err)
+	}
+	defer zr.Close()
+	reader := bufio.NewReader(zr)
+
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack: %w", err)
+	}
+	signature, countField, _ := strings.Cut(strings.TrimSuffix(header, "\n"), " ")
+	count, err := strconv.Atoi(countField)
+	if signature != PACK_SIGNATURE || err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid pack header '%s'", strings.TrimSpace(header))
+	}
+
+	received := []string{}
+	for i := 0; i < count; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("truncated pack: %w", err)
+		}
+		hash, sizeField, _ := strings.Cut(strings.TrimSuffix(line, "\n"), " ")
+		size, err := strconv.Atoi(sizeField)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid pack entry '%s'", strings.TrimSpace(line))
+		}
+		// the buffer grows with the data received, not with the size announced
+		var content strings.Builder
+		if _, err := io.CopyN(&content, reader, int64(size)); err != nil {
+			return nil, fmt.Errorf("truncated pack: %w", err)
+		}
+		if err := WriteObject(dst, hash, content.String()); err != nil {
+			return nil, err
+		}
+		received = append(received, hash)
+	}
+	return received, nil
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"got_it/internal/utils"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The paths served for a repository over HTTP, after its URL
+const (
+	INFO_REFS_PATH    string = "/info/refs"
+	UPLOAD_PACK_PATH  string = "/upload-pack"
+	RECEIVE_PACK_PATH string = "/receive-pack"
+)
+
+// The content types of the requests and responses
+const (
+	REFS_CONTENT_TYPE   string = "text/plain; charset=utf-8"
+	PACK_CONTENT_TYPE   string = "application/x-got-pack"
+	STATUS_CONTENT_TYPE string = "text/plain; charset=utf-8"
+)
+
+// The keywords of an upload-pack request and of a receive-pack status
+const (
+	KW_WANT string = "want"
+	KW_HAVE string = "have"
+	KW_DONE string = "done"
+	KW_OK   string = "ok"
+	KW_NG   string = "ng"
+)
+
+// HEAD_SYMREF starts the line of a ref advertisement naming the branch
+// HEAD points to
+const HEAD_SYMREF string = "ref: "
+
+// OBJECT_FORMAT_LINE starts the line of a ref advertisement naming the hash
+// algorithm of the repository, left out for SHA-1
+const OBJECT_FORMAT_LINE string = "object-format "
+
+// writeAdvertisement writes the refs as "<hash> <name>" lines, after the
+// object format and the branch HEAD points to, if any
+func writeAdvertisement(w io.Writer, adv Advertisement) error {
+	if adv.ObjectFormat != "" && adv.ObjectFormat != utils.OF_SHA1 {
+		if _, err := fmt.Fprintf(w, "%s%s\n", OBJECT_FORMAT_LINE, adv.ObjectFormat); err != nil {
+			return err
+		}
+	}
+	if adv.Head != "" {
+		if _, err := fmt.Fprintf(w, "%s%s HEAD\n", HEAD_SYMREF, adv.Head); err != nil {
+			return err
+		}
+	}
+	names := []string{}
+	for name := range adv.Refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s %s\n", adv.Refs[name], name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAdvertisement parses what writeAdvertisement wrote
+func readAdvertisement(r io.Reader) (Advertisement, error) {
+	adv := Advertisement{Refs: map[string]string{}, ObjectFormat: utils.OF_SHA1}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, found := strings.CutPrefix(line, OBJECT_FORMAT_LINE); found {
+			format, err := utils.ParseObjectFormat(name)
+			if err != nil {
+				return Advertisement{}, err
+			}
+			adv.ObjectFormat = format
+			continue
+		}
+		if target, found := strings.CutPrefix(line, HEAD_SYMREF); found {
+			adv.Head = strings.TrimSuffix(target, " HEAD")
+			continue
+		}
+		hash, name, found := strings.Cut(line, " ")
+		if !found || name == "" {
+			return Advertisement{}, fmt.Errorf("invalid ref advertisement line '%s'", line)
+		}
+		adv.Refs[name] = hash
+	}
+	return adv, scanner.Err()
+}
+
+// writeCommands writes the updates of a push as "<old> <new> <name>"
+// lines, the zero hash of the format standing for a missing side, and a
+// blank line
+func writeCommands(w io.Writer, updates []Update, format utils.ObjectFormat) error {
+	for _, update := range updates {
+		old, new := update.Old, update.New
+		if old == "" {
+			old = format.ZeroHash()
+		}
+		if new == "" {
+			new = format.ZeroHash()
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", old, new, update.Name); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// readCommands parses what writeCommands wrote, up to the blank line
+func readCommands(r *bufio.Reader) ([]Update, error) {
+	updates := []Update{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("truncated push commands: %w", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return updates, nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid push command '%s'", line)
+		}
+		update := Update{Old: fields[0], New: fields[1], Name: fields[2]}
+		if utils.IsZeroHash(update.Old) {
+			update.Old = ""
+		}
+		if utils.IsZeroHash(update.New) {
+			update.New = ""
+		}
+		updates = append(updates, update)
+	}
+}
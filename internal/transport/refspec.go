@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"strings"
+)
+
+// Refspec maps refs of one repository to refs of another, like
+// +refs/heads/*:refs/remotes/origin/*. Force allows non fast-forward updates.
+type Refspec struct {
+	Force bool
+	Src   string
+	Dst   string
+}
+
+// ParseRefspec reads "[+]<src>[:<dst>]"; both sides hold the same number of
+// "*", at most one
+func ParseRefspec(spec string) (Refspec, error) {
+	refspec := Refspec{}
+	if strings.HasPrefix(spec, "+") {
+		refspec.Force = true
+		spec = spec[1:]
+	}
+	src, dst, _ := strings.Cut(spec, ":")
+	refspec.Src, refspec.Dst = src, dst
+	if strings.Count(src, "*") > 1 || strings.Count(src, "*") != strings.Count(dst, "*") && dst != "" {
+		return Refspec{}, fmt.Errorf("invalid refspec '%s'", spec)
+	}
+	return refspec, nil
+}
+
+// Match tells if name matches the source side and returns the destination
+// it maps to
+func (r Refspec) Match(name string) (string, bool) {
+	prefix, suffix, glob := strings.Cut(r.Src, "*")
+	if !glob {
+		return r.Dst, name == r.Src
+	}
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) < len(prefix)+len(suffix) {
+		return "", false
+	}
+	middle := name[len(prefix) : len(name)-len(suffix)]
+	return strings.Replace(r.Dst, "*", middle, 1), true
+}
+
+// String formats the refspec as it is written in the config
+func (r Refspec) String() string {
+	spec := r.Src
+	if r.Dst != "" {
+		spec += ":" + r.Dst
+	}
+	if r.Force {
+		spec = "+" + spec
+	}
+	return spec
+}
+
+// UpstreamRef returns the local ref following the upstream of the branch:
+// the upstream branch itself when it is local, else the remote-tracking
+// branch the fetch refspecs of its remote map it to
+func UpstreamRef(conf *config.Config, branch string) (string, error) {
+	upstream, err := conf.GetUpstream(branch)
+	if err != nil {
+		return "", err
+	}
+	if upstream.Remote == config.LOCAL_REMOTE {
+		return upstream.Merge, nil
+	}
+	remote, err := conf.GetRemote(upstream.Remote)
+	if err != nil {
+		return "", err
+	}
+	for _, spec := range remote.Fetch {
+		refspec, err := ParseRefspec(spec)
+		if err != nil {
+			return "", err
+		}
+		if dst, matches := refspec.Match(upstream.Merge); matches && dst != "" {
+			return dst, nil
+		}
+	}
+	return "", fmt.Errorf("upstream branch '%s' is not fetched from remote '%s'", upstream.Merge, upstream.Remote)
+}
@@ -0,0 +1,222 @@
+package transport
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"net/http"
+	"strings"
+)
+
+// AUTH_REALM is the realm announced to clients that must authenticate
+const AUTH_REALM string = "got"
+
+// Server serves a repository over HTTP: the ref advertisement, the packs
+// fetched and the packs pushed
+type Server struct {
+	logger *logger.Logger
+	store  *objects.Store
+	refs   *refs.Store
+	bare   bool
+	opts   ServerOptions
+}
+
+// ServerOptions tune what the server allows
+type ServerOptions struct {
+	ReadOnly bool              // refuse pushes
+	Users    map[string]string // passwords by user name; empty to allow anybody
+	// MaxRequestSize bounds the body of the requests, as the packs pushed
+	// may hold any number of objects; 0 for no limit
+	MaxRequestSize int64
+}
+
+func NewServer(conf *config.Config, logger *logger.Logger, bare bool, opts ServerOptions) *Server {
+	return &Server{
+		logger: logger,
+		store:  objects.NewStore(conf, logger),
+		refs:   refs.NewStore(conf, logger),
+		bare:   bare,
+		opts:   opts,
+	}
+}
+
+// ServeHTTP answers the requests of the HTTP transport, whatever path the
+// repository is served under
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", AUTH_REALM))
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	s.logger.Debug("%s %s", r.Method, r.URL.Path)
+	if s.opts.MaxRequestSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxRequestSize)
+	}
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, INFO_REFS_PATH):
+		s.serveRefs(w)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, UPLOAD_PACK_PATH):
+		s.serveUploadPack(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, RECEIVE_PACK_PATH):
+		if s.opts.ReadOnly {
+			http.Error(w, "the repository is read-only", http.StatusForbidden)
+			return
+		}
+		s.serveReceivePack(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorized checks the basic auth credentials when users are configured
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.opts.Users) == 0 {
+		return true
+	}
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	expected, found := s.opts.Users[user]
+	return found && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
+
+func (s *Server) serveRefs(w http.ResponseWriter) {
+	adv, err := ListRefs(s.refs, s.store.Format())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", REFS_CONTENT_TYPE)
+	writeAdvertisement(w, adv)
+}
+
+// serveUploadPack reads the "want" and "have" lines of a fetch and sends
+// the objects reachable from the wants and not from the haves. Only the
+// objects of advertised refs can be wanted.
+func (s *Server) serveUploadPack(w http.ResponseWriter, r *http.Request) {
+	adv, err := ListRefs(s.refs, s.store.Format())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	advertised := map[string]bool{}
+	for _, hash := range adv.Refs {
+		advertised[hash] = true
+	}
+
+	wants, haves := []string{}, []string{}
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		keyword, hash, _ := strings.Cut(scanner.Text(), " ")
+		switch keyword {
+		case KW_WANT:
+			if !advertised[hash] {
+				http.Error(w, fmt.Sprintf("not our ref %s", hash), http.StatusBadRequest)
+				return
+			}
+			wants = append(wants, hash)
+		case KW_HAVE:
+			haves = append(haves, hash)
+		case KW_DONE:
+		default:
+			http.Error(w, fmt.Sprintf("unexpected line '%s'", scanner.Text()), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	hashes, err := ObjectsToSend(s.store, wants, haves)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Debug("Sending %d objects for %d wants and %d haves", len(hashes), len(wants), len(haves))
+	w.Header().Set("Content-Type", PACK_CONTENT_TYPE)
+	if err := WritePack(w, s.store, hashes); err != nil {
+		s.logger.Debug("Error sending the pack: %s", err)
+	}
+}
+
+// serveReceivePack stores the pack of a push and applies its updates, then
+// reports an "ok <name>" or "ng <name> <reason>" line for each one
+func (s *Server) serveReceivePack(w http.ResponseWriter, r *http.Request) {
+	reader := bufio.NewReader(r.Body)
+	updates, err := readCommands(reader)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	adv, err := ListRefs(s.refs, s.store.Format())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tips := map[string]bool{}
+	for _, hash := range adv.Refs {
+		tips[hash] = true
+	}
+	sendsObjects := false
+	for _, update := range updates {
+		sendsObjects = sendsObjects || update.New != ""
+	}
+	if sendsObjects {
+		if _, err := ReadPack(reader, s.store); err != nil {
+			badRequest(w, err)
+			return
+		}
+	}
+
+	rejected := map[string]error{}
+	for _, update := range updates {
+		if update.New == "" {
+			continue
+		}
+		// the history behind the refs of the server is complete
+		if _, err := walkObjects(s.store, []string{update.New}, func(hash string) bool { return tips[hash] }); err != nil {
+			rejected[update.Name] = fmt.Errorf("missing necessary objects")
+		}
+	}
+	accepted := []Update{}
+	for _, update := range updates {
+		if rejected[update.Name] == nil {
+			accepted = append(accepted, update)
+		}
+	}
+	applied, err := ApplyUpdates(s.store, s.store, s.refs, s.bare, accepted)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for name, reason := range applied {
+		rejected[name] = reason
+	}
+
+	w.Header().Set("Content-Type", STATUS_CONTENT_TYPE)
+	for _, update := range updates {
+		if reason := rejected[update.Name]; reason != nil {
+			fmt.Fprintf(w, "%s %s %s\n", KW_NG, update.Name, reason)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", KW_OK, update.Name)
+		}
+	}
+}
+
+// badRequest answers a request whose body could not be read, telling apart
+// the bodies above the size limit
+func badRequest(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("request larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
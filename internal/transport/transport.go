@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"fmt"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/utils"
+	"strings"
+)
+
+// FILE_SCHEME starts the URLs of repositories on the local filesystem
+const FILE_SCHEME string = "file://"
+
+// Advertisement is what a remote repository tells about its refs
+type Advertisement struct {
+	Refs         map[string]string // branches and tags by full name
+	Head         string            // the branch HEAD points to, empty when detached
+	ObjectFormat utils.ObjectFormat
+}
+
+// Update asks the remote to move a ref from Old to New. Old is empty when
+// the ref must not exist yet, New is empty to delete the ref.
+type Update struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Transport talks to a remote repository
+type Transport interface {
+	// ListRefs returns the refs of the remote
+	ListRefs() (Advertisement, error)
+	// Fetch copies into dst the objects reachable from wants it lacks;
+	// haves are commits dst has, to spare sending their history
+	Fetch(dst *objects.Store, wants, haves []string) error
+	// Push sends the objects of src the updates need and applies them. The
+	// updates the remote refused are returned with the reason.
+	Push(src *objects.Store, updates []Update) (map[string]error, error)
+}
+
+// CheckObjectFormat makes sure the remote names its objects with the
+// hash algorithm of the local repository
+func CheckObjectFormat(local utils.ObjectFormat, adv Advertisement) error {
+	if adv.ObjectFormat != local {
+		return fmt.Errorf("mismatched object formats: the local repository uses %s, the remote %s", local, adv.ObjectFormat)
+	}
+	return nil
+}
+
+// Open returns the transport for the URL of a remote
+func Open(url string, logger *logger.Logger) (Transport, error) {
+	if path, isLocal := LocalPath(url); isLocal {
+		return openLocal(path, logger)
+	}
+	if IsHTTP(url) {
+		return openHTTP(url, logger)
+	}
+	scheme, _, _ := strings.Cut(url, "://")
+	return nil, fmt.Errorf("unsupported protocol '%s' in %s", scheme, url)
+}
+
+// LocalPath returns the path of a file:// URL or of a plain path, and
+// false for URLs of other protocols
+func LocalPath(url string) (string, bool) {
+	if strings.HasPrefix(url, FILE_SCHEME) {
+		return strings.TrimPrefix(url, FILE_SCHEME), true
+	}
+	return url, !strings.Contains(url, "://")
+}
@@ -0,0 +1,183 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/logger"
+	"got_it/internal/objects"
+	"got_it/internal/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newStore creates an empty repository in a temporary directory
+func newStore(t *testing.T) (*objects.Store, string) {
+	t.Helper()
+	dir := t.TempDir()
+	conf := config.NewConfig()
+	conf.GotDir = filepath.Join(dir, config.GOT_DIR)
+	os.MkdirAll(filepath.Join(conf.GotDir, "objects"), 0755)
+	os.WriteFile(filepath.Join(conf.GotDir, "HEAD"), []byte("ref: refs/heads/main"), 0644)
+	return objects.NewStore(conf, logger.NewLogger(false, false)), dir
+}
+
+// writeCommit writes a commit holding one file on top of parent
+func writeCommit(t *testing.T, store *objects.Store, content, parent string) string {
+	t.Helper()
+	blob, _ := store.Write(content)
+	tree, _ := store.Write(fmt.Sprintf("100644 blob %s\tfile.txt\n", blob))
+	header := "tree " + tree + "\n"
+	if parent != "" {
+		header += "parent " + parent + "\n"
+	}
+	commit, err := store.Write(header + "author A U Thor <author@example.com> 1623501234 +0200\n" +
+		"committer A U Thor <author@example.com> 1623501234 +0200\n\n" + content)
+	if err != nil {
+		t.Fatalf("Error writing commit: %v", err)
+	}
+	return commit
+}
+
+func TestRefspec(t *testing.T) {
+	refspec, err := ParseRefspec("+refs/heads/*:refs/remotes/origin/*")
+	if err != nil {
+		t.Fatalf("ParseRefspec returned error: %v", err)
+	}
+	if !refspec.Force || refspec.String() != "+refs/heads/*:refs/remotes/origin/*" {
+		t.Errorf("Unexpected refspec: %+v", refspec)
+	}
+	if dst, matches := refspec.Match("refs/heads/feature/x"); !matches || dst != "refs/remotes/origin/feature/x" {
+		t.Errorf("Unexpected match: %s %v", dst, matches)
+	}
+	if _, matches := refspec.Match("refs/tags/v1"); matches {
+		t.Errorf("Expected refs/tags/v1 not to match")
+	}
+
+	refspec, _ = ParseRefspec("refs/heads/main:refs/heads/backup")
+	if dst, matches := refspec.Match("refs/heads/main"); !matches || dst != "refs/heads/backup" {
+		t.Errorf("Unexpected match: %s %v", dst, matches)
+	}
+	if _, err := ParseRefspec("refs/heads/*:refs/heads/main"); err == nil {
+		t.Errorf("Expected an error for unbalanced globs")
+	}
+}
+
+func TestCopyObjects(t *testing.T) {
+	src, _ := newStore(t)
+	dst, _ := newStore(t)
+	first := writeCommit(t, src, "one\n", "")
+	second := writeCommit(t, src, "two\n", first)
+
+	if err := CopyObjects(src, dst, []string{first}); err != nil {
+		t.Fatalf("CopyObjects returned error: %v", err)
+	}
+	missing, err := MissingObjects(src, dst, []string{second})
+	if err != nil {
+		t.Fatalf("MissingObjects returned error: %v", err)
+	}
+	// the commit, its tree and blob; the history dst has is not walked
+	if len(missing) != 3 || missing[0] != second {
+		t.Errorf("Unexpected missing objects: %v", missing)
+	}
+	if err := CopyObjects(src, dst, []string{second}); err != nil {
+		t.Fatalf("CopyObjects returned error: %v", err)
+	}
+	commit, err := dst.ReadCommit(second)
+	if err != nil || commit.Parents[0] != first || !dst.Exists(commit.Tree) {
+		t.Errorf("Unexpected copied commit: %+v, %v", commit, err)
+	}
+}
+
+func TestOpenLocal(t *testing.T) {
+	store, dir := newStore(t)
+	commit := writeCommit(t, store, "one\n", "")
+	os.MkdirAll(filepath.Join(dir, config.GOT_DIR, "refs", "heads"), 0755)
+	os.WriteFile(filepath.Join(dir, config.GOT_DIR, "refs", "heads", "main"), []byte(commit), 0644)
+
+	conn, err := Open(FILE_SCHEME+dir, logger.NewLogger(false, false))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	adv, err := conn.ListRefs()
+	if err != nil {
+		t.Fatalf("ListRefs returned error: %v", err)
+	}
+	if adv.Head != "refs/heads/main" || adv.Refs["refs/heads/main"] != commit {
+		t.Errorf("Unexpected advertisement: %+v", adv)
+	}
+
+	other, _ := newStore(t)
+	second := writeCommit(t, other, "two\n", commit)
+	CopyObjects(store, other, []string{commit})
+	rejected, err := conn.Push(other, []Update{
+		{Name: "refs/heads/main", Old: commit, New: second},
+		{Name: "refs/heads/topic", New: second},
+	})
+	if err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	if len(rejected) != 1 || rejected["refs/heads/main"] == nil {
+		t.Errorf("Expected only the checked out branch to be rejected: %v", rejected)
+	}
+	if !store.Exists(second) {
+		t.Errorf("Expected the pushed commit to be copied")
+	}
+
+	if _, err := Open("ssh://example.com/repo", logger.NewLogger(false, false)); err == nil {
+		t.Errorf("Expected an error for an unsupported protocol")
+	}
+}
+
+func TestAdvertiseObjectFormat(t *testing.T) {
+	adv := Advertisement{Refs: map[string]string{"refs/heads/main": utils.OF_SHA256.HashContent("x")},
+		Head: "refs/heads/main", ObjectFormat: utils.OF_SHA256}
+	var buffer bytes.Buffer
+	if err := writeAdvertisement(&buffer, adv); err != nil {
+		t.Fatalf("writeAdvertisement returned error: %v", err)
+	}
+	read, err := readAdvertisement(&buffer)
+	if err != nil {
+		t.Fatalf("readAdvertisement returned error: %v", err)
+	}
+	if !reflect.DeepEqual(read, adv) {
+		t.Errorf("Expected %+v, got %+v", adv, read)
+	}
+	if err := CheckObjectFormat(utils.OF_SHA1, read); err == nil {
+		t.Errorf("Expected a SHA-1 repository to refuse a SHA-256 remote")
+	}
+
+	// SHA-1 repositories advertise the same lines as before
+	buffer.Reset()
+	writeAdvertisement(&buffer, Advertisement{Refs: map[string]string{}, ObjectFormat: utils.OF_SHA1})
+	if buffer.Len() != 0 {
+		t.Errorf("Expected nothing advertised for an empty SHA-1 repository, got %q", buffer.String())
+	}
+	if read, _ := readAdvertisement(&buffer); read.ObjectFormat != utils.OF_SHA1 {
+		t.Errorf("Expected sha1 when the format is not advertised, got %s", read.ObjectFormat)
+	}
+}
+
+func TestCommandsZeroHash(t *testing.T) {
+	hash := utils.OF_SHA256.HashContent("x")
+	updates := []Update{{Name: "refs/heads/new", New: hash}, {Name: "refs/heads/gone", Old: hash}}
+	var buffer bytes.Buffer
+	if err := writeCommands(&buffer, updates, utils.OF_SHA256); err != nil {
+		t.Fatalf("writeCommands returned error: %v", err)
+	}
+	zero := utils.OF_SHA256.ZeroHash()
+	want := zero + " " + hash + " refs/heads/new\n" + hash + " " + zero + " refs/heads/gone\n\n"
+	if buffer.String() != want {
+		t.Errorf("Expected %q, got %q", want, buffer.String())
+	}
+	read, err := readCommands(bufio.NewReader(&buffer))
+	if err != nil {
+		t.Fatalf("readCommands returned error: %v", err)
+	}
+	if !reflect.DeepEqual(read, updates) {
+		t.Errorf("Expected %+v, got %+v", updates, read)
+	}
+}
@@ -3,36 +3,86 @@ package utils
 import (
 	"bufio"
 	"crypto/sha1"
+	"crypto/sha256"
 	"fmt"
 	"got_it/internal/models"
+	"hash"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-// hashFile returns the SHA1 hash of the file
-func HashFile(filePath string) (string, error) {
+// ObjectFormat is the hash algorithm naming the objects of a repository,
+// chosen when the repository is created
+type ObjectFormat string
+
+const (
+	OF_SHA1   ObjectFormat = "sha1"
+	OF_SHA256 ObjectFormat = "sha256"
+)
+
+// OBJECT_FORMATS are the hash algorithms a repository can use
+var OBJECT_FORMATS = []ObjectFormat{OF_SHA1, OF_SHA256}
+
+// NewHasher returns a hash of the algorithm of the format, to hash content
+// as it is read
+func (f ObjectFormat) NewHasher() hash.Hash {
+	if f == OF_SHA256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+// HexLength returns the number of hex digits of the hashes of the format
+func (f ObjectFormat) HexLength() int {
+	return f.NewHasher().Size() * 2
+}
+
+// ZeroHash returns the hash made of zeros that stands for no object
+func (f ObjectFormat) ZeroHash() string {
+	return strings.Repeat("0", f.HexLength())
+}
+
+// HashFile returns the hash of the content of the file, read in chunks
+func (f ObjectFormat) HashFile(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
-	hasher := sha1.New()
+	hasher := f.NewHasher()
 	if _, err := io.Copy(hasher, file); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
-func HashContent(content string) string {
-	hasher := sha1.New()
+// HashContent returns the hash of the content
+func (f ObjectFormat) HashContent(content string) string {
+	hasher := f.NewHasher()
 	hasher.Write([]byte(content))
 	return fmt.Sprintf("%x", hasher.Sum(nil))
 }
 
+// IsHash tells if s is a full hash of the format
+func (f ObjectFormat) IsHash(s string) bool {
+	return len(s) == f.HexLength() && IsHexString(s)
+}
+
+// ParseObjectFormat returns the format named name, case insensitive
+func ParseObjectFormat(name string) (ObjectFormat, error) {
+	for _, format := range OBJECT_FORMATS {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hash algorithm '%s'", name)
+}
+
 func GeneratePatch(oldContent, newContent []byte) []byte {
 	dmp := diffmatchpatch.New()
 	diffs := dmp.DiffMain(string(oldContent), string(newContent), false)
@@ -72,3 +122,45 @@ func ReadIndex(indexFile string) (map[string]string, error) {
 	}
 	return stagedFiles, nil
 }
+
+// SlashPath turns a path given on the command line into a clean slash
+// separated path, relative to the working directory when it is absolute
+func SlashPath(file string) string {
+	if filepath.IsAbs(file) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil {
+				file = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+// IsHash tells if s looks like a full object hash of any format, for the
+// parsers of objects that do not know the format of their repository
+func IsHash(s string) bool {
+	for _, format := range OBJECT_FORMATS {
+		if format.IsHash(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZeroHash tells if s is the zero hash of any format
+func IsZeroHash(s string) bool {
+	return s != "" && strings.Trim(s, "0") == ""
+}
+
+// IsHexString tells if s is a non empty string of lowercase hex digits
+func IsHexString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -27,12 +27,12 @@ func Test_HashContent_vs_HashFile(t *testing.T) {
 		t.Fatalf("Error writing to temporary file: %v", err)
 	}
 	// get hash using HashContent
-	hashContent := HashContent(content)
+	hashContent := OF_SHA1.HashContent(content)
 	if err != nil {
 		t.Fatalf("Error hashing content: %v", err)
 	}
 	// get hash using HashFile
-	hashFile, err := HashFile(tempFile.Name())
+	hashFile, err := OF_SHA1.HashFile(tempFile.Name())
 	if err != nil {
 		t.Fatalf("Error hashing file: %v", err)
 	}
@@ -41,3 +41,51 @@ func Test_HashContent_vs_HashFile(t *testing.T) {
 		t.Fatalf("Hashes do not match: %s != %s", hashContent, hashFile)
 	}
 }
+
+func TestObjectFormat(t *testing.T) {
+	if hash := OF_SHA1.HashContent("abc"); hash != "a9993e364706816aba3e25717850c26c9cd0d89d" {
+		t.Errorf("Unexpected SHA-1 hash %s", hash)
+	}
+	if hash := OF_SHA256.HashContent("abc"); hash != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("Unexpected SHA-256 hash %s", hash)
+	}
+	for _, format := range OBJECT_FORMATS {
+		hash := format.HashContent("abc")
+		if len(hash) != format.HexLength() || !format.IsHash(hash) || !IsHash(hash) {
+			t.Errorf("Expected %s to be a %s hash", hash, format)
+		}
+		if zero := format.ZeroHash(); len(zero) != format.HexLength() || !IsZeroHash(zero) {
+			t.Errorf("Unexpected zero hash %s for %s", zero, format)
+		}
+	}
+	if OF_SHA1.IsHash(OF_SHA256.HashContent("abc")) {
+		t.Errorf("Expected a SHA-256 hash not to be a SHA-1 one")
+	}
+	if IsZeroHash("") || IsZeroHash("00a0") {
+		t.Errorf("Expected only strings of zeros to be zero hashes")
+	}
+	if format, err := ParseObjectFormat("SHA256"); err != nil || format != OF_SHA256 {
+		t.Errorf("Expected sha256, got %s (%v)", format, err)
+	}
+	if _, err := ParseObjectFormat("md5"); err == nil {
+		t.Errorf("Expected an unknown hash algorithm to be refused")
+	}
+}
+
+func TestSlashPath(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	wd, _ := os.Getwd()
+	for file, expected := range map[string]string{
+		"dir/./f.txt":               "dir/f.txt",
+		"dir/sub/../f.txt":          "dir/f.txt",
+		wd + "/dir/f.txt":           "dir/f.txt",
+		wd + "/../outside/file.txt": "../outside/file.txt",
+	} {
+		if got := SlashPath(file); got != expected {
+			t.Errorf("SlashPath(%q) = %q, want %q", file, got, expected)
+		}
+	}
+}
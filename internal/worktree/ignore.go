@@ -0,0 +1,79 @@
+package worktree
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignorePattern is a line of .gotignore
+type ignorePattern struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // matched against the whole path instead of the base name
+}
+
+// ignoreRules are the patterns of .gotignore, in the order they were written
+type ignoreRules []ignorePattern
+
+// readIgnore reads the .gotignore file at the root of the worktree
+func (wt *Worktree) readIgnore() ignoreRules {
+	file, err := os.Open(filepath.Join(wt.Root(), wt.conf.GotignoreFile))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	rules := ignoreRules{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// IsIgnored tells if a slash separated path relative to the root matches
+// .gotignore
+func (wt *Worktree) IsIgnored(filePath string, isDir bool) bool {
+	return wt.readIgnore().matches(filePath, isDir)
+}
+
+// matches tells if the path is ignored; the last matching pattern wins and
+// .gotignore itself is never ignored
+func (rules ignoreRules) matches(filePath string, isDir bool) bool {
+	if filePath == ".gotignore" {
+		return false
+	}
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		subject := path.Base(filePath)
+		if rule.anchored {
+			subject = filePath
+		}
+		if matched, _ := path.Match(rule.pattern, subject); matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,495 @@
+package worktree
+
+import (
+	"fmt"
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/index"
+	"got_it/internal/lfs"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"got_it/internal/objects"
+	"got_it/internal/refs"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Worktree gives access to the checked out files, the index and the HEAD
+// commit, and moves the files from one tree to another
+type Worktree struct {
+	conf   *config.Config
+	logger *logger.Logger
+	store  *objects.Store
+	index  *index.Index
+	refs   *refs.Store
+	lfs    *lfs.Store
+	out    io.Writer // where the warnings are written
+	// core.filemode, and the modes of HEAD read once when it is false
+	trustFileMode bool
+	headModes     map[string]string
+	// the patterns of .gotattributes, read the first time a file is checked
+	lfsPatterns []string
+	lfsLoaded   bool
+}
+
+func init() {
+	config.Register(config.KeyInfo{Key: "core.filemode", Type: config.TYPE_BOOL, Default: "true",
+		Description: "trust the executable bit of the files, false on file systems that do not keep it"})
+}
+
+// Status lists the differences between HEAD, the index and the files
+type Status struct {
+	Staged    []diff.Change // from HEAD to the index
+	Unstaged  []diff.Change // from the index to the files
+	Untracked []string
+}
+
+func NewWorktree(conf *config.Config, logger *logger.Logger) *Worktree {
+	return &Worktree{
+		conf:   conf,
+		logger: logger,
+		store:  objects.NewStore(conf, logger),
+		index:  index.NewIndex(conf, logger),
+		refs:   refs.NewStore(conf, logger),
+		lfs:    lfs.NewStore(conf, logger),
+		out:    os.Stderr,
+
+		trustFileMode: conf.Bool("core.filemode"),
+	}
+}
+
+// Root returns the directory holding .got, where the files are checked out
+func (wt *Worktree) Root() string {
+	gotDir, err := filepath.Abs(wt.conf.GotDir)
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(gotDir)
+}
+
+// HeadFiles returns the files of the HEAD commit and its hash; both are
+// empty on a branch without commits
+func (wt *Worktree) HeadFiles() (map[string]models.TreeEntry, string, error) {
+	hash, _, err := wt.refs.Resolve(refs.HEAD)
+	if err != nil {
+		return make(map[string]models.TreeEntry), "", nil
+	}
+	files, err := wt.CommitFiles(hash)
+	return files, hash, err
+}
+
+// CommitFiles returns the files of the tree of a commit
+func (wt *Worktree) CommitFiles(commitHash string) (map[string]models.TreeEntry, error) {
+	commit, err := wt.store.ReadCommit(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	return wt.store.FlattenTree(commit.Tree)
+}
+
+// IndexFiles returns the staged files. The index does not keep modes, they
+// come from HEAD or from the files themselves.
+func (wt *Worktree) IndexFiles() (map[string]models.TreeEntry, error) {
+	staged, err := wt.index.Read()
+	if err != nil {
+		return nil, err
+	}
+	headFiles, _, err := wt.HeadFiles()
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]models.TreeEntry, len(staged))
+	for filePath, hash := range staged {
+		mode := "100644"
+		if entry, found := headFiles[filePath]; found {
+			mode = entry.Mode
+		}
+		// without core.filemode the files cannot tell, the mode of HEAD is kept
+		if wt.trustFileMode {
+			if fileMode, err := wt.fileMode(filePath); err == nil {
+				mode = fileMode
+			}
+		}
+		files[filePath] = models.TreeEntry{Mode: mode, Type: string(models.TT_BLOB), Hash: hash, Name: path.Base(filePath)}
+	}
+	return files, nil
+}
+
+// WriteIndex replaces the index with the files
+func (wt *Worktree) WriteIndex(files map[string]models.TreeEntry) error {
+	return wt.index.Write(index.FromTree(files))
+}
+
+// WorkingFiles returns the current version of the given files, hashed but
+// not stored; missing files are left out
+func (wt *Worktree) WorkingFiles(paths []string) (map[string]models.TreeEntry, error) {
+	files := make(map[string]models.TreeEntry, len(paths))
+	for _, filePath := range paths {
+		entry, err := wt.hashFile(filePath, false)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		files[filePath] = entry
+	}
+	return files, nil
+}
+
+// StoreFiles saves the current version of the files in the object store
+// and returns their entries; missing files are left out
+func (wt *Worktree) StoreFiles(paths []string) (map[string]models.TreeEntry, error) {
+	files := make(map[string]models.TreeEntry, len(paths))
+	for _, filePath := range paths {
+		entry, err := wt.hashFile(filePath, true)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		files[filePath] = entry
+	}
+	return files, nil
+}
+
+// Untracked returns the files that are neither staged nor ignored, sorted
+func (wt *Worktree) Untracked() ([]string, error) {
+	staged, err := wt.index.Read()
+	if err != nil {
+		return nil, err
+	}
+	ignore := wt.readIgnore()
+	root := wt.Root()
+	gotDir, _ := filepath.Abs(wt.conf.GotDir)
+	untracked := []string{}
+	err = filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, _ := filepath.Rel(root, filePath)
+		relative = filepath.ToSlash(relative)
+		if info.IsDir() {
+			if filePath == gotDir || (relative != "." && ignore.matches(relative, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, found := staged[relative]; !found && !ignore.matches(relative, false) {
+			untracked = append(untracked, relative)
+		}
+		return nil
+	})
+	sort.Strings(untracked)
+	return untracked, err
+}
+
+// Status compares HEAD, the index and the files
+func (wt *Worktree) Status() (Status, error) {
+	headFiles, _, err := wt.HeadFiles()
+	if err != nil {
+		return Status{}, err
+	}
+	indexFiles, err := wt.IndexFiles()
+	if err != nil {
+		return Status{}, err
+	}
+	working, err := wt.WorkingFiles(sortedPaths(indexFiles))
+	if err != nil {
+		return Status{}, err
+	}
+	untracked, err := wt.Untracked()
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{
+		Staged:    diff.CompareTrees(headFiles, indexFiles),
+		Unstaged:  diff.CompareTrees(indexFiles, working),
+		Untracked: untracked,
+	}, nil
+}
+
+// IsClean tells if there is nothing staged nor modified; untracked files
+// do not count
+func (s Status) IsClean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0
+}
+
+// Checkout moves the files from the from tree to the to tree: files only in
+// from are removed, the others are written when they differ. The index is
+// not touched.
+func (wt *Worktree) Checkout(from, to map[string]models.TreeEntry) error {
+	for _, filePath := range sortedPaths(from) {
+		if _, found := to[filePath]; !found {
+			if err := wt.RemoveFile(filePath); err != nil {
+				return err
+			}
+		}
+	}
+	for _, filePath := range sortedPaths(to) {
+		entry := to[filePath]
+		if current, found := from[filePath]; found && current.Hash == entry.Hash && current.Mode == entry.Mode {
+			if _, err := os.Stat(wt.fullPath(filePath)); err == nil {
+				continue
+			}
+		}
+		if err := wt.WriteFile(filePath, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset makes the index and the tracked files match the tree, dropping every
+// local change; untracked files are left alone
+func (wt *Worktree) Reset(to map[string]models.TreeEntry) error {
+	indexFiles, err := wt.IndexFiles()
+	if err != nil {
+		return err
+	}
+	working, err := wt.WorkingFiles(sortedPaths(indexFiles))
+	if err != nil {
+		return err
+	}
+	if err := wt.Checkout(working, to); err != nil {
+		return err
+	}
+	return wt.WriteIndex(to)
+}
+
+// CheckConflicts returns an error naming the files that have local changes,
+// staged or not, and would be overwritten by moving from one tree to the other
+func (wt *Worktree) CheckConflicts(from, to map[string]models.TreeEntry) error {
+	status, err := wt.Status()
+	if err != nil {
+		return err
+	}
+	touched := make(map[string]bool)
+	for _, change := range diff.CompareTrees(from, to) {
+		touched[change.Path] = true
+	}
+	conflicts := []string{}
+	for _, change := range append(status.Staged, status.Unstaged...) {
+		if touched[change.Path] && !contains(conflicts, change.Path) {
+			conflicts = append(conflicts, change.Path)
+		}
+	}
+	for _, filePath := range status.Untracked {
+		if _, found := to[filePath]; found {
+			if _, tracked := from[filePath]; !tracked {
+				conflicts = append(conflicts, filePath)
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+	return fmt.Errorf("your local changes to the following files would be overwritten:\n\t%s\nplease commit or stash them",
+		strings.Join(conflicts, "\n\t"))
+}
+
+// ReadFile returns the content of a checked out file
+func (wt *Worktree) ReadFile(filePath string) (string, error) {
+	content, err := os.ReadFile(wt.fullPath(filePath))
+	return string(content), err
+}
+
+// WriteFile checks out the blob of the entry at filePath, copying it in
+// chunks. The content of an lfs pointer is written in its place, or the
+// pointer itself when the content cannot be found.
+func (wt *Worktree) WriteFile(filePath string, entry models.TreeEntry) error {
+	if pointer, ok := wt.readPointer(entry.Hash); ok {
+		return wt.smudge(filePath, pointer, entry.Mode)
+	}
+	blob, err := wt.store.Open(entry.Hash)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	return wt.writeFrom(filePath, blob, entry.Mode)
+}
+
+// readPointer returns the lfs pointer the blob holds, if it is one
+func (wt *Worktree) readPointer(hash string) (lfs.Pointer, bool) {
+	size, err := wt.store.Size(hash)
+	if err != nil || size > lfs.MAX_POINTER_SIZE {
+		return lfs.Pointer{}, false
+	}
+	content, err := wt.store.Read(hash)
+	if err != nil {
+		return lfs.Pointer{}, false
+	}
+	return lfs.ParsePointer(content)
+}
+
+// smudge checks out the content of the pointer at filePath, or the pointer
+// when its content is neither in .got/lfs nor on the LFS server
+func (wt *Worktree) smudge(filePath string, pointer lfs.Pointer, mode string) error {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(wt.lfs.Smudge(pointer, w))
+	}()
+	err := wt.writeFrom(filePath, r, mode)
+	r.Close()
+	if err == nil {
+		return nil
+	}
+	wt.logger.Debug("could not smudge %s: %v", filePath, err)
+	fmt.Fprintf(wt.out, "warning: the content of %s is missing, its LFS pointer is checked out instead\n", filePath)
+	return wt.WriteContent(filePath, pointer.String(), mode)
+}
+
+// IsLFS tells if a slash separated path relative to the root is tracked by
+// lfs in .gotattributes
+func (wt *Worktree) IsLFS(filePath string) bool {
+	if !wt.lfsLoaded {
+		patterns, err := lfs.Patterns(wt.Root())
+		if err != nil {
+			wt.logger.Debug("could not read %s: %v", lfs.ATTRIBUTES_FILE, err)
+		}
+		wt.lfsPatterns, wt.lfsLoaded = patterns, true
+	}
+	return lfs.IsTracked(wt.lfsPatterns, filePath)
+}
+
+// WriteContent writes content at filePath with the permissions of the mode
+func (wt *Worktree) WriteContent(filePath, content, mode string) error {
+	return wt.writeFrom(filePath, strings.NewReader(content), mode)
+}
+
+// writeFrom writes what r holds at filePath with the permissions of the mode
+func (wt *Worktree) writeFrom(filePath string, r io.Reader, mode string) error {
+	fullPath := wt.fullPath(filePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if mode == "100755" {
+		perm = 0755
+	}
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(fullPath, perm)
+}
+
+// RemoveFile deletes a checked out file and the directories it leaves empty
+func (wt *Worktree) RemoveFile(filePath string) error {
+	fullPath := wt.fullPath(filePath)
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	root := wt.Root()
+	for dir := filepath.Dir(fullPath); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// hashFile hashes a checked out file as it is read, storing it when asked
+// to. A file tracked by lfs is hashed as its pointer.
+func (wt *Worktree) hashFile(filePath string, store bool) (models.TreeEntry, error) {
+	mode, err := wt.fileMode(filePath)
+	if err != nil {
+		return models.TreeEntry{}, err
+	}
+	hash := ""
+	if wt.IsLFS(filePath) {
+		var pointer lfs.Pointer
+		pointer, err = wt.lfs.Clean(wt.fullPath(filePath), store)
+		if err == nil && store {
+			hash, err = wt.store.Write(pointer.String())
+		} else if err == nil {
+			hash = wt.conf.ObjectFormat().HashContent(pointer.String())
+		}
+	} else if store {
+		hash, err = wt.store.WriteFile(wt.fullPath(filePath))
+	} else {
+		hash, err = wt.conf.ObjectFormat().HashFile(wt.fullPath(filePath))
+	}
+	if err != nil {
+		return models.TreeEntry{}, err
+	}
+	return models.TreeEntry{Mode: mode, Type: string(models.TT_BLOB), Hash: hash, Name: path.Base(filePath)}, nil
+}
+
+// fileMode returns the tree mode of a checked out file. Without
+// core.filemode the executable bit is ignored and the mode comes from HEAD.
+func (wt *Worktree) fileMode(filePath string) (string, error) {
+	info, err := os.Stat(wt.fullPath(filePath))
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", filePath)
+	}
+	if !wt.trustFileMode {
+		return wt.headMode(filePath)
+	}
+	if info.Mode()&0111 != 0 {
+		return "100755", nil
+	}
+	return "100644", nil
+}
+
+// headMode returns the mode of the file in HEAD, 100644 for a new file
+func (wt *Worktree) headMode(filePath string) (string, error) {
+	if wt.headModes == nil {
+		headFiles, _, err := wt.HeadFiles()
+		if err != nil {
+			return "", err
+		}
+		wt.headModes = make(map[string]string, len(headFiles))
+		for headPath, entry := range headFiles {
+			wt.headModes[headPath] = entry.Mode
+		}
+	}
+	if mode, found := wt.headModes[filePath]; found {
+		return mode, nil
+	}
+	return "100644", nil
+}
+
+func (wt *Worktree) fullPath(filePath string) string {
+	return filepath.Join(wt.Root(), filepath.FromSlash(filePath))
+}
+
+// SetOutput changes where the warnings are written
+func (wt *Worktree) SetOutput(out io.Writer) {
+	wt.out = out
+}
+
+// sortedPaths returns the paths of the files, sorted
+func sortedPaths(files map[string]models.TreeEntry) []string {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func contains(paths []string, filePath string) bool {
+	for _, p := range paths {
+		if p == filePath {
+			return true
+		}
+	}
+	return false
+}
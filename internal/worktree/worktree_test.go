@@ -0,0 +1,202 @@
+package worktree
+
+import (
+	"got_it/internal/commands/config"
+	"got_it/internal/diff"
+	"got_it/internal/logger"
+	"got_it/internal/models"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.Mkdir(".got", 0755)
+	os.WriteFile(".gotignore", []byte("# comment\n*.log\n!keep.log\nbuild/\n/root.txt\ndocs/*.tmp\n"), 0644)
+	wt := NewWorktree(config.NewConfig(), logger.NewLogger(false, false))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"debug.log", false, true},
+		{"dir/debug.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"root.txt", false, true},
+		{"dir/root.txt", false, false},
+		{"docs/a.tmp", false, true},
+		{"other/docs/a.tmp", false, false},
+		{".gotignore", false, false},
+	}
+	for _, tt := range tests {
+		if got := wt.IsIgnored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("IsIgnored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIsLFSReadsAttributesOnce(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.Mkdir(".got", 0755)
+	os.WriteFile(".gotattributes", []byte("*.psd filter=lfs diff=lfs merge=lfs -text\n"), 0644)
+	wt := NewWorktree(config.NewConfig(), logger.NewLogger(false, false))
+
+	if !wt.IsLFS("art/logo.psd") || wt.IsLFS("README.md") {
+		t.Errorf("Expected only the .psd file to be tracked by lfs")
+	}
+	os.Remove(".gotattributes")
+	if !wt.IsLFS("logo.psd") {
+		t.Errorf("Expected the patterns to be kept once read")
+	}
+}
+
+func TestStatusAndCheckout(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.Mkdir(".got", 0755)
+	os.WriteFile("a.txt", []byte("a\n"), 0644)
+	os.WriteFile("b.txt", []byte("b\n"), 0644)
+	wt := NewWorktree(config.NewConfig(), logger.NewLogger(false, false))
+	files, err := wt.StoreFiles([]string{"a.txt", "b.txt"})
+	if err != nil {
+		t.Fatalf("StoreFiles returned error: %v", err)
+	}
+	if err := wt.WriteIndex(files); err != nil {
+		t.Fatalf("WriteIndex returned error: %v", err)
+	}
+
+	os.WriteFile("a.txt", []byte("changed\n"), 0644)
+	os.Remove("b.txt")
+	os.MkdirAll("dir", 0755)
+	os.WriteFile(filepath.Join("dir", "c.txt"), []byte("c\n"), 0644)
+	status, err := wt.Status()
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	// nothing is committed, so both staged files are new
+	if len(status.Staged) != 2 || status.Staged[0].Status != diff.ST_ADDED {
+		t.Errorf("Expected two staged additions, got %+v", status.Staged)
+	}
+	if len(status.Unstaged) != 2 || status.Unstaged[0].Status != diff.ST_MODIFIED || status.Unstaged[1].Status != diff.ST_DELETED {
+		t.Errorf("Expected a.txt modified and b.txt deleted, got %+v", status.Unstaged)
+	}
+	if len(status.Untracked) != 1 || status.Untracked[0] != "dir/c.txt" {
+		t.Errorf("Expected dir/c.txt to be untracked, got %v", status.Untracked)
+	}
+
+	current, _ := wt.WorkingFiles([]string{"a.txt", "dir/c.txt"})
+	if err := wt.Checkout(current, files); err != nil {
+		t.Fatalf("Checkout returned error: %v", err)
+	}
+	for name, expected := range map[string]string{"a.txt": "a\n", "b.txt": "b\n"} {
+		if content, _ := os.ReadFile(name); string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q", name, expected, content)
+		}
+	}
+	if _, err := os.Stat("dir"); !os.IsNotExist(err) {
+		t.Errorf("Expected dir to be removed with its last file")
+	}
+}
+
+func TestFileModeConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	t.Setenv(config.GLOBAL_CONFIG_ENV, filepath.Join(dir, "global"))
+	t.Setenv(config.NOSYSTEM_ENV, "true")
+	os.Mkdir(".got", 0755)
+	os.WriteFile("run.sh", []byte("#!/bin/sh\n"), 0755)
+	conf := config.NewConfig()
+
+	files, err := NewWorktree(conf, logger.NewLogger(false, false)).WorkingFiles([]string{"run.sh"})
+	if err != nil || files["run.sh"].Mode != "100755" {
+		t.Errorf("Expected the executable bit to be kept by default, got %+v (%v)", files["run.sh"], err)
+	}
+
+	if err := conf.SetConfigKeyValue("core.filemode", "false"); err != nil {
+		t.Fatalf("SetConfigKeyValue returned error: %v", err)
+	}
+	files, err = NewWorktree(conf, logger.NewLogger(false, false)).WorkingFiles([]string{"run.sh"})
+	if err != nil || files["run.sh"].Mode != "100644" {
+		t.Errorf("Expected the executable bit to be ignored, got %+v (%v)", files["run.sh"], err)
+	}
+}
+
+// createSparseFile creates a file of the given size that takes no room on
+// disk but for its first and last lines
+func createSparseFile(t *testing.T, path string, size int64) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error creating %s: %v", path, err)
+	}
+	defer file.Close()
+	file.WriteString("start\n")
+	if err := file.Truncate(size - 4); err != nil {
+		t.Fatalf("Error extending %s: %v", path, err)
+	}
+	file.Seek(0, io.SeekEnd)
+	file.WriteString("end\n")
+}
+
+// allocated returns the bytes allocated while running f
+func allocated(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// TestBigFileStatusAndCheckout stores, compares and checks out a file larger
+// than the memory the test allows, so every step must stream it
+func TestBigFileStatusAndCheckout(t *testing.T) {
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Error changing directory: %v", err)
+	}
+	os.Mkdir(".got", 0755)
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(64 << 20))
+	const size = 256 << 20
+	createSparseFile(t, "big.bin", size)
+	wt := NewWorktree(config.NewConfig(), logger.NewLogger(false, false))
+
+	var status Status
+	used := allocated(func() {
+		files, err := wt.StoreFiles([]string{"big.bin"})
+		if err != nil {
+			t.Fatalf("StoreFiles returned error: %v", err)
+		}
+		wt.WriteIndex(files)
+		if status, err = wt.Status(); err != nil {
+			t.Fatalf("Status returned error: %v", err)
+		}
+		os.Remove("big.bin")
+		if err := wt.Checkout(map[string]models.TreeEntry{}, files); err != nil {
+			t.Fatalf("Checkout returned error: %v", err)
+		}
+	})
+
+	if used > 16<<20 {
+		t.Errorf("Expected the file to be streamed, %d MiB were allocated", used>>20)
+	}
+	if len(status.Unstaged) != 0 {
+		t.Errorf("Expected the stored file to be unchanged, got %+v", status.Unstaged)
+	}
+	if info, err := os.Stat("big.bin"); err != nil || info.Size() != size {
+		t.Errorf("Expected big.bin to be checked out with %d bytes, got %v", size, err)
+	}
+}